@@ -1,91 +1,166 @@
-package main
-
-import (
-    "log"
-    "net/http"
-    "time"
-
-    "github.com/go-chi/chi/v5"
-    "github.com/go-chi/chi/v4/middleware"
-    "github.com/go-chi/cors"
-
-    "voting-blockchain/config"
-    "voting-blockchain/db"
-
-    // Auth-модуль
-    authHandlers "voting-blockchain/internal/auth/handlers"
-    authRepos "voting-blockchain/internal/auth/repositories"
-    authRouters "voting-blockchain/internal/auth/routers"
-    authServices "voting-blockchain/internal/auth/services"
-
-    // Voting-модуль
-    votingHandlers "voting-blockchain/internal/voting/handlers"
-    votingRepos "voting-blockchain/internal/voting/repositories"
-    votingRouters "voting-blockchain/internal/voting/routers"
-    votingServices "voting-blockchain/internal/voting/services"
-)
-
-func main() {
-    // Загружаем конфигурацию из .env
-    cfg := config.LoadConfig()
-
-    // Инициализируем БД
-    db.InitDB(cfg)
-
-    // Конвертация TTL для токенов
-    accessTTL := time.Duration(cfg.AccessTokenTTLMin) * time.Minute
-    refreshTTL := time.Duration(cfg.RefreshTokenTTLDays*24) * time.Hour
-
-    // ===== AUTH =====
-    userRepo := authRepos.NewUserRepository()
-    refreshRepo := authRepos.NewRefreshTokenRepository()
-    authService := authServices.NewAuthService(userRepo, refreshRepo, cfg.JWTSecret, accessTTL, refreshTTL)
-    authHandler := authHandlers.NewAuthHandler(authService)
-
-    // ===== VOTING =====
-    voteRepo := votingRepos.NewVotePostgres(db.DB)
-    blockchainRepo := votingRepos.NewBlockchainPostgres(db.DB)
-    electionRepo := votingRepos.NewElectionPostgres(db.DB)
-    choiceRepo := votingRepos.NewChoicePostgres(db.DB) // добавлено
-
-    electionService := votingServices.NewElectionService(electionRepo, choiceRepo) // изменено
-    electionHandler := votingHandlers.NewElectionHandler(electionService)
-
-    voteService := votingServices.NewVoteService(voteRepo, blockchainRepo)
-    voteHandler := votingHandlers.NewVoteHandler(voteService)
-
-
-    // ===== ROUTING =====
-    r := chi.NewRouter()
-
-    // ==== MIDDLEWARE ====
-    r.Use(middleware.Logger)
-    r.Use(middleware.Recoverer)
-
-    // CORS разрешаем фронту доступ
-    r.Use(cors.Handler(cors.Options{
-        AllowedOrigins:   []string{"*"},
-        AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-        AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token"},
-        ExposedHeaders:   []string{"Link"},
-        AllowCredentials: true,
-        MaxAge:           300,
-    }))
-
-    // (опционально) префикс API
-    r.Route("/api/v1", func(api chi.Router) {
-        // Auth маршруты
-        api.Mount("/auth", authRouters.NewAuthRouter(authHandler, []byte(cfg.JWTSecret)))
-
-        // Voting маршруты (с JWT)
-        api.Mount("/voting",
-            authHandlers.NewJWTMiddleware([]byte(cfg.JWTSecret))(
-                votingRouters.NewVotingRouter(voteHandler, electionHandler),
-            ),
-        )
-    })
-
-    // Запуск сервера
-    log.Println("Сервер запущен на :8080")
-    log.Fatal(http.ListenAndServe(":8080", r))
+package main
+
+import (
+    "context"
+    "log"
+    "net/http"
+    "time"
+
+    "github.com/go-chi/chi/v5"
+    "github.com/go-chi/chi/v4/middleware"
+    "github.com/go-chi/cors"
+
+    "voting-blockchain/config"
+    "voting-blockchain/db"
+
+    // Auth-модуль
+    authHandlers "voting-blockchain/internal/auth/handlers"
+    authRepos "voting-blockchain/internal/auth/repositories"
+    authRouters "voting-blockchain/internal/auth/routers"
+    authServices "voting-blockchain/internal/auth/services"
+
+    // Voting-модуль
+    votingHandlers "voting-blockchain/internal/voting/handlers"
+    votingModels "voting-blockchain/internal/voting/models"
+    votingRepos "voting-blockchain/internal/voting/repositories"
+    votingRouters "voting-blockchain/internal/voting/routers"
+    votingServices "voting-blockchain/internal/voting/services"
+)
+
+func main() {
+    // Загружаем конфигурацию из .env
+    cfg := config.LoadConfig()
+
+    // Инициализируем БД
+    db.InitDB(cfg)
+
+    // Конвертация TTL для токенов
+    accessTTL := time.Duration(cfg.AccessTokenTTLMin) * time.Minute
+    refreshTTL := time.Duration(cfg.RefreshTokenTTLDays*24) * time.Hour
+
+    // ===== AUTH =====
+    userRepo := authRepos.NewUserRepository()
+    refreshRepo := authRepos.NewRefreshTokenRepository()
+    authService := authServices.NewAuthService(userRepo, refreshRepo, cfg.JWTSecret, accessTTL, refreshTTL)
+    authHandler := authHandlers.NewAuthHandler(authService)
+
+    // ===== VOTING =====
+    voteRepo := votingRepos.NewVotePostgres(db.DB)
+    blockchainRepo := votingRepos.NewBlockchainPostgres(db.DB)
+    electionRepo := votingRepos.NewElectionPostgres(db.DB)
+    choiceRepo := votingRepos.NewChoicePostgres(db.DB) // добавлено
+    signingKeyRepo := votingRepos.NewSigningKeyPostgres(db.DB)
+    rollRepo := votingRepos.NewVoterRollPostgres(db.DB)
+    codeRepo := votingRepos.NewBallotCodePostgres(db.DB)
+    unitOfWork := votingRepos.NewPgUnitOfWork(db.DB)
+
+    // Ключ подписи блоков хранится вне базы, в Postgres публикуется только его публичная часть
+    var signer *votingServices.BlockSigner
+    var err error
+    switch {
+    case cfg.BlockSigningKey != "":
+        signer, err = votingServices.NewBlockSigner(cfg.BlockSigningKey)
+    case cfg.EphemeralSigningKey:
+        // Каждый перезапуск выводит прежний ключ из оборота, поэтому только для разработки
+        log.Println("BLOCK_SIGNING_KEY не задан: блоки будут подписаны временным ключом до перезапуска")
+        signer, err = votingServices.GenerateBlockSigner()
+    default:
+        log.Fatal("BLOCK_SIGNING_KEY не задан (для разработки задайте BLOCK_SIGNING_KEY_EPHEMERAL=true)")
+    }
+    if err != nil {
+        log.Fatalf("Ошибка ключа подписи блоков: %v", err)
+    }
+    if err := signingKeyRepo.Activate(context.Background(), signer.PublicKey()); err != nil {
+        log.Fatalf("Ошибка регистрации ключа подписи: %v", err)
+    }
+    // Подписи цепочек проверяются только текущим и перечисленными здесь прежними ключами
+    var trustedKeys []*votingModels.SigningKey
+    for _, k := range cfg.BlockTrustedKeys {
+        key, err := votingModels.ParseSigningKey(k)
+        if err != nil {
+            log.Fatalf("Ошибка доверенного ключа подписи %q: %v", k, err)
+        }
+        trustedKeys = append(trustedKeys, key)
+    }
+
+    electionService := votingServices.NewElectionService(electionRepo, choiceRepo, unitOfWork, signer)
+    electionHandler := votingHandlers.NewElectionHandler(electionService)
+
+    choiceService := votingServices.NewChoiceService(electionRepo, choiceRepo, unitOfWork)
+    choiceHandler := votingHandlers.NewChoiceHandler(choiceService)
+
+    writeInService := votingServices.NewWriteInService(electionRepo, voteRepo, unitOfWork)
+    writeInHandler := votingHandlers.NewWriteInHandler(writeInService)
+
+    rollService := votingServices.NewVoterRollService(electionRepo, rollRepo, unitOfWork)
+    rollHandler := votingHandlers.NewVoterRollHandler(rollService)
+
+    codeService := votingServices.NewBallotCodeService(electionRepo, codeRepo, unitOfWork)
+    codeHandler := votingHandlers.NewBallotCodeHandler(codeService)
+
+    tokenService := votingServices.NewBlindTokenService(unitOfWork)
+    tokenHandler := votingHandlers.NewBlindTokenHandler(tokenService)
+
+    trusteeService := votingServices.NewTrusteeService(unitOfWork)
+    trusteeHandler := votingHandlers.NewTrusteeHandler(trusteeService)
+
+    voteService := votingServices.NewVoteService(voteRepo, blockchainRepo, electionRepo, choiceRepo, rollRepo, codeRepo, unitOfWork, signer)
+    voteHandler := votingHandlers.NewVoteHandler(voteService)
+
+    blockchainService := votingServices.NewBlockchainService(blockchainRepo, voteRepo, electionRepo, choiceRepo, signingKeyRepo, unitOfWork, signer, trustedKeys)
+    blockchainHandler := votingHandlers.NewBlockchainHandler(blockchainService)
+
+    // Цепочки, записанные до подписей, закрепляются подписанным блоком-якорем.
+    // Якорь подписывает то, что лежит в базе, поэтому это разовый шаг при обновлении
+    if cfg.AnchorUnsignedChains {
+        anchored, err := blockchainService.AnchorUnsignedChains(context.Background())
+        if err != nil {
+            log.Fatalf("Ошибка закрепления неподписанных цепочек: %v", err)
+        }
+        log.Printf("Закреплено неподписанных цепочек: %d", anchored)
+    }
+
+    // Планировщик открывает и закрывает голосования по opens_at/closes_at;
+    // при нескольких репликах работает только одна из них
+    scheduler := votingServices.NewScheduler(
+        electionRepo,
+        unitOfWork,
+        votingRepos.NewPgLocker(db.DB),
+        signer,
+        time.Duration(cfg.SchedulerIntervalSec)*time.Second,
+    )
+    go scheduler.Run(context.Background())
+
+
+    // ===== ROUTING =====
+    r := chi.NewRouter()
+
+    // ==== MIDDLEWARE ====
+    r.Use(middleware.Logger)
+    r.Use(middleware.Recoverer)
+
+    // CORS разрешаем фронту доступ
+    r.Use(cors.Handler(cors.Options{
+        AllowedOrigins:   []string{"*"},
+        AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
+        AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token"},
+        ExposedHeaders:   []string{"Link"},
+        AllowCredentials: true,
+        MaxAge:           300,
+    }))
+
+    // (опционально) префикс API
+    r.Route("/api/v1", func(api chi.Router) {
+        // Auth маршруты
+        api.Mount("/auth", authRouters.NewAuthRouter(authHandler, []byte(cfg.JWTSecret)))
+
+        // Voting маршруты (JWT проверяется внутри, кроме публичных)
+        api.Mount("/voting",
+            votingRouters.NewVotingRouter(voteHandler, electionHandler, choiceHandler, writeInHandler, rollHandler, codeHandler, tokenHandler, trusteeHandler, blockchainHandler, []byte(cfg.JWTSecret)),
+        )
+    })
+
+    // Запуск сервера
+    log.Println("Сервер запущен на :8080")
+    log.Fatal(http.ListenAndServe(":8080", r))
 }
\ No newline at end of file
@@ -1,32 +1,55 @@
-package config
-
-import (
-	"os"
-	"strconv"
-)
-
-type Config struct {
-	DBURL               string
-	JWTSecret           string
-	AccessTokenTTLMin   int
-	RefreshTokenTTLDays int
-}
-
-func LoadConfig() *Config {
-	accessTTL, err := strconv.Atoi(os.Getenv("ACCESS_TOKEN_TTL_MINUTES"))
-	if err != nil {
-		accessTTL = 15
-	}
-
-	refreshTTL, err := strconv.Atoi(os.Getenv("REFRESH_TOKEN_TTL_DAYS"))
-	if err != nil {
-		refreshTTL = 7
-	}
-
-	return &Config{
-		DBURL:               os.Getenv("DB_URL"),
-		JWTSecret:           os.Getenv("JWT_SECRET"),
-		AccessTokenTTLMin:   accessTTL,
-		RefreshTokenTTLDays: refreshTTL,
-	}
-}
+package config
+
+import (
+	"os"
+	"strconv"
+	"strings"
+)
+
+type Config struct {
+	DBURL                string
+	JWTSecret            string
+	AccessTokenTTLMin    int
+	RefreshTokenTTLDays  int
+	BlockSigningKey      string   // seed Ed25519 в hex, обязателен
+	BlockTrustedKeys     []string // публичные ключи Ed25519 прежних ключей узла в hex
+	EphemeralSigningKey  bool     // только для разработки: без BLOCK_SIGNING_KEY генерировать временный ключ
+	AnchorUnsignedChains bool     // однократно при обновлении: закрепить якорем цепочки, записанные до подписей
+	SchedulerIntervalSec int
+}
+
+func LoadConfig() *Config {
+	accessTTL, err := strconv.Atoi(os.Getenv("ACCESS_TOKEN_TTL_MINUTES"))
+	if err != nil {
+		accessTTL = 15
+	}
+
+	refreshTTL, err := strconv.Atoi(os.Getenv("REFRESH_TOKEN_TTL_DAYS"))
+	if err != nil {
+		refreshTTL = 7
+	}
+
+	schedulerInterval, err := strconv.Atoi(os.Getenv("SCHEDULER_INTERVAL_SECONDS"))
+	if err != nil || schedulerInterval < 1 {
+		schedulerInterval = 15
+	}
+
+	var trustedKeys []string
+	for _, k := range strings.Split(os.Getenv("BLOCK_TRUSTED_KEYS"), ",") {
+		if k = strings.TrimSpace(k); k != "" {
+			trustedKeys = append(trustedKeys, k)
+		}
+	}
+
+	return &Config{
+		DBURL:                os.Getenv("DB_URL"),
+		JWTSecret:            os.Getenv("JWT_SECRET"),
+		AccessTokenTTLMin:    accessTTL,
+		RefreshTokenTTLDays:  refreshTTL,
+		BlockSigningKey:      os.Getenv("BLOCK_SIGNING_KEY"),
+		BlockTrustedKeys:     trustedKeys,
+		EphemeralSigningKey:  os.Getenv("BLOCK_SIGNING_KEY_EPHEMERAL") == "true",
+		AnchorUnsignedChains: os.Getenv("ANCHOR_UNSIGNED_CHAINS") == "true",
+		SchedulerIntervalSec: schedulerInterval,
+	}
+}
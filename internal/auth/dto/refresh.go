@@ -1,9 +1,9 @@
-package dto
-
-type RefreshRequest struct {
-    RefreshToken string `json:"refresh_token"`
-}
-
-type RefreshResponse struct {
-    AccessToken string `json:"access_token"`
-}
+package dto
+
+type RefreshRequest struct {
+    RefreshToken string `json:"refresh_token"`
+}
+
+type RefreshResponse struct {
+    AccessToken string `json:"access_token"`
+}
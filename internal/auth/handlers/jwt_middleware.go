@@ -1,107 +1,107 @@
-package handlers
-
-import (
-	"context"
-	"errors"
-	"fmt"
-	"log"
-	"net/http"
-	"strings"
-
-	"github.com/golang-jwt/jwt/v4"
-)
-
-type contextKey string
-
-const (
-	userIDKey   contextKey = "user_id"
-	userRoleKey contextKey = "user_role"
-)
-
-// NewJWTMiddleware возвращает middleware для проверки JWT с заданным секретом.
-func NewJWTMiddleware(secret []byte) func(http.Handler) http.Handler {
-	return func(next http.Handler) http.Handler {
-		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			authHeader := r.Header.Get("Authorization")
-			if authHeader == "" {
-				http.Error(w, "Missing Authorization header", http.StatusUnauthorized)
-				return
-			}
-
-			parts := strings.Split(authHeader, " ")
-			if len(parts) != 2 || parts[0] != "Bearer" {
-				http.Error(w, "Invalid Authorization header", http.StatusUnauthorized)
-				return
-			}
-
-			tokenStr := parts[1]
-
-			token, err := jwt.Parse(tokenStr, func(t *jwt.Token) (interface{}, error) {
-				if t.Method != jwt.SigningMethodHS256 {
-					return nil, errors.New("unexpected signing method")
-				}
-				return secret, nil
-			})
-
-			if err != nil {
-				log.Printf("JWT parse error: %v", err)
-				http.Error(w, "Invalid token: "+err.Error(), http.StatusUnauthorized)
-				return
-			}
-
-			if !token.Valid {
-				http.Error(w, "Invalid token: token not valid", http.StatusUnauthorized)
-				return
-			}
-
-			claims, ok := token.Claims.(jwt.MapClaims)
-			if !ok {
-				http.Error(w, "Invalid claims", http.StatusUnauthorized)
-				return
-			}
-
-			userIDFloat, ok := claims["user_id"].(float64)
-			if !ok {
-				http.Error(w, "Invalid user_id", http.StatusUnauthorized)
-				return
-			}
-
-			rawRole, ok := claims["role"]
-			if !ok {
-				http.Error(w, "Role claim not found", http.StatusUnauthorized)
-				return
-			}
-
-			roleStr := strings.TrimSpace(fmt.Sprintf("%v", rawRole))
-			if roleStr == "" {
-				http.Error(w, "Empty role", http.StatusUnauthorized)
-				return
-			}
-
-			ctx := context.WithValue(r.Context(), userIDKey, int(userIDFloat))
-			ctx = context.WithValue(ctx, userRoleKey, roleStr)
-
-			next.ServeHTTP(w, r.WithContext(ctx))
-		})
-	}
-}
-
-// GetUserID достает user_id из контекста запроса
-func GetUserID(r *http.Request) (int, error) {
-	id := r.Context().Value(userIDKey)
-	userID, ok := id.(int)
-	if !ok {
-		return 0, errors.New("user_id not found")
-	}
-	return userID, nil
-}
-
-// GetUserRole достает роль пользователя из контекста запроса
-func GetUserRole(r *http.Request) (string, error) {
-	role := r.Context().Value(userRoleKey)
-	roleStr, ok := role.(string)
-	if !ok {
-		return "", errors.New("user_role not found")
-	}
-	return strings.TrimSpace(roleStr), nil
-}
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+type contextKey string
+
+const (
+	userIDKey   contextKey = "user_id"
+	userRoleKey contextKey = "user_role"
+)
+
+// NewJWTMiddleware возвращает middleware для проверки JWT с заданным секретом.
+func NewJWTMiddleware(secret []byte) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			authHeader := r.Header.Get("Authorization")
+			if authHeader == "" {
+				http.Error(w, "Missing Authorization header", http.StatusUnauthorized)
+				return
+			}
+
+			parts := strings.Split(authHeader, " ")
+			if len(parts) != 2 || parts[0] != "Bearer" {
+				http.Error(w, "Invalid Authorization header", http.StatusUnauthorized)
+				return
+			}
+
+			tokenStr := parts[1]
+
+			token, err := jwt.Parse(tokenStr, func(t *jwt.Token) (interface{}, error) {
+				if t.Method != jwt.SigningMethodHS256 {
+					return nil, errors.New("unexpected signing method")
+				}
+				return secret, nil
+			})
+
+			if err != nil {
+				log.Printf("JWT parse error: %v", err)
+				http.Error(w, "Invalid token: "+err.Error(), http.StatusUnauthorized)
+				return
+			}
+
+			if !token.Valid {
+				http.Error(w, "Invalid token: token not valid", http.StatusUnauthorized)
+				return
+			}
+
+			claims, ok := token.Claims.(jwt.MapClaims)
+			if !ok {
+				http.Error(w, "Invalid claims", http.StatusUnauthorized)
+				return
+			}
+
+			userIDFloat, ok := claims["user_id"].(float64)
+			if !ok {
+				http.Error(w, "Invalid user_id", http.StatusUnauthorized)
+				return
+			}
+
+			rawRole, ok := claims["role"]
+			if !ok {
+				http.Error(w, "Role claim not found", http.StatusUnauthorized)
+				return
+			}
+
+			roleStr := strings.TrimSpace(fmt.Sprintf("%v", rawRole))
+			if roleStr == "" {
+				http.Error(w, "Empty role", http.StatusUnauthorized)
+				return
+			}
+
+			ctx := context.WithValue(r.Context(), userIDKey, int(userIDFloat))
+			ctx = context.WithValue(ctx, userRoleKey, roleStr)
+
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// GetUserID достает user_id из контекста запроса
+func GetUserID(r *http.Request) (int, error) {
+	id := r.Context().Value(userIDKey)
+	userID, ok := id.(int)
+	if !ok {
+		return 0, errors.New("user_id not found")
+	}
+	return userID, nil
+}
+
+// GetUserRole достает роль пользователя из контекста запроса
+func GetUserRole(r *http.Request) (string, error) {
+	role := r.Context().Value(userRoleKey)
+	roleStr, ok := role.(string)
+	if !ok {
+		return "", errors.New("user_role not found")
+	}
+	return strings.TrimSpace(roleStr), nil
+}
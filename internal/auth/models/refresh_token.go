@@ -1,10 +1,10 @@
-package models
-
-import "time"
-
-type RefreshToken struct {
-	Token     string
-	UserID    int
-	ExpiresAt time.Time
-	Revoked   bool
-}
+package models
+
+import "time"
+
+type RefreshToken struct {
+	Token     string
+	UserID    int
+	ExpiresAt time.Time
+	Revoked   bool
+}
@@ -0,0 +1,12 @@
+package dto
+
+// BallotCodesRequest — выпуск одноразовых кодов голосования
+type BallotCodesRequest struct {
+	Count int `json:"count"`
+}
+
+// CastVoteWithCodeRequest — голос по одноразовому коду вместо входа в систему
+type CastVoteWithCodeRequest struct {
+	Code string `json:"code"`
+	CastVoteRequest
+}
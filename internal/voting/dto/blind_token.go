@@ -0,0 +1,18 @@
+package dto
+
+// BlindTokenRequest — маска жетона анонимного голосования (hex)
+type BlindTokenRequest struct {
+	Blinded string `json:"blinded"`
+}
+
+// BlindTokenResponse — слепая подпись маски (hex)
+type BlindTokenResponse struct {
+	Signature string `json:"signature"`
+}
+
+// AnonymousVoteRequest — голос по жетону: жетон и снятая с маски подпись (hex)
+type AnonymousVoteRequest struct {
+	Token     string `json:"token"`
+	Signature string `json:"signature"`
+	CastVoteRequest
+}
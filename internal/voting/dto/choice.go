@@ -0,0 +1,15 @@
+package dto
+
+// ChoiceRequest — DTO для добавления и изменения варианта голосования
+type ChoiceRequest struct {
+	Text        string `json:"text"`
+	Description string `json:"description"`
+	ImageURL    string `json:"image_url"`
+	// ContestID — вопрос, к которому относится вариант; только при добавлении
+	ContestID int `json:"contest_id"`
+}
+
+// ReorderChoicesRequest — новый порядок вариантов: id всех вариантов голосования
+type ReorderChoicesRequest struct {
+	ChoiceIDs []int `json:"choice_ids"`
+}
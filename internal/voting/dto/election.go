@@ -1,16 +1,77 @@
-package dto
-
-// CreateElectionRequest — DTO для создания голосования
-type CreateElectionRequest struct {
-	Title       string   `json:"title"`
-	Description string   `json:"description"`
-	IsActive    bool     `json:"is_active"`
-	Choices     []string `json:"choices"` 
-}
-
-// UpdateElectionRequest — DTO для обновления голосования
-type UpdateElectionRequest struct {
-	Title       string   `json:"title"`
-	Description string   `json:"description"`
-	IsActive    bool     `json:"is_active"`
-}
+package dto
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// CreateElectionRequest — DTO для создания голосования
+type CreateElectionRequest struct {
+	Title       string   `json:"title"`
+	Description string   `json:"description"`
+	IsActive    bool     `json:"is_active"` // устарело: true — то же, что status "open"
+	Choices     []string `json:"choices"` 
+	// Начальное состояние: draft (по умолчанию), scheduled или open
+	Status   string     `json:"status"`
+	OpensAt  *time.Time `json:"opens_at"`
+	ClosesAt *time.Time `json:"closes_at"`
+	// Способ голосования: plurality (по умолчанию), irv, schulze, stv, approval
+	// или score; для score — наибольшая оценка (по умолчанию 5), для stv —
+	// число мест (по умолчанию 1)
+	VotingMethod string `json:"voting_method"`
+	MaxScore     int    `json:"max_score"`
+	Seats        int    `json:"seats"`
+	// Разрешить вписанные варианты (только plurality)
+	AllowWriteIns bool `json:"allow_write_ins"`
+	// Кто может голосовать: all (по умолчанию) или roll — только список избирателей
+	Eligibility string `json:"eligibility"`
+	// Анонимность: none (по умолчанию) или blind — голоса по жетонам со слепой подписью
+	Anonymity string `json:"anonymity"`
+	// Шифрование бюллетеней: none (по умолчанию), elgamal — итоги только по
+	// сумме зашифрованных бюллетеней (plurality, approval, score) или threshold —
+	// то же, но ключ создают доверенные лица, и расшифровывают итоги любые
+	// trustee_threshold из них
+	Encryption       string `json:"encryption"`
+	TrusteeThreshold int    `json:"trustee_threshold"`
+	// Вопросы бюллетеня со своими вариантами и способами подсчёта; вместо
+	// choices и voting_method
+	Contests []ContestRequest `json:"contests"`
+	// Пачки голосов: блок запечатывается по числу голосов или по времени ожидания
+	BatchSize          int `json:"batch_size"`
+	BatchWindowSeconds int `json:"batch_window_seconds"`
+}
+
+// ContestRequest — вопрос бюллетеня при создании голосования
+type ContestRequest struct {
+	Title         string   `json:"title"`
+	VotingMethod  string   `json:"voting_method"`
+	MaxScore      int      `json:"max_score"`
+	Seats         int      `json:"seats"`
+	AllowWriteIns bool     `json:"allow_write_ins"`
+	Choices       []string `json:"choices"`
+}
+
+// UpdateElectionRequest — DTO для обновления голосования; не переданные поля не меняются
+type UpdateElectionRequest struct {
+	Title       string `json:"title"`       // пусто — не меняется
+	Description string `json:"description"` // пусто — не меняется
+	IsActive    *bool  `json:"is_active"`   // устарело: true — переход в open, false — в closed
+	Status      string `json:"status"`      // пусто — состояние не меняется
+	// Окно голосования: не передано — не меняется, null — снимается
+	OpensAt  json.RawMessage `json:"opens_at"`
+	ClosesAt json.RawMessage `json:"closes_at"`
+	// Способ голосования меняется только в черновике; пусто — не меняется
+	// вместе с max_score и seats
+	VotingMethod string `json:"voting_method"`
+	MaxScore     int    `json:"max_score"`
+	Seats        int    `json:"seats"`
+	// Не передано (или null) — не меняется; false снимает разрешение
+	AllowWriteIns *bool `json:"allow_write_ins"`
+	// Режимы допуска, анонимности и шифрования меняются только в черновике;
+	// пусто (0 для порога) — не меняются
+	Eligibility      string `json:"eligibility"`
+	Anonymity        string `json:"anonymity"`
+	Encryption       string `json:"encryption"`
+	TrusteeThreshold int    `json:"trustee_threshold"`
+}
+
@@ -0,0 +1,24 @@
+package dto
+
+// EncryptedBallotRequest — бюллетень, зашифрованный на открытый ключ
+// голосования: по шифртексту на каждый вариант в порядке возрастания id
+type EncryptedBallotRequest struct {
+	Choices []EncryptedChoiceRequest `json:"choices"`
+	// SumProof — доказательство того, что выбран ровно один вариант (plurality)
+	SumProof []ProofRequest `json:"sum_proof"`
+}
+
+// EncryptedChoiceRequest — шифртекст (a, b) значения варианта и
+// доказательство его допустимости; числа в hex
+type EncryptedChoiceRequest struct {
+	ChoiceID int            `json:"choice_id"`
+	A        string         `json:"a"`
+	B        string         `json:"b"`
+	Proof    []ProofRequest `json:"proof"`
+}
+
+// ProofRequest — ветвь доказательства: вызов и ответ в hex
+type ProofRequest struct {
+	C string `json:"c"`
+	Z string `json:"z"`
+}
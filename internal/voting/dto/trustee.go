@@ -0,0 +1,44 @@
+package dto
+
+// RegisterTrusteeRequest — пользователь, которого админ назначает доверенным лицом
+type RegisterTrusteeRequest struct {
+	UserID int `json:"user_id"`
+}
+
+// TrusteeKeyRequest — ключ доверенного лица для получения долей, в hex
+type TrusteeKeyRequest struct {
+	PublicKey string `json:"public_key"`
+}
+
+// TrusteeDealRequest — обязательства многочлена доверенного лица и его
+// доли всем доверенным лицам, зашифрованные на их ключи
+type TrusteeDealRequest struct {
+	Commitments []string                `json:"commitments"`
+	Shares      []EncryptedShareRequest `json:"shares"`
+}
+
+// EncryptedShareRequest — доля для доверенного лица to: R и C в hex
+type EncryptedShareRequest struct {
+	To int    `json:"to"`
+	R  string `json:"r"`
+	C  string `json:"c"`
+}
+
+// VerifySharesRequest — сошлись ли полученные доли с обязательствами
+type VerifySharesRequest struct {
+	Accepted bool `json:"accepted"`
+}
+
+// PartialDecryptionsRequest — частичные расшифровки сумм по вариантам в
+// порядке возрастания id
+type PartialDecryptionsRequest struct {
+	Partials []PartialDecryptionRequest `json:"partials"`
+}
+
+// PartialDecryptionRequest — D = A^x_i суммы варианта и доказательство
+// правильности расшифровки
+type PartialDecryptionRequest struct {
+	ChoiceID int          `json:"choice_id"`
+	D        string       `json:"d"`
+	Proof    ProofRequest `json:"proof"`
+}
@@ -1,5 +1,34 @@
-package dto
-
-type CastVoteRequest struct {
-	Choice string `json:"choice"`
-}
+package dto
+
+type CastVoteRequest struct {
+	BallotRequest
+	// Contests — ответы по вопросам для голосований из нескольких вопросов
+	Contests []ContestBallotRequest `json:"contests"`
+	// Encrypted — зашифрованный бюллетень для голосований с шифрованием
+	Encrypted *EncryptedBallotRequest `json:"encrypted"`
+}
+
+// BallotRequest — ответ на один вопрос бюллетеня
+type BallotRequest struct {
+	ChoiceID int    `json:"choice_id"`
+	Choice   string `json:"choice"` // устарело: текст варианта, если choice_id не передан
+	// WriteIn — вписанный вариант, если голосование или вопрос их разрешает
+	WriteIn string `json:"write_in"`
+	// Ranking — id вариантов в порядке предпочтения для ранжированных голосований
+	Ranking []int `json:"ranking"`
+	// Approvals — одобренные варианты (approval), Scores — оценки вариантов (score)
+	Approvals []int          `json:"approvals"`
+	Scores    []ScoreRequest `json:"scores"`
+}
+
+// ContestBallotRequest — ответ на вопрос contest_id
+type ContestBallotRequest struct {
+	ContestID int `json:"contest_id"`
+	BallotRequest
+}
+
+// ScoreRequest — оценка варианта в бюллетене score
+type ScoreRequest struct {
+	ChoiceID int `json:"choice_id"`
+	Score    int `json:"score"`
+}
@@ -0,0 +1,9 @@
+package dto
+
+// VoterRollRequest — загрузка списка избирателей в JSON; replace — заменить
+// весь список, иначе записи добавляются к нему
+type VoterRollRequest struct {
+	UserIDs []int    `json:"user_ids"`
+	Emails  []string `json:"emails"`
+	Replace bool     `json:"replace"`
+}
@@ -0,0 +1,9 @@
+package dto
+
+// WriteInReviewRequest — решение по вписанному варианту: голоса за text в
+// вопросе contest_id засчитываются варианту choice_id (0 — остаются вписанными)
+type WriteInReviewRequest struct {
+	ContestID int    `json:"contest_id"`
+	Text      string `json:"text"`
+	ChoiceID  int    `json:"choice_id"`
+}
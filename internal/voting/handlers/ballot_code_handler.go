@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"voting-blockchain/internal/voting/dto"
+	"voting-blockchain/internal/voting/services"
+	authhandlers "voting-blockchain/internal/auth/handlers"
+)
+
+type BallotCodeHandler struct {
+	service services.BallotCodeService
+}
+
+func NewBallotCodeHandler(s services.BallotCodeService) *BallotCodeHandler {
+	return &BallotCodeHandler{service: s}
+}
+
+// GET /elections/{id}/codes — сколько кодов выпущено и использовано
+func (h *BallotCodeHandler) Stats(w http.ResponseWriter, r *http.Request) {
+	role, err := authhandlers.GetUserRole(r)
+	if err != nil {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	if role != "admin" {
+		http.Error(w, "forbidden: only admin can manage ballot codes", http.StatusForbidden)
+		return
+	}
+
+	electionID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "invalid election ID", http.StatusBadRequest)
+		return
+	}
+
+	stats, err := h.service.Stats(r.Context(), electionID)
+	if err != nil {
+		writeBallotCodeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(stats); err != nil {
+		http.Error(w, "failed to encode response", http.StatusInternalServerError)
+	}
+}
+
+// POST /elections/{id}/codes — выпускает коды и отдаёт их файлом CSV.
+// Коды больше нигде не показываются: файл нужно сохранить сразу.
+func (h *BallotCodeHandler) Generate(w http.ResponseWriter, r *http.Request) {
+	role, err := authhandlers.GetUserRole(r)
+	if err != nil {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	if role != "admin" {
+		http.Error(w, "forbidden: only admin can manage ballot codes", http.StatusForbidden)
+		return
+	}
+
+	electionID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "invalid election ID", http.StatusBadRequest)
+		return
+	}
+
+	var req dto.BallotCodesRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	codes, err := h.service.Generate(r.Context(), electionID, req.Count)
+	if err != nil {
+		writeBallotCodeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="election-%d-codes.csv"`, electionID))
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusCreated)
+	if err := services.WriteBallotCodesCSV(w, electionID, codes); err != nil {
+		http.Error(w, "failed to write codes", http.StatusInternalServerError)
+	}
+}
+
+func writeBallotCodeError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, services.ErrElectionNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, services.ErrBallotCodesLocked):
+		http.Error(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, services.ErrInvalidCodeCount):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	default:
+		http.Error(w, "failed to manage ballot codes: "+err.Error(), http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,96 @@
+package handlers
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"voting-blockchain/internal/voting/dto"
+	"voting-blockchain/internal/voting/services"
+	authhandlers "voting-blockchain/internal/auth/handlers"
+)
+
+type BlindTokenHandler struct {
+	service services.BlindTokenService
+}
+
+func NewBlindTokenHandler(s services.BlindTokenService) *BlindTokenHandler {
+	return &BlindTokenHandler{service: s}
+}
+
+// GET /elections/{id}/blind-key — открытый ключ подписи жетонов
+func (h *BlindTokenHandler) PublicKey(w http.ResponseWriter, r *http.Request) {
+	electionID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "invalid election ID", http.StatusBadRequest)
+		return
+	}
+
+	key, err := h.service.PublicKey(r.Context(), electionID)
+	if err != nil {
+		writeBlindTokenError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(key); err != nil {
+		http.Error(w, "failed to encode response", http.StatusInternalServerError)
+	}
+}
+
+// POST /elections/{id}/tokens — слепая подпись маски жетона вошедшему пользователю
+func (h *BlindTokenHandler) Issue(w http.ResponseWriter, r *http.Request) {
+	userID, err := authhandlers.GetUserID(r)
+	if err != nil {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	electionID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "invalid election ID", http.StatusBadRequest)
+		return
+	}
+
+	var req dto.BlindTokenRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid JSON", http.StatusBadRequest)
+		return
+	}
+	blinded, err := hex.DecodeString(req.Blinded)
+	if err != nil || len(blinded) == 0 {
+		http.Error(w, "blinded must be a hex string", http.StatusBadRequest)
+		return
+	}
+
+	sig, err := h.service.Issue(r.Context(), userID, electionID, blinded)
+	if err != nil {
+		writeBlindTokenError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(dto.BlindTokenResponse{Signature: hex.EncodeToString(sig)}); err != nil {
+		http.Error(w, "failed to encode response", http.StatusInternalServerError)
+	}
+}
+
+func writeBlindTokenError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, services.ErrElectionNotFound), errors.Is(err, services.ErrBlindKeyPending):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, services.ErrNotEligible):
+		http.Error(w, err.Error(), http.StatusForbidden)
+	case errors.Is(err, services.ErrNotAnonymous), errors.Is(err, services.ErrElectionNotOpen),
+		errors.Is(err, services.ErrTokenAlreadyIssued):
+		http.Error(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, services.ErrInvalidBlindToken):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	default:
+		http.Error(w, "failed to issue token: "+err.Error(), http.StatusInternalServerError)
+	}
+}
@@ -1,68 +1,212 @@
-package handlers
-
-import (
-	"encoding/json"
-	"net/http"
-	"strconv"
-
-	"github.com/go-chi/chi/v5"
-	"voting-blockchain/internal/voting/dto"
-	"voting-blockchain/internal/voting/services"
-)
-
-// BlockchainHandler — обработчик блокчейна
-type BlockchainHandler struct {
-	blockchain services.BlockchainService
-}
-
-// NewBlockchainHandler — конструктор
-func NewBlockchainHandler(svc services.BlockchainService) *BlockchainHandler {
-	return &BlockchainHandler{blockchain: svc}
-}
-
-// POST /elections/{id}/vote
-func (h *BlockchainHandler) Vote(w http.ResponseWriter, r *http.Request) {
-	electionIDStr := chi.URLParam(r, "id")
-	electionID, err := strconv.Atoi(electionIDStr)
-	if err != nil {
-		http.Error(w, "invalid election id", http.StatusBadRequest)
-		return
-	}
-
-	var req dto.AddBlockRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.VoteHash == "" {
-		http.Error(w, "invalid request", http.StatusBadRequest)
-		return
-	}
-
-	block, err := h.blockchain.AddBlock(r.Context(), electionID, req.VoteHash)
-	if err != nil {
-		http.Error(w, "failed to add block: "+err.Error(), http.StatusInternalServerError)
-		return
-	}
-
-	w.WriteHeader(http.StatusCreated)
-	if err := json.NewEncoder(w).Encode(block); err != nil {
-		http.Error(w, "failed to encode response", http.StatusInternalServerError)
-	}
-}
-
-// GET /elections/{id}/blocks
-func (h *BlockchainHandler) GetChain(w http.ResponseWriter, r *http.Request) {
-	electionIDStr := chi.URLParam(r, "id")
-	electionID, err := strconv.Atoi(electionIDStr)
-	if err != nil {
-		http.Error(w, "invalid election id", http.StatusBadRequest)
-		return
-	}
-
-	chain, err := h.blockchain.GetChain(r.Context(), electionID)
-	if err != nil {
-		http.Error(w, "failed to load chain: "+err.Error(), http.StatusInternalServerError)
-		return
-	}
-
-	if err := json.NewEncoder(w).Encode(chain); err != nil {
-		http.Error(w, "failed to encode response", http.StatusInternalServerError)
-	}
-}
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"voting-blockchain/internal/voting/dto"
+	"voting-blockchain/internal/voting/services"
+	authhandlers "voting-blockchain/internal/auth/handlers"
+)
+
+// BlockchainHandler — обработчик блокчейна
+type BlockchainHandler struct {
+	blockchain services.BlockchainService
+}
+
+// NewBlockchainHandler — конструктор
+func NewBlockchainHandler(svc services.BlockchainService) *BlockchainHandler {
+	return &BlockchainHandler{blockchain: svc}
+}
+
+// POST /elections/{id}/vote
+func (h *BlockchainHandler) Vote(w http.ResponseWriter, r *http.Request) {
+	electionIDStr := chi.URLParam(r, "id")
+	electionID, err := strconv.Atoi(electionIDStr)
+	if err != nil {
+		http.Error(w, "invalid election id", http.StatusBadRequest)
+		return
+	}
+
+	var req dto.AddBlockRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.VoteHash == "" {
+		http.Error(w, "invalid request", http.StatusBadRequest)
+		return
+	}
+
+	block, err := h.blockchain.AddBlock(r.Context(), electionID, req.VoteHash)
+	if err != nil {
+		http.Error(w, "failed to add block: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(block); err != nil {
+		http.Error(w, "failed to encode response", http.StatusInternalServerError)
+	}
+}
+
+// GET /elections/{id}/blocks
+func (h *BlockchainHandler) GetChain(w http.ResponseWriter, r *http.Request) {
+	electionIDStr := chi.URLParam(r, "id")
+	electionID, err := strconv.Atoi(electionIDStr)
+	if err != nil {
+		http.Error(w, "invalid election id", http.StatusBadRequest)
+		return
+	}
+
+	chain, err := h.blockchain.GetChain(r.Context(), electionID)
+	if err != nil {
+		http.Error(w, "failed to load chain: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(chain); err != nil {
+		http.Error(w, "failed to encode response", http.StatusInternalServerError)
+	}
+}
+
+// GET /keys
+func (h *BlockchainHandler) GetSigningKeys(w http.ResponseWriter, r *http.Request) {
+	keys, err := h.blockchain.GetSigningKeys(r.Context())
+	if err != nil {
+		http.Error(w, "failed to load signing keys: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(keys); err != nil {
+		http.Error(w, "failed to encode response", http.StatusInternalServerError)
+	}
+}
+
+// GET /elections/{id}/blocks/verify
+func (h *BlockchainHandler) VerifyChain(w http.ResponseWriter, r *http.Request) {
+	electionIDStr := chi.URLParam(r, "id")
+	electionID, err := strconv.Atoi(electionIDStr)
+	if err != nil {
+		http.Error(w, "invalid election id", http.StatusBadRequest)
+		return
+	}
+
+	result, err := h.blockchain.VerifyChain(r.Context(), electionID)
+	if err != nil {
+		http.Error(w, "failed to verify chain: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(result); err != nil {
+		http.Error(w, "failed to encode response", http.StatusInternalServerError)
+	}
+}
+
+// POST /elections/{id}/blocks/seal
+func (h *BlockchainHandler) SealPending(w http.ResponseWriter, r *http.Request) {
+	role, err := authhandlers.GetUserRole(r)
+	if err != nil {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	if role != "admin" {
+		http.Error(w, "forbidden: only admin can seal blocks", http.StatusForbidden)
+		return
+	}
+
+	electionIDStr := chi.URLParam(r, "id")
+	electionID, err := strconv.Atoi(electionIDStr)
+	if err != nil {
+		http.Error(w, "invalid election id", http.StatusBadRequest)
+		return
+	}
+
+	block, err := h.blockchain.SealPending(r.Context(), electionID)
+	if err != nil {
+		http.Error(w, "failed to seal block: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if block == nil {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(block); err != nil {
+		http.Error(w, "failed to encode response", http.StatusInternalServerError)
+	}
+}
+
+// GET /elections/{id}/export
+func (h *BlockchainHandler) ExportElection(w http.ResponseWriter, r *http.Request) {
+	electionIDStr := chi.URLParam(r, "id")
+	electionID, err := strconv.Atoi(electionIDStr)
+	if err != nil {
+		http.Error(w, "invalid election id", http.StatusBadRequest)
+		return
+	}
+
+	archive, err := h.blockchain.ExportElection(r.Context(), electionID)
+	if errors.Is(err, services.ErrElectionNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, "failed to export election: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="election-%d.jsonl"`, electionID))
+	if err := services.WriteArchive(w, archive); err != nil {
+		http.Error(w, "failed to encode archive", http.StatusInternalServerError)
+	}
+}
+
+// POST /elections/import
+func (h *BlockchainHandler) ImportElection(w http.ResponseWriter, r *http.Request) {
+	role, err := authhandlers.GetUserRole(r)
+	if err != nil {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	if role != "admin" {
+		http.Error(w, "forbidden: only admin can import elections", http.StatusForbidden)
+		return
+	}
+
+	archive, err := services.ReadArchive(r.Body)
+	if err != nil {
+		http.Error(w, "invalid archive: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	result, err := h.blockchain.ImportElection(r.Context(), archive)
+	switch {
+	case errors.Is(err, services.ErrInvalidArchive):
+		http.Error(w, "invalid archive: "+err.Error(), http.StatusBadRequest)
+		return
+	case errors.Is(err, services.ErrElectionExists):
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	case errors.Is(err, services.ErrArchiveChainInvalid):
+		// Вердикт проверки отдаётся целиком, чтобы было видно, где сломана цепочка
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		_ = json.NewEncoder(w).Encode(result)
+		return
+	case err != nil:
+		http.Error(w, "failed to import election: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(result); err != nil {
+		http.Error(w, "failed to encode response", http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,207 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"voting-blockchain/internal/voting/dto"
+	"voting-blockchain/internal/voting/models"
+	"voting-blockchain/internal/voting/services"
+	authhandlers "voting-blockchain/internal/auth/handlers"
+)
+
+type ChoiceHandler struct {
+	service services.ChoiceService
+}
+
+func NewChoiceHandler(s services.ChoiceService) *ChoiceHandler {
+	return &ChoiceHandler{service: s}
+}
+
+// GET /elections/{id}/choices
+func (h *ChoiceHandler) List(w http.ResponseWriter, r *http.Request) {
+	electionID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "invalid election ID", http.StatusBadRequest)
+		return
+	}
+
+	choices, err := h.service.List(r.Context(), electionID)
+	if err != nil {
+		writeChoiceError(w, err)
+		return
+	}
+
+	writeChoiceJSON(w, http.StatusOK, choices)
+}
+
+// POST /elections/{id}/choices
+func (h *ChoiceHandler) Add(w http.ResponseWriter, r *http.Request) {
+	role, err := authhandlers.GetUserRole(r)
+	if err != nil {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	if role != "admin" {
+		http.Error(w, "forbidden: only admin can manage choices", http.StatusForbidden)
+		return
+	}
+
+	electionID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "invalid election ID", http.StatusBadRequest)
+		return
+	}
+
+	var req dto.ChoiceRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	c := &models.Choice{
+		ElectionID:  electionID,
+		Text:        req.Text,
+		Description: req.Description,
+		ImageURL:    req.ImageURL,
+		ContestID:   req.ContestID,
+	}
+	if err := h.service.Add(r.Context(), c); err != nil {
+		writeChoiceError(w, err)
+		return
+	}
+
+	writeChoiceJSON(w, http.StatusCreated, c)
+}
+
+// PUT /elections/{id}/choices/{choiceID}
+func (h *ChoiceHandler) Update(w http.ResponseWriter, r *http.Request) {
+	role, err := authhandlers.GetUserRole(r)
+	if err != nil {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	if role != "admin" {
+		http.Error(w, "forbidden: only admin can manage choices", http.StatusForbidden)
+		return
+	}
+
+	electionID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "invalid election ID", http.StatusBadRequest)
+		return
+	}
+	choiceID, err := strconv.Atoi(chi.URLParam(r, "choiceID"))
+	if err != nil {
+		http.Error(w, "invalid choice ID", http.StatusBadRequest)
+		return
+	}
+
+	var req dto.ChoiceRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	c := &models.Choice{
+		ID:          choiceID,
+		ElectionID:  electionID,
+		Text:        req.Text,
+		Description: req.Description,
+		ImageURL:    req.ImageURL,
+	}
+	if err := h.service.Update(r.Context(), c); err != nil {
+		writeChoiceError(w, err)
+		return
+	}
+
+	writeChoiceJSON(w, http.StatusOK, c)
+}
+
+// PUT /elections/{id}/choices/order
+func (h *ChoiceHandler) Reorder(w http.ResponseWriter, r *http.Request) {
+	role, err := authhandlers.GetUserRole(r)
+	if err != nil {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	if role != "admin" {
+		http.Error(w, "forbidden: only admin can manage choices", http.StatusForbidden)
+		return
+	}
+
+	electionID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "invalid election ID", http.StatusBadRequest)
+		return
+	}
+
+	var req dto.ReorderChoicesRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	choices, err := h.service.Reorder(r.Context(), electionID, req.ChoiceIDs)
+	if err != nil {
+		writeChoiceError(w, err)
+		return
+	}
+
+	writeChoiceJSON(w, http.StatusOK, choices)
+}
+
+// DELETE /elections/{id}/choices/{choiceID}
+func (h *ChoiceHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	role, err := authhandlers.GetUserRole(r)
+	if err != nil {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	if role != "admin" {
+		http.Error(w, "forbidden: only admin can manage choices", http.StatusForbidden)
+		return
+	}
+
+	electionID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "invalid election ID", http.StatusBadRequest)
+		return
+	}
+	choiceID, err := strconv.Atoi(chi.URLParam(r, "choiceID"))
+	if err != nil {
+		http.Error(w, "invalid choice ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.service.Delete(r.Context(), electionID, choiceID); err != nil {
+		writeChoiceError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func writeChoiceError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, services.ErrElectionNotFound), errors.Is(err, services.ErrChoiceNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, services.ErrChoicesLocked):
+		http.Error(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, services.ErrInvalidChoiceData):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	default:
+		http.Error(w, "failed to manage choices: "+err.Error(), http.StatusInternalServerError)
+	}
+}
+
+func writeChoiceJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		http.Error(w, "failed to encode response", http.StatusInternalServerError)
+	}
+}
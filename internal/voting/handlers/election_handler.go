@@ -1,171 +1,325 @@
-package handlers
-
-import (
-	"encoding/json"
-	"net/http"
-	"strconv"
-
-	"github.com/go-chi/chi/v5"
-	"voting-blockchain/internal/voting/dto"
-	"voting-blockchain/internal/voting/models"
-	"voting-blockchain/internal/voting/services"
-	authhandlers "voting-blockchain/internal/auth/handlers"
-)
-
-type ElectionHandler struct {
-	service services.ElectionService
-}
-
-func NewElectionHandler(s services.ElectionService) *ElectionHandler {
-	return &ElectionHandler{service: s}
-}
-
-func (h *ElectionHandler) Create(w http.ResponseWriter, r *http.Request) {
-	role, err := authhandlers.GetUserRole(r)
-	if err != nil {
-		http.Error(w, "unauthorized", http.StatusUnauthorized)
-		return
-	}
-	if role != "admin" {
-		http.Error(w, "forbidden: only admin can create elections", http.StatusForbidden)
-		return
-	}
-
-	var req dto.CreateElectionRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "invalid JSON", http.StatusBadRequest)
-		return
-	}
-
-	userID, err := authhandlers.GetUserID(r)
-	if err != nil {
-		http.Error(w, "unauthorized", http.StatusUnauthorized)
-		return
-	}
-
-	e := &models.Election{
-		Title:       req.Title,
-		Description: req.Description,
-		IsActive:    req.IsActive,
-		CreatedBy:   userID,
-	}
-
-	if err := h.service.Create(r.Context(), e); err != nil {
-		http.Error(w, "failed to create election: "+err.Error(), http.StatusInternalServerError)
-		return
-	}
-
-	if len(req.Choices) > 0 {
-		if err := h.service.CreateChoices(r.Context(), e.ID, req.Choices); err != nil {
-			http.Error(w, "failed to save choices: "+err.Error(), http.StatusInternalServerError)
-			return
-		}
-	}
-
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusCreated)
-	if err := json.NewEncoder(w).Encode(e); err != nil {
-		http.Error(w, "failed to encode response", http.StatusInternalServerError)
-	}
-}
-
-func (h *ElectionHandler) Get(w http.ResponseWriter, r *http.Request) {
-	idStr := chi.URLParam(r, "id")
-	id, err := strconv.Atoi(idStr)
-	if err != nil {
-		http.Error(w, "invalid election ID", http.StatusBadRequest)
-		return
-	}
-
-	e, err := h.service.GetByID(r.Context(), id)
-	if err != nil {
-		http.Error(w, "not found", http.StatusNotFound)
-		return
-	}
-
-	w.Header().Set("Content-Type", "application/json")
-	if err := json.NewEncoder(w).Encode(e); err != nil {
-		http.Error(w, "failed to encode response", http.StatusInternalServerError)
-	}
-}
-
-func (h *ElectionHandler) List(w http.ResponseWriter, r *http.Request) {
-	list, err := h.service.List(r.Context())
-	if err != nil {
-		http.Error(w, "failed to list elections: "+err.Error(), http.StatusInternalServerError)
-		return
-	}
-
-	w.Header().Set("Content-Type", "application/json")
-	if err := json.NewEncoder(w).Encode(list); err != nil {
-		http.Error(w, "failed to encode response", http.StatusInternalServerError)
-	}
-}
-
-func (h *ElectionHandler) Update(w http.ResponseWriter, r *http.Request) {
-	role, err := authhandlers.GetUserRole(r)
-	if err != nil {
-		http.Error(w, "unauthorized", http.StatusUnauthorized)
-		return
-	}
-	if role != "admin" {
-		http.Error(w, "forbidden: only admin can update elections", http.StatusForbidden)
-		return
-	}
-
-	idStr := chi.URLParam(r, "id")
-	id, err := strconv.Atoi(idStr)
-	if err != nil {
-		http.Error(w, "invalid election ID", http.StatusBadRequest)
-		return
-	}
-
-	var req dto.UpdateElectionRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "invalid JSON", http.StatusBadRequest)
-		return
-	}
-
-	e := &models.Election{
-		ID:          id,
-		Title:       req.Title,
-		Description: req.Description,
-		IsActive:    req.IsActive,
-	}
-
-	if err := h.service.Update(r.Context(), e); err != nil {
-		http.Error(w, "failed to update election: "+err.Error(), http.StatusInternalServerError)
-		return
-	}
-
-	w.Header().Set("Content-Type", "application/json")
-	if err := json.NewEncoder(w).Encode(e); err != nil {
-		http.Error(w, "failed to encode response", http.StatusInternalServerError)
-	}
-}
-
-func (h *ElectionHandler) Delete(w http.ResponseWriter, r *http.Request) {
-	role, err := authhandlers.GetUserRole(r)
-	if err != nil {
-		http.Error(w, "unauthorized", http.StatusUnauthorized)
-		return
-	}
-	if role != "admin" {
-		http.Error(w, "forbidden: only admin can delete elections", http.StatusForbidden)
-		return
-	}
-
-	idStr := chi.URLParam(r, "id")
-	id, err := strconv.Atoi(idStr)
-	if err != nil {
-		http.Error(w, "invalid election ID", http.StatusBadRequest)
-		return
-	}
-
-	if err := h.service.Delete(r.Context(), id); err != nil {
-		http.Error(w, "failed to delete election: "+err.Error(), http.StatusInternalServerError)
-		return
-	}
-
-	w.WriteHeader(http.StatusNoContent)
-}
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"voting-blockchain/internal/voting/dto"
+	"voting-blockchain/internal/voting/models"
+	"voting-blockchain/internal/voting/services"
+	authhandlers "voting-blockchain/internal/auth/handlers"
+)
+
+type ElectionHandler struct {
+	service services.ElectionService
+}
+
+func NewElectionHandler(s services.ElectionService) *ElectionHandler {
+	return &ElectionHandler{service: s}
+}
+
+func (h *ElectionHandler) Create(w http.ResponseWriter, r *http.Request) {
+	role, err := authhandlers.GetUserRole(r)
+	if err != nil {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	if role != "admin" {
+		http.Error(w, "forbidden: only admin can create elections", http.StatusForbidden)
+		return
+	}
+
+	var req dto.CreateElectionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	userID, err := authhandlers.GetUserID(r)
+	if err != nil {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	if req.BatchSize < 0 || req.BatchWindowSeconds < 0 {
+		http.Error(w, "batch_size and batch_window_seconds must not be negative", http.StatusBadRequest)
+		return
+	}
+
+	status := req.Status
+	if status == "" && req.IsActive {
+		status = models.ElectionOpen
+	}
+
+	e := &models.Election{
+		Title:              req.Title,
+		Description:        req.Description,
+		CreatedBy:          userID,
+		Status:             status,
+		VotingMethod:       req.VotingMethod,
+		MaxScore:           req.MaxScore,
+		Seats:              req.Seats,
+		AllowWriteIns:      req.AllowWriteIns,
+		Eligibility:        req.Eligibility,
+		Anonymity:          req.Anonymity,
+		Encryption:         req.Encryption,
+		TrusteeThreshold:   req.TrusteeThreshold,
+		OpensAt:            req.OpensAt,
+		ClosesAt:           req.ClosesAt,
+		BatchSize:          req.BatchSize,
+		BatchWindowSeconds: req.BatchWindowSeconds,
+	}
+	for _, c := range req.Contests {
+		e.Contests = append(e.Contests, &models.Contest{
+			Title:         c.Title,
+			VotingMethod:  c.VotingMethod,
+			MaxScore:      c.MaxScore,
+			Seats:         c.Seats,
+			AllowWriteIns: c.AllowWriteIns,
+			Choices:       c.Choices,
+		})
+	}
+
+	if err := h.service.Create(r.Context(), e, req.Choices); err != nil {
+		if errors.Is(err, services.ErrInvalidTransition) || errors.Is(err, services.ErrInvalidSchedule) ||
+			errors.Is(err, services.ErrInvalidChoiceData) || errors.Is(err, services.ErrInvalidVotingMethod) ||
+			errors.Is(err, services.ErrInvalidContest) || errors.Is(err, services.ErrInvalidEligibility) ||
+			errors.Is(err, services.ErrInvalidAnonymity) || errors.Is(err, services.ErrInvalidEncryption) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if errors.Is(err, services.ErrCeremonyPending) {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+		http.Error(w, "failed to create election: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(e); err != nil {
+		http.Error(w, "failed to encode response", http.StatusInternalServerError)
+	}
+}
+
+func (h *ElectionHandler) Get(w http.ResponseWriter, r *http.Request) {
+	idStr := chi.URLParam(r, "id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		http.Error(w, "invalid election ID", http.StatusBadRequest)
+		return
+	}
+
+	e, err := h.service.GetByID(r.Context(), id)
+	if err != nil {
+		http.Error(w, "not found", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(e); err != nil {
+		http.Error(w, "failed to encode response", http.StatusInternalServerError)
+	}
+}
+
+func (h *ElectionHandler) List(w http.ResponseWriter, r *http.Request) {
+	list, err := h.service.List(r.Context())
+	if err != nil {
+		http.Error(w, "failed to list elections: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(list); err != nil {
+		http.Error(w, "failed to encode response", http.StatusInternalServerError)
+	}
+}
+
+func (h *ElectionHandler) Update(w http.ResponseWriter, r *http.Request) {
+	role, err := authhandlers.GetUserRole(r)
+	if err != nil {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	if role != "admin" {
+		http.Error(w, "forbidden: only admin can update elections", http.StatusForbidden)
+		return
+	}
+
+	idStr := chi.URLParam(r, "id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		http.Error(w, "invalid election ID", http.StatusBadRequest)
+		return
+	}
+
+	var req dto.UpdateElectionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	opensAt, opensSet, err := optionalTime(req.OpensAt)
+	if err != nil {
+		http.Error(w, "opens_at must be an RFC 3339 time or null", http.StatusBadRequest)
+		return
+	}
+	closesAt, closesSet, err := optionalTime(req.ClosesAt)
+	if err != nil {
+		http.Error(w, "closes_at must be an RFC 3339 time or null", http.StatusBadRequest)
+		return
+	}
+
+	userID, err := authhandlers.GetUserID(r)
+	if err != nil {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	status := req.Status
+	if status == "" && req.IsActive != nil {
+		status = models.ElectionClosed
+		if *req.IsActive {
+			status = models.ElectionOpen
+		}
+	}
+
+	e := &models.Election{
+		ID:               id,
+		Title:            req.Title,
+		Description:      req.Description,
+		Status:           status,
+		VotingMethod:     req.VotingMethod,
+		MaxScore:         req.MaxScore,
+		Seats:            req.Seats,
+		AllowWriteIns:    req.AllowWriteIns != nil && *req.AllowWriteIns,
+		Eligibility:      req.Eligibility,
+		Anonymity:        req.Anonymity,
+		Encryption:       req.Encryption,
+		TrusteeThreshold: req.TrusteeThreshold,
+		OpensAt:          opensAt,
+		ClosesAt:         closesAt,
+	}
+	set := services.UpdateFields{OpensAt: opensSet, ClosesAt: closesSet, AllowWriteIns: req.AllowWriteIns != nil}
+
+	if err := h.service.Update(r.Context(), e, set, userID); err != nil {
+		switch {
+		case errors.Is(err, services.ErrElectionNotFound):
+			http.Error(w, err.Error(), http.StatusNotFound)
+		case errors.Is(err, services.ErrDefinitionLocked),
+			errors.Is(err, services.ErrScheduleLocked),
+			errors.Is(err, services.ErrInvalidTransition),
+			errors.Is(err, services.ErrWriteInsPending),
+			errors.Is(err, services.ErrCeremonyPending),
+			errors.Is(err, services.ErrDecryptionPending):
+			http.Error(w, err.Error(), http.StatusConflict)
+		case errors.Is(err, services.ErrInvalidSchedule), errors.Is(err, services.ErrInvalidVotingMethod),
+			errors.Is(err, services.ErrInvalidEligibility), errors.Is(err, services.ErrInvalidAnonymity),
+			errors.Is(err, services.ErrInvalidEncryption):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		default:
+			http.Error(w, "failed to update election: "+err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(e); err != nil {
+		http.Error(w, "failed to encode response", http.StatusInternalServerError)
+	}
+}
+
+// optionalTime — время из поля PUT: set — поле передано, t — его значение
+// (nil для null)
+func optionalTime(raw json.RawMessage) (t *time.Time, set bool, err error) {
+	if raw == nil {
+		return nil, false, nil
+	}
+	if string(raw) == "null" {
+		return nil, true, nil
+	}
+	var v time.Time
+	if err := json.Unmarshal(raw, &v); err != nil {
+		return nil, true, err
+	}
+	return &v, true, nil
+}
+
+// GET /elections/{id}/encryption-key — открытый ключ шифрования бюллетеней
+func (h *ElectionHandler) GetEncryptionKey(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "invalid election ID", http.StatusBadRequest)
+		return
+	}
+
+	key, err := h.service.EncryptionKey(r.Context(), id)
+	switch {
+	case errors.Is(err, services.ErrElectionNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	case errors.Is(err, services.ErrNotEncrypted), errors.Is(err, services.ErrCeremonyPending):
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	case err != nil:
+		http.Error(w, "failed to load encryption key: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(key); err != nil {
+		http.Error(w, "failed to encode response", http.StatusInternalServerError)
+	}
+}
+
+// GET /elections/{id}/transitions
+func (h *ElectionHandler) GetTransitions(w http.ResponseWriter, r *http.Request) {
+	idStr := chi.URLParam(r, "id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		http.Error(w, "invalid election ID", http.StatusBadRequest)
+		return
+	}
+
+	list, err := h.service.GetTransitions(r.Context(), id)
+	if err != nil {
+		http.Error(w, "failed to load transitions: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(list); err != nil {
+		http.Error(w, "failed to encode response", http.StatusInternalServerError)
+	}
+}
+
+func (h *ElectionHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	role, err := authhandlers.GetUserRole(r)
+	if err != nil {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	if role != "admin" {
+		http.Error(w, "forbidden: only admin can delete elections", http.StatusForbidden)
+		return
+	}
+
+	idStr := chi.URLParam(r, "id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		http.Error(w, "invalid election ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.service.Delete(r.Context(), id); err != nil {
+		http.Error(w, "failed to delete election: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,261 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"voting-blockchain/internal/voting/dto"
+	"voting-blockchain/internal/voting/models"
+	"voting-blockchain/internal/voting/services"
+	authhandlers "voting-blockchain/internal/auth/handlers"
+)
+
+type TrusteeHandler struct {
+	service services.TrusteeService
+}
+
+func NewTrusteeHandler(s services.TrusteeService) *TrusteeHandler {
+	return &TrusteeHandler{service: s}
+}
+
+// GET /elections/{id}/ceremony — этап церемонии ключа и опубликованные данные
+func (h *TrusteeHandler) Ceremony(w http.ResponseWriter, r *http.Request) {
+	electionID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "invalid election ID", http.StatusBadRequest)
+		return
+	}
+
+	ceremony, err := h.service.Ceremony(r.Context(), electionID)
+	if err != nil {
+		writeTrusteeError(w, err)
+		return
+	}
+	if ceremony.Trustees == nil {
+		ceremony.Trustees = []*models.Trustee{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(ceremony); err != nil {
+		http.Error(w, "failed to encode response", http.StatusInternalServerError)
+	}
+}
+
+// POST /elections/{id}/trustees — админ назначает доверенное лицо
+func (h *TrusteeHandler) Register(w http.ResponseWriter, r *http.Request) {
+	role, err := authhandlers.GetUserRole(r)
+	if err != nil {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	if role != "admin" {
+		http.Error(w, "forbidden: only admin can register trustees", http.StatusForbidden)
+		return
+	}
+
+	electionID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "invalid election ID", http.StatusBadRequest)
+		return
+	}
+
+	var req dto.RegisterTrusteeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	trustee, err := h.service.Register(r.Context(), electionID, req.UserID)
+	if err != nil {
+		writeTrusteeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(trustee); err != nil {
+		http.Error(w, "failed to encode response", http.StatusInternalServerError)
+	}
+}
+
+// PUT /elections/{id}/ceremony/key — доверенное лицо публикует ключ для получения долей
+func (h *TrusteeHandler) PublishKey(w http.ResponseWriter, r *http.Request) {
+	userID, err := authhandlers.GetUserID(r)
+	if err != nil {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	electionID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "invalid election ID", http.StatusBadRequest)
+		return
+	}
+
+	var req dto.TrusteeKeyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	trustee, err := h.service.PublishKey(r.Context(), electionID, userID, req.PublicKey)
+	if err != nil {
+		writeTrusteeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(trustee); err != nil {
+		http.Error(w, "failed to encode response", http.StatusInternalServerError)
+	}
+}
+
+// POST /elections/{id}/ceremony/deal — обязательства и зашифрованные доли доверенного лица
+func (h *TrusteeHandler) Deal(w http.ResponseWriter, r *http.Request) {
+	userID, err := authhandlers.GetUserID(r)
+	if err != nil {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	electionID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "invalid election ID", http.StatusBadRequest)
+		return
+	}
+
+	var req dto.TrusteeDealRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid JSON", http.StatusBadRequest)
+		return
+	}
+	deal := &models.TrusteeDeal{Commitments: req.Commitments}
+	for _, s := range req.Shares {
+		deal.Shares = append(deal.Shares, models.EncryptedShare{To: s.To, R: s.R, C: s.C})
+	}
+
+	if err := h.service.Deal(r.Context(), electionID, userID, deal); err != nil {
+		writeTrusteeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GET /elections/{id}/ceremony/shares — доли, адресованные доверенному лицу
+func (h *TrusteeHandler) Shares(w http.ResponseWriter, r *http.Request) {
+	userID, err := authhandlers.GetUserID(r)
+	if err != nil {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	electionID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "invalid election ID", http.StatusBadRequest)
+		return
+	}
+
+	shares, err := h.service.Shares(r.Context(), electionID, userID)
+	if err != nil {
+		writeTrusteeError(w, err)
+		return
+	}
+	if shares == nil {
+		shares = []models.EncryptedShare{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(shares); err != nil {
+		http.Error(w, "failed to encode response", http.StatusInternalServerError)
+	}
+}
+
+// POST /elections/{id}/ceremony/verify — доверенное лицо подтверждает или
+// отклоняет полученные доли
+func (h *TrusteeHandler) Verify(w http.ResponseWriter, r *http.Request) {
+	userID, err := authhandlers.GetUserID(r)
+	if err != nil {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	electionID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "invalid election ID", http.StatusBadRequest)
+		return
+	}
+
+	var req dto.VerifySharesRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	ceremony, err := h.service.Verify(r.Context(), electionID, userID, req.Accepted)
+	if err != nil {
+		writeTrusteeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(ceremony); err != nil {
+		http.Error(w, "failed to encode response", http.StatusInternalServerError)
+	}
+}
+
+// POST /elections/{id}/decryptions — частичные расшифровки сумм закрытого голосования
+func (h *TrusteeHandler) SubmitDecryption(w http.ResponseWriter, r *http.Request) {
+	userID, err := authhandlers.GetUserID(r)
+	if err != nil {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	electionID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "invalid election ID", http.StatusBadRequest)
+		return
+	}
+
+	var req dto.PartialDecryptionsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid JSON", http.StatusBadRequest)
+		return
+	}
+	var partials []models.PartialDecryption
+	for _, p := range req.Partials {
+		partials = append(partials, models.PartialDecryption{
+			ChoiceID: p.ChoiceID,
+			D:        p.D,
+			Proof:    models.ProofPart{C: p.Proof.C, Z: p.Proof.Z},
+		})
+	}
+
+	if err := h.service.SubmitDecryption(r.Context(), electionID, userID, partials); err != nil {
+		writeTrusteeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func writeTrusteeError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, services.ErrElectionNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, services.ErrNotTrustee):
+		http.Error(w, err.Error(), http.StatusForbidden)
+	case errors.Is(err, services.ErrNotEncrypted), errors.Is(err, services.ErrDefinitionLocked),
+		errors.Is(err, services.ErrTrusteeExists), errors.Is(err, services.ErrCeremonyStage),
+		errors.Is(err, services.ErrElectionNotClosed):
+		http.Error(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, services.ErrInvalidTrusteeData):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	default:
+		http.Error(w, "failed to run key ceremony: "+err.Error(), http.StatusInternalServerError)
+	}
+}
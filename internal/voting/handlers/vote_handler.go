@@ -1,92 +1,317 @@
-package handlers
-
-import (
-    "encoding/json"
-    "net/http"
-    "strconv"
-
-    "voting-blockchain/internal/voting/dto"
-    "voting-blockchain/internal/voting/services"
-
-    authhandlers "voting-blockchain/internal/auth/handlers"
-    "github.com/go-chi/chi/v5"
-)
-
-type VoteHandler struct {
-    voteService services.VoteService
-}
-
-func NewVoteHandler(vs services.VoteService) *VoteHandler {
-    return &VoteHandler{voteService: vs}
-}
-
-// CastVoteHandler — принимает голос от пользователя
-func (h *VoteHandler) CastVoteHandler(w http.ResponseWriter, r *http.Request) {
-    userID, err := authhandlers.GetUserID(r)
-    if err != nil {
-        http.Error(w, "unauthorized", http.StatusUnauthorized)
-        return
-    }
-
-    electionIDStr := chi.URLParam(r, "id")
-    electionID, err := strconv.Atoi(electionIDStr)
-    if err != nil {
-        http.Error(w, "invalid election ID", http.StatusBadRequest)
-        return
-    }
-
-    var req dto.CastVoteRequest
-    if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-        http.Error(w, "invalid JSON", http.StatusBadRequest)
-        return
-    }
-
-    if err := h.voteService.CastVote(r.Context(), userID, electionID, req.Choice); err != nil {
-        http.Error(w, err.Error(), http.StatusBadRequest)
-        return
-    }
-
-    w.WriteHeader(http.StatusCreated)
-}
-
-// GetElectionBlockchainHandler — возвращает всю цепочку блоков для голосования
-func (h *VoteHandler) GetElectionBlockchainHandler(w http.ResponseWriter, r *http.Request) {
-    electionIDStr := chi.URLParam(r, "id")
-    electionID, err := strconv.Atoi(electionIDStr)
-    if err != nil {
-        http.Error(w, "invalid election ID", http.StatusBadRequest)
-        return
-    }
-
-    blocks, err := h.voteService.GetBlockchain(r.Context(), electionID)
-    if err != nil {
-        http.Error(w, "failed to get blockchain: "+err.Error(), http.StatusInternalServerError)
-        return
-    }
-
-    w.Header().Set("Content-Type", "application/json")
-    if err := json.NewEncoder(w).Encode(blocks); err != nil {
-        http.Error(w, "failed to encode response", http.StatusInternalServerError)
-    }
-}
-
-func (h *VoteHandler) GetResults(w http.ResponseWriter, r *http.Request) {
-    idStr := chi.URLParam(r, "id")
-    id, err := strconv.Atoi(idStr)
-    if err != nil {
-        http.Error(w, "invalid election ID", http.StatusBadRequest)
-        return
-    }
-
-    results, err := h.voteService.GetResults(r.Context(), id)
-    if err != nil {
-        http.Error(w, "failed to get results: "+err.Error(), http.StatusInternalServerError)
-        return
-    }
-
-    w.Header().Set("Content-Type", "application/json")
-    if err := json.NewEncoder(w).Encode(results); err != nil {
-        http.Error(w, "failed to encode response", http.StatusInternalServerError)
-    }
-}
-
+package handlers
+
+import (
+    "encoding/hex"
+    "encoding/json"
+    "errors"
+    "fmt"
+    "net/http"
+    "strconv"
+
+    "voting-blockchain/internal/voting/dto"
+    "voting-blockchain/internal/voting/models"
+    "voting-blockchain/internal/voting/services"
+
+    authhandlers "voting-blockchain/internal/auth/handlers"
+    "github.com/go-chi/chi/v5"
+)
+
+type VoteHandler struct {
+    voteService services.VoteService
+}
+
+func NewVoteHandler(vs services.VoteService) *VoteHandler {
+    return &VoteHandler{voteService: vs}
+}
+
+// CastVoteHandler — принимает голос от пользователя
+func (h *VoteHandler) CastVoteHandler(w http.ResponseWriter, r *http.Request) {
+    userID, err := authhandlers.GetUserID(r)
+    if err != nil {
+        http.Error(w, "unauthorized", http.StatusUnauthorized)
+        return
+    }
+
+    electionIDStr := chi.URLParam(r, "id")
+    electionID, err := strconv.Atoi(electionIDStr)
+    if err != nil {
+        http.Error(w, "invalid election ID", http.StatusBadRequest)
+        return
+    }
+
+    var req dto.CastVoteRequest
+    if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+        http.Error(w, "invalid JSON", http.StatusBadRequest)
+        return
+    }
+
+    receipt, err := h.voteService.CastVote(r.Context(), userID, electionID, requestBallot(req))
+    if err != nil {
+        writeCastVoteError(w, err)
+        return
+    }
+
+    w.Header().Set("Content-Type", "application/json")
+    w.WriteHeader(http.StatusCreated)
+    if err := json.NewEncoder(w).Encode(receipt); err != nil {
+        http.Error(w, "failed to encode response", http.StatusInternalServerError)
+    }
+}
+
+// CastVoteWithCodeHandler — принимает голос по одноразовому коду; вход в
+// систему не нужен, голос не связывается ни с пользователем, ни с кодом
+func (h *VoteHandler) CastVoteWithCodeHandler(w http.ResponseWriter, r *http.Request) {
+    electionID, err := strconv.Atoi(chi.URLParam(r, "id"))
+    if err != nil {
+        http.Error(w, "invalid election ID", http.StatusBadRequest)
+        return
+    }
+
+    var req dto.CastVoteWithCodeRequest
+    if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+        http.Error(w, "invalid JSON", http.StatusBadRequest)
+        return
+    }
+    if req.Code == "" {
+        http.Error(w, "code is required", http.StatusBadRequest)
+        return
+    }
+
+    receipt, err := h.voteService.CastVoteWithCode(r.Context(), electionID, req.Code, requestBallot(req.CastVoteRequest))
+    if err != nil {
+        writeCastVoteError(w, err)
+        return
+    }
+
+    w.Header().Set("Content-Type", "application/json")
+    w.WriteHeader(http.StatusCreated)
+    if err := json.NewEncoder(w).Encode(receipt); err != nil {
+        http.Error(w, "failed to encode response", http.StatusInternalServerError)
+    }
+}
+
+// CastAnonymousVoteHandler — принимает голос анонимного голосования по
+// жетону со слепой подписью; вход в систему не нужен
+func (h *VoteHandler) CastAnonymousVoteHandler(w http.ResponseWriter, r *http.Request) {
+    electionID, err := strconv.Atoi(chi.URLParam(r, "id"))
+    if err != nil {
+        http.Error(w, "invalid election ID", http.StatusBadRequest)
+        return
+    }
+
+    var req dto.AnonymousVoteRequest
+    if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+        http.Error(w, "invalid JSON", http.StatusBadRequest)
+        return
+    }
+    token, err := hex.DecodeString(req.Token)
+    if err != nil || len(token) == 0 {
+        http.Error(w, "token must be a hex string", http.StatusBadRequest)
+        return
+    }
+    signature, err := hex.DecodeString(req.Signature)
+    if err != nil || len(signature) == 0 {
+        http.Error(w, "signature must be a hex string", http.StatusBadRequest)
+        return
+    }
+
+    receipt, err := h.voteService.CastAnonymousVote(r.Context(), electionID, token, signature, requestBallot(req.CastVoteRequest))
+    if err != nil {
+        writeCastVoteError(w, err)
+        return
+    }
+
+    w.Header().Set("Content-Type", "application/json")
+    w.WriteHeader(http.StatusCreated)
+    if err := json.NewEncoder(w).Encode(receipt); err != nil {
+        http.Error(w, "failed to encode response", http.StatusInternalServerError)
+    }
+}
+
+// requestBallot — бюллетень из запроса голоса, с ответами по вопросам
+func requestBallot(req dto.CastVoteRequest) *models.Ballot {
+    ballot := toBallot(req.BallotRequest)
+    for _, c := range req.Contests {
+        ballot.Contests = append(ballot.Contests, models.ContestBallot{ContestID: c.ContestID, Ballot: *toBallot(c.BallotRequest)})
+    }
+    if req.Encrypted != nil {
+        ballot.Encrypted = &models.EncryptedBallot{SumProof: toProof(req.Encrypted.SumProof)}
+        for _, c := range req.Encrypted.Choices {
+            ballot.Encrypted.Choices = append(ballot.Encrypted.Choices, models.EncryptedChoice{
+                ChoiceID:   c.ChoiceID,
+                Ciphertext: models.Ciphertext{A: c.A, B: c.B},
+                Proof:      toProof(c.Proof),
+            })
+        }
+    }
+    return ballot
+}
+
+// toProof — переводит доказательство из запроса
+func toProof(req []dto.ProofRequest) []models.ProofPart {
+    var proof []models.ProofPart
+    for _, p := range req {
+        proof = append(proof, models.ProofPart{C: p.C, Z: p.Z})
+    }
+    return proof
+}
+
+func writeCastVoteError(w http.ResponseWriter, err error) {
+    switch {
+    case errors.Is(err, services.ErrElectionNotFound):
+        http.Error(w, err.Error(), http.StatusNotFound)
+    case errors.Is(err, services.ErrNotEligible), errors.Is(err, services.ErrInvalidBallotCode),
+        errors.Is(err, services.ErrInvalidBlindToken):
+        http.Error(w, err.Error(), http.StatusForbidden)
+    case errors.Is(err, services.ErrElectionNotOpen), errors.Is(err, services.ErrAlreadyVoted),
+        errors.Is(err, services.ErrAnonymousVoting), errors.Is(err, services.ErrNotAnonymous),
+        errors.Is(err, services.ErrNotEncrypted):
+        http.Error(w, err.Error(), http.StatusConflict)
+    case errors.Is(err, services.ErrChoiceRequired):
+        http.Error(w, err.Error(), http.StatusBadRequest)
+    case errors.Is(err, services.ErrInvalidChoice), errors.Is(err, services.ErrInvalidBallot),
+        errors.Is(err, services.ErrWriteInsNotAllowed):
+        http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+    default:
+        http.Error(w, err.Error(), http.StatusBadRequest)
+    }
+}
+
+// toBallot — переводит ответ из запроса в бюллетень
+func toBallot(req dto.BallotRequest) *models.Ballot {
+    ballot := &models.Ballot{
+        ChoiceID:  req.ChoiceID,
+        Choice:    req.Choice,
+        WriteIn:   req.WriteIn,
+        Ranking:   req.Ranking,
+        Approvals: req.Approvals,
+    }
+    for _, s := range req.Scores {
+        ballot.Scores = append(ballot.Scores, models.ChoiceScore{ChoiceID: s.ChoiceID, Score: s.Score})
+    }
+    return ballot
+}
+
+// GetInclusionProofHandler — публичное доказательство включения голоса в цепочку по его хешу
+func (h *VoteHandler) GetInclusionProofHandler(w http.ResponseWriter, r *http.Request) {
+    electionIDStr := chi.URLParam(r, "id")
+    electionID, err := strconv.Atoi(electionIDStr)
+    if err != nil {
+        http.Error(w, "invalid election ID", http.StatusBadRequest)
+        return
+    }
+
+    receipt, err := h.voteService.GetInclusionProof(r.Context(), electionID, chi.URLParam(r, "voteHash"))
+    if errors.Is(err, services.ErrVoteNotFound) {
+        http.Error(w, err.Error(), http.StatusNotFound)
+        return
+    }
+    if err != nil {
+        http.Error(w, "failed to build proof: "+err.Error(), http.StatusInternalServerError)
+        return
+    }
+
+    w.Header().Set("Content-Type", "application/json")
+    if err := json.NewEncoder(w).Encode(receipt); err != nil {
+        http.Error(w, "failed to encode response", http.StatusInternalServerError)
+    }
+}
+
+// GetElectionBlockchainHandler — возвращает всю цепочку блоков для голосования
+func (h *VoteHandler) GetElectionBlockchainHandler(w http.ResponseWriter, r *http.Request) {
+    electionIDStr := chi.URLParam(r, "id")
+    electionID, err := strconv.Atoi(electionIDStr)
+    if err != nil {
+        http.Error(w, "invalid election ID", http.StatusBadRequest)
+        return
+    }
+
+    blocks, err := h.voteService.GetBlockchain(r.Context(), electionID)
+    if err != nil {
+        http.Error(w, "failed to get blockchain: "+err.Error(), http.StatusInternalServerError)
+        return
+    }
+
+    w.Header().Set("Content-Type", "application/json")
+    if err := json.NewEncoder(w).Encode(blocks); err != nil {
+        http.Error(w, "failed to encode response", http.StatusInternalServerError)
+    }
+}
+
+func (h *VoteHandler) GetResults(w http.ResponseWriter, r *http.Request) {
+    idStr := chi.URLParam(r, "id")
+    id, err := strconv.Atoi(idStr)
+    if err != nil {
+        http.Error(w, "invalid election ID", http.StatusBadRequest)
+        return
+    }
+
+    results, err := h.voteService.GetResults(r.Context(), id)
+    if errors.Is(err, services.ErrElectionNotFound) {
+        http.Error(w, err.Error(), http.StatusNotFound)
+        return
+    }
+    if err != nil {
+        http.Error(w, "failed to get results: "+err.Error(), http.StatusInternalServerError)
+        return
+    }
+
+    w.Header().Set("Content-Type", "application/json")
+    if err := json.NewEncoder(w).Encode(results); err != nil {
+        http.Error(w, "failed to encode response", http.StatusInternalServerError)
+    }
+}
+
+// GET /elections/{id}/results/count-sheet — протокол подсчёта STV в CSV
+func (h *VoteHandler) GetCountSheet(w http.ResponseWriter, r *http.Request) {
+    idStr := chi.URLParam(r, "id")
+    id, err := strconv.Atoi(idStr)
+    if err != nil {
+        http.Error(w, "invalid election ID", http.StatusBadRequest)
+        return
+    }
+
+    results, err := h.voteService.GetResults(r.Context(), id)
+    if errors.Is(err, services.ErrElectionNotFound) {
+        http.Error(w, err.Error(), http.StatusNotFound)
+        return
+    }
+    if err != nil {
+        http.Error(w, "failed to get results: "+err.Error(), http.StatusInternalServerError)
+        return
+    }
+    filename := fmt.Sprintf("election-%d-count-sheet.csv", id)
+    if contest := r.URL.Query().Get("contest"); contest != "" {
+        contestID, err := strconv.Atoi(contest)
+        if err != nil {
+            http.Error(w, "invalid contest ID", http.StatusBadRequest)
+            return
+        }
+        results = contestResults(results, contestID)
+        if results == nil {
+            http.Error(w, "contest not found", http.StatusNotFound)
+            return
+        }
+        filename = fmt.Sprintf("election-%d-contest-%d-count-sheet.csv", id, contestID)
+    }
+    if results.VotingMethod != models.VotingSTV {
+        http.Error(w, "count sheet is available only for stv elections", http.StatusNotFound)
+        return
+    }
+
+    w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+    w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+    if err := services.WriteCountSheetCSV(w, results); err != nil {
+        http.Error(w, "failed to write count sheet", http.StatusInternalServerError)
+    }
+}
+
+// contestResults — итоги вопроса contestID или nil, если такого вопроса нет
+func contestResults(results *models.ElectionResults, contestID int) *models.ElectionResults {
+    for _, r := range results.Contests {
+        if r.ContestID == contestID {
+            return r
+        }
+    }
+    return nil
+}
@@ -0,0 +1,124 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"mime"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"voting-blockchain/internal/voting/dto"
+	"voting-blockchain/internal/voting/models"
+	"voting-blockchain/internal/voting/services"
+	authhandlers "voting-blockchain/internal/auth/handlers"
+)
+
+type VoterRollHandler struct {
+	service services.VoterRollService
+}
+
+func NewVoterRollHandler(s services.VoterRollService) *VoterRollHandler {
+	return &VoterRollHandler{service: s}
+}
+
+// GET /elections/{id}/roll
+func (h *VoterRollHandler) List(w http.ResponseWriter, r *http.Request) {
+	role, err := authhandlers.GetUserRole(r)
+	if err != nil {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	if role != "admin" {
+		http.Error(w, "forbidden: only admin can manage voter rolls", http.StatusForbidden)
+		return
+	}
+
+	electionID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "invalid election ID", http.StatusBadRequest)
+		return
+	}
+
+	entries, err := h.service.List(r.Context(), electionID)
+	if err != nil {
+		writeRollError(w, err)
+		return
+	}
+	if entries == nil {
+		entries = []*models.RollEntry{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(entries); err != nil {
+		http.Error(w, "failed to encode response", http.StatusInternalServerError)
+	}
+}
+
+// POST /elections/{id}/roll — тело JSON (dto.VoterRollRequest) или text/csv
+// (см. services.ParseRollCSV); для CSV замена списка задаётся ?replace=true
+func (h *VoterRollHandler) Upload(w http.ResponseWriter, r *http.Request) {
+	role, err := authhandlers.GetUserRole(r)
+	if err != nil {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	if role != "admin" {
+		http.Error(w, "forbidden: only admin can manage voter rolls", http.StatusForbidden)
+		return
+	}
+
+	electionID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "invalid election ID", http.StatusBadRequest)
+		return
+	}
+
+	var entries []models.RollEntry
+	replace := false
+	if mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mediaType == "text/csv" {
+		entries, err = services.ParseRollCSV(r.Body)
+		if err != nil {
+			writeRollError(w, err)
+			return
+		}
+		replace, _ = strconv.ParseBool(r.URL.Query().Get("replace"))
+	} else {
+		var req dto.VoterRollRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "invalid JSON", http.StatusBadRequest)
+			return
+		}
+		for _, id := range req.UserIDs {
+			entries = append(entries, models.RollEntry{UserID: id})
+		}
+		for _, email := range req.Emails {
+			entries = append(entries, models.RollEntry{Email: email})
+		}
+		replace = req.Replace
+	}
+
+	summary, err := h.service.Upload(r.Context(), electionID, entries, replace)
+	if err != nil {
+		writeRollError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(summary); err != nil {
+		http.Error(w, "failed to encode response", http.StatusInternalServerError)
+	}
+}
+
+func writeRollError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, services.ErrElectionNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, services.ErrRollLocked):
+		http.Error(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, services.ErrInvalidRollEntry):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	default:
+		http.Error(w, "failed to manage voter roll: "+err.Error(), http.StatusInternalServerError)
+	}
+}
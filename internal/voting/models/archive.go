@@ -0,0 +1,86 @@
+package models
+
+import "time"
+
+// Формат архива голосования
+const (
+	ArchiveFormat  = "vbc-election-archive"
+	ArchiveVersion = 1
+)
+
+// Типы строк архива JSON Lines
+const (
+	ArchiveRecordManifest = "manifest"
+	ArchiveRecordElection = "election"
+	ArchiveRecordChoice   = "choice"
+	ArchiveRecordBlock    = "block"
+	ArchiveRecordVote     = "vote"
+	ArchiveRecordKey      = "key"
+)
+
+// ArchiveManifest — первая строка архива
+type ArchiveManifest struct {
+	Format     string    `json:"format"`
+	Version    int       `json:"version"`
+	ElectionID int       `json:"election_id"`
+	ExportedAt time.Time `json:"exported_at"`
+	Choices    int       `json:"choices"`
+	Blocks     int       `json:"blocks"`
+	Votes      int       `json:"votes"`
+	HeadHash   string    `json:"head_hash"` // хеш последнего блока на момент выгрузки
+}
+
+// ArchiveVote — голос без user_id и времени подачи: по архиву нельзя узнать,
+// кто и когда проголосовал, но можно пересчитать обязательство из выбора и соли
+type ArchiveVote struct {
+	ElectionID  int    `json:"election_id"`
+	Choice      string `json:"choice"`
+	ChoiceID    int    `json:"choice_id,omitempty"`
+	Ballot      string `json:"ballot,omitempty"`
+	Salt        string `json:"salt,omitempty"`
+	HashVersion int    `json:"hash_version"`
+	VoteHash    string `json:"vote_hash"`
+	BlockID     *int   `json:"block_id,omitempty"`
+	LeafIndex   int    `json:"leaf_index"`
+}
+
+// ElectionArchive — самодостаточная выгрузка голосования: определение,
+// цепочка блоков, обезличенные голоса и ключи, которыми подписаны блоки
+type ElectionArchive struct {
+	Manifest ArchiveManifest
+	Election *Election
+	Choices  []*Choice
+	Blocks   []*Block
+	Votes    []*ArchiveVote
+	Keys     []*SigningKey
+}
+
+// AnonymizeVote — голос в виде, пригодном для публикации в архиве
+func AnonymizeVote(v *Vote) *ArchiveVote {
+	return &ArchiveVote{
+		ElectionID:  v.ElectionID,
+		Choice:      v.Choice,
+		ChoiceID:    v.ChoiceID,
+		Ballot:      v.Ballot,
+		Salt:        v.Salt,
+		HashVersion: v.HashVersion,
+		VoteHash:    v.VoteHash,
+		BlockID:     v.BlockID,
+		LeafIndex:   v.LeafIndex,
+	}
+}
+
+// Vote — голос архива в виде модели; UserID остаётся нулевым
+func (a *ArchiveVote) Vote() *Vote {
+	return &Vote{
+		ElectionID:  a.ElectionID,
+		Choice:      a.Choice,
+		ChoiceID:    a.ChoiceID,
+		Ballot:      a.Ballot,
+		Salt:        a.Salt,
+		HashVersion: a.HashVersion,
+		VoteHash:    a.VoteHash,
+		BlockID:     a.BlockID,
+		LeafIndex:   a.LeafIndex,
+	}
+}
@@ -0,0 +1,80 @@
+package models
+
+import "encoding/json"
+
+// Ballot — содержимое бюллетеня. Хеш голоса версии 3 фиксирует его
+// каноническую кодировку (см. Canonical), а не текст варианта.
+type Ballot struct {
+	ChoiceID int `json:"choice_id,omitempty"`
+	// Choice — текст варианта для клиентов, которые ещё не передают choice_id.
+	// При приёме голоса заменяется на ChoiceID и в кодировку не попадает.
+	Choice string `json:"choice,omitempty"`
+	// WriteIn — вписанный вариант (plurality с разрешёнными вписанными
+	// вариантами), в виде NormalizeWriteIn; ChoiceID при этом пуст
+	WriteIn string `json:"write_in,omitempty"`
+	// Ranking — id вариантов в порядке предпочтения (ранжированные способы).
+	// Можно ранжировать не все варианты.
+	Ranking []int `json:"ranking,omitempty"`
+	// Approvals — одобренные варианты (approval), по возрастанию id
+	Approvals []int `json:"approvals,omitempty"`
+	// Scores — оценки вариантов (score), по возрастанию id
+	Scores []ChoiceScore `json:"scores,omitempty"`
+	// Contests — ответы на вопросы голосования с вопросами, по возрастанию id
+	// вопроса; остальные поля при этом пусты
+	Contests []ContestBallot `json:"contests,omitempty"`
+	// Encrypted — зашифрованный бюллетень голосования с шифрованием;
+	// остальные поля при этом пусты
+	Encrypted *EncryptedBallot `json:"encrypted,omitempty"`
+}
+
+// ContestBallot — ответ на один вопрос: бюллетень способа этого вопроса
+type ContestBallot struct {
+	ContestID int `json:"contest_id"`
+	Ballot
+}
+
+// ChoiceScore — оценка варианта в бюллетене score
+type ChoiceScore struct {
+	ChoiceID int `json:"choice_id"`
+	Score    int `json:"score"`
+}
+
+// FirstChoice — вариант, который сохраняется в голосе рядом с бюллетенем:
+// ChoiceID, первое предпочтение ранжированного бюллетеня, первый вариант
+// бюллетеня approval и score или первый вариант первого ответа на вопрос
+func (b *Ballot) FirstChoice() int {
+	switch {
+	case b.ChoiceID != 0:
+		return b.ChoiceID
+	case len(b.Ranking) > 0:
+		return b.Ranking[0]
+	case len(b.Approvals) > 0:
+		return b.Approvals[0]
+	case len(b.Scores) > 0:
+		return b.Scores[0].ChoiceID
+	case len(b.Contests) > 0:
+		return b.Contests[0].FirstChoice()
+	}
+	return 0
+}
+
+// IsEmpty — в бюллетене не указан ни один вариант
+func (b *Ballot) IsEmpty() bool {
+	return b.FirstChoice() == 0 && b.Choice == "" && b.WriteIn == "" && b.Encrypted == nil
+}
+
+// Canonical — каноническая кодировка бюллетеня: JSON с фиксированным порядком полей
+func (b *Ballot) Canonical() string {
+	// Маршалинг структуры с фиксированным порядком полей не может завершиться ошибкой
+	data, _ := json.Marshal(b)
+	return string(data)
+}
+
+// ParseBallot — разбирает сохранённую каноническую кодировку бюллетеня
+func ParseBallot(raw string) (*Ballot, error) {
+	var b Ballot
+	if err := json.Unmarshal([]byte(raw), &b); err != nil {
+		return nil, err
+	}
+	return &b, nil
+}
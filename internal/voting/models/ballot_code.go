@@ -0,0 +1,34 @@
+package models
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"unicode"
+)
+
+// BallotCodeBytes — случайные байты одноразового кода голосования (160 бит)
+const BallotCodeBytes = 20
+
+// BallotCodeStats — выпущенные и использованные коды голосования
+type BallotCodeStats struct {
+	Issued int `json:"issued"`
+	Used   int `json:"used"`
+}
+
+// NormalizeBallotCode — код без разделителей и пробелов в верхнем регистре:
+// так код, переписанный с листа или из письма, совпадает с выпущенным
+func NormalizeBallotCode(code string) string {
+	return strings.ToUpper(strings.Map(func(r rune) rune {
+		if r == '-' || unicode.IsSpace(r) {
+			return -1
+		}
+		return r
+	}, code))
+}
+
+// BallotCodeHash — sha256 нормализованного кода в hex; в базе хранится только он
+func BallotCodeHash(code string) string {
+	hash := sha256.Sum256([]byte(NormalizeBallotCode(code)))
+	return hex.EncodeToString(hash[:])
+}
@@ -0,0 +1,65 @@
+package models
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"time"
+)
+
+// Анонимность голосования (пустой Anonymity — none)
+const (
+	AnonymityNone  = "none"  // голос подаёт вошедший пользователь, votes хранит его id
+	AnonymityBlind = "blind" // голос подаётся по жетону со слепой подписью, без пользователя
+)
+
+// BlindTokenMinBytes — наименьшая длина жетона анонимного голосования
+const BlindTokenMinBytes = 32
+
+// IsAnonymity — является ли строка известным режимом анонимности
+func IsAnonymity(s string) bool {
+	return s == AnonymityNone || s == AnonymityBlind
+}
+
+// AnonymityMode — режим анонимности; у голосований, созданных до появления
+// анонимных, это none
+func (e *Election) AnonymityMode() string {
+	if e.Anonymity == "" {
+		return AnonymityNone
+	}
+	return e.Anonymity
+}
+
+// BlindTokens — голоса подаются по жетонам со слепой подписью
+func (e *Election) BlindTokens() bool {
+	return e.Anonymity == AnonymityBlind
+}
+
+// BlindKey — ключ RSA, которым голосование подписывает жетоны
+type BlindKey struct {
+	ElectionID int
+	PrivateKey []byte // PKCS#1 DER
+	CreatedAt  time.Time
+}
+
+// BlindPublicKey — открытый ключ подписи жетонов, публикуется избирателям
+type BlindPublicKey struct {
+	ElectionID  int    `json:"election_id"`
+	N           string `json:"n"` // модуль, hex
+	E           int    `json:"e"`
+	Fingerprint string `json:"fingerprint"` // совпадает с отпечатком в определении голосования
+}
+
+// BlindKeyFingerprint — отпечаток открытого ключа подписи жетонов: sha256
+// модуля (hex) и экспоненты. Он входит в определение голосования, поэтому
+// подменить ключ после генезис-блока незаметно нельзя.
+func BlindKeyFingerprint(n string, e int) string {
+	hash := sha256.Sum256([]byte(fmt.Sprintf("%s:%d", n, e)))
+	return hex.EncodeToString(hash[:])
+}
+
+// BlindTokenHash — sha256 жетона в hex; использованные жетоны хранятся им
+func BlindTokenHash(token []byte) string {
+	hash := sha256.Sum256(token)
+	return hex.EncodeToString(hash[:])
+}
@@ -1,14 +1,157 @@
-
-package models
-
-import "time"
-
-// Block — элемент цепочки блоков голосования
-type Block struct {
-	Index       int       `db:"id"`            // PRIMARY KEY
-	Timestamp   time.Time `db:"created_at"`    // автоматически now()
-	VoteHash    string    `db:"vote_hash"`     // хеш голосования
-	PrevHash    string    `db:"previous_hash"` // хеш предыдущего блока
-	Hash        string    `db:"current_hash"`  // хеш текущего блока
-	ElectionID  int       `db:"election_id"`   // к какому голосованию
-}
+
+package models
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Версии формата хеширования блоков
+const (
+	HashVersionLegacy     = 1 // Timestamp.String() Go-времени, которое не сохранялось в БД
+	HashVersionCanonical  = 2 // каноническая кодировка, см. CanonicalBytes
+	HashVersionMerkleLeaf = 3 // как 2, но листья дерева Merkle хешируются с префиксом 0x00
+
+	CurrentHashVersion = HashVersionMerkleLeaf
+	HashAlgorithm      = "sha256"
+)
+
+// LegacyHashCutoff — время миграции block_hash_version: после неё блоки
+// версии 1 не создаются
+var LegacyHashCutoff = time.Date(2025, time.July, 10, 12, 0, 0, 0, time.UTC)
+
+// SignatureCutoff — время миграции block_signatures: блоки до неё записаны
+// без подписи узла
+var SignatureCutoff = time.Date(2025, time.July, 21, 10, 0, 0, 0, time.UTC)
+
+// Типы блоков
+const (
+	BlockKindGenesis = "genesis" // первый блок, VoteHash = DefinitionHash голосования
+	BlockKindVote    = "vote"
+	BlockKindAnchor  = "anchor" // подписанный блок без голосов, закрепляющий неподписанные блоки до него
+)
+
+// Block — элемент цепочки блоков голосования
+type Block struct {
+	Index       int       `db:"id"`            // PRIMARY KEY
+	Height      int       `db:"height"`        // позиция блока в цепочке голосования, начиная с 0
+	Timestamp   time.Time `db:"created_at"`    // время создания, входит в хеш
+	VoteHash    string    `db:"vote_hash"`     // корень Merkle хешей голосов блока (у генезиса — хеш определения)
+	VoteCount   int       `db:"vote_count"`    // сколько голосов запечатано в блоке
+	PrevHash    string    `db:"previous_hash"` // хеш предыдущего блока
+	Hash        string    `db:"current_hash"`  // хеш текущего блока
+	HashVersion int       `db:"hash_version"`  // формат, которым посчитан Hash
+	Kind        string    `db:"kind"`          // genesis или vote
+	ElectionID  int       `db:"election_id"`   // к какому голосованию
+	Signature   string    `db:"signature"`     // подпись Ed25519 ключом узла в hex, в хеш не входит
+	KeyID       string    `db:"key_id"`        // каким ключом подписан блок
+}
+
+// NewBlock — создаёт следующий за prev блок (prev == nil для первого блока цепочки)
+// и считает его хеш текущей версией формата. voteHash — хеш голоса или корень
+// Merkle для пачки голосов.
+func NewBlock(electionID int, prev *Block, voteHash string, ts time.Time) *Block {
+	b := &Block{
+		// Postgres хранит микросекунды, поэтому время округляется до них заранее,
+		// чтобы хеш пересчитывался из сохранённой строки
+		Timestamp:   ts.UTC().Truncate(time.Microsecond),
+		VoteHash:    voteHash,
+		HashVersion: CurrentHashVersion,
+		Kind:        BlockKindVote,
+		VoteCount:   1,
+		ElectionID:  electionID,
+	}
+	if prev != nil {
+		b.Height = prev.Height + 1
+		b.PrevHash = prev.Hash
+	}
+	b.Hash = b.CalculateHash()
+	return b
+}
+
+// NewAnchorBlock — создаёт блок, закрепляющий подписью узла неподписанную
+// вершину цепочки prev
+func NewAnchorBlock(electionID int, prev *Block, ts time.Time) *Block {
+	b := NewBlock(electionID, prev, "", ts)
+	b.Kind = BlockKindAnchor
+	b.VoteCount = 0
+	return b
+}
+
+// NewGenesisBlock — создаёт генезис-блок голосования, фиксирующий его определение
+func NewGenesisBlock(electionID int, definitionHash string, ts time.Time) *Block {
+	b := NewBlock(electionID, nil, definitionHash, ts)
+	b.Kind = BlockKindGenesis
+	b.VoteCount = 0
+	return b
+}
+
+// CanonicalBytes — каноническая кодировка блока (версии 2 и 3): фиксированный
+// порядок полей, по одному на строку, время в RFC 3339 с наносекундами в UTC
+func (b *Block) CanonicalBytes() []byte {
+	fields := []string{
+		"vbc-block",
+		fmt.Sprintf("version:%d", b.HashVersion),
+		"alg:" + HashAlgorithm,
+		fmt.Sprintf("election:%d", b.ElectionID),
+		fmt.Sprintf("height:%d", b.Height),
+		"timestamp:" + b.Timestamp.UTC().Format(time.RFC3339Nano),
+		"prev:" + b.PrevHash,
+		"vote:" + b.VoteHash,
+	}
+	return []byte(strings.Join(fields, "\n"))
+}
+
+// CalculateHash — вычисляет хеш блока в формате его HashVersion.
+// Для неизвестной версии возвращает пустую строку. Для v1 результат совпадает
+// с сохранённым хешем только у блока, только что созданного в памяти: из БД
+// исходное время уже не восстановить.
+func (b *Block) CalculateHash() string {
+	var data []byte
+	switch b.HashVersion {
+	case HashVersionLegacy:
+		data = []byte(
+			b.Timestamp.String() + b.VoteHash + b.PrevHash + fmt.Sprintf("%d", b.ElectionID),
+		)
+	case HashVersionCanonical, HashVersionMerkleLeaf:
+		data = b.CanonicalBytes()
+	default:
+		return ""
+	}
+	hash := sha256.Sum256(data)
+	return hex.EncodeToString(hash[:])
+}
+
+// LegacyAllowed — может ли блок быть версии 1: такие блоки создавались только
+// до LegacyHashCutoff, не встречаются после блока версии 2 (afterCanonical) и
+// не бывают генезис-блоками, которые появились позже. Иначе версия 1 выставлена
+// задним числом, чтобы обойти проверку хеша.
+func (b *Block) LegacyAllowed(afterCanonical bool) bool {
+	return !afterCanonical && b.Kind != BlockKindGenesis && b.Timestamp.Before(LegacyHashCutoff)
+}
+
+// UnsignedAllowed — может ли блок быть без подписи: только в голосовании,
+// созданном до SignatureCutoff (electionCreated), в блоке, записанном до неё,
+// и до первого подписанного блока цепочки (afterSigned)
+func (b *Block) UnsignedAllowed(electionCreated time.Time, afterSigned bool) bool {
+	return !afterSigned && electionCreated.Before(SignatureCutoff) && b.Timestamp.Before(SignatureCutoff)
+}
+
+// SigningPayload — сообщение, которое подписывает узел. Hash уже фиксирует
+// все поля блока, поэтому подписывается он, с префиксом домена.
+func (b *Block) SigningPayload() []byte {
+	return []byte("vbc-block-signature\n" + b.Hash)
+}
+
+// VerifySignature — проверяет подпись блока публичным ключом
+func (b *Block) VerifySignature(pub ed25519.PublicKey) bool {
+	sig, err := hex.DecodeString(b.Signature)
+	if err != nil || len(sig) != ed25519.SignatureSize {
+		return false
+	}
+	return ed25519.Verify(pub, b.SigningPayload(), sig)
+}
@@ -1,8 +1,12 @@
-package models
-
-type Choice struct {
-    ID         int    `db:"id"`
-    ElectionID int    `db:"election_id"`
-    Text       string `db:"text"`
-    Count      int    `db:"count,omitempty"`
-}
+package models
+
+type Choice struct {
+    ID          int    `db:"id"`
+    ElectionID  int    `db:"election_id"`
+    ContestID   int    `db:"contest_id"` // вопрос голосования; 0 — голосование без вопросов
+    Text        string `db:"text"`
+    Description string `db:"description"`
+    ImageURL    string `db:"image_url"`
+    Position    int    `db:"position"` // порядок в бюллетене, начиная с 1
+    Count       int    `db:"count,omitempty"`
+}
@@ -0,0 +1,50 @@
+package models
+
+// Contest — отдельный вопрос голосования со своим способом и вариантами.
+// Голосование без вопросов состоит из одного неявного вопроса, см. DefaultContest.
+type Contest struct {
+	ID           int    `db:"id"`
+	ElectionID   int    `db:"election_id"`
+	Title        string `db:"title"`
+	Position     int    `db:"position"` // порядок в бюллетене, начиная с 1
+	VotingMethod string `db:"voting_method"`
+	MaxScore     int    `db:"max_score"`
+	Seats        int    `db:"seats"`
+	// AllowWriteIns — ответ plurality может содержать вписанный вариант
+	AllowWriteIns bool `db:"allow_write_ins"`
+	// Choices — тексты вариантов вопроса при создании голосования; сохранённые
+	// варианты читаются через ChoiceRepository по ContestID
+	Choices []string `json:"-" db:"-"`
+}
+
+// Method — способ голосования по вопросу; пустой — plurality
+func (c *Contest) Method() string {
+	if c.VotingMethod == "" {
+		return VotingPlurality
+	}
+	return c.VotingMethod
+}
+
+// DefaultContest — единственный вопрос голосования без вопросов: способ,
+// оценка, число мест и разрешение вписанных вариантов берутся из самого голосования
+func (e *Election) DefaultContest() *Contest {
+	return &Contest{
+		ElectionID:    e.ID,
+		Title:         e.Title,
+		VotingMethod:  e.VotingMethod,
+		MaxScore:      e.MaxScore,
+		Seats:         e.Seats,
+		AllowWriteIns: e.AllowWriteIns,
+	}
+}
+
+// ContestChoices — варианты вопроса contestID (0 — варианты без вопроса)
+func ContestChoices(choices []*Choice, contestID int) []*Choice {
+	var res []*Choice
+	for _, c := range choices {
+		if c.ContestID == contestID {
+			res = append(res, c)
+		}
+	}
+	return res
+}
@@ -1,13 +1,252 @@
-package models
-
-import "time"
-
-// Election — структура голосования (создаётся админом)
-type Election struct {
-	ID          int       `db:"id"`
-	Title       string    `db:"title"`
-	Description string    `db:"description"`
-	CreatedBy   int       `db:"created_by"` // ID администратора
-	CreatedAt   time.Time `db:"created_at"`
-	IsActive    bool      `db:"is_active"`  // Можно ли ещё голосовать
-}
+package models
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+)
+
+// Состояния голосования
+const (
+	ElectionDraft     = "draft"     // определение и варианты ещё редактируются
+	ElectionScheduled = "scheduled" // определение зафиксировано, ждёт OpensAt
+	ElectionOpen      = "open"      // принимаются голоса
+	ElectionClosed    = "closed"    // голоса больше не принимаются
+	ElectionTallied   = "tallied"   // итоги подсчитаны
+	ElectionCertified = "certified" // итоги утверждены
+	ElectionArchived  = "archived"
+)
+
+// Способы голосования (пустой VotingMethod — plurality)
+const (
+	VotingPlurality     = "plurality" // один вариант, побеждает набравший больше голосов
+	VotingInstantRunoff = "irv"       // ранжированный бюллетень, мгновенный второй тур
+	VotingApproval      = "approval"  // одобряется любое число вариантов
+	VotingScore         = "score"     // каждому варианту ставится оценка от 0 до MaxScore
+	VotingSchulze       = "schulze"   // ранжированный бюллетень, победитель по Кондорсе (метод Шульце)
+	VotingSTV           = "stv"       // ранжированный бюллетень, Seats мест, единый передаваемый голос
+)
+
+// DefaultMaxScore — наибольшая оценка в голосовании score, если она не задана
+const DefaultMaxScore = 5
+
+// IsVotingMethod — является ли строка известным способом голосования
+func IsVotingMethod(method string) bool {
+	switch method {
+	case VotingPlurality, VotingInstantRunoff, VotingApproval, VotingScore, VotingSchulze, VotingSTV:
+		return true
+	}
+	return false
+}
+
+// electionTransitions — допустимые переходы между состояниями голосования.
+// Из черновика выходят один раз: при этом пишется генезис-блок, и вернуться
+// к редактированию определения уже нельзя.
+var electionTransitions = map[string][]string{
+	ElectionDraft:     {ElectionScheduled, ElectionOpen},
+	ElectionScheduled: {ElectionOpen},
+	ElectionOpen:      {ElectionClosed},
+	ElectionClosed:    {ElectionTallied},
+	ElectionTallied:   {ElectionCertified},
+	ElectionCertified: {ElectionArchived},
+}
+
+// Election — структура голосования (создаётся админом)
+type Election struct {
+	ID                 int        `db:"id"`
+	Title              string     `db:"title"`
+	Description        string     `db:"description"`
+	CreatedBy          int        `db:"created_by"` // ID администратора
+	CreatedAt          time.Time  `db:"created_at"`
+	IsActive           bool       `db:"is_active"` // Status == open, оставлено для совместимости
+	Status             string     `db:"status"`
+	VotingMethod       string     `db:"voting_method"`
+	MaxScore           int        `db:"max_score"`
+	Seats              int        `db:"seats"`                // число мест (stv)
+	OpensAt            *time.Time `db:"opens_at"`             // голосование открывается само, голоса принимаются не раньше (nil — вручную)
+	ClosesAt           *time.Time `db:"closes_at"`            // и закрывается само, голоса принимаются не позже (nil — вручную)
+	BatchSize          int        `db:"batch_size"`           // блок запечатывается, когда накопится столько голосов
+	BatchWindowSeconds int        `db:"batch_window_seconds"` // или когда первый из них ждёт столько секунд (0 — без окна)
+	AllowWriteIns      bool       `db:"allow_write_ins"`      // бюллетень plurality может содержать вписанный вариант
+	Eligibility        string     `db:"eligibility"`          // кто может голосовать: all или roll
+	Anonymity          string     `db:"anonymity"`            // как подаются голоса: none или blind (по жетонам)
+	Encryption         string     `db:"encryption"`           // шифрование бюллетеней: none или elgamal
+	EncryptionKey      string     `db:"encryption_key"`       // открытый ключ h шифрования бюллетеней (hex)
+	TrusteeThreshold   int        `db:"trustee_threshold"`    // сколько доверенных лиц нужно для расшифровки (threshold)
+	// BlindKeyFingerprint — отпечаток ключа подписи жетонов; ключ создаётся,
+	// когда голосование с анонимностью blind выходит из черновика
+	BlindKeyFingerprint string `db:"blind_key_fingerprint"`
+	// Contests — вопросы голосования в порядке бюллетеня; пусто — один вопрос
+	// со способом VotingMethod
+	Contests []*Contest `db:"-"`
+	// WriteInReviews — решения по вписанным вариантам; не входят в определение,
+	// заполняются сервисами перед подсчётом и выгрузкой архива
+	WriteInReviews []*WriteInReview `db:"-"`
+}
+
+// ElectionTransition — запись о смене состояния голосования
+type ElectionTransition struct {
+	ID         int       `json:"id" db:"id"`
+	ElectionID int       `json:"election_id" db:"election_id"`
+	FromStatus string    `json:"from_status" db:"from_status"`
+	ToStatus   string    `json:"to_status" db:"to_status"`
+	ChangedBy  *int      `json:"changed_by,omitempty" db:"changed_by"` // nil — переход сделала система
+	ChangedAt  time.Time `json:"changed_at" db:"changed_at"`
+}
+
+// Method — способ голосования; у голосований, созданных до появления
+// способов, это plurality
+func (e *Election) Method() string {
+	if e.VotingMethod == "" {
+		return VotingPlurality
+	}
+	return e.VotingMethod
+}
+
+// IsElectionStatus — является ли строка известным состоянием голосования
+func IsElectionStatus(status string) bool {
+	_, ok := electionTransitions[status]
+	return ok || status == ElectionArchived
+}
+
+// CanTransition — допустим ли переход голосования из from в to
+func CanTransition(from, to string) bool {
+	for _, next := range electionTransitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// AcceptsVotes — принимает ли голосование голоса в момент now:
+// оно открыто, и now попадает в окно [OpensAt, ClosesAt)
+func (e *Election) AcceptsVotes(now time.Time) bool {
+	if e.Status != ElectionOpen {
+		return false
+	}
+	if e.OpensAt != nil && now.Before(*e.OpensAt) {
+		return false
+	}
+	return e.ClosesAt == nil || now.Before(*e.ClosesAt)
+}
+
+// BatchDue — пора ли запечатывать ожидающие голоса (pending упорядочены по времени)
+func (e *Election) BatchDue(pending []*Vote, now time.Time) bool {
+	if len(pending) == 0 {
+		return false
+	}
+	if len(pending) >= e.BatchSize {
+		return true
+	}
+	window := time.Duration(e.BatchWindowSeconds) * time.Second
+	return window > 0 && now.Sub(pending[0].CreatedAt) >= window
+}
+
+// electionDefinition — то, что фиксируется генезис-блоком голосования
+type electionDefinition struct {
+	ElectionID  int                `json:"election_id"`
+	Title       string             `json:"title"`
+	Description string             `json:"description"`
+	CreatedBy   int                `json:"created_by"`
+	Choices     []definitionChoice `json:"choices"`
+	// Способ голосования входит в хеш, только если он не plurality: так хеши
+	// определений, записанные до его появления, не меняются
+	VotingMethod string `json:"voting_method,omitempty"`
+	MaxScore     int    `json:"max_score,omitempty"`
+	Seats        int    `json:"seats,omitempty"`
+	// Разрешение вписанных вариантов, голосование по списку и анонимность
+	// входят в хеш, только если они заданы
+	AllowWriteIns bool   `json:"allow_write_ins,omitempty"`
+	Eligibility   string `json:"eligibility,omitempty"`
+	Anonymity     string `json:"anonymity,omitempty"`
+	// Отпечаток ключа подписи жетонов входит в хеш, только если он есть:
+	// определения, зафиксированные до его появления, не меняются
+	BlindKey string `json:"blind_key,omitempty"`
+	// Шифрование бюллетеней входит в хеш вместе с ключом и порогом
+	// доверенных лиц, только если оно задано
+	Encryption       string `json:"encryption,omitempty"`
+	EncryptionKey    string `json:"encryption_key,omitempty"`
+	TrusteeThreshold int    `json:"trustee_threshold,omitempty"`
+	// Вопросы входят в хеш, только если они есть
+	Contests []definitionContest `json:"contests,omitempty"`
+}
+
+type definitionContest struct {
+	ID            int    `json:"id"`
+	Title         string `json:"title"`
+	VotingMethod  string `json:"voting_method"`
+	MaxScore      int    `json:"max_score,omitempty"`
+	Seats         int    `json:"seats,omitempty"`
+	AllowWriteIns bool   `json:"allow_write_ins,omitempty"`
+}
+
+// Описание и изображение входят в хеш, только если заданы: так хеши
+// определений, записанные до их появления, не меняются
+type definitionChoice struct {
+	ID          int    `json:"id"`
+	Text        string `json:"text"`
+	Description string `json:"description,omitempty"`
+	ImageURL    string `json:"image_url,omitempty"`
+	ContestID   int    `json:"contest_id,omitempty"`
+}
+
+// DefinitionHash — хеш определения голосования: название, описание, создатель,
+// способ голосования, вопросы и варианты (с описаниями и изображениями) в
+// порядке их следования
+func (e *Election) DefinitionHash(choices []*Choice) string {
+	def := electionDefinition{
+		ElectionID:  e.ID,
+		Title:       e.Title,
+		Description: e.Description,
+		CreatedBy:   e.CreatedBy,
+		Choices:     make([]definitionChoice, 0, len(choices)),
+	}
+	if e.Method() != VotingPlurality {
+		def.VotingMethod = e.Method()
+	}
+	if e.Method() == VotingScore {
+		def.MaxScore = e.MaxScore
+	}
+	if e.Method() == VotingSTV {
+		def.Seats = e.Seats
+	}
+	def.AllowWriteIns = e.AllowWriteIns
+	if e.RollRestricted() {
+		def.Eligibility = e.Eligibility
+	}
+	if e.BlindTokens() {
+		def.Anonymity, def.BlindKey = e.Anonymity, e.BlindKeyFingerprint
+	}
+	if e.Encrypted() {
+		def.Encryption, def.EncryptionKey = e.Encryption, e.EncryptionKey
+	}
+	if e.TrusteeDecryption() {
+		def.TrusteeThreshold = e.TrusteeThreshold
+	}
+	for _, c := range e.Contests {
+		def.Contests = append(def.Contests, definitionContest{
+			ID:            c.ID,
+			Title:         c.Title,
+			VotingMethod:  c.Method(),
+			MaxScore:      c.MaxScore,
+			Seats:         c.Seats,
+			AllowWriteIns: c.AllowWriteIns,
+		})
+	}
+	for _, c := range choices {
+		def.Choices = append(def.Choices, definitionChoice{
+			ID:          c.ID,
+			Text:        c.Text,
+			Description: c.Description,
+			ImageURL:    c.ImageURL,
+			ContestID:   c.ContestID,
+		})
+	}
+
+	// Маршалинг структуры с фиксированным порядком полей не может завершиться ошибкой
+	data, _ := json.Marshal(def)
+	hash := sha256.Sum256(append([]byte("vbc-election-definition\nversion:1\n"), data...))
+	return hex.EncodeToString(hash[:])
+}
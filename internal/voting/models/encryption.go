@@ -0,0 +1,96 @@
+package models
+
+// Шифрование бюллетеней (пустой Encryption — none)
+const (
+	EncryptionNone    = "none"    // бюллетени открыты, итоги считаются по каждому голосу
+	EncryptionElGamal = "elgamal" // бюллетени зашифрованы экспоненциальным ElGamal, расшифровывается только сумма
+	// EncryptionThreshold — то же, но закрытого ключа нет ни у кого: его доли у
+	// доверенных лиц, и сумму расшифровывают любые TrusteeThreshold из них
+	EncryptionThreshold = "threshold"
+)
+
+// IsEncryption — является ли строка известным режимом шифрования бюллетеней
+func IsEncryption(s string) bool {
+	return s == EncryptionNone || s == EncryptionElGamal || s == EncryptionThreshold
+}
+
+// EncryptionMode — режим шифрования бюллетеней; у голосований, созданных до
+// появления шифрования, это none
+func (e *Election) EncryptionMode() string {
+	if e.Encryption == "" {
+		return EncryptionNone
+	}
+	return e.Encryption
+}
+
+// Encrypted — бюллетени голосования зашифрованы
+func (e *Election) Encrypted() bool {
+	return e.EncryptionMode() != EncryptionNone
+}
+
+// TrusteeDecryption — ключ голосования создают и сумму расшифровывают доверенные лица
+func (e *Election) TrusteeDecryption() bool {
+	return e.EncryptionMode() == EncryptionThreshold
+}
+
+// Ciphertext — шифртекст ElGamal (g^r, g^m·h^r); числа в hex
+type Ciphertext struct {
+	A string `json:"a"`
+	B string `json:"b"`
+}
+
+// ProofPart — ветвь доказательства с нулевым разглашением: вызов и ответ в hex
+type ProofPart struct {
+	C string `json:"c"`
+	Z string `json:"z"`
+}
+
+// EncryptedChoice — зашифрованное значение варианта (0/1 или оценка) и
+// доказательство того, что оно из допустимых для способа голосования
+type EncryptedChoice struct {
+	ChoiceID int `json:"choice_id"`
+	Ciphertext
+	Proof []ProofPart `json:"proof"`
+}
+
+// EncryptedBallot — зашифрованный бюллетень: по значению на каждый вариант в
+// порядке возрастания id; для plurality — ещё доказательство того, что сумма
+// значений равна 1
+type EncryptedBallot struct {
+	Choices  []EncryptedChoice `json:"choices"`
+	SumProof []ProofPart       `json:"sum_proof,omitempty"`
+}
+
+// ElectionPublicKey — открытый ключ шифрования бюллетеней голосования:
+// группа (p, q, g) и h = g^x
+type ElectionPublicKey struct {
+	ElectionID   int    `json:"election_id"`
+	Encryption   string `json:"encryption"`
+	P            string `json:"p"`
+	Q            string `json:"q"`
+	G            string `json:"g"`
+	H            string `json:"h"`
+	VotingMethod string `json:"voting_method"`
+	MaxValue     int    `json:"max_value"` // наибольшее значение варианта: 1 или наибольшая оценка
+	// Порог и ключи долей доверенных лиц (threshold)
+	Threshold int          `json:"threshold,omitempty"`
+	Trustees  []TrusteeKey `json:"trustees,omitempty"`
+}
+
+// EncryptedTally — суммы зашифрованных бюллетеней по вариантам. Каждый может
+// пересчитать их по голосам цепочки и проверить доказательства расшифровки.
+type EncryptedTally struct {
+	Choices   []EncryptedChoiceTally `json:"choices"`
+	Decrypted bool                   `json:"decrypted"` // суммы расшифрованы, итоги в ElectionResults.Choices
+}
+
+// EncryptedChoiceTally — произведение шифртекстов варианта и его расшифровка:
+// D = A^x с доказательством равенства логарифмов log_g h = log_A D. В режиме
+// threshold D собирается из частичных расшифровок доверенных лиц Partials.
+type EncryptedChoiceTally struct {
+	ChoiceID int                 `json:"choice_id"`
+	Sum      Ciphertext          `json:"sum"`
+	D        string              `json:"d,omitempty"`
+	Proof    *ProofPart          `json:"proof,omitempty"`
+	Partials []PartialDecryption `json:"partials,omitempty"`
+}
@@ -0,0 +1,118 @@
+package models
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+// merkleLeafHash — хеш листа дерева. Префикс 0x00 отделяет листья от
+// внутренних узлов, чтобы узел нельзя было выдать за лист с коротким путём.
+// В блоках до HashVersionMerkleLeaf листьями служили сами хеши голосов.
+func merkleLeafHash(version int, leaf string) string {
+	if version < HashVersionMerkleLeaf {
+		return leaf
+	}
+	hash := sha256.Sum256([]byte("\x00" + leaf))
+	return hex.EncodeToString(hash[:])
+}
+
+// merkleNodeHash — хеш внутреннего узла дерева. Префикс 0x01 отделяет узлы
+// от листьев, а разделитель — левый хеш от правого.
+func merkleNodeHash(left, right string) string {
+	hash := sha256.Sum256([]byte("\x01" + left + "|" + right))
+	return hex.EncodeToString(hash[:])
+}
+
+// merkleLevels — уровни дерева от листьев к корню; непарный узел переносится
+// на уровень выше без дублирования
+func merkleLevels(version int, leaves []string) [][]string {
+	level := make([]string, len(leaves))
+	for i, leaf := range leaves {
+		level[i] = merkleLeafHash(version, leaf)
+	}
+	levels := [][]string{level}
+	for len(level) > 1 {
+		next := make([]string, 0, (len(level)+1)/2)
+		for i := 0; i < len(level); i += 2 {
+			if i+1 == len(level) {
+				next = append(next, level[i])
+				continue
+			}
+			next = append(next, merkleNodeHash(level[i], level[i+1]))
+		}
+		levels = append(levels, next)
+		level = next
+	}
+	return levels
+}
+
+// MerkleRoot — корень дерева Merkle над хешами голосов в порядке их следования,
+// в формате блока версии version
+func MerkleRoot(version int, leaves []string) string {
+	if len(leaves) == 0 {
+		return ""
+	}
+	levels := merkleLevels(version, leaves)
+	return levels[len(levels)-1][0]
+}
+
+// MerkleProofStep — соседний узел на пути от листа к корню
+type MerkleProofStep struct {
+	Hash string `json:"hash"`
+	Left bool   `json:"left"` // сосед стоит слева от текущего узла
+}
+
+// merklePath — стороны соседей на пути листа index из count листьев.
+// Уровни, на которых узел переносится выше без пары, в путь не попадают.
+func merklePath(index, count int) []bool {
+	var left []bool
+	for ; count > 1; count = (count + 1) / 2 {
+		if sibling := index ^ 1; sibling < count {
+			left = append(left, sibling < index)
+		}
+		index /= 2
+	}
+	return left
+}
+
+// MerkleProof — путь включения листа index в дерево MerkleRoot(version, leaves)
+func MerkleProof(version int, leaves []string, index int) []MerkleProofStep {
+	if index < 0 || index >= len(leaves) {
+		return nil
+	}
+
+	proof := []MerkleProofStep{}
+	for _, level := range merkleLevels(version, leaves) {
+		if sibling := index ^ 1; sibling < len(level) {
+			proof = append(proof, MerkleProofStep{Hash: level[sibling], Left: sibling < index})
+		}
+		index /= 2
+	}
+	return proof
+}
+
+// VerifyMerkleProof — проверяет, что leaf — лист index дерева из count листьев
+// с корнем root. Длина и стороны пути должны совпасть с положением листа,
+// иначе внутренний узел можно было бы выдать за лист.
+func VerifyMerkleProof(version int, leaf string, index, count int, proof []MerkleProofStep, root string) bool {
+	if index < 0 || index >= count {
+		return false
+	}
+	path := merklePath(index, count)
+	if len(proof) != len(path) {
+		return false
+	}
+
+	node := merkleLeafHash(version, leaf)
+	for i, step := range proof {
+		if step.Left != path[i] {
+			return false
+		}
+		if step.Left {
+			node = merkleNodeHash(step.Hash, node)
+		} else {
+			node = merkleNodeHash(node, step.Hash)
+		}
+	}
+	return node == root
+}
@@ -0,0 +1,59 @@
+package models
+
+// Статусы квитанции голоса
+const (
+	ReceiptPending = "pending" // голос принят, но ещё не запечатан в блок
+	ReceiptSealed  = "sealed"
+)
+
+// VoteReceipt — квитанция избирателя: по ней можно убедиться, что голос
+// попал в цепочку, не раскрывая сам выбор
+type VoteReceipt struct {
+	ElectionID  int               `json:"election_id"`
+	VoteHash    string            `json:"vote_hash"`
+	Salt        string            `json:"salt,omitempty"` // только в квитанции при голосовании
+	Status      string            `json:"status"`
+	BlockIndex  *int              `json:"block_index,omitempty"` // высота блока в цепочке
+	BlockHash   string            `json:"block_hash,omitempty"`
+	HashVersion int               `json:"hash_version,omitempty"` // версия блока, задаёт хеширование листьев
+	MerkleRoot  string            `json:"merkle_root,omitempty"`
+	LeafIndex   int               `json:"leaf_index"`
+	LeafCount   int               `json:"leaf_count,omitempty"` // голосов в блоке
+	MerkleProof []MerkleProofStep `json:"merkle_proof,omitempty"`
+	// Блоки от блока голоса до последнего блока цепочки: по ним проверяются
+	// хеши и связи PrevHash до текущей вершины
+	ChainPath []*Block `json:"chain_path,omitempty"`
+	HeadHash  string   `json:"head_hash,omitempty"`
+}
+
+// Verify — проверяет квитанцию без обращения к серверу: путь Merkle ведёт
+// к корню блока, хеши блоков пути (кроме v1) пересчитываются и связаны между собой
+func (r *VoteReceipt) Verify() bool {
+	if r.Status != ReceiptSealed || !VerifyMerkleProof(r.HashVersion, r.VoteHash, r.LeafIndex, r.LeafCount, r.MerkleProof, r.MerkleRoot) {
+		return false
+	}
+	if len(r.ChainPath) == 0 {
+		return true
+	}
+
+	first := r.ChainPath[0]
+	if first.VoteHash != r.MerkleRoot || first.Hash != r.BlockHash ||
+		first.HashVersion != r.HashVersion || first.VoteCount != r.LeafCount {
+		return false
+	}
+	canonical := false
+	for i, b := range r.ChainPath {
+		if b.HashVersion == HashVersionLegacy && !b.LegacyAllowed(canonical) {
+			return false
+		}
+		// Хеш v1 не пересчитывается: время, от которого он считался, не сохранено
+		canonical = canonical || b.HashVersion != HashVersionLegacy
+		if b.HashVersion != HashVersionLegacy && b.CalculateHash() != b.Hash {
+			return false
+		}
+		if i > 0 && b.PrevHash != r.ChainPath[i-1].Hash {
+			return false
+		}
+	}
+	return r.ChainPath[len(r.ChainPath)-1].Hash == r.HeadHash
+}
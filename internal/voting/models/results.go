@@ -0,0 +1,75 @@
+package models
+
+// ChoiceResult — число голосов за вариант
+type ChoiceResult struct {
+	ChoiceID int    `json:"choice_id"`
+	Text     string `json:"text"`
+	Votes    int    `json:"votes"`           // для approval — одобрения, для score — оценившие бюллетени
+	Score    int    `json:"score,omitempty"` // сумма оценок (score)
+}
+
+// RunoffRound — тур подсчёта ранжированных бюллетеней
+type RunoffRound struct {
+	Round      int            `json:"round"`
+	Tallies    []ChoiceResult `json:"tallies"`              // голоса за оставшиеся варианты
+	Exhausted  int            `json:"exhausted"`            // бюллетени, в которых не осталось вариантов
+	Eliminated []int          `json:"eliminated,omitempty"` // варианты, выбывшие по итогам тура
+}
+
+// CondorcetResults — попарные сравнения вариантов (schulze). Строки и
+// столбцы матриц идут в порядке ChoiceIDs: Pairwise[i][j] — число
+// бюллетеней, где i выше j, StrongestPaths[i][j] — сила сильнейшего пути от i к j.
+type CondorcetResults struct {
+	ChoiceIDs      []int   `json:"choice_ids"`
+	Pairwise       [][]int `json:"pairwise"`
+	StrongestPaths [][]int `json:"strongest_paths"`
+}
+
+// Состояния варианта в протоколе подсчёта STV
+const (
+	CountContinuing = "continuing"
+	CountElected    = "elected"
+	CountExcluded   = "excluded"
+)
+
+// CountTally — голоса за вариант в начале тура STV (с учётом весов бюллетеней)
+type CountTally struct {
+	ChoiceID int     `json:"choice_id"`
+	Text     string  `json:"text"`
+	Votes    float64 `json:"votes"`
+	Status   string  `json:"status"`
+}
+
+// CountRound — строка протокола подсчёта STV: голоса в начале тура и
+// действие тура — избрание, передача излишка или исключение
+type CountRound struct {
+	Round       int          `json:"round"`
+	Tallies     []CountTally `json:"tallies"`
+	Exhausted   float64      `json:"exhausted"`
+	Elected     []int        `json:"elected,omitempty"`
+	Transferred int          `json:"transferred,omitempty"` // вариант, чей излишек передан
+	Excluded    []int        `json:"excluded,omitempty"`
+}
+
+// ElectionResults — итоги голосования по всем его вариантам, включая
+// варианты без голосов, в порядке вариантов
+type ElectionResults struct {
+	ElectionID   int                `json:"election_id"`
+	ContestID    int                `json:"contest_id,omitempty"` // итоги вопроса голосования с вопросами
+	Title        string             `json:"title,omitempty"`
+	VotingMethod string             `json:"voting_method"`
+	Choices      []ChoiceResult     `json:"choices"`            // для ранжированных способов — первые предпочтения
+	TotalVotes   int                `json:"total_votes"`        // учтённые голоса
+	Rejected     int                `json:"rejected,omitempty"` // голоса, не прошедшие проверку бюллетеня
+	Rounds       []RunoffRound      `json:"rounds,omitempty"`
+	Condorcet    *CondorcetResults  `json:"condorcet,omitempty"`
+	Seats        int                `json:"seats,omitempty"`
+	Quota        float64            `json:"quota,omitempty"` // квота Друпа (stv)
+	CountSheet   []CountRound       `json:"count_sheet,omitempty"`
+	WriteIns     []WriteInTally     `json:"write_ins,omitempty"` // вписанные варианты, не засчитанные ни одному варианту
+	Turnout      *Turnout           `json:"turnout,omitempty"`   // явка голосования по списку избирателей
+	Encrypted    *EncryptedTally    `json:"encrypted,omitempty"` // суммы зашифрованных бюллетеней
+	Contests     []*ElectionResults `json:"contests,omitempty"`  // итоги по вопросам; TotalVotes — поданные бюллетени
+	Winners      []int              `json:"winners,omitempty"`   // id победивших вариантов, если способ их определяет
+	Final        bool               `json:"final"`               // итоги сохранены при подсчёте; до утверждения их меняют только решения по вписанным вариантам
+}
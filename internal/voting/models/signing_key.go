@@ -0,0 +1,55 @@
+package models
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"time"
+)
+
+// SignatureAlgorithm — алгоритм подписи блоков
+const SignatureAlgorithm = "ed25519"
+
+// SigningKey — публичный ключ узла, которым подписывались блоки
+type SigningKey struct {
+	KeyID     string     `json:"key_id" db:"key_id"`         // KeyIDFor(PublicKey)
+	Algorithm string     `json:"algorithm" db:"algorithm"`   // ed25519
+	PublicKey string     `json:"public_key" db:"public_key"` // hex
+	CreatedAt time.Time  `json:"created_at" db:"created_at"`
+	RetiredAt *time.Time `json:"retired_at,omitempty" db:"retired_at"` // nil — текущий ключ
+}
+
+// KeyIDFor — идентификатор ключа: первые 8 байт sha256 публичного ключа в hex
+func KeyIDFor(pub ed25519.PublicKey) string {
+	sum := sha256.Sum256(pub)
+	return hex.EncodeToString(sum[:8])
+}
+
+// ParseSigningKey — ключ Ed25519 из публичного ключа в hex, например заданного
+// в конфигурации или в командной строке
+func ParseSigningKey(publicKey string) (*SigningKey, error) {
+	raw, err := hex.DecodeString(publicKey)
+	if err != nil || len(raw) != ed25519.PublicKeySize {
+		return nil, errors.New("invalid ed25519 public key")
+	}
+	return &SigningKey{
+		KeyID:     KeyIDFor(raw),
+		Algorithm: SignatureAlgorithm,
+		PublicKey: publicKey,
+		CreatedAt: time.Now().UTC(),
+	}, nil
+}
+
+// Ed25519 — разбирает публичный ключ и сверяет его с KeyID
+func (k *SigningKey) Ed25519() (ed25519.PublicKey, error) {
+	raw, err := hex.DecodeString(k.PublicKey)
+	if err != nil || len(raw) != ed25519.PublicKeySize {
+		return nil, errors.New("invalid ed25519 public key")
+	}
+	pub := ed25519.PublicKey(raw)
+	if KeyIDFor(pub) != k.KeyID {
+		return nil, errors.New("key id does not match public key")
+	}
+	return pub, nil
+}
@@ -0,0 +1,10 @@
+package models
+
+import "time"
+
+// Tally — итоги голосования, подсчитанные при переходе в tallied
+type Tally struct {
+	ElectionID int              `json:"election_id"`
+	Results    *ElectionResults `json:"results"`
+	ComputedAt time.Time        `json:"computed_at"`
+}
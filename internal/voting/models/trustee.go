@@ -0,0 +1,66 @@
+package models
+
+import "time"
+
+// Этапы церемонии ключа голосования с доверенными лицами
+const (
+	CeremonyKeys   = "keys"   // доверенные лица публикуют ключи для передачи долей
+	CeremonyDeal   = "deal"   // каждое публикует обязательства многочлена и зашифрованные доли
+	CeremonyVerify = "verify" // каждое проверяет полученные доли по обязательствам
+	CeremonyDone   = "done"   // ключ голосования опубликован
+)
+
+// Trustee — доверенное лицо голосования. Index — номер от 1, точка, в которой
+// вычисляются доли многочленов; PublicKey — его ключ ElGamal для получения
+// долей, Commitments — g^a_j коэффициентов его многочлена (Фельдман).
+type Trustee struct {
+	ElectionID  int       `json:"election_id"`
+	Index       int       `json:"index"`
+	UserID      int       `json:"user_id"`
+	PublicKey   string    `json:"public_key,omitempty"`
+	Commitments []string  `json:"commitments,omitempty"`
+	Verified    bool      `json:"verified"` // доли, полученные доверенным лицом, сошлись с обязательствами
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// EncryptedShare — доля f_from(to), зашифрованная на ключ получателя:
+// R = g^r, C = доля + H(y^r) mod q; числа в hex
+type EncryptedShare struct {
+	From int    `json:"from"`
+	To   int    `json:"to"`
+	R    string `json:"r"`
+	C    string `json:"c"`
+}
+
+// TrusteeDeal — то, что доверенное лицо публикует на этапе deal:
+// обязательства многочлена степени порог−1 и доли для всех доверенных лиц
+type TrusteeDeal struct {
+	Commitments []string         `json:"commitments"`
+	Shares      []EncryptedShare `json:"shares"`
+}
+
+// Ceremony — состояние церемонии ключа голосования. VerificationKeys —
+// g^x_i долей доверенных лиц, по ним проверяются частичные расшифровки.
+type Ceremony struct {
+	ElectionID       int          `json:"election_id"`
+	Threshold        int          `json:"threshold"`
+	Stage            string       `json:"stage"`
+	Trustees         []*Trustee   `json:"trustees"`
+	PublicKey        string       `json:"public_key,omitempty"`
+	VerificationKeys []TrusteeKey `json:"verification_keys,omitempty"`
+}
+
+// TrusteeKey — открытая часть доли ключа доверенного лица
+type TrusteeKey struct {
+	Index int    `json:"index"`
+	H     string `json:"h"`
+}
+
+// PartialDecryption — частичная расшифровка суммы варианта долей
+// доверенного лица: D = A^x_i с доказательством log_g h_i = log_A D
+type PartialDecryption struct {
+	Trustee  int       `json:"trustee,omitempty"`
+	ChoiceID int       `json:"choice_id,omitempty"`
+	D        string    `json:"d"`
+	Proof    ProofPart `json:"proof"`
+}
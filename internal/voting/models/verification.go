@@ -0,0 +1,31 @@
+package models
+
+// Статусы результата проверки цепочки
+const (
+	ChainValid        = "valid"
+	ChainBroken       = "broken"
+	ChainMissingVote  = "missing_vote"
+	ChainNoGenesis    = "no_genesis"    // голосование вышло из черновика, а генезис-блока нет
+	ChainMissingBlock = "missing_block" // голос ссылается на блок, которого нет в цепочке
+)
+
+// Откуда взяты ключи, которыми проверены подписи
+const (
+	KeySourcePinned  = "pinned"  // ключи узла или переданные проверяющим
+	KeySourceArchive = "archive" // ключи из самого архива: подписи ничего не доказывают о его происхождении
+)
+
+// ChainVerification — результат проверки цепочки блоков голосования
+type ChainVerification struct {
+	ElectionID     int    `json:"election_id"`
+	Status         string `json:"status"` // valid / broken / missing_vote / no_genesis / missing_block
+	Valid          bool   `json:"valid"`
+	BlocksChecked  int    `json:"blocks_checked"`
+	HasGenesis     bool   `json:"has_genesis"`               // без него цепочка вне черновика не проходит проверку
+	LegacyBlocks   int    `json:"legacy_blocks,omitempty"`   // блоки v1, созданные до канонического формата
+	UnsignedBlocks int    `json:"unsigned_blocks,omitempty"` // блоки без подписи узла, записанные до её появления
+	KeySource      string `json:"key_source"`                // pinned / archive
+	BrokenIndex    *int   `json:"broken_index,omitempty"`    // позиция первого плохого блока в цепочке
+	BlockID        *int   `json:"block_id,omitempty"`        // id этого блока в таблице blockchain
+	Reason         string `json:"reason,omitempty"`
+}
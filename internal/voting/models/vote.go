@@ -1,13 +1,76 @@
-package models
-
-import "time"
-
-// Vote представляет голос пользователя в конкретных выборах.
-type Vote struct {
-	ID         int       // Уникальный ID голоса
-	UserID     int       // ID пользователя, который проголосовал
-	ElectionID int       // ID выборов, в которых проголосовал
-	Choice     string
-	VoteHash   string    // Хэш голоса (содержимое + подпись)
-	CreatedAt  time.Time // Время создания голоса
-}
+package models
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Версии формата хеша голоса
+const (
+	VoteHashLegacy = 1 // sha256("userID|electionID|choice"): перебирается по открытым блокам
+	VoteHashSalted = 2 // обязательство с секретной солью голоса, см. CalculateHash
+	VoteHashBallot = 3 // то же, но над канонической кодировкой бюллетеня вместо текста варианта
+
+	CurrentVoteHashVersion = VoteHashBallot
+)
+
+// Vote представляет голос пользователя в конкретных выборах.
+type Vote struct {
+	ID          int       // Уникальный ID голоса
+	UserID      int       // ID пользователя, который проголосовал
+	ElectionID  int       // ID выборов, в которых проголосовал
+	Choice      string    // Текст варианта на момент голосования
+	ChoiceID    int       // Вариант голосования; 0 у голосов до проверки вариантов
+	Ballot      string    // Каноническая кодировка бюллетеня (версия 3)
+	Salt        string    // Секретная соль голоса (hex), не публикуется в блоках
+	HashVersion int       // Формат, которым посчитан VoteHash
+	VoteHash    string    // Хэш голоса (содержимое + подпись)
+	BlockID     *int      // Блок, в котором запечатан голос; nil — ждёт запечатывания
+	LeafIndex   int       // Позиция голоса среди листьев дерева Merkle блока
+	CreatedAt   time.Time // Время создания голоса
+}
+
+// CalculateHash — вычисляет хеш голоса в формате его HashVersion.
+// Версия 2 — обязательство sha256 над голосованием, выбором и 32 байтами
+// случайной соли: не зная соли, выбор по хешу из блока не подобрать.
+// Версия 3 устроена так же, но фиксирует бюллетень целиком.
+// Для неизвестной версии возвращает пустую строку.
+func (v *Vote) CalculateHash() string {
+	var raw string
+	switch v.HashVersion {
+	case VoteHashLegacy:
+		raw = fmt.Sprintf("%d|%d|%s", v.UserID, v.ElectionID, v.Choice)
+	case VoteHashSalted:
+		raw = strings.Join([]string{
+			"vbc-vote",
+			fmt.Sprintf("version:%d", VoteHashSalted),
+			fmt.Sprintf("election:%d", v.ElectionID),
+			"choice:" + v.Choice,
+			"salt:" + v.Salt,
+		}, "\n")
+	case VoteHashBallot:
+		raw = strings.Join([]string{
+			"vbc-vote",
+			fmt.Sprintf("version:%d", VoteHashBallot),
+			fmt.Sprintf("election:%d", v.ElectionID),
+			"ballot:" + v.Ballot,
+			"salt:" + v.Salt,
+		}, "\n")
+	default:
+		return ""
+	}
+	hash := sha256.Sum256([]byte(raw))
+	return hex.EncodeToString(hash[:])
+}
+
+// BallotContents — бюллетень голоса. У голосов версии 3 он разбирается из
+// зафиксированной хешем кодировки, у более старых собирается из варианта.
+func (v *Vote) BallotContents() (*Ballot, error) {
+	if v.HashVersion == VoteHashBallot {
+		return ParseBallot(v.Ballot)
+	}
+	return &Ballot{ChoiceID: v.ChoiceID, Choice: v.Choice}, nil
+}
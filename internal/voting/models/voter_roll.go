@@ -0,0 +1,52 @@
+package models
+
+import "time"
+
+// Кто может голосовать (пустой Eligibility — all)
+const (
+	EligibilityAll  = "all"  // любой зарегистрированный пользователь
+	EligibilityRoll = "roll" // только пользователи из списка избирателей
+)
+
+// IsEligibility — является ли строка известным режимом допуска избирателей
+func IsEligibility(s string) bool {
+	return s == EligibilityAll || s == EligibilityRoll
+}
+
+// EligibilityMode — режим допуска избирателей; у голосований, созданных до
+// появления списков, это all
+func (e *Election) EligibilityMode() string {
+	if e.Eligibility == "" {
+		return EligibilityAll
+	}
+	return e.Eligibility
+}
+
+// RollRestricted — голосуют только пользователи из списка избирателей
+func (e *Election) RollRestricted() bool {
+	return e.Eligibility == EligibilityRoll
+}
+
+// RollEntry — запись списка избирателей: id пользователя или email (ровно одно
+// из двух). Email сравнивается без учёта регистра.
+type RollEntry struct {
+	ID         int       `json:"id" db:"id"`
+	ElectionID int       `json:"election_id" db:"election_id"`
+	UserID     int       `json:"user_id,omitempty" db:"user_id"`
+	Email      string    `json:"email,omitempty" db:"email"`
+	AddedAt    time.Time `json:"added_at" db:"added_at"`
+}
+
+// RollSummary — итог загрузки списка избирателей
+type RollSummary struct {
+	Added int `json:"added"` // новые записи; уже бывшие в списке пропускаются
+	Size  int `json:"size"`  // избиратели в списке после загрузки
+}
+
+// Turnout — явка голосования по списку избирателей
+type Turnout struct {
+	RollSize int     `json:"roll_size"`
+	Codes    int     `json:"codes,omitempty"` // выпущенные коды голосования, избиратели вне списка
+	Voted    int     `json:"voted"`
+	Percent  float64 `json:"percent"` // Voted от RollSize и Codes, с точностью до сотых
+}
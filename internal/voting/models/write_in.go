@@ -0,0 +1,55 @@
+package models
+
+import (
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// MaxWriteInLength — наибольшая длина вписанного варианта в символах
+const MaxWriteInLength = 100
+
+// WriteInReview — решение администратора по вписанному варианту: голоса за
+// текст Text в вопросе ContestID (0 — голосование без вопросов) засчитываются
+// варианту ChoiceID. ChoiceID == 0 — вариант проверен и остаётся вписанным.
+type WriteInReview struct {
+	ElectionID int       `json:"election_id" db:"election_id"`
+	ContestID  int       `json:"contest_id,omitempty" db:"contest_id"`
+	Text       string    `json:"text" db:"text"`
+	ChoiceID   int       `json:"choice_id,omitempty" db:"choice_id"`
+	ReviewedBy *int      `json:"reviewed_by,omitempty" db:"reviewed_by"`
+	ReviewedAt time.Time `json:"reviewed_at" db:"reviewed_at"`
+}
+
+// WriteInTally — вписанный вариант вопроса с числом запечатанных голосов и
+// решением по нему, если оно уже принято
+type WriteInTally struct {
+	ContestID int    `json:"contest_id,omitempty"`
+	Text      string `json:"text"`
+	Votes     int    `json:"votes"`
+	Reviewed  bool   `json:"reviewed"`
+	ChoiceID  int    `json:"choice_id,omitempty"` // вариант, которому засчитаны голоса
+}
+
+// NormalizeWriteIn — вписанный вариант в каноническом виде: пробелы по краям
+// отброшены, подряд идущие пробельные символы заменены одним пробелом.
+// Пустой, слишком длинный или содержащий непечатные символы текст не принимается.
+func NormalizeWriteIn(text string) (string, bool) {
+	text = strings.Join(strings.Fields(text), " ")
+	if text == "" || utf8.RuneCountInString(text) > MaxWriteInLength {
+		return "", false
+	}
+	for _, r := range text {
+		if !unicode.IsPrint(r) {
+			return "", false
+		}
+	}
+	return text, true
+}
+
+// WriteInKey — ключ, по которому группируются вписанные варианты: одинаковые
+// с точностью до регистра тексты считаются одним вариантом
+func WriteInKey(text string) string {
+	return strings.ToLower(text)
+}
@@ -0,0 +1,57 @@
+package repositories
+
+import (
+	"context"
+
+	"voting-blockchain/internal/voting/models"
+)
+
+// BallotCodeRepository — одноразовые коды голосования. Коды хранятся хешами
+// (models.BallotCodeHash) и ни с пользователями, ни с голосами не связаны.
+type BallotCodeRepository interface {
+	Add(ctx context.Context, electionID int, hashes []string) error
+	// Consume — отмечает код использованным; false, если кода нет или он уже использован
+	Consume(ctx context.Context, electionID int, hash string) (bool, error)
+	Stats(ctx context.Context, electionID int) (*models.BallotCodeStats, error)
+}
+
+// BallotCodePostgres — реализация BallotCodeRepository через PostgreSQL
+type BallotCodePostgres struct {
+	DB DBTX
+}
+
+func NewBallotCodePostgres(db DBTX) *BallotCodePostgres {
+	return &BallotCodePostgres{DB: db}
+}
+
+func (r *BallotCodePostgres) Add(ctx context.Context, electionID int, hashes []string) error {
+	_, err := r.DB.Exec(ctx, `
+		INSERT INTO ballot_codes (election_id, code_hash)
+		SELECT $1, h FROM unnest($2::text[]) AS t(h)
+	`, electionID, hashes)
+	return err
+}
+
+func (r *BallotCodePostgres) Consume(ctx context.Context, electionID int, hash string) (bool, error) {
+	tag, err := r.DB.Exec(ctx, `
+		UPDATE ballot_codes SET used_at = date_trunc('hour', now())
+		WHERE election_id = $1 AND code_hash = $2 AND used_at IS NULL
+	`, electionID, hash)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
+func (r *BallotCodePostgres) Stats(ctx context.Context, electionID int) (*models.BallotCodeStats, error) {
+	var s models.BallotCodeStats
+	err := r.DB.QueryRow(ctx, `
+		SELECT count(*), count(used_at)
+		FROM ballot_codes
+		WHERE election_id = $1
+	`, electionID).Scan(&s.Issued, &s.Used)
+	if err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
@@ -0,0 +1,74 @@
+package repositories
+
+import (
+	"context"
+
+	"voting-blockchain/internal/voting/models"
+)
+
+// BlindTokenRepository — ключи, выдача и погашение жетонов анонимных
+// голосований. Выдача записывается за пользователем, погашение — только
+// хешем жетона: связать одно с другим по базе нельзя.
+type BlindTokenRepository interface {
+	// GetKey — ключ подписи жетонов; pgx.ErrNoRows, если он ещё не создан
+	GetKey(ctx context.Context, electionID int) (*models.BlindKey, error)
+	SaveKey(ctx context.Context, key *models.BlindKey) error
+	// RecordIssuance — отмечает выдачу жетона; false, если пользователь уже получил жетон
+	RecordIssuance(ctx context.Context, electionID, userID int) (bool, error)
+	// SpendToken — отмечает жетон использованным; false, если он уже использован
+	SpendToken(ctx context.Context, electionID int, tokenHash string) (bool, error)
+}
+
+// BlindTokenPostgres — реализация BlindTokenRepository через PostgreSQL
+type BlindTokenPostgres struct {
+	DB DBTX
+}
+
+func NewBlindTokenPostgres(db DBTX) *BlindTokenPostgres {
+	return &BlindTokenPostgres{DB: db}
+}
+
+func (r *BlindTokenPostgres) GetKey(ctx context.Context, electionID int) (*models.BlindKey, error) {
+	var k models.BlindKey
+	err := r.DB.QueryRow(ctx, `
+		SELECT election_id, private_key, created_at
+		FROM blind_keys
+		WHERE election_id = $1
+	`, electionID).Scan(&k.ElectionID, &k.PrivateKey, &k.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &k, nil
+}
+
+func (r *BlindTokenPostgres) SaveKey(ctx context.Context, key *models.BlindKey) error {
+	return r.DB.QueryRow(ctx, `
+		INSERT INTO blind_keys (election_id, private_key)
+		VALUES ($1, $2)
+		RETURNING created_at
+	`, key.ElectionID, key.PrivateKey).Scan(&key.CreatedAt)
+}
+
+func (r *BlindTokenPostgres) RecordIssuance(ctx context.Context, electionID, userID int) (bool, error) {
+	tag, err := r.DB.Exec(ctx, `
+		INSERT INTO blind_issuances (election_id, user_id)
+		VALUES ($1, $2)
+		ON CONFLICT DO NOTHING
+	`, electionID, userID)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
+func (r *BlindTokenPostgres) SpendToken(ctx context.Context, electionID int, tokenHash string) (bool, error) {
+	tag, err := r.DB.Exec(ctx, `
+		INSERT INTO spent_tokens (election_id, token_hash)
+		VALUES ($1, $2)
+		ON CONFLICT DO NOTHING
+	`, electionID, tokenHash)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() == 1, nil
+}
@@ -1,97 +1,149 @@
-package repositories
-
-import (
-	"context"
-	"github.com/jackc/pgx/v5/pgxpool"
-	"voting-blockchain/internal/voting/models"
-)
-
-// BlockchainRepository — интерфейс для работы с блоками.
-type BlockchainRepository interface {
-	AddBlock(ctx context.Context, block *models.Block) error
-	GetLastBlock(ctx context.Context, electionID int) (*models.Block, error)
-	GetAllBlocks(ctx context.Context, electionID int) ([]*models.Block, error)
-}
-
-// BlockchainPostgres — реализация BlockchainRepository через PostgreSQL.
-type BlockchainPostgres struct {
-	DB *pgxpool.Pool
-}
-
-// Конструктор
-func NewBlockchainPostgres(db *pgxpool.Pool) *BlockchainPostgres {
-	return &BlockchainPostgres{DB: db}
-}
-
-// AddBlock — сохраняет новый блок в таблицу blockchain.
-func (r *BlockchainPostgres) AddBlock(ctx context.Context, block *models.Block) error {
-	query := `
-		INSERT INTO blockchain (vote_hash, previous_hash, current_hash, election_id)
-		VALUES ($1, $2, $3, $4)
-		RETURNING id, created_at
-	`
-	return r.DB.QueryRow(ctx, query,
-		block.VoteHash,
-		block.PrevHash,
-		block.Hash,
-		block.ElectionID,
-	).Scan(&block.Index, &block.Timestamp)
-}
-
-// GetLastBlock — получает последний блок по голосованию.
-func (r *BlockchainPostgres) GetLastBlock(ctx context.Context, electionID int) (*models.Block, error) {
-	query := `
-		SELECT id, created_at, vote_hash, previous_hash, current_hash, election_id
-		FROM blockchain
-		WHERE election_id = $1
-		ORDER BY id DESC
-		LIMIT 1
-	`
-
-	var b models.Block
-	err := r.DB.QueryRow(ctx, query, electionID).Scan(
-		&b.Index,
-		&b.Timestamp,
-		&b.VoteHash,
-		&b.PrevHash,
-		&b.Hash,
-		&b.ElectionID,
-	)
-	if err != nil {
-		return nil, err
-	}
-	return &b, nil
-}
-
-// GetAllBlocks — возвращает полную цепочку блоков для голосования.
-func (r *BlockchainPostgres) GetAllBlocks(ctx context.Context, electionID int) ([]*models.Block, error) {
-	query := `
-		SELECT id, created_at, vote_hash, previous_hash, current_hash, election_id
-		FROM blockchain
-		WHERE election_id = $1
-		ORDER BY id ASC
-	`
-
-	rows, err := r.DB.Query(ctx, query, electionID)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-
-	var blocks []*models.Block
-	for rows.Next() {
-		var b models.Block
-		if err := rows.Scan(
-			&b.Index,
-			&b.Timestamp,
-			&b.VoteHash,
-			&b.PrevHash,
-			&b.Hash,
-			&b.ElectionID,
-		); err != nil {
-			return nil, err
-		}
-		blocks = append(blocks, &b)
-	}
-	return blocks, nil
-}
\ No newline at end of file
+package repositories
+
+import (
+	"context"
+	"voting-blockchain/internal/voting/models"
+)
+
+// BlockchainRepository — интерфейс для работы с блоками.
+type BlockchainRepository interface {
+	AddBlock(ctx context.Context, block *models.Block) error
+	GetLastBlock(ctx context.Context, electionID int) (*models.Block, error)
+	GetAllBlocks(ctx context.Context, electionID int) ([]*models.Block, error)
+	// RestoreBlocks — вставляет блоки из архива с их исходными id
+	RestoreBlocks(ctx context.Context, blocks []*models.Block) error
+}
+
+// BlockchainPostgres — реализация BlockchainRepository через PostgreSQL.
+type BlockchainPostgres struct {
+	DB DBTX
+}
+
+// Конструктор
+func NewBlockchainPostgres(db DBTX) *BlockchainPostgres {
+	return &BlockchainPostgres{DB: db}
+}
+
+// AddBlock — сохраняет новый блок в таблицу blockchain.
+func (r *BlockchainPostgres) AddBlock(ctx context.Context, block *models.Block) error {
+	query := `
+		INSERT INTO blockchain (created_at, height, vote_hash, vote_count, previous_hash, current_hash, hash_version, kind, election_id, signature, key_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, NULLIF($11, ''))
+		RETURNING id
+	`
+	// created_at берётся из блока: он входит в хеш и не должен подменяться now()
+	return r.DB.QueryRow(ctx, query,
+		block.Timestamp,
+		block.Height,
+		block.VoteHash,
+		block.VoteCount,
+		block.PrevHash,
+		block.Hash,
+		block.HashVersion,
+		block.Kind,
+		block.ElectionID,
+		block.Signature,
+		block.KeyID,
+	).Scan(&block.Index)
+}
+
+// GetLastBlock — получает последний блок по голосованию.
+func (r *BlockchainPostgres) GetLastBlock(ctx context.Context, electionID int) (*models.Block, error) {
+	query := `
+		SELECT id, height, created_at, vote_hash, vote_count, previous_hash, current_hash, hash_version, kind, election_id,
+			signature, COALESCE(key_id, '')
+		FROM blockchain
+		WHERE election_id = $1
+		ORDER BY height DESC
+		LIMIT 1
+	`
+
+	var b models.Block
+	err := r.DB.QueryRow(ctx, query, electionID).Scan(
+		&b.Index,
+		&b.Height,
+		&b.Timestamp,
+		&b.VoteHash,
+		&b.VoteCount,
+		&b.PrevHash,
+		&b.Hash,
+		&b.HashVersion,
+		&b.Kind,
+		&b.ElectionID,
+		&b.Signature,
+		&b.KeyID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &b, nil
+}
+
+// GetAllBlocks — возвращает полную цепочку блоков для голосования.
+func (r *BlockchainPostgres) GetAllBlocks(ctx context.Context, electionID int) ([]*models.Block, error) {
+	query := `
+		SELECT id, height, created_at, vote_hash, vote_count, previous_hash, current_hash, hash_version, kind, election_id,
+			signature, COALESCE(key_id, '')
+		FROM blockchain
+		WHERE election_id = $1
+		ORDER BY height ASC
+	`
+
+	rows, err := r.DB.Query(ctx, query, electionID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var blocks []*models.Block
+	for rows.Next() {
+		var b models.Block
+		if err := rows.Scan(
+			&b.Index,
+			&b.Height,
+			&b.Timestamp,
+			&b.VoteHash,
+			&b.VoteCount,
+			&b.PrevHash,
+			&b.Hash,
+			&b.HashVersion,
+			&b.Kind,
+			&b.ElectionID,
+			&b.Signature,
+			&b.KeyID,
+		); err != nil {
+			return nil, err
+		}
+		blocks = append(blocks, &b)
+	}
+	return blocks, nil
+}
+
+// RestoreBlocks — вставляет блоки архива как есть: id сохраняются, потому что
+// на них ссылаются голоса
+func (r *BlockchainPostgres) RestoreBlocks(ctx context.Context, blocks []*models.Block) error {
+	query := `
+		INSERT INTO blockchain (id, created_at, height, vote_hash, vote_count, previous_hash, current_hash, hash_version, kind, election_id, signature, key_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, NULLIF($12, ''))
+	`
+	for _, b := range blocks {
+		_, err := r.DB.Exec(ctx, query,
+			b.Index,
+			b.Timestamp,
+			b.Height,
+			b.VoteHash,
+			b.VoteCount,
+			b.PrevHash,
+			b.Hash,
+			b.HashVersion,
+			b.Kind,
+			b.ElectionID,
+			b.Signature,
+			b.KeyID,
+		)
+		if err != nil {
+			return err
+		}
+	}
+	return resetSequence(ctx, r.DB, "blockchain")
+}
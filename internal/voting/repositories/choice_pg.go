@@ -1,48 +1,126 @@
-package repositories
-
-import (
-    "context"
-
-    "github.com/jackc/pgx/v5/pgxpool"
-    "voting-blockchain/internal/voting/models"
-)
-
-type ChoicePostgres struct {
-    DB *pgxpool.Pool
-}
-
-func NewChoicePostgres(db *pgxpool.Pool) *ChoicePostgres {
-    return &ChoicePostgres{DB: db}
-}
-
-func (r *ChoicePostgres) CreateChoices(ctx context.Context, electionID int, choices []string) error {
-    for _, text := range choices {
-        _, err := r.DB.Exec(ctx,
-            `INSERT INTO choices (election_id, text) VALUES ($1, $2)`,
-            electionID, text,
-        )
-        if err != nil {
-            return err
-        }
-    }
-    return nil
-}
-
-func (r *ChoicePostgres) GetChoices(ctx context.Context, electionID int) ([]*models.Choice, error) {
-    rows, err := r.DB.Query(ctx,
-        `SELECT id, election_id, text FROM choices WHERE election_id = $1 ORDER BY id`, electionID)
-    if err != nil {
-        return nil, err
-    }
-    defer rows.Close()
-
-    var res []*models.Choice
-    for rows.Next() {
-        var c models.Choice
-        if err := rows.Scan(&c.ID, &c.ElectionID, &c.Text); err != nil {
-            return nil, err
-        }
-        res = append(res, &c)
-    }
-    return res, nil
-}
+package repositories
+
+import (
+    "context"
+
+    "github.com/jackc/pgx/v5"
+    "voting-blockchain/internal/voting/models"
+)
+
+const choiceColumns = "id, election_id, COALESCE(contest_id, 0), text, description, image_url, position"
+
+type ChoicePostgres struct {
+    DB DBTX
+}
+
+func NewChoicePostgres(db DBTX) *ChoicePostgres {
+    return &ChoicePostgres{DB: db}
+}
+
+func scanChoice(row pgx.Row) (*models.Choice, error) {
+    var c models.Choice
+    if err := row.Scan(&c.ID, &c.ElectionID, &c.ContestID, &c.Text, &c.Description, &c.ImageURL, &c.Position); err != nil {
+        return nil, err
+    }
+    return &c, nil
+}
+
+func (r *ChoicePostgres) CreateChoices(ctx context.Context, electionID int, choices []string) error {
+    for _, text := range choices {
+        if err := r.AddChoice(ctx, &models.Choice{ElectionID: electionID, Text: text}); err != nil {
+            return err
+        }
+    }
+    return nil
+}
+
+func (r *ChoicePostgres) GetChoices(ctx context.Context, electionID int) ([]*models.Choice, error) {
+    rows, err := r.DB.Query(ctx,
+        `SELECT `+choiceColumns+` FROM choices WHERE election_id = $1 ORDER BY position, id`, electionID)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    var res []*models.Choice
+    for rows.Next() {
+        c, err := scanChoice(rows)
+        if err != nil {
+            return nil, err
+        }
+        res = append(res, c)
+    }
+    return res, rows.Err()
+}
+
+func (r *ChoicePostgres) GetChoice(ctx context.Context, electionID, choiceID int) (*models.Choice, error) {
+    row := r.DB.QueryRow(ctx,
+        `SELECT `+choiceColumns+` FROM choices WHERE election_id = $1 AND id = $2`, electionID, choiceID)
+    return scanChoice(row)
+}
+
+func (r *ChoicePostgres) AddChoice(ctx context.Context, c *models.Choice) error {
+    return r.DB.QueryRow(ctx,
+        `INSERT INTO choices (election_id, contest_id, text, description, image_url, position)
+         VALUES ($1, NULLIF($2, 0), $3, $4, $5, (SELECT COALESCE(MAX(position), 0) + 1 FROM choices WHERE election_id = $1))
+         RETURNING id, position`,
+        c.ElectionID, c.ContestID, c.Text, c.Description, c.ImageURL,
+    ).Scan(&c.ID, &c.Position)
+}
+
+func (r *ChoicePostgres) UpdateChoice(ctx context.Context, c *models.Choice) error {
+    tag, err := r.DB.Exec(ctx,
+        `UPDATE choices SET text = $1, description = $2, image_url = $3 WHERE election_id = $4 AND id = $5`,
+        c.Text, c.Description, c.ImageURL, c.ElectionID, c.ID,
+    )
+    if err != nil {
+        return err
+    }
+    if tag.RowsAffected() == 0 {
+        return pgx.ErrNoRows
+    }
+    return nil
+}
+
+func (r *ChoicePostgres) ReorderChoices(ctx context.Context, electionID int, ids []int) error {
+    for i, id := range ids {
+        _, err := r.DB.Exec(ctx,
+            `UPDATE choices SET position = $1 WHERE election_id = $2 AND id = $3`,
+            i+1, electionID, id,
+        )
+        if err != nil {
+            return err
+        }
+    }
+    return nil
+}
+
+func (r *ChoicePostgres) DeleteChoice(ctx context.Context, electionID, choiceID int) error {
+    tag, err := r.DB.Exec(ctx, `DELETE FROM choices WHERE election_id = $1 AND id = $2`, electionID, choiceID)
+    if err != nil {
+        return err
+    }
+    if tag.RowsAffected() == 0 {
+        return pgx.ErrNoRows
+    }
+    return nil
+}
+
+func (r *ChoicePostgres) RestoreChoices(ctx context.Context, choices []*models.Choice) error {
+    for i, c := range choices {
+        // В архивах до появления порядка он задан последовательностью записей
+        position := c.Position
+        if position == 0 {
+            position = i + 1
+        }
+        _, err := r.DB.Exec(ctx,
+            `INSERT INTO choices (id, election_id, contest_id, text, description, image_url, position)
+             VALUES ($1, $2, NULLIF($3, 0), $4, $5, $6, $7)`,
+            c.ID, c.ElectionID, c.ContestID, c.Text, c.Description, c.ImageURL, position,
+        )
+        if err != nil {
+            return err
+        }
+    }
+    return resetSequence(ctx, r.DB, "choices")
+}
@@ -1,11 +1,23 @@
-package repositories
-
-import (
-    "context"
-    "voting-blockchain/internal/voting/models"
-)
-
-type ChoiceRepository interface {
-    CreateChoices(ctx context.Context, electionID int, choices []string) error
-    GetChoices(ctx context.Context, electionID int) ([]*models.Choice, error)
-}
+package repositories
+
+import (
+    "context"
+    "voting-blockchain/internal/voting/models"
+)
+
+type ChoiceRepository interface {
+    CreateChoices(ctx context.Context, electionID int, choices []string) error
+    GetChoices(ctx context.Context, electionID int) ([]*models.Choice, error)
+    // GetChoice — вариант голосования по id; pgx.ErrNoRows, если его нет
+    GetChoice(ctx context.Context, electionID, choiceID int) (*models.Choice, error)
+    // AddChoice — добавляет вариант (в вопрос c.ContestID, если он задан) в конец
+    // бюллетеня и заполняет c.ID и c.Position
+    AddChoice(ctx context.Context, c *models.Choice) error
+    // UpdateChoice — меняет текст, описание и изображение варианта
+    UpdateChoice(ctx context.Context, c *models.Choice) error
+    // ReorderChoices — задаёт порядок вариантов: ids перечисляет их все
+    ReorderChoices(ctx context.Context, electionID int, ids []int) error
+    DeleteChoice(ctx context.Context, electionID, choiceID int) error
+    // RestoreChoices — вставляет варианты из архива с их исходными id
+    RestoreChoices(ctx context.Context, choices []*models.Choice) error
+}
@@ -0,0 +1,26 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// DBTX — общий интерфейс пула соединений и транзакции pgx.
+// Репозитории принимают его, чтобы одинаково работать и вне, и внутри транзакции.
+type DBTX interface {
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+// resetSequence — сдвигает SERIAL-последовательность id таблицы за максимальный
+// id, чтобы после вставки строк с явными id новые строки не получили занятый
+func resetSequence(ctx context.Context, db DBTX, table string) error {
+	_, err := db.Exec(ctx,
+		`SELECT setval(pg_get_serial_sequence($1, 'id'), GREATEST((SELECT MAX(id) FROM `+table+`), 1))`,
+		table,
+	)
+	return err
+}
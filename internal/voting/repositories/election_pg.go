@@ -1,102 +1,408 @@
-package repositories
-
-import (
-    "context"
-
-    "github.com/jackc/pgx/v5/pgxpool"
-    "voting-blockchain/internal/voting/models"
-)
-
-type ElectionPostgres struct {
-    DB *pgxpool.Pool
-}
-
-func NewElectionPostgres(db *pgxpool.Pool) *ElectionPostgres {
-    return &ElectionPostgres{DB: db}
-}
-
-func (r *ElectionPostgres) Create(ctx context.Context, e *models.Election) error {
-    query := `
-        INSERT INTO elections (title, description, created_by, is_active)
-        VALUES ($1, $2, $3, $4)
-        RETURNING id, created_at
-    `
-    return r.DB.QueryRow(ctx, query,
-        e.Title,
-        e.Description,
-        e.CreatedBy,
-        e.IsActive,
-    ).Scan(&e.ID, &e.CreatedAt)
-}
-
-func (r *ElectionPostgres) GetByID(ctx context.Context, id int) (*models.Election, error) {
-    query := `
-        SELECT id, title, description, created_by, created_at, is_active
-        FROM elections
-        WHERE id = $1
-    `
-    var e models.Election
-    err := r.DB.QueryRow(ctx, query, id).Scan(
-        &e.ID,
-        &e.Title,
-        &e.Description,
-        &e.CreatedBy,
-        &e.CreatedAt,
-        &e.IsActive,
-    )
-    if err != nil {
-        return nil, err
-    }
-    return &e, nil
-}
-
-func (r *ElectionPostgres) List(ctx context.Context) ([]*models.Election, error) {
-    query := `
-        SELECT id, title, description, created_by, created_at, is_active
-        FROM elections
-        ORDER BY created_at DESC
-    `
-    rows, err := r.DB.Query(ctx, query)
-    if err != nil {
-        return nil, err
-    }
-    defer rows.Close()
-
-    var elections []*models.Election
-    for rows.Next() {
-        var e models.Election
-        if err := rows.Scan(
-            &e.ID,
-            &e.Title,
-            &e.Description,
-            &e.CreatedBy,
-            &e.CreatedAt,
-            &e.IsActive,
-        ); err != nil {
-            return nil, err
-        }
-        elections = append(elections, &e)
-    }
-    return elections, nil
-}
-
-func (r *ElectionPostgres) Update(ctx context.Context, e *models.Election) error {
-    query := `
-        UPDATE elections
-        SET title = $1, description = $2, is_active = $3
-        WHERE id = $4
-    `
-    _, err := r.DB.Exec(ctx, query,
-        e.Title,
-        e.Description,
-        e.IsActive,
-        e.ID,
-    )
-    return err
-}
-
-func (r *ElectionPostgres) Delete(ctx context.Context, id int) error {
-    query := `DELETE FROM elections WHERE id = $1`
-    _, err := r.DB.Exec(ctx, query, id)
-    return err
-}
+package repositories
+
+import (
+    "context"
+    "time"
+
+    "github.com/jackc/pgx/v5"
+    "voting-blockchain/internal/voting/models"
+)
+
+// electionColumns — колонки elections в порядке, который ожидает scanElection
+const electionColumns = `id, title, description, created_by, created_at, is_active,
+        status, voting_method, max_score, seats, opens_at, closes_at, batch_size, batch_window_seconds, allow_write_ins,
+        eligibility, anonymity, encryption, encryption_key, trustee_threshold, blind_key_fingerprint`
+
+type ElectionPostgres struct {
+    DB DBTX
+}
+
+func NewElectionPostgres(db DBTX) *ElectionPostgres {
+    return &ElectionPostgres{DB: db}
+}
+
+// scanElection — читает строку, выбранную по electionColumns
+func scanElection(row pgx.Row) (*models.Election, error) {
+    var e models.Election
+    err := row.Scan(
+        &e.ID,
+        &e.Title,
+        &e.Description,
+        &e.CreatedBy,
+        &e.CreatedAt,
+        &e.IsActive,
+        &e.Status,
+        &e.VotingMethod,
+        &e.MaxScore,
+        &e.Seats,
+        &e.OpensAt,
+        &e.ClosesAt,
+        &e.BatchSize,
+        &e.BatchWindowSeconds,
+        &e.AllowWriteIns,
+        &e.Eligibility,
+        &e.Anonymity,
+        &e.Encryption,
+        &e.EncryptionKey,
+        &e.TrusteeThreshold,
+        &e.BlindKeyFingerprint,
+    )
+    if err != nil {
+        return nil, err
+    }
+    return &e, nil
+}
+
+func (r *ElectionPostgres) Create(ctx context.Context, e *models.Election) error {
+    query := `
+        INSERT INTO elections (title, description, created_by, is_active, status, voting_method, max_score, seats, opens_at, closes_at, batch_size, batch_window_seconds, allow_write_ins, eligibility, anonymity, encryption, encryption_key, trustee_threshold, blind_key_fingerprint)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19)
+        RETURNING id, created_at
+    `
+    err := r.DB.QueryRow(ctx, query,
+        e.Title,
+        e.Description,
+        e.CreatedBy,
+        e.IsActive,
+        e.Status,
+        e.Method(),
+        e.MaxScore,
+        e.Seats,
+        e.OpensAt,
+        e.ClosesAt,
+        e.BatchSize,
+        e.BatchWindowSeconds,
+        e.AllowWriteIns,
+        e.EligibilityMode(),
+        e.AnonymityMode(),
+        e.EncryptionMode(),
+        e.EncryptionKey,
+        e.TrusteeThreshold,
+        e.BlindKeyFingerprint,
+    ).Scan(&e.ID, &e.CreatedAt)
+    if err != nil {
+        return err
+    }
+
+    for i, c := range e.Contests {
+        c.ElectionID = e.ID
+        c.Position = i + 1
+        err := r.DB.QueryRow(ctx,
+            `INSERT INTO contests (election_id, title, position, voting_method, max_score, seats, allow_write_ins)
+             VALUES ($1, $2, $3, $4, $5, $6, $7)
+             RETURNING id`,
+            c.ElectionID, c.Title, c.Position, c.Method(), c.MaxScore, c.Seats, c.AllowWriteIns,
+        ).Scan(&c.ID)
+        if err != nil {
+            return err
+        }
+    }
+    return nil
+}
+
+// loadContests — заполняет вопросы голосований одним запросом
+func (r *ElectionPostgres) loadContests(ctx context.Context, elections []*models.Election) error {
+    if len(elections) == 0 {
+        return nil
+    }
+    byID := make(map[int]*models.Election, len(elections))
+    ids := make([]int, 0, len(elections))
+    for _, e := range elections {
+        byID[e.ID] = e
+        ids = append(ids, e.ID)
+    }
+
+    rows, err := r.DB.Query(ctx, `
+        SELECT id, election_id, title, position, voting_method, max_score, seats, allow_write_ins
+        FROM contests
+        WHERE election_id = ANY($1)
+        ORDER BY election_id, position
+    `, ids)
+    if err != nil {
+        return err
+    }
+    defer rows.Close()
+
+    for rows.Next() {
+        var c models.Contest
+        if err := rows.Scan(&c.ID, &c.ElectionID, &c.Title, &c.Position, &c.VotingMethod, &c.MaxScore, &c.Seats, &c.AllowWriteIns); err != nil {
+            return err
+        }
+        e := byID[c.ElectionID]
+        e.Contests = append(e.Contests, &c)
+    }
+    return rows.Err()
+}
+
+func (r *ElectionPostgres) GetByID(ctx context.Context, id int) (*models.Election, error) {
+    query := `
+        SELECT ` + electionColumns + `
+        FROM elections
+        WHERE id = $1
+    `
+    e, err := scanElection(r.DB.QueryRow(ctx, query, id))
+    if err != nil {
+        return nil, err
+    }
+    if err := r.loadContests(ctx, []*models.Election{e}); err != nil {
+        return nil, err
+    }
+    return e, nil
+}
+
+func (r *ElectionPostgres) List(ctx context.Context) ([]*models.Election, error) {
+    query := `
+        SELECT ` + electionColumns + `
+        FROM elections
+        ORDER BY created_at DESC
+    `
+    rows, err := r.DB.Query(ctx, query)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    var elections []*models.Election
+    for rows.Next() {
+        e, err := scanElection(rows)
+        if err != nil {
+            return nil, err
+        }
+        elections = append(elections, e)
+    }
+    if err := rows.Err(); err != nil {
+        return nil, err
+    }
+    rows.Close()
+    return elections, r.loadContests(ctx, elections)
+}
+
+func (r *ElectionPostgres) ListDue(ctx context.Context, now time.Time) ([]*models.Election, error) {
+    query := `
+        SELECT ` + electionColumns + `
+        FROM elections
+        WHERE (status = 'scheduled' AND opens_at <= $1)
+           OR (status = 'open' AND (closes_at <= $1 OR batch_window_seconds > 0))
+        ORDER BY id
+    `
+    rows, err := r.DB.Query(ctx, query, now)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    var elections []*models.Election
+    for rows.Next() {
+        e, err := scanElection(rows)
+        if err != nil {
+            return nil, err
+        }
+        elections = append(elections, e)
+    }
+    if err := rows.Err(); err != nil {
+        return nil, err
+    }
+    rows.Close()
+    return elections, r.loadContests(ctx, elections)
+}
+
+func (r *ElectionPostgres) Update(ctx context.Context, e *models.Election) error {
+    query := `
+        UPDATE elections
+        SET title = $1, description = $2, is_active = $3, status = $4, voting_method = $5, max_score = $6, seats = $7, opens_at = $8, closes_at = $9,
+            allow_write_ins = $10, eligibility = $11, anonymity = $12, encryption = $13, encryption_key = $14,
+            trustee_threshold = $15, blind_key_fingerprint = $16
+        WHERE id = $17
+    `
+    _, err := r.DB.Exec(ctx, query,
+        e.Title,
+        e.Description,
+        e.IsActive,
+        e.Status,
+        e.Method(),
+        e.MaxScore,
+        e.Seats,
+        e.OpensAt,
+        e.ClosesAt,
+        e.AllowWriteIns,
+        e.EligibilityMode(),
+        e.AnonymityMode(),
+        e.EncryptionMode(),
+        e.EncryptionKey,
+        e.TrusteeThreshold,
+        e.BlindKeyFingerprint,
+        e.ID,
+    )
+    return err
+}
+
+func (r *ElectionPostgres) Restore(ctx context.Context, e *models.Election) error {
+    query := `
+        INSERT INTO elections (id, title, description, created_by, created_at, is_active, status, voting_method, max_score, seats, opens_at, closes_at, batch_size, batch_window_seconds, allow_write_ins, eligibility, anonymity, encryption, encryption_key, trustee_threshold, blind_key_fingerprint)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21)
+    `
+    _, err := r.DB.Exec(ctx, query,
+        e.ID,
+        e.Title,
+        e.Description,
+        e.CreatedBy,
+        e.CreatedAt,
+        e.IsActive,
+        e.Status,
+        e.Method(),
+        e.MaxScore,
+        e.Seats,
+        e.OpensAt,
+        e.ClosesAt,
+        e.BatchSize,
+        e.BatchWindowSeconds,
+        e.AllowWriteIns,
+        e.EligibilityMode(),
+        e.AnonymityMode(),
+        e.EncryptionMode(),
+        e.EncryptionKey,
+        e.TrusteeThreshold,
+        e.BlindKeyFingerprint,
+    )
+    if err != nil {
+        return err
+    }
+    for _, c := range e.Contests {
+        _, err := r.DB.Exec(ctx,
+            `INSERT INTO contests (id, election_id, title, position, voting_method, max_score, seats, allow_write_ins)
+             VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`,
+            c.ID, e.ID, c.Title, c.Position, c.Method(), c.MaxScore, c.Seats, c.AllowWriteIns,
+        )
+        if err != nil {
+            return err
+        }
+    }
+    for _, w := range e.WriteInReviews {
+        w.ElectionID = e.ID
+        if err := r.restoreWriteInReview(ctx, w); err != nil {
+            return err
+        }
+    }
+    if err := resetSequence(ctx, r.DB, "contests"); err != nil {
+        return err
+    }
+    return resetSequence(ctx, r.DB, "elections")
+}
+
+func (r *ElectionPostgres) Delete(ctx context.Context, id int) error {
+    query := `DELETE FROM elections WHERE id = $1`
+    _, err := r.DB.Exec(ctx, query, id)
+    return err
+}
+
+// RecordTransition — записывает смену состояния голосования
+func (r *ElectionPostgres) RecordTransition(ctx context.Context, t *models.ElectionTransition) error {
+    query := `
+        INSERT INTO election_transitions (election_id, from_status, to_status, changed_by)
+        VALUES ($1, $2, $3, $4)
+        RETURNING id, changed_at
+    `
+    return r.DB.QueryRow(ctx, query,
+        t.ElectionID,
+        t.FromStatus,
+        t.ToStatus,
+        t.ChangedBy,
+    ).Scan(&t.ID, &t.ChangedAt)
+}
+
+// ListTransitions — история состояний голосования от старых к новым
+func (r *ElectionPostgres) ListTransitions(ctx context.Context, electionID int) ([]*models.ElectionTransition, error) {
+    query := `
+        SELECT id, election_id, from_status, to_status, changed_by, changed_at
+        FROM election_transitions
+        WHERE election_id = $1
+        ORDER BY id
+    `
+    rows, err := r.DB.Query(ctx, query, electionID)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    var res []*models.ElectionTransition
+    for rows.Next() {
+        var t models.ElectionTransition
+        if err := rows.Scan(&t.ID, &t.ElectionID, &t.FromStatus, &t.ToStatus, &t.ChangedBy, &t.ChangedAt); err != nil {
+            return nil, err
+        }
+        res = append(res, &t)
+    }
+    return res, rows.Err()
+}
+
+// SaveTally — сохраняет итоги голосования, заменяя подсчитанные ранее
+func (r *ElectionPostgres) SaveTally(ctx context.Context, t *models.Tally) error {
+    query := `
+        INSERT INTO election_tallies (election_id, results)
+        VALUES ($1, $2)
+        ON CONFLICT (election_id) DO UPDATE SET results = EXCLUDED.results, computed_at = now()
+        RETURNING computed_at
+    `
+    return r.DB.QueryRow(ctx, query, t.ElectionID, t.Results).Scan(&t.ComputedAt)
+}
+
+func (r *ElectionPostgres) GetTally(ctx context.Context, electionID int) (*models.Tally, error) {
+    query := `
+        SELECT election_id, results, computed_at
+        FROM election_tallies
+        WHERE election_id = $1
+    `
+    var t models.Tally
+    err := r.DB.QueryRow(ctx, query, electionID).Scan(&t.ElectionID, &t.Results, &t.ComputedAt)
+    if err != nil {
+        return nil, err
+    }
+    return &t, nil
+}
+
+// SaveWriteInReview — записывает решение по вписанному варианту, заменяя
+// принятое ранее для того же текста
+func (r *ElectionPostgres) SaveWriteInReview(ctx context.Context, w *models.WriteInReview) error {
+    query := `
+        INSERT INTO write_in_reviews (election_id, contest_id, text, choice_id, reviewed_by)
+        VALUES ($1, $2, $3, NULLIF($4, 0), $5)
+        ON CONFLICT (election_id, contest_id, lower(text)) DO UPDATE
+        SET text = EXCLUDED.text, choice_id = EXCLUDED.choice_id, reviewed_by = EXCLUDED.reviewed_by, reviewed_at = now()
+        RETURNING reviewed_at
+    `
+    return r.DB.QueryRow(ctx, query, w.ElectionID, w.ContestID, w.Text, w.ChoiceID, w.ReviewedBy).Scan(&w.ReviewedAt)
+}
+
+// ListWriteInReviews — решения по вписанным вариантам голосования
+func (r *ElectionPostgres) ListWriteInReviews(ctx context.Context, electionID int) ([]*models.WriteInReview, error) {
+    query := `
+        SELECT election_id, contest_id, text, COALESCE(choice_id, 0), reviewed_by, reviewed_at
+        FROM write_in_reviews
+        WHERE election_id = $1
+        ORDER BY contest_id, lower(text)
+    `
+    rows, err := r.DB.Query(ctx, query, electionID)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    var res []*models.WriteInReview
+    for rows.Next() {
+        var w models.WriteInReview
+        if err := rows.Scan(&w.ElectionID, &w.ContestID, &w.Text, &w.ChoiceID, &w.ReviewedBy, &w.ReviewedAt); err != nil {
+            return nil, err
+        }
+        res = append(res, &w)
+    }
+    return res, rows.Err()
+}
+
+// restoreWriteInReview — вставляет решение из архива с исходным временем
+func (r *ElectionPostgres) restoreWriteInReview(ctx context.Context, w *models.WriteInReview) error {
+    _, err := r.DB.Exec(ctx, `
+        INSERT INTO write_in_reviews (election_id, contest_id, text, choice_id, reviewed_by, reviewed_at)
+        VALUES ($1, $2, $3, NULLIF($4, 0), $5, $6)
+    `, w.ElectionID, w.ContestID, w.Text, w.ChoiceID, w.ReviewedBy, w.ReviewedAt)
+    return err
+}
@@ -1,14 +1,29 @@
-package repositories
-
-import (
-	"context"
-	"voting-blockchain/internal/voting/models"
-)
-
-type ElectionRepository interface {
-    Create(ctx context.Context, e *models.Election) error
-    GetByID(ctx context.Context, id int) (*models.Election, error)
-    List(ctx context.Context) ([]*models.Election, error)
-    Update(ctx context.Context, e *models.Election) error
-    Delete(ctx context.Context, id int) error
-}
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"voting-blockchain/internal/voting/models"
+)
+
+type ElectionRepository interface {
+    Create(ctx context.Context, e *models.Election) error
+    GetByID(ctx context.Context, id int) (*models.Election, error)
+    List(ctx context.Context) ([]*models.Election, error)
+    // ListDue — голосования, которые к моменту now пора открыть, закрыть
+    // или у которых может истечь окно пачки голосов
+    ListDue(ctx context.Context, now time.Time) ([]*models.Election, error)
+    Update(ctx context.Context, e *models.Election) error
+    Delete(ctx context.Context, id int) error
+    // Restore — вставляет голосование из архива с его исходными id и created_at
+    Restore(ctx context.Context, e *models.Election) error
+    RecordTransition(ctx context.Context, t *models.ElectionTransition) error
+    ListTransitions(ctx context.Context, electionID int) ([]*models.ElectionTransition, error)
+    SaveTally(ctx context.Context, t *models.Tally) error
+    GetTally(ctx context.Context, electionID int) (*models.Tally, error)
+    // SaveWriteInReview — записывает решение по вписанному варианту; решение
+    // по тому же тексту (без учёта регистра) заменяется
+    SaveWriteInReview(ctx context.Context, w *models.WriteInReview) error
+    ListWriteInReviews(ctx context.Context, electionID int) ([]*models.WriteInReview, error)
+}
@@ -0,0 +1,41 @@
+package repositories
+
+import "context"
+
+// ElectionSecretRepository — закрытые ключи шифрования бюллетеней голосований
+type ElectionSecretRepository interface {
+	// Save — сохраняет ключ голосования, заменяя прежний
+	Save(ctx context.Context, electionID int, privateKey string) error
+	// Get — ключ голосования; pgx.ErrNoRows, если его нет
+	Get(ctx context.Context, electionID int) (string, error)
+	Delete(ctx context.Context, electionID int) error
+}
+
+// ElectionSecretPostgres — реализация ElectionSecretRepository через PostgreSQL
+type ElectionSecretPostgres struct {
+	DB DBTX
+}
+
+func NewElectionSecretPostgres(db DBTX) *ElectionSecretPostgres {
+	return &ElectionSecretPostgres{DB: db}
+}
+
+func (r *ElectionSecretPostgres) Save(ctx context.Context, electionID int, privateKey string) error {
+	_, err := r.DB.Exec(ctx, `
+		INSERT INTO election_secrets (election_id, private_key)
+		VALUES ($1, $2)
+		ON CONFLICT (election_id) DO UPDATE SET private_key = EXCLUDED.private_key, created_at = now()
+	`, electionID, privateKey)
+	return err
+}
+
+func (r *ElectionSecretPostgres) Get(ctx context.Context, electionID int) (string, error) {
+	var key string
+	err := r.DB.QueryRow(ctx, `SELECT private_key FROM election_secrets WHERE election_id = $1`, electionID).Scan(&key)
+	return key, err
+}
+
+func (r *ElectionSecretPostgres) Delete(ctx context.Context, electionID int) error {
+	_, err := r.DB.Exec(ctx, `DELETE FROM election_secrets WHERE election_id = $1`, electionID)
+	return err
+}
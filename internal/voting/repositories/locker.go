@@ -0,0 +1,47 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// leaderLockNamespace — первый ключ pg_try_advisory_xact_lock для фоновых задач,
+// второй — ключ задачи
+const leaderLockNamespace = 7302
+
+// Locker — исключительное выполнение задачи среди всех реплик приложения
+type Locker interface {
+	// RunExclusive — выполняет fn, если блокировку key удалось взять, и
+	// возвращает true; если её держит другая реплика, сразу возвращает false
+	RunExclusive(ctx context.Context, key int, fn func(ctx context.Context) error) (bool, error)
+}
+
+// PgLocker — реализация Locker на advisory-блокировке Postgres. Блокировка
+// держится транзакцией и снимается при её завершении, в том числе если
+// реплика упала.
+type PgLocker struct {
+	DB *pgxpool.Pool
+}
+
+func NewPgLocker(db *pgxpool.Pool) *PgLocker {
+	return &PgLocker{DB: db}
+}
+
+func (l *PgLocker) RunExclusive(ctx context.Context, key int, fn func(ctx context.Context) error) (bool, error) {
+	tx, err := l.DB.Begin(ctx)
+	if err != nil {
+		return false, err
+	}
+	// Транзакция только держит блокировку, фиксировать в ней нечего
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	var locked bool
+	if err := tx.QueryRow(ctx, `SELECT pg_try_advisory_xact_lock($1, $2)`, leaderLockNamespace, key).Scan(&locked); err != nil {
+		return false, err
+	}
+	if !locked {
+		return false, nil
+	}
+	return true, fn(ctx)
+}
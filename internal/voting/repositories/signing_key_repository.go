@@ -0,0 +1,64 @@
+package repositories
+
+import (
+	"context"
+
+	"voting-blockchain/internal/voting/models"
+)
+
+// SigningKeyRepository — публичные ключи, которыми подписываются блоки
+type SigningKeyRepository interface {
+	// Activate — делает ключ текущим: добавляет его, если его ещё нет,
+	// и помечает остальные ключи выведенными из оборота
+	Activate(ctx context.Context, key *models.SigningKey) error
+	List(ctx context.Context) ([]*models.SigningKey, error)
+}
+
+// SigningKeyPostgres — реализация SigningKeyRepository через PostgreSQL
+type SigningKeyPostgres struct {
+	DB DBTX
+}
+
+func NewSigningKeyPostgres(db DBTX) *SigningKeyPostgres {
+	return &SigningKeyPostgres{DB: db}
+}
+
+func (r *SigningKeyPostgres) Activate(ctx context.Context, key *models.SigningKey) error {
+	_, err := r.DB.Exec(ctx, `
+		INSERT INTO signing_keys (key_id, algorithm, public_key, created_at)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (key_id) DO UPDATE SET retired_at = NULL
+	`, key.KeyID, key.Algorithm, key.PublicKey, key.CreatedAt)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.DB.Exec(ctx, `
+		UPDATE signing_keys SET retired_at = now()
+		WHERE key_id <> $1 AND retired_at IS NULL
+	`, key.KeyID)
+	return err
+}
+
+// List — все ключи, текущий первым, затем выведенные от новых к старым
+func (r *SigningKeyPostgres) List(ctx context.Context) ([]*models.SigningKey, error) {
+	rows, err := r.DB.Query(ctx, `
+		SELECT key_id, algorithm, public_key, created_at, retired_at
+		FROM signing_keys
+		ORDER BY retired_at DESC NULLS FIRST, created_at DESC
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var keys []*models.SigningKey
+	for rows.Next() {
+		var k models.SigningKey
+		if err := rows.Scan(&k.KeyID, &k.Algorithm, &k.PublicKey, &k.CreatedAt, &k.RetiredAt); err != nil {
+			return nil, err
+		}
+		keys = append(keys, &k)
+	}
+	return keys, rows.Err()
+}
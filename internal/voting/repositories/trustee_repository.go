@@ -0,0 +1,153 @@
+package repositories
+
+import (
+	"context"
+
+	"voting-blockchain/internal/voting/models"
+)
+
+// TrusteeRepository — доверенные лица голосования, их доли ключа и
+// частичные расшифровки. Сервер хранит доли только зашифрованными на ключи
+// получателей и ни в какой момент не знает закрытого ключа голосования.
+type TrusteeRepository interface {
+	// Add — добавляет доверенное лицо со следующим номером
+	Add(ctx context.Context, t *models.Trustee) error
+	// List — доверенные лица голосования по номерам
+	List(ctx context.Context, electionID int) ([]*models.Trustee, error)
+	// Update — сохраняет ключ, обязательства и отметку о проверке долей
+	Update(ctx context.Context, t *models.Trustee) error
+	SaveShares(ctx context.Context, electionID int, shares []models.EncryptedShare) error
+	// SharesFor — доли, адресованные доверенному лицу index
+	SharesFor(ctx context.Context, electionID, index int) ([]models.EncryptedShare, error)
+	// ResetDeals — удаляет доли и обязательства всех доверенных лиц, чтобы
+	// этап deal прошёл заново
+	ResetDeals(ctx context.Context, electionID int) error
+	SaveDecryption(ctx context.Context, electionID, index int, partials []models.PartialDecryption) error
+	// ListDecryptions — частичные расшифровки по номерам доверенных лиц
+	ListDecryptions(ctx context.Context, electionID int) (map[int][]models.PartialDecryption, error)
+}
+
+// TrusteePostgres — реализация TrusteeRepository через PostgreSQL
+type TrusteePostgres struct {
+	DB DBTX
+}
+
+func NewTrusteePostgres(db DBTX) *TrusteePostgres {
+	return &TrusteePostgres{DB: db}
+}
+
+func (r *TrusteePostgres) Add(ctx context.Context, t *models.Trustee) error {
+	return r.DB.QueryRow(ctx, `
+		INSERT INTO trustees (election_id, idx, user_id)
+		SELECT $1, COALESCE(MAX(idx), 0) + 1, $2 FROM trustees WHERE election_id = $1
+		RETURNING idx, created_at
+	`, t.ElectionID, t.UserID).Scan(&t.Index, &t.CreatedAt)
+}
+
+func (r *TrusteePostgres) List(ctx context.Context, electionID int) ([]*models.Trustee, error) {
+	rows, err := r.DB.Query(ctx, `
+		SELECT election_id, idx, user_id, public_key, commitments, verified, created_at
+		FROM trustees
+		WHERE election_id = $1
+		ORDER BY idx
+	`, electionID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var res []*models.Trustee
+	for rows.Next() {
+		var t models.Trustee
+		if err := rows.Scan(&t.ElectionID, &t.Index, &t.UserID, &t.PublicKey, &t.Commitments, &t.Verified, &t.CreatedAt); err != nil {
+			return nil, err
+		}
+		res = append(res, &t)
+	}
+	return res, rows.Err()
+}
+
+func (r *TrusteePostgres) Update(ctx context.Context, t *models.Trustee) error {
+	_, err := r.DB.Exec(ctx, `
+		UPDATE trustees
+		SET public_key = $3, commitments = $4, verified = $5
+		WHERE election_id = $1 AND idx = $2
+	`, t.ElectionID, t.Index, t.PublicKey, t.Commitments, t.Verified)
+	return err
+}
+
+func (r *TrusteePostgres) SaveShares(ctx context.Context, electionID int, shares []models.EncryptedShare) error {
+	for _, s := range shares {
+		_, err := r.DB.Exec(ctx, `
+			INSERT INTO trustee_shares (election_id, from_idx, to_idx, r, c)
+			VALUES ($1, $2, $3, $4, $5)
+		`, electionID, s.From, s.To, s.R, s.C)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *TrusteePostgres) SharesFor(ctx context.Context, electionID, index int) ([]models.EncryptedShare, error) {
+	rows, err := r.DB.Query(ctx, `
+		SELECT from_idx, to_idx, r, c
+		FROM trustee_shares
+		WHERE election_id = $1 AND to_idx = $2
+		ORDER BY from_idx
+	`, electionID, index)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var res []models.EncryptedShare
+	for rows.Next() {
+		var s models.EncryptedShare
+		if err := rows.Scan(&s.From, &s.To, &s.R, &s.C); err != nil {
+			return nil, err
+		}
+		res = append(res, s)
+	}
+	return res, rows.Err()
+}
+
+func (r *TrusteePostgres) ResetDeals(ctx context.Context, electionID int) error {
+	if _, err := r.DB.Exec(ctx, `DELETE FROM trustee_shares WHERE election_id = $1`, electionID); err != nil {
+		return err
+	}
+	_, err := r.DB.Exec(ctx, `
+		UPDATE trustees SET commitments = NULL, verified = false WHERE election_id = $1
+	`, electionID)
+	return err
+}
+
+func (r *TrusteePostgres) SaveDecryption(ctx context.Context, electionID, index int, partials []models.PartialDecryption) error {
+	_, err := r.DB.Exec(ctx, `
+		INSERT INTO partial_decryptions (election_id, idx, partials)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (election_id, idx) DO UPDATE SET partials = EXCLUDED.partials, created_at = now()
+	`, electionID, index, partials)
+	return err
+}
+
+func (r *TrusteePostgres) ListDecryptions(ctx context.Context, electionID int) (map[int][]models.PartialDecryption, error) {
+	rows, err := r.DB.Query(ctx, `
+		SELECT idx, partials FROM partial_decryptions WHERE election_id = $1
+	`, electionID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	res := make(map[int][]models.PartialDecryption)
+	for rows.Next() {
+		var index int
+		var partials []models.PartialDecryption
+		if err := rows.Scan(&index, &partials); err != nil {
+			return nil, err
+		}
+		res[index] = partials
+	}
+	return res, rows.Err()
+}
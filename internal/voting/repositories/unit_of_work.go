@@ -0,0 +1,85 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// electionLockNamespace — первый ключ pg_advisory_xact_lock для блокировок голосований,
+// второй ключ — ID голосования
+const electionLockNamespace = 7301
+
+// Repositories — набор репозиториев, работающих в одной транзакции
+type Repositories struct {
+	Votes     VoteRepository
+	Blocks    BlockchainRepository
+	Elections ElectionRepository
+	Choices   ChoiceRepository
+	Keys      SigningKeyRepository
+	Rolls     VoterRollRepository
+	Codes     BallotCodeRepository
+	Blind     BlindTokenRepository
+	Secrets   ElectionSecretRepository
+	Trustees  TrusteeRepository
+}
+
+// UnitOfWork — выполняет fn в одной транзакции: изменения фиксируются,
+// только если fn вернула nil, иначе откатываются целиком.
+type UnitOfWork interface {
+	Do(ctx context.Context, fn func(repos *Repositories) error) error
+	// DoInElection — то же самое под блокировкой голосования: транзакции,
+	// дописывающие цепочку одного голосования, выполняются строго по очереди
+	DoInElection(ctx context.Context, electionID int, fn func(repos *Repositories) error) error
+}
+
+// PgUnitOfWork — реализация UnitOfWork на транзакциях pgx
+type PgUnitOfWork struct {
+	DB *pgxpool.Pool
+}
+
+func NewPgUnitOfWork(db *pgxpool.Pool) *PgUnitOfWork {
+	return &PgUnitOfWork{DB: db}
+}
+
+func (u *PgUnitOfWork) Do(ctx context.Context, fn func(repos *Repositories) error) error {
+	return u.run(ctx, nil, fn)
+}
+
+func (u *PgUnitOfWork) DoInElection(ctx context.Context, electionID int, fn func(repos *Repositories) error) error {
+	return u.run(ctx, &electionID, fn)
+}
+
+func (u *PgUnitOfWork) run(ctx context.Context, electionID *int, fn func(repos *Repositories) error) error {
+	tx, err := u.DB.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	// После Commit откат ничего не делает
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	if electionID != nil {
+		// Блокировка снимается автоматически в конце транзакции
+		if _, err := tx.Exec(ctx, `SELECT pg_advisory_xact_lock($1, $2)`, electionLockNamespace, *electionID); err != nil {
+			return err
+		}
+	}
+
+	repos := &Repositories{
+		Votes:     NewVotePostgres(tx),
+		Blocks:    NewBlockchainPostgres(tx),
+		Elections: NewElectionPostgres(tx),
+		Choices:   NewChoicePostgres(tx),
+		Keys:      NewSigningKeyPostgres(tx),
+		Rolls:     NewVoterRollPostgres(tx),
+		Codes:     NewBallotCodePostgres(tx),
+		Blind:     NewBlindTokenPostgres(tx),
+		Secrets:   NewElectionSecretPostgres(tx),
+		Trustees:  NewTrusteePostgres(tx),
+	}
+	if err := fn(repos); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
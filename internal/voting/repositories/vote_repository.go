@@ -1,109 +1,176 @@
-package repositories
-
-import (
-	"context"
-
-	"github.com/jackc/pgx/v5/pgxpool"
-	"voting-blockchain/internal/voting/models"
-)
-
-type VoteRepository interface {
-	Create(ctx context.Context, v *models.Vote) error
-	HasVoted(ctx context.Context, userID, electionID int) (bool, error)
-	GetByElectionID(ctx context.Context, electionID int) ([]*models.Vote, error)
-	GetResults(ctx context.Context, electionID int) ([]*models.Choice, error)
-	GetByHash(ctx context.Context, hash string) (*models.Vote, error)
-}
-
-type VotePostgres struct {
-	DB *pgxpool.Pool
-}
-
-func NewVotePostgres(db *pgxpool.Pool) *VotePostgres {
-	return &VotePostgres{DB: db}
-}
-
-// Create — сохраняет голос в таблицу votes
-func (r *VotePostgres) Create(ctx context.Context, v *models.Vote) error {
-	query := `
-		INSERT INTO votes (user_id, election_id, choice, vote_hash)
-		VALUES ($1, $2, $3, $4)
-		RETURNING id, created_at
-	`
-	return r.DB.QueryRow(ctx, query, v.UserID, v.ElectionID, v.Choice, v.VoteHash).
-		Scan(&v.ID, &v.CreatedAt)
-}
-
-// HasVoted — проверяет, голосовал ли уже пользователь
-func (r *VotePostgres) HasVoted(ctx context.Context, userID, electionID int) (bool, error) {
-	query := `SELECT COUNT(*) FROM votes WHERE user_id = $1 AND election_id = $2`
-	var count int
-	err := r.DB.QueryRow(ctx, query, userID, electionID).Scan(&count)
-	return count > 0, err
-}
-
-// GetByElectionID — получает все голоса по ID выборов
-func (r *VotePostgres) GetByElectionID(ctx context.Context, electionID int) ([]*models.Vote, error) {
-	query := `
-		SELECT id, user_id, election_id, vote_hash, created_at
-		FROM votes
-		WHERE election_id = $1
-		ORDER BY created_at
-	`
-	rows, err := r.DB.Query(ctx, query, electionID)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-
-	var votes []*models.Vote
-	for rows.Next() {
-		var v models.Vote
-		if err := rows.Scan(&v.ID, &v.UserID, &v.ElectionID, &v.VoteHash, &v.CreatedAt); err != nil {
-			return nil, err
-		}
-		votes = append(votes, &v)
-	}
-	return votes, nil
-}
-
-// GetResults — возвращает список уникальных вариантов выбора
-func (r *VotePostgres) GetResults(ctx context.Context, electionID int) ([]*models.Choice, error) {
-	query := `
-		SELECT DISTINCT choice
-		FROM votes
-		WHERE election_id = $1
-	`
-	rows, err := r.DB.Query(ctx, query, electionID)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-
-	var choices []*models.Choice
-	for rows.Next() {
-		var c models.Choice
-		if err := rows.Scan(&c.Text); err != nil {
-			return nil, err
-		}
-		choices = append(choices, &c)
-	}
-	return choices, nil
-}
-
-// GetByHash — возвращает голос по его хэшу
-func (r *VotePostgres) GetByHash(ctx context.Context, hash string) (*models.Vote, error) {
-	query := `
-		SELECT id, user_id, election_id, choice, vote_hash, created_at
-		FROM votes
-		WHERE vote_hash = $1
-	`
-	var v models.Vote
-	err := r.DB.QueryRow(ctx, query, hash).Scan(
-		&v.ID, &v.UserID, &v.ElectionID, &v.Choice, &v.VoteHash, &v.CreatedAt,
-	)
-	if err != nil {
-		return nil, err
-	}
-	return &v, nil
-}
+package repositories
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5"
+	"voting-blockchain/internal/voting/models"
+)
+
+type VoteRepository interface {
+	Create(ctx context.Context, v *models.Vote) error
+	HasVoted(ctx context.Context, userID, electionID int) (bool, error)
+	GetByElectionID(ctx context.Context, electionID int) ([]*models.Vote, error)
+	GetResults(ctx context.Context, electionID int) ([]*models.Choice, error)
+	GetByHash(ctx context.Context, hash string) (*models.Vote, error)
+	GetPending(ctx context.Context, electionID int) ([]*models.Vote, error)
+	GetByBlockID(ctx context.Context, blockID int) ([]*models.Vote, error)
+	AttachToBlock(ctx context.Context, blockID int, voteIDs []int) error
+	// Restore — вставляет обезличенный голос из архива, без user_id
+	Restore(ctx context.Context, v *models.Vote) error
+}
+
+// voteColumns — колонки votes в порядке, который ожидает scanVote
+const voteColumns = `id, COALESCE(user_id, 0), election_id, choice, COALESCE(choice_id, 0), COALESCE(ballot, ''),
+	COALESCE(salt, ''), hash_version, vote_hash, block_id, COALESCE(leaf_index, 0), created_at`
+
+type VotePostgres struct {
+	DB DBTX
+}
+
+func NewVotePostgres(db DBTX) *VotePostgres {
+	return &VotePostgres{DB: db}
+}
+
+// scanVote — читает строку, выбранную по voteColumns
+func scanVote(row pgx.Row) (*models.Vote, error) {
+	var v models.Vote
+	err := row.Scan(
+		&v.ID, &v.UserID, &v.ElectionID, &v.Choice, &v.ChoiceID, &v.Ballot,
+		&v.Salt, &v.HashVersion, &v.VoteHash, &v.BlockID, &v.LeafIndex, &v.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &v, nil
+}
+
+// queryVotes — выполняет запрос и читает все голоса
+func (r *VotePostgres) queryVotes(ctx context.Context, query string, args ...any) ([]*models.Vote, error) {
+	rows, err := r.DB.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var votes []*models.Vote
+	for rows.Next() {
+		v, err := scanVote(rows)
+		if err != nil {
+			return nil, err
+		}
+		votes = append(votes, v)
+	}
+	return votes, rows.Err()
+}
+
+// Create — сохраняет голос в таблицу votes; голос по коду (UserID 0) — без user_id
+func (r *VotePostgres) Create(ctx context.Context, v *models.Vote) error {
+	query := `
+		INSERT INTO votes (user_id, election_id, choice, choice_id, ballot, salt, hash_version, vote_hash)
+		VALUES (NULLIF($1, 0), $2, $3, NULLIF($4, 0), NULLIF($5, ''), $6, $7, $8)
+		RETURNING id, created_at
+	`
+	return r.DB.QueryRow(ctx, query,
+		v.UserID, v.ElectionID, v.Choice, v.ChoiceID, v.Ballot, v.Salt, v.HashVersion, v.VoteHash,
+	).Scan(&v.ID, &v.CreatedAt)
+}
+
+// HasVoted — проверяет, голосовал ли уже пользователь
+func (r *VotePostgres) HasVoted(ctx context.Context, userID, electionID int) (bool, error) {
+	query := `SELECT COUNT(*) FROM votes WHERE user_id = $1 AND election_id = $2`
+	var count int
+	err := r.DB.QueryRow(ctx, query, userID, electionID).Scan(&count)
+	return count > 0, err
+}
+
+// GetByElectionID — получает все голоса по ID выборов
+func (r *VotePostgres) GetByElectionID(ctx context.Context, electionID int) ([]*models.Vote, error) {
+	query := `
+		SELECT ` + voteColumns + `
+		FROM votes
+		WHERE election_id = $1
+		ORDER BY created_at
+	`
+	return r.queryVotes(ctx, query, electionID)
+}
+
+// GetResults — возвращает список уникальных вариантов выбора
+func (r *VotePostgres) GetResults(ctx context.Context, electionID int) ([]*models.Choice, error) {
+	query := `
+		SELECT DISTINCT choice
+		FROM votes
+		WHERE election_id = $1
+	`
+	rows, err := r.DB.Query(ctx, query, electionID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var choices []*models.Choice
+	for rows.Next() {
+		var c models.Choice
+		if err := rows.Scan(&c.Text); err != nil {
+			return nil, err
+		}
+		choices = append(choices, &c)
+	}
+	return choices, nil
+}
+
+// GetByHash — возвращает голос по его хэшу
+func (r *VotePostgres) GetByHash(ctx context.Context, hash string) (*models.Vote, error) {
+	query := `
+		SELECT ` + voteColumns + `
+		FROM votes
+		WHERE vote_hash = $1
+	`
+	return scanVote(r.DB.QueryRow(ctx, query, hash))
+}
+
+// GetPending — голоса, ещё не запечатанные в блок, в порядке поступления
+func (r *VotePostgres) GetPending(ctx context.Context, electionID int) ([]*models.Vote, error) {
+	query := `
+		SELECT ` + voteColumns + `
+		FROM votes
+		WHERE election_id = $1 AND block_id IS NULL
+		ORDER BY id
+	`
+	return r.queryVotes(ctx, query, electionID)
+}
+
+// GetByBlockID — голоса блока в порядке листьев дерева Merkle
+func (r *VotePostgres) GetByBlockID(ctx context.Context, blockID int) ([]*models.Vote, error) {
+	query := `
+		SELECT ` + voteColumns + `
+		FROM votes
+		WHERE block_id = $1
+		ORDER BY leaf_index
+	`
+	return r.queryVotes(ctx, query, blockID)
+}
+
+// AttachToBlock — привязывает голоса к блоку; позиция в voteIDs становится индексом листа
+func (r *VotePostgres) AttachToBlock(ctx context.Context, blockID int, voteIDs []int) error {
+	query := `
+		UPDATE votes AS v
+		SET block_id = $1, leaf_index = ids.ord - 1
+		FROM unnest($2::int[]) WITH ORDINALITY AS ids(id, ord)
+		WHERE v.id = ids.id
+	`
+	_, err := r.DB.Exec(ctx, query, blockID, voteIDs)
+	return err
+}
+
+// Restore — вставляет голос из архива. user_id в архив не выгружается и остаётся NULL.
+func (r *VotePostgres) Restore(ctx context.Context, v *models.Vote) error {
+	query := `
+		INSERT INTO votes (user_id, election_id, choice, choice_id, ballot, salt, hash_version, vote_hash, block_id, leaf_index)
+		VALUES (NULL, $1, $2, NULLIF($3, 0), NULLIF($4, ''), NULLIF($5, ''), $6, $7, $8, $9)
+		RETURNING id, created_at
+	`
+	return r.DB.QueryRow(ctx, query,
+		v.ElectionID, v.Choice, v.ChoiceID, v.Ballot, v.Salt, v.HashVersion, v.VoteHash, v.BlockID, v.LeafIndex,
+	).Scan(&v.ID, &v.CreatedAt)
+}
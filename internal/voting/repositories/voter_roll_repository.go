@@ -0,0 +1,121 @@
+package repositories
+
+import (
+	"context"
+
+	"voting-blockchain/internal/voting/models"
+)
+
+// VoterRollRepository — списки избирателей голосований
+type VoterRollRepository interface {
+	// Add — добавляет записи, пропуская уже бывшие в списке; возвращает число добавленных
+	Add(ctx context.Context, electionID int, entries []models.RollEntry) (int, error)
+	Clear(ctx context.Context, electionID int) error
+	List(ctx context.Context, electionID int) ([]*models.RollEntry, error)
+	// Size — число различных избирателей: запись по email зарегистрированного
+	// пользователя и запись по его id считаются одним избирателем
+	Size(ctx context.Context, electionID int) (int, error)
+	// IsEligible — есть ли пользователь в списке по id или по email
+	IsEligible(ctx context.Context, electionID, userID int) (bool, error)
+	// UnknownUsers — id из ids, которых нет среди пользователей
+	UnknownUsers(ctx context.Context, ids []int) ([]int, error)
+}
+
+// VoterRollPostgres — реализация VoterRollRepository через PostgreSQL
+type VoterRollPostgres struct {
+	DB DBTX
+}
+
+func NewVoterRollPostgres(db DBTX) *VoterRollPostgres {
+	return &VoterRollPostgres{DB: db}
+}
+
+func (r *VoterRollPostgres) Add(ctx context.Context, electionID int, entries []models.RollEntry) (int, error) {
+	added := 0
+	for _, e := range entries {
+		tag, err := r.DB.Exec(ctx, `
+			INSERT INTO voter_rolls (election_id, user_id, email)
+			VALUES ($1, NULLIF($2, 0), NULLIF($3, ''))
+			ON CONFLICT DO NOTHING
+		`, electionID, e.UserID, e.Email)
+		if err != nil {
+			return added, err
+		}
+		added += int(tag.RowsAffected())
+	}
+	return added, nil
+}
+
+func (r *VoterRollPostgres) Clear(ctx context.Context, electionID int) error {
+	_, err := r.DB.Exec(ctx, `DELETE FROM voter_rolls WHERE election_id = $1`, electionID)
+	return err
+}
+
+func (r *VoterRollPostgres) List(ctx context.Context, electionID int) ([]*models.RollEntry, error) {
+	rows, err := r.DB.Query(ctx, `
+		SELECT id, election_id, COALESCE(user_id, 0), COALESCE(email, ''), added_at
+		FROM voter_rolls
+		WHERE election_id = $1
+		ORDER BY id
+	`, electionID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var res []*models.RollEntry
+	for rows.Next() {
+		var e models.RollEntry
+		if err := rows.Scan(&e.ID, &e.ElectionID, &e.UserID, &e.Email, &e.AddedAt); err != nil {
+			return nil, err
+		}
+		res = append(res, &e)
+	}
+	return res, rows.Err()
+}
+
+func (r *VoterRollPostgres) Size(ctx context.Context, electionID int) (int, error) {
+	var n int
+	err := r.DB.QueryRow(ctx, `
+		SELECT count(DISTINCT COALESCE(r.user_id::text, u.id::text, lower(r.email)))
+		FROM voter_rolls r
+		LEFT JOIN users u ON r.user_id IS NULL AND lower(u.email) = lower(r.email)
+		WHERE r.election_id = $1
+	`, electionID).Scan(&n)
+	return n, err
+}
+
+func (r *VoterRollPostgres) IsEligible(ctx context.Context, electionID, userID int) (bool, error) {
+	var ok bool
+	err := r.DB.QueryRow(ctx, `
+		SELECT EXISTS (
+			SELECT 1
+			FROM voter_rolls r
+			LEFT JOIN users u ON u.id = $2
+			WHERE r.election_id = $1 AND (r.user_id = $2 OR lower(r.email) = lower(u.email))
+		)
+	`, electionID, userID).Scan(&ok)
+	return ok, err
+}
+
+func (r *VoterRollPostgres) UnknownUsers(ctx context.Context, ids []int) ([]int, error) {
+	rows, err := r.DB.Query(ctx, `
+		SELECT t.id FROM unnest($1::int[]) AS t(id)
+		WHERE NOT EXISTS (SELECT 1 FROM users u WHERE u.id = t.id)
+		ORDER BY t.id
+	`, ids)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var res []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		res = append(res, id)
+	}
+	return res, rows.Err()
+}
@@ -1,30 +1,94 @@
-package routers
-
-import (
-	"net/http"
-
-	"github.com/go-chi/chi/v5"
-	"voting-blockchain/internal/voting/handlers"
-)
-
-// NewVotingRouter создает роутер для голосования и управления выборами.
-// Принимает VoteHandler и ElectionHandler.
-func NewVotingRouter(voteHandler *handlers.VoteHandler, electionHandler *handlers.ElectionHandler) http.Handler {
-	r := chi.NewRouter()
-
-	// Эндпоинты голосования
-	r.Post("/elections/{id}/vote", voteHandler.CastVoteHandler)
-	r.Get("/elections/{id}/blocks", voteHandler.GetElectionBlockchainHandler)
-
-	// CRUD выборов
-	r.Route("/elections", func(r chi.Router) {
-		r.Post("/", electionHandler.Create)
-		r.Get("/", electionHandler.List)
-		r.Get("/{id}", electionHandler.Get)
-		r.Get("/{id}/results", voteHandler.GetResults)
-		r.Put("/{id}", electionHandler.Update)
-		r.Delete("/{id}", electionHandler.Delete)
-	})
-
-	return r
-}
+package routers
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	authhandlers "voting-blockchain/internal/auth/handlers"
+	"voting-blockchain/internal/voting/handlers"
+)
+
+// NewVotingRouter создает роутер для голосования и управления выборами.
+// Принимает VoteHandler, ElectionHandler, ChoiceHandler, WriteInHandler, VoterRollHandler,
+// BallotCodeHandler, BlindTokenHandler, TrusteeHandler, BlockchainHandler и JWT секрет.
+func NewVotingRouter(
+	voteHandler *handlers.VoteHandler,
+	electionHandler *handlers.ElectionHandler,
+	choiceHandler *handlers.ChoiceHandler,
+	writeInHandler *handlers.WriteInHandler,
+	rollHandler *handlers.VoterRollHandler,
+	codeHandler *handlers.BallotCodeHandler,
+	tokenHandler *handlers.BlindTokenHandler,
+	trusteeHandler *handlers.TrusteeHandler,
+	blockchainHandler *handlers.BlockchainHandler,
+	jwtSecret []byte,
+) http.Handler {
+	r := chi.NewRouter()
+
+	// Открытые маршруты: избиратель проверяет квитанцию без входа в систему,
+	// а подписи блоков — опубликованными ключами узла; по коду голосуют без учётной записи,
+	// а по жетону анонимного голосования — без входа, чтобы голос не связывался с пользователем;
+	// ключ шифрования бюллетеней нужен клиенту до голосования, а ход церемонии ключа открыт всем
+	r.Get("/elections/{id}/receipts/{voteHash}/proof", voteHandler.GetInclusionProofHandler)
+	r.Post("/elections/{id}/vote-with-code", voteHandler.CastVoteWithCodeHandler)
+	r.Get("/elections/{id}/blind-key", tokenHandler.PublicKey)
+	r.Post("/elections/{id}/anonymous-vote", voteHandler.CastAnonymousVoteHandler)
+	r.Get("/elections/{id}/encryption-key", electionHandler.GetEncryptionKey)
+	r.Get("/elections/{id}/ceremony", trusteeHandler.Ceremony)
+	r.Get("/keys", blockchainHandler.GetSigningKeys)
+
+	// Защищенные маршруты
+	r.Group(func(r chi.Router) {
+		r.Use(authhandlers.NewJWTMiddleware(jwtSecret))
+
+		// Эндпоинты голосования
+		r.Post("/elections/{id}/vote", voteHandler.CastVoteHandler)
+		r.Post("/elections/{id}/tokens", tokenHandler.Issue)
+		r.Get("/elections/{id}/blocks", voteHandler.GetElectionBlockchainHandler)
+		r.Get("/elections/{id}/blocks/verify", blockchainHandler.VerifyChain)
+		r.Post("/elections/{id}/blocks/seal", blockchainHandler.SealPending)
+		r.Get("/elections/{id}/export", blockchainHandler.ExportElection)
+		r.Post("/elections/import", blockchainHandler.ImportElection)
+
+		// CRUD выборов
+		r.Route("/elections", func(r chi.Router) {
+			r.Post("/", electionHandler.Create)
+			r.Get("/", electionHandler.List)
+			r.Get("/{id}", electionHandler.Get)
+			r.Get("/{id}/results", voteHandler.GetResults)
+			r.Get("/{id}/results/count-sheet", voteHandler.GetCountSheet)
+			r.Get("/{id}/transitions", electionHandler.GetTransitions)
+			r.Put("/{id}", electionHandler.Update)
+			r.Delete("/{id}", electionHandler.Delete)
+
+			// Варианты меняются только в черновике
+			r.Get("/{id}/choices", choiceHandler.List)
+			r.Post("/{id}/choices", choiceHandler.Add)
+			r.Put("/{id}/choices/order", choiceHandler.Reorder)
+			r.Put("/{id}/choices/{choiceID}", choiceHandler.Update)
+			r.Delete("/{id}/choices/{choiceID}", choiceHandler.Delete)
+
+			// Вписанные варианты разбираются до утверждения итогов
+			r.Get("/{id}/write-ins", writeInHandler.List)
+			r.Put("/{id}/write-ins", writeInHandler.Review)
+
+			// Список избирателей: JSON или CSV
+			r.Get("/{id}/roll", rollHandler.List)
+			r.Post("/{id}/roll", rollHandler.Upload)
+
+			// Одноразовые коды голосования: выпуск файлом CSV
+			r.Get("/{id}/codes", codeHandler.Stats)
+			r.Post("/{id}/codes", codeHandler.Generate)
+
+			// Церемония ключа с доверенными лицами и частичные расшифровки итогов
+			r.Post("/{id}/trustees", trusteeHandler.Register)
+			r.Put("/{id}/ceremony/key", trusteeHandler.PublishKey)
+			r.Post("/{id}/ceremony/deal", trusteeHandler.Deal)
+			r.Get("/{id}/ceremony/shares", trusteeHandler.Shares)
+			r.Post("/{id}/ceremony/verify", trusteeHandler.Verify)
+			r.Post("/{id}/decryptions", trusteeHandler.SubmitDecryption)
+		})
+	})
+
+	return r
+}
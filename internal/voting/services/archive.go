@@ -0,0 +1,162 @@
+package services
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"voting-blockchain/internal/voting/models"
+)
+
+// archiveLine — строка архива JSON Lines: тип записи и её содержимое
+type archiveLine struct {
+	Type string          `json:"type"`
+	Data json.RawMessage `json:"data"`
+}
+
+// WriteArchive — записывает архив голосования в формате JSON Lines:
+// первой строкой манифест, затем голосование, варианты, блоки, голоса и ключи
+func WriteArchive(w io.Writer, a *models.ElectionArchive) error {
+	enc := json.NewEncoder(w)
+	write := func(kind string, v any) error {
+		data, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		return enc.Encode(archiveLine{Type: kind, Data: data})
+	}
+
+	if err := write(models.ArchiveRecordManifest, a.Manifest); err != nil {
+		return err
+	}
+	if err := write(models.ArchiveRecordElection, a.Election); err != nil {
+		return err
+	}
+	for _, c := range a.Choices {
+		if err := write(models.ArchiveRecordChoice, c); err != nil {
+			return err
+		}
+	}
+	for _, b := range a.Blocks {
+		if err := write(models.ArchiveRecordBlock, b); err != nil {
+			return err
+		}
+	}
+	for _, v := range a.Votes {
+		if err := write(models.ArchiveRecordVote, v); err != nil {
+			return err
+		}
+	}
+	for _, k := range a.Keys {
+		if err := write(models.ArchiveRecordKey, k); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ReadArchive — читает архив, записанный WriteArchive, и проверяет его
+// структуру: формат и версию манифеста, единственное голосование и число записей
+func ReadArchive(r io.Reader) (*models.ElectionArchive, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+
+	a := &models.ElectionArchive{}
+	line := 0
+	for scanner.Scan() {
+		line++
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+
+		var rec archiveLine
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+			return nil, fmt.Errorf("%w: строка %d: %v", ErrInvalidArchive, line, err)
+		}
+		if line == 1 && rec.Type != models.ArchiveRecordManifest {
+			return nil, fmt.Errorf("%w: первой строкой должен быть манифест", ErrInvalidArchive)
+		}
+
+		var err error
+		switch rec.Type {
+		case models.ArchiveRecordManifest:
+			if line != 1 {
+				return nil, fmt.Errorf("%w: строка %d: повторный манифест", ErrInvalidArchive, line)
+			}
+			err = json.Unmarshal(rec.Data, &a.Manifest)
+		case models.ArchiveRecordElection:
+			if a.Election != nil {
+				return nil, fmt.Errorf("%w: строка %d: в архиве больше одного голосования", ErrInvalidArchive, line)
+			}
+			a.Election = &models.Election{}
+			err = json.Unmarshal(rec.Data, a.Election)
+		case models.ArchiveRecordChoice:
+			c := &models.Choice{}
+			err = json.Unmarshal(rec.Data, c)
+			a.Choices = append(a.Choices, c)
+		case models.ArchiveRecordBlock:
+			b := &models.Block{}
+			err = json.Unmarshal(rec.Data, b)
+			a.Blocks = append(a.Blocks, b)
+		case models.ArchiveRecordVote:
+			v := &models.ArchiveVote{}
+			err = json.Unmarshal(rec.Data, v)
+			a.Votes = append(a.Votes, v)
+		case models.ArchiveRecordKey:
+			k := &models.SigningKey{}
+			err = json.Unmarshal(rec.Data, k)
+			a.Keys = append(a.Keys, k)
+		default:
+			return nil, fmt.Errorf("%w: строка %d: неизвестный тип записи %q", ErrInvalidArchive, line, rec.Type)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: строка %d: %v", ErrInvalidArchive, line, err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	m := a.Manifest
+	switch {
+	case m.Format != models.ArchiveFormat:
+		return nil, fmt.Errorf("%w: неизвестный формат %q", ErrInvalidArchive, m.Format)
+	case m.Version != models.ArchiveVersion:
+		return nil, fmt.Errorf("%w: неподдерживаемая версия %d", ErrInvalidArchive, m.Version)
+	case a.Election == nil || a.Election.ID != m.ElectionID:
+		return nil, fmt.Errorf("%w: голосование не совпадает с манифестом", ErrInvalidArchive)
+	case len(a.Choices) != m.Choices || len(a.Blocks) != m.Blocks || len(a.Votes) != m.Votes:
+		return nil, fmt.Errorf("%w: число записей не совпадает с манифестом", ErrInvalidArchive)
+	}
+	return a, nil
+}
+
+// VerifyArchive — проверяет цепочку архива теми же правилами, что и VerifyChain:
+// каждый блок, кроме v1, должен быть подписан одним из ключей. Если trusted не пуст,
+// подписи принимаются только этими ключами, а не ключами из самого архива: так
+// наблюдатель не зависит от того, что ему выгрузили. Иначе KeySource = archive.
+func VerifyArchive(a *models.ElectionArchive, trusted []*models.SigningKey) *models.ChainVerification {
+	keys, source := publicKeys(a.Keys), models.KeySourceArchive
+	if len(trusted) > 0 {
+		keys, source = publicKeys(trusted), models.KeySourcePinned
+	}
+
+	votes := make([]*models.Vote, len(a.Votes))
+	for i, v := range a.Votes {
+		votes[i] = v.Vote()
+	}
+
+	result := verifyBlocks(a.Election, a.Choices, a.Blocks, groupVotesByBlock(votes), keys, true)
+	result.KeySource = source
+	if result.Valid && len(a.Blocks) > 0 && a.Blocks[len(a.Blocks)-1].Hash != a.Manifest.HeadHash {
+		last := len(a.Blocks) - 1
+		blockID := a.Blocks[last].Index
+		result.Status = models.ChainBroken
+		result.Valid = false
+		result.BrokenIndex = &last
+		result.BlockID = &blockID
+		result.Reason = "head hash does not match the manifest"
+	}
+	return result
+}
@@ -0,0 +1,186 @@
+package services
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"voting-blockchain/internal/voting/models"
+)
+
+// normalizeBallot — проверяет бюллетень голосования и сводит его к
+// каноническому виду, который фиксирует хеш голоса. Бюллетень голосования с
+// вопросами состоит из ответов на вопросы (можно ответить не на все), каждый
+// ответ проверяется способом своего вопроса. Возвращает вариант, сохраняемый
+// в голосе (см. Ballot.FirstChoice). Бюллетень голосования с шифрованием
+// проверяется normalizeEncryptedBallot.
+func normalizeBallot(e *models.Election, ballot *models.Ballot, choices []*models.Choice) (*models.Choice, error) {
+	if e.Encrypted() {
+		return normalizeEncryptedBallot(e, ballot, choices)
+	}
+	if len(e.Contests) == 0 {
+		if ballot != nil && len(ballot.Contests) > 0 {
+			return nil, ErrInvalidBallot
+		}
+		return normalizeContestBallot(e.DefaultContest(), ballot, choices)
+	}
+
+	if ballot == nil || ballot.IsEmpty() {
+		return nil, ErrChoiceRequired
+	}
+	if otherFields(ballot, "contests") {
+		return nil, ErrInvalidBallot
+	}
+	contests := make(map[int]*models.Contest, len(e.Contests))
+	for _, q := range e.Contests {
+		contests[q.ID] = q
+	}
+	sort.Slice(ballot.Contests, func(i, j int) bool { return ballot.Contests[i].ContestID < ballot.Contests[j].ContestID })
+
+	var first *models.Choice
+	for i := range ballot.Contests {
+		answer := &ballot.Contests[i]
+		q := contests[answer.ContestID]
+		if q == nil {
+			return nil, fmt.Errorf("%w: вопроса %d нет в голосовании", ErrInvalidBallot, answer.ContestID)
+		}
+		if (i > 0 && ballot.Contests[i-1].ContestID == answer.ContestID) || len(answer.Contests) > 0 {
+			return nil, ErrInvalidBallot
+		}
+		c, err := normalizeContestBallot(q, &answer.Ballot, models.ContestChoices(choices, q.ID))
+		if err != nil {
+			return nil, fmt.Errorf("вопрос %d: %w", q.ID, err)
+		}
+		if first == nil {
+			first = c
+		}
+	}
+	return first, nil
+}
+
+// normalizeContestBallot — проверяет ответ на вопрос по его способу
+// голосования: варианты задаются только по id и должны быть среди вариантов
+// вопроса, заполнено только поле способа, а одобрения и оценки упорядочены по id
+func normalizeContestBallot(q *models.Contest, ballot *models.Ballot, choices []*models.Choice) (*models.Choice, error) {
+	if ballot == nil || ballot.IsEmpty() {
+		return nil, ErrChoiceRequired
+	}
+	byID := make(map[int]*models.Choice, len(choices))
+	for _, c := range choices {
+		byID[c.ID] = c
+	}
+
+	switch q.Method() {
+	case models.VotingInstantRunoff, models.VotingSchulze, models.VotingSTV:
+		return normalizeList(ballot.Ranking, otherFields(ballot, "ranking"), byID)
+	case models.VotingApproval:
+		sort.Ints(ballot.Approvals)
+		return normalizeList(ballot.Approvals, otherFields(ballot, "approvals"), byID)
+	case models.VotingScore:
+		return normalizeScores(ballot, q.MaxScore, byID)
+	}
+
+	if ballot.WriteIn != "" {
+		return normalizeWriteIn(q, ballot, choices)
+	}
+	if otherFields(ballot, "choice") {
+		return nil, ErrInvalidBallot
+	}
+	for _, c := range choices {
+		if (ballot.ChoiceID != 0 && c.ID == ballot.ChoiceID) || (ballot.ChoiceID == 0 && c.Text == ballot.Choice) {
+			ballot.ChoiceID = c.ID
+			ballot.Choice = ""
+			return c, nil
+		}
+	}
+	return nil, ErrInvalidChoice
+}
+
+// normalizeWriteIn — вписанный вариант: разрешён вопросом, других полей в
+// бюллетене нет, текст приводится к виду models.NormalizeWriteIn. Текст,
+// совпадающий с вариантом вопроса без учёта регистра, засчитывается этому
+// варианту. Возвращает вариант без id с текстом вписанного варианта.
+func normalizeWriteIn(q *models.Contest, ballot *models.Ballot, choices []*models.Choice) (*models.Choice, error) {
+	if !q.AllowWriteIns {
+		return nil, ErrWriteInsNotAllowed
+	}
+	if otherFields(ballot, "write_in") {
+		return nil, ErrInvalidBallot
+	}
+	text, ok := models.NormalizeWriteIn(ballot.WriteIn)
+	if !ok {
+		return nil, fmt.Errorf("%w: вписанный вариант пуст, длиннее %d символов или содержит непечатные символы",
+			ErrInvalidBallot, models.MaxWriteInLength)
+	}
+	for _, c := range choices {
+		if strings.EqualFold(c.Text, text) {
+			ballot.ChoiceID, ballot.WriteIn = c.ID, ""
+			return c, nil
+		}
+	}
+	ballot.WriteIn = text
+	return &models.Choice{ElectionID: q.ElectionID, ContestID: q.ID, Text: text}, nil
+}
+
+// normalizeList — ранжирование или одобрения: непустой список различных
+// вариантов голосования
+func normalizeList(ids []int, mixed bool, byID map[int]*models.Choice) (*models.Choice, error) {
+	if len(ids) == 0 {
+		return nil, ErrChoiceRequired
+	}
+	if mixed {
+		return nil, ErrInvalidBallot
+	}
+	seen := make(map[int]bool, len(ids))
+	for _, id := range ids {
+		if byID[id] == nil {
+			return nil, ErrInvalidChoice
+		}
+		if seen[id] {
+			return nil, ErrInvalidBallot
+		}
+		seen[id] = true
+	}
+	return byID[ids[0]], nil
+}
+
+// normalizeScores — оценки от 0 до maxScore различных вариантов голосования;
+// неоценённые варианты при подсчёте получают 0
+func normalizeScores(ballot *models.Ballot, maxScore int, byID map[int]*models.Choice) (*models.Choice, error) {
+	if len(ballot.Scores) == 0 {
+		return nil, ErrChoiceRequired
+	}
+	if otherFields(ballot, "scores") {
+		return nil, ErrInvalidBallot
+	}
+	sort.Slice(ballot.Scores, func(i, j int) bool { return ballot.Scores[i].ChoiceID < ballot.Scores[j].ChoiceID })
+
+	for i, s := range ballot.Scores {
+		if byID[s.ChoiceID] == nil {
+			return nil, ErrInvalidChoice
+		}
+		if (i > 0 && ballot.Scores[i-1].ChoiceID == s.ChoiceID) || s.Score < 0 || s.Score > maxScore {
+			return nil, ErrInvalidBallot
+		}
+	}
+	return byID[ballot.Scores[0].ChoiceID], nil
+}
+
+// otherFields — заполнены ли в бюллетене поля, кроме поля способа field
+func otherFields(ballot *models.Ballot, field string) bool {
+	set := map[string]bool{
+		"choice":    ballot.ChoiceID != 0 || ballot.Choice != "",
+		"ranking":   len(ballot.Ranking) > 0,
+		"approvals": len(ballot.Approvals) > 0,
+		"scores":    len(ballot.Scores) > 0,
+		"contests":  len(ballot.Contests) > 0,
+		"write_in":  ballot.WriteIn != "",
+		"encrypted": ballot.Encrypted != nil,
+	}
+	for name, filled := range set {
+		if filled && name != field {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,128 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base32"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"voting-blockchain/internal/voting/models"
+	"voting-blockchain/internal/voting/repositories"
+)
+
+// MaxBallotCodes — сколько кодов голосования выпускается за один запрос
+const MaxBallotCodes = 10000
+
+// BallotCodeService — одноразовые коды для избирателей без учётной записи.
+// Код показывается только при выпуске: в базе остаётся его хеш, поэтому
+// использованный код нельзя связать ни с избирателем, ни с его голосом.
+type BallotCodeService interface {
+	Generate(ctx context.Context, electionID, count int) ([]string, error)
+	Stats(ctx context.Context, electionID int) (*models.BallotCodeStats, error)
+}
+
+type ballotCodeService struct {
+	electionRepo repositories.ElectionRepository
+	codeRepo     repositories.BallotCodeRepository
+	uow          repositories.UnitOfWork
+}
+
+func NewBallotCodeService(
+	electionRepo repositories.ElectionRepository,
+	codeRepo repositories.BallotCodeRepository,
+	uow repositories.UnitOfWork,
+) BallotCodeService {
+	return &ballotCodeService{
+		electionRepo: electionRepo,
+		codeRepo:     codeRepo,
+		uow:          uow,
+	}
+}
+
+// Generate — выпускает count новых кодов голосования. Коды выпускаются до
+// открытия голосования и добавляются к выпущенным ранее: после открытия
+// число кодов, а значит и голосов без учётной записи, уже не растёт.
+func (s *ballotCodeService) Generate(ctx context.Context, electionID, count int) ([]string, error) {
+	if count < 1 || count > MaxBallotCodes {
+		return nil, fmt.Errorf("%w: от 1 до %d за раз", ErrInvalidCodeCount, MaxBallotCodes)
+	}
+
+	codes := make([]string, count)
+	hashes := make([]string, count)
+	for i := range codes {
+		code, err := generateBallotCode()
+		if err != nil {
+			return nil, err
+		}
+		codes[i] = code
+		hashes[i] = models.BallotCodeHash(code)
+	}
+
+	err := s.uow.DoInElection(ctx, electionID, func(repos *repositories.Repositories) error {
+		election, err := repos.Elections.GetByID(ctx, electionID)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrElectionNotFound
+		}
+		if err != nil {
+			return err
+		}
+		switch election.Status {
+		case models.ElectionDraft, models.ElectionScheduled:
+		default:
+			return ErrBallotCodesLocked
+		}
+		return repos.Codes.Add(ctx, electionID, hashes)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// Stats — сколько кодов выпущено и сколько из них использовано
+func (s *ballotCodeService) Stats(ctx context.Context, electionID int) (*models.BallotCodeStats, error) {
+	if _, err := s.electionRepo.GetByID(ctx, electionID); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrElectionNotFound
+		}
+		return nil, err
+	}
+	return s.codeRepo.Stats(ctx, electionID)
+}
+
+// generateBallotCode — models.BallotCodeBytes случайных байт в base32 группами
+// по четыре символа: XXXX-XXXX-…
+func generateBallotCode() (string, error) {
+	b := make([]byte, models.BallotCodeBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	raw := base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(b)
+	groups := make([]string, 0, len(raw)/4+1)
+	for len(raw) > 4 {
+		groups = append(groups, raw[:4])
+		raw = raw[4:]
+	}
+	return strings.Join(append(groups, raw), "-"), nil
+}
+
+// WriteBallotCodesCSV — коды голосования для рассылки: номер, голосование, код
+func WriteBallotCodesCSV(w io.Writer, electionID int, codes []string) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write([]string{"n", "election_id", "code"}); err != nil {
+		return err
+	}
+	for i, code := range codes {
+		if err := cw.Write([]string{strconv.Itoa(i + 1), strconv.Itoa(electionID), code}); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
@@ -0,0 +1,155 @@
+package services
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"voting-blockchain/internal/voting/models"
+)
+
+// blindKeyBits — размер ключа RSA для подписи жетонов
+const blindKeyBits = 2048
+
+// Слепая подпись RSA с хешем на всю область (RSA-FDH). Избиратель выбирает
+// случайный жетон, маскирует его хеш множителем r^e и получает подпись
+// маски; сняв множитель r, он получает обычную подпись жетона, которую
+// сервер ни разу не видел. Хеш жетона привязан к голосованию, а у каждого
+// голосования свой ключ, поэтому жетон одного голосования не годится в другом.
+
+// generateBlindKey — новый ключ подписи жетонов голосования
+func generateBlindKey(electionID int) (*models.BlindKey, *rsa.PrivateKey, error) {
+	priv, err := rsa.GenerateKey(rand.Reader, blindKeyBits)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &models.BlindKey{ElectionID: electionID, PrivateKey: x509.MarshalPKCS1PrivateKey(priv)}, priv, nil
+}
+
+// parseBlindKey — ключ RSA из сохранённого ключа голосования
+func parseBlindKey(k *models.BlindKey) (*rsa.PrivateKey, error) {
+	return x509.ParsePKCS1PrivateKey(k.PrivateKey)
+}
+
+// blindPublicKey — открытая часть ключа для публикации
+func blindPublicKey(electionID int, pub *rsa.PublicKey) *models.BlindPublicKey {
+	n := hex.EncodeToString(pub.N.Bytes())
+	return &models.BlindPublicKey{ElectionID: electionID, N: n, E: pub.E, Fingerprint: models.BlindKeyFingerprint(n, pub.E)}
+}
+
+// ParseBlindPublicKey — ключ RSA из опубликованного ключа голосования
+func ParseBlindPublicKey(k *models.BlindPublicKey) (*rsa.PublicKey, error) {
+	raw, err := hex.DecodeString(k.N)
+	if err != nil || len(raw) == 0 || k.E < 3 {
+		return nil, errors.New("некорректный ключ подписи жетонов")
+	}
+	return &rsa.PublicKey{N: new(big.Int).SetBytes(raw), E: k.E}, nil
+}
+
+// blindTokenDigest — хеш жетона на всю область ключа: sha256 в режиме
+// счётчика над доменом, голосованием и жетоном, приведённый по модулю N
+func blindTokenDigest(pub *rsa.PublicKey, electionID int, token []byte) *big.Int {
+	size := (pub.N.BitLen() + 7) / 8
+	prefix := fmt.Sprintf("vbc-blind-token\nelection:%d\n", electionID)
+	out := make([]byte, 0, size+sha256.Size)
+	var counter [4]byte
+	for i := uint32(0); len(out) < size; i++ {
+		binary.BigEndian.PutUint32(counter[:], i)
+		h := sha256.New()
+		h.Write([]byte(prefix))
+		h.Write(token)
+		h.Write(counter[:])
+		out = h.Sum(out)
+	}
+	m := new(big.Int).SetBytes(out[:size])
+	return m.Mod(m, pub.N)
+}
+
+// BlindToken — маскирует жетон для подписи: возвращает маску, которую
+// избиратель отправляет на подпись, и множитель r для UnblindSignature.
+// Выполняется на стороне избирателя; r никому не передаётся.
+func BlindToken(pub *rsa.PublicKey, electionID int, token []byte) ([]byte, *big.Int, error) {
+	if len(token) < models.BlindTokenMinBytes {
+		return nil, nil, fmt.Errorf("%w: жетон короче %d байт", ErrInvalidBlindToken, models.BlindTokenMinBytes)
+	}
+	r, err := randomUnit(pub.N)
+	if err != nil {
+		return nil, nil, err
+	}
+	m := blindTokenDigest(pub, electionID, token)
+	re := new(big.Int).Exp(r, big.NewInt(int64(pub.E)), pub.N)
+	blinded := m.Mul(m, re).Mod(m, pub.N)
+	return fixedBytes(blinded, pub), r, nil
+}
+
+// randomUnit — случайное r из (1, N), взаимно простое с N
+func randomUnit(n *big.Int) (*big.Int, error) {
+	one := big.NewInt(1)
+	for {
+		r, err := rand.Int(rand.Reader, n)
+		if err != nil {
+			return nil, err
+		}
+		if r.Cmp(one) > 0 && new(big.Int).GCD(nil, nil, r, n).Cmp(one) == 0 {
+			return r, nil
+		}
+	}
+}
+
+// signBlinded — подпись маски жетона: blinded^d mod N. Сервер не видит ни
+// жетона, ни его хеша. big.Int.Exp не постоянного времени, а маску присылает
+// избиратель, поэтому возводится в степень не она сама, а blinded·r^e со
+// случайным r, и результат умножается на r⁻¹: по времени нельзя
+// сопоставить присланное значение с d.
+func signBlinded(priv *rsa.PrivateKey, blinded []byte) ([]byte, error) {
+	b := new(big.Int).SetBytes(blinded)
+	if len(blinded) != (priv.N.BitLen()+7)/8 || b.Sign() <= 0 || b.Cmp(priv.N) >= 0 {
+		return nil, fmt.Errorf("%w: маска вне области ключа", ErrInvalidBlindToken)
+	}
+	r, err := randomUnit(priv.N)
+	if err != nil {
+		return nil, err
+	}
+	masked := new(big.Int).Exp(r, big.NewInt(int64(priv.E)), priv.N)
+	masked.Mul(masked, b).Mod(masked, priv.N)
+	s := new(big.Int).Exp(masked, priv.D, priv.N)
+	s.Mul(s, new(big.Int).ModInverse(r, priv.N)).Mod(s, priv.N)
+	// Проверка подписи защищает ключ от утечки при сбое вычисления
+	if new(big.Int).Exp(s, big.NewInt(int64(priv.E)), priv.N).Cmp(b) != 0 {
+		return nil, errors.New("сбой подписи жетона")
+	}
+	return fixedBytes(s, &priv.PublicKey), nil
+}
+
+// UnblindSignature — снимает маску с подписи: подпись жетона = s' · r⁻¹ mod N.
+// Выполняется на стороне избирателя.
+func UnblindSignature(pub *rsa.PublicKey, blindSig []byte, r *big.Int) []byte {
+	inv := new(big.Int).ModInverse(r, pub.N)
+	s := new(big.Int).SetBytes(blindSig)
+	s.Mul(s, inv).Mod(s, pub.N)
+	return fixedBytes(s, pub)
+}
+
+// VerifyBlindToken — подпись sig сделана ключом голосования над жетоном token
+func VerifyBlindToken(pub *rsa.PublicKey, electionID int, token, sig []byte) bool {
+	if len(token) < models.BlindTokenMinBytes || len(sig) != (pub.N.BitLen()+7)/8 {
+		return false
+	}
+	s := new(big.Int).SetBytes(sig)
+	if s.Cmp(pub.N) >= 0 {
+		return false
+	}
+	m := new(big.Int).Exp(s, big.NewInt(int64(pub.E)), pub.N)
+	return m.Cmp(blindTokenDigest(pub, electionID, token)) == 0
+}
+
+// fixedBytes — число в big-endian длиной с модуль ключа
+func fixedBytes(n *big.Int, pub *rsa.PublicKey) []byte {
+	return n.FillBytes(make([]byte, (pub.N.BitLen()+7)/8))
+}
@@ -0,0 +1,138 @@
+package services
+
+import (
+	"context"
+	"crypto/rsa"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+	"voting-blockchain/internal/voting/models"
+	"voting-blockchain/internal/voting/repositories"
+)
+
+// BlindTokenService — выдача жетонов анонимных голосований. Вошедший
+// пользователь получает слепую подпись жетона, а голос по жетону подаётся
+// отдельно и без входа (VoteService.CastAnonymousVote): система знает, что
+// пользователь получил жетон, но не знает, какой голос его.
+type BlindTokenService interface {
+	PublicKey(ctx context.Context, electionID int) (*models.BlindPublicKey, error)
+	Issue(ctx context.Context, userID, electionID int, blinded []byte) ([]byte, error)
+}
+
+type blindTokenService struct {
+	uow repositories.UnitOfWork
+}
+
+func NewBlindTokenService(uow repositories.UnitOfWork) BlindTokenService {
+	return &blindTokenService{uow: uow}
+}
+
+// PublicKey — открытый ключ подписи жетонов. Ключ создаётся, когда голосование
+// выходит из черновика; до этого возвращается ErrBlindKeyPending.
+func (s *blindTokenService) PublicKey(ctx context.Context, electionID int) (*models.BlindPublicKey, error) {
+	var pub *models.BlindPublicKey
+	err := s.uow.DoInElection(ctx, electionID, func(repos *repositories.Repositories) error {
+		if _, err := anonymousElection(ctx, repos, electionID); err != nil {
+			return err
+		}
+		priv, err := electionBlindKey(ctx, repos, electionID, false)
+		if errors.Is(err, ErrInvalidBlindToken) {
+			return ErrBlindKeyPending
+		}
+		if err != nil {
+			return err
+		}
+		pub = blindPublicKey(electionID, &priv.PublicKey)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return pub, nil
+}
+
+// Issue — подписывает маску жетона пользователя (см. BlindToken). Жетон
+// выдаётся один раз, до закрытия голосования и, если голосование по списку,
+// только избирателям из списка.
+func (s *blindTokenService) Issue(ctx context.Context, userID, electionID int, blinded []byte) ([]byte, error) {
+	var sig []byte
+	err := s.uow.DoInElection(ctx, electionID, func(repos *repositories.Repositories) error {
+		election, err := anonymousElection(ctx, repos, electionID)
+		if err != nil {
+			return err
+		}
+		if election.Status != models.ElectionScheduled && election.Status != models.ElectionOpen {
+			return ErrElectionNotOpen
+		}
+		if election.RollRestricted() {
+			eligible, err := repos.Rolls.IsEligible(ctx, electionID, userID)
+			if err != nil {
+				return err
+			}
+			if !eligible {
+				return ErrNotEligible
+			}
+		}
+
+		issued, err := repos.Blind.RecordIssuance(ctx, electionID, userID)
+		if err != nil {
+			return err
+		}
+		if !issued {
+			return ErrTokenAlreadyIssued
+		}
+		priv, err := electionBlindKey(ctx, repos, electionID, false)
+		if errors.Is(err, ErrInvalidBlindToken) {
+			return ErrBlindKeyPending
+		}
+		if err != nil {
+			return err
+		}
+		// Неверная маска откатывает и отметку о выдаче
+		sig, err = signBlinded(priv, blinded)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return sig, nil
+}
+
+// anonymousElection — голосование electionID, если оно анонимное
+func anonymousElection(ctx context.Context, repos *repositories.Repositories, electionID int) (*models.Election, error) {
+	election, err := repos.Elections.GetByID(ctx, electionID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrElectionNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !election.BlindTokens() {
+		return nil, ErrNotAnonymous
+	}
+	return election, nil
+}
+
+// electionBlindKey — ключ подписи жетонов голосования; если его нет и create
+// (голосование выходит из черновика), он создаётся, иначе возвращается
+// ErrInvalidBlindToken
+func electionBlindKey(ctx context.Context, repos *repositories.Repositories, electionID int, create bool) (*rsa.PrivateKey, error) {
+	key, err := repos.Blind.GetKey(ctx, electionID)
+	switch {
+	case err == nil:
+		return parseBlindKey(key)
+	case !errors.Is(err, pgx.ErrNoRows):
+		return nil, err
+	case !create:
+		return nil, ErrInvalidBlindToken
+	}
+
+	key, priv, err := generateBlindKey(electionID)
+	if err != nil {
+		return nil, err
+	}
+	if err := repos.Blind.SaveKey(ctx, key); err != nil {
+		return nil, err
+	}
+	return priv, nil
+}
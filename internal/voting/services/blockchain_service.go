@@ -1,62 +1,492 @@
-package services
-
-import (
-	"context"
-	"crypto/sha256"
-	"encoding/hex"
-	"fmt"
-	"time"
-
-	"voting-blockchain/internal/voting/models"
-	"voting-blockchain/internal/voting/repositories"
-)
-
-// BlockchainService описывает методы работы с блокчейном
-type BlockchainService interface {
-	AddBlock(ctx context.Context, electionID int, voteHash string) (*models.Block, error)
-	GetChain(ctx context.Context, electionID int) ([]*models.Block, error)
-}
-
-type blockchainService struct {
-	blockRepo repositories.BlockchainRepository
-}
-
-// NewBlockchainService — конструктор сервиса
-func NewBlockchainService(blockRepo repositories.BlockchainRepository) BlockchainService {
-	return &blockchainService{blockRepo: blockRepo}
-}
-
-// AddBlock — добавляет блок в блокчейн голосования
-func (s *blockchainService) AddBlock(ctx context.Context, electionID int, voteHash string) (*models.Block, error) {
-	// Получаем последний блок
-	prevBlock, err := s.blockRepo.GetLastBlock(ctx, electionID)
-	var prevHash string
-	if err == nil && prevBlock != nil {
-		prevHash = prevBlock.Hash
-	}
-
-	// Строим хеш нового блока
-	timestamp := time.Now()
-	raw := fmt.Sprintf("%s|%s|%d|%s", voteHash, prevHash, electionID, timestamp.UTC().String())
-	hash := sha256.Sum256([]byte(raw))
-	hashStr := hex.EncodeToString(hash[:])
-
-	newBlock := &models.Block{
-		Timestamp:  timestamp,
-		VoteHash:   voteHash,
-		PrevHash:   prevHash,
-		Hash:       hashStr,
-		ElectionID: electionID,
-	}
-
-	err = s.blockRepo.AddBlock(ctx, newBlock)
-	if err != nil {
-		return nil, err
-	}
-	return newBlock, nil
-}
-
-// GetChain — получить всю цепочку блоков для голосования
-func (s *blockchainService) GetChain(ctx context.Context, electionID int) ([]*models.Block, error) {
-	return s.blockRepo.GetAllBlocks(ctx, electionID)
-}
+package services
+
+import (
+	"context"
+	"crypto/ed25519"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"voting-blockchain/internal/voting/models"
+	"voting-blockchain/internal/voting/repositories"
+)
+
+// BlockchainService описывает методы работы с блокчейном
+type BlockchainService interface {
+	AddBlock(ctx context.Context, electionID int, voteHash string) (*models.Block, error)
+	GetChain(ctx context.Context, electionID int) ([]*models.Block, error)
+	GetSigningKeys(ctx context.Context) ([]*models.SigningKey, error)
+	VerifyChain(ctx context.Context, electionID int) (*models.ChainVerification, error)
+	SealPending(ctx context.Context, electionID int) (*models.Block, error)
+	ExportElection(ctx context.Context, electionID int) (*models.ElectionArchive, error)
+	ImportElection(ctx context.Context, archive *models.ElectionArchive) (*models.ChainVerification, error)
+	AnchorUnsignedChains(ctx context.Context) (int, error)
+}
+
+type blockchainService struct {
+	blockRepo    repositories.BlockchainRepository
+	voteRepo     repositories.VoteRepository
+	electionRepo repositories.ElectionRepository
+	choiceRepo   repositories.ChoiceRepository
+	keyRepo      repositories.SigningKeyRepository
+	uow          repositories.UnitOfWork
+	signer       *BlockSigner
+	historical   []*models.SigningKey
+}
+
+// NewBlockchainService — конструктор сервиса. historical — прежние ключи узла
+// из конфигурации: вместе с ключом signer только им доверяют при проверке подписей.
+func NewBlockchainService(
+	blockRepo repositories.BlockchainRepository,
+	voteRepo repositories.VoteRepository,
+	electionRepo repositories.ElectionRepository,
+	choiceRepo repositories.ChoiceRepository,
+	keyRepo repositories.SigningKeyRepository,
+	uow repositories.UnitOfWork,
+	signer *BlockSigner,
+	historical []*models.SigningKey,
+) BlockchainService {
+	return &blockchainService{
+		blockRepo:    blockRepo,
+		voteRepo:     voteRepo,
+		electionRepo: electionRepo,
+		choiceRepo:   choiceRepo,
+		keyRepo:      keyRepo,
+		uow:          uow,
+		signer:       signer,
+		historical:   historical,
+	}
+}
+
+// AddBlock — добавляет блок в блокчейн голосования
+func (s *blockchainService) AddBlock(ctx context.Context, electionID int, voteHash string) (*models.Block, error) {
+	var newBlock *models.Block
+	err := s.uow.DoInElection(ctx, electionID, func(repos *repositories.Repositories) error {
+		// Получаем последний блок
+		prevBlock, err := repos.Blocks.GetLastBlock(ctx, electionID)
+		if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+			return err
+		}
+
+		newBlock = models.NewBlock(electionID, prevBlock, voteHash, time.Now())
+		s.signer.Sign(newBlock)
+		return repos.Blocks.AddBlock(ctx, newBlock)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return newBlock, nil
+}
+
+// SealPending — запечатывает ожидающие голоса в блок, не дожидаясь размера пачки
+// или окна. Возвращает nil, если запечатывать нечего.
+func (s *blockchainService) SealPending(ctx context.Context, electionID int) (*models.Block, error) {
+	var block *models.Block
+	err := s.uow.DoInElection(ctx, electionID, func(repos *repositories.Repositories) error {
+		var err error
+		block, err = sealPending(ctx, repos, s.signer, electionID)
+		return err
+	})
+	return block, err
+}
+
+// AnchorUnsignedChains — дописывает подписанный блок-якорь к цепочкам голосований,
+// созданных до подписей, у которых вершина ещё без подписи: якорь фиксирует хеш
+// вершины, а через связи PrevHash — и все неподписанные блоки до неё.
+// Якорь подписывает то, что сейчас в базе, поэтому вызывается один раз при
+// обновлении (ANCHOR_UNSIGNED_CHAINS); возвращает число закреплённых цепочек.
+func (s *blockchainService) AnchorUnsignedChains(ctx context.Context) (int, error) {
+	elections, err := s.electionRepo.List(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	anchored := 0
+	for _, e := range elections {
+		if !e.CreatedAt.Before(models.SignatureCutoff) {
+			continue
+		}
+		err := s.uow.DoInElection(ctx, e.ID, func(repos *repositories.Repositories) error {
+			last, err := repos.Blocks.GetLastBlock(ctx, e.ID)
+			if errors.Is(err, pgx.ErrNoRows) {
+				return nil
+			}
+			if err != nil || last.Signature != "" {
+				return err
+			}
+
+			anchor := models.NewAnchorBlock(e.ID, last, time.Now())
+			s.signer.Sign(anchor)
+			if err := repos.Blocks.AddBlock(ctx, anchor); err != nil {
+				return err
+			}
+			anchored++
+			return nil
+		})
+		if err != nil {
+			return anchored, err
+		}
+	}
+	return anchored, nil
+}
+
+// GetChain — получить всю цепочку блоков для голосования
+func (s *blockchainService) GetChain(ctx context.Context, electionID int) ([]*models.Block, error) {
+	return s.blockRepo.GetAllBlocks(ctx, electionID)
+}
+
+// GetSigningKeys — текущий и все прежние публичные ключи подписи блоков
+func (s *blockchainService) GetSigningKeys(ctx context.Context) ([]*models.SigningKey, error) {
+	return s.keyRepo.List(ctx)
+}
+
+// VerifyChain — пересчитывает хеши всех блоков голосования, проверяет связи
+// PrevHash, подписи, генезис-блок и наличие голосов, на которые ссылаются блоки.
+// Подписи проверяются ключами из конфигурации, а не из signing_keys: таблица
+// лежит в той же базе, которую подписи защищают.
+func (s *blockchainService) VerifyChain(ctx context.Context, electionID int) (*models.ChainVerification, error) {
+	election, err := s.electionRepo.GetByID(ctx, electionID)
+	if err != nil {
+		return nil, err
+	}
+
+	choices, err := s.choiceRepo.GetChoices(ctx, electionID)
+	if err != nil {
+		return nil, err
+	}
+
+	blocks, err := s.blockRepo.GetAllBlocks(ctx, electionID)
+	if err != nil {
+		return nil, err
+	}
+
+	votes, err := s.voteRepo.GetByElectionID(ctx, electionID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := verifyBlocks(election, choices, blocks, groupVotesByBlock(votes), publicKeys(s.trustedKeys()), false)
+	result.KeySource = models.KeySourcePinned
+	return result, nil
+}
+
+// trustedKeys — текущий ключ узла и прежние ключи из конфигурации
+func (s *blockchainService) trustedKeys() []*models.SigningKey {
+	return append([]*models.SigningKey{s.signer.PublicKey()}, s.historical...)
+}
+
+// publicKeys — ключи подписи по KeyID; ключи, не совпадающие со своим KeyID, пропускаются
+func publicKeys(keys []*models.SigningKey) map[string]ed25519.PublicKey {
+	res := make(map[string]ed25519.PublicKey, len(keys))
+	for _, k := range keys {
+		if pub, err := k.Ed25519(); err == nil {
+			res[k.KeyID] = pub
+		}
+	}
+	return res
+}
+
+// ExportElection — выгружает голосование целиком: определение, решения по
+// вписанным вариантам, цепочку блоков, обезличенные голоса и доверенные ключи,
+// которыми подписаны блоки. Выгрузка идёт под блокировкой голосования, чтобы в неё не
+// попала наполовину дописанная цепочка.
+func (s *blockchainService) ExportElection(ctx context.Context, electionID int) (*models.ElectionArchive, error) {
+	a := &models.ElectionArchive{}
+	err := s.uow.DoInElection(ctx, electionID, func(repos *repositories.Repositories) error {
+		var err error
+		a.Election, err = repos.Elections.GetByID(ctx, electionID)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrElectionNotFound
+		}
+		if err != nil {
+			return err
+		}
+		if a.Election.WriteInReviews, err = repos.Elections.ListWriteInReviews(ctx, electionID); err != nil {
+			return err
+		}
+		if a.Choices, err = repos.Choices.GetChoices(ctx, electionID); err != nil {
+			return err
+		}
+		if a.Blocks, err = repos.Blocks.GetAllBlocks(ctx, electionID); err != nil {
+			return err
+		}
+
+		votes, err := repos.Votes.GetByElectionID(ctx, electionID)
+		if err != nil {
+			return err
+		}
+		for _, v := range votes {
+			a.Votes = append(a.Votes, models.AnonymizeVote(v))
+		}
+
+		used := make(map[string]bool)
+		for _, b := range a.Blocks {
+			used[b.KeyID] = true
+		}
+		for _, k := range s.trustedKeys() {
+			if used[k.KeyID] {
+				a.Keys = append(a.Keys, k)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	a.Manifest = models.ArchiveManifest{
+		Format:     models.ArchiveFormat,
+		Version:    models.ArchiveVersion,
+		ElectionID: electionID,
+		ExportedAt: time.Now().UTC(),
+		Choices:    len(a.Choices),
+		Blocks:     len(a.Blocks),
+		Votes:      len(a.Votes),
+	}
+	if len(a.Blocks) > 0 {
+		a.Manifest.HeadHash = a.Blocks[len(a.Blocks)-1].Hash
+	}
+	return a, nil
+}
+
+// ImportElection — восстанавливает голосование из архива с исходными id.
+// Цепочка архива сначала проверяется целиком; голосование с тем же id в базе
+// быть не должно. Подписи проверяются только ключами, которым доверяет сам узел;
+// ключи из архива в signing_keys не сохраняются и доверия не получают.
+func (s *blockchainService) ImportElection(ctx context.Context, a *models.ElectionArchive) (*models.ChainVerification, error) {
+	electionID := a.Election.ID
+	for _, c := range a.Choices {
+		if c.ElectionID != electionID {
+			return nil, fmt.Errorf("%w: вариант %d относится к другому голосованию", ErrInvalidArchive, c.ID)
+		}
+	}
+	for _, v := range a.Votes {
+		if v.ElectionID != electionID {
+			return nil, fmt.Errorf("%w: голос %s относится к другому голосованию", ErrInvalidArchive, v.VoteHash)
+		}
+	}
+
+	result := VerifyArchive(a, s.trustedKeys())
+	if !result.Valid {
+		return result, ErrArchiveChainInvalid
+	}
+
+	err := s.uow.DoInElection(ctx, electionID, func(repos *repositories.Repositories) error {
+		_, err := repos.Elections.GetByID(ctx, electionID)
+		if err == nil {
+			return ErrElectionExists
+		}
+		if !errors.Is(err, pgx.ErrNoRows) {
+			return err
+		}
+
+		if err := repos.Elections.Restore(ctx, a.Election); err != nil {
+			return err
+		}
+		if err := repos.Choices.RestoreChoices(ctx, a.Choices); err != nil {
+			return err
+		}
+		if err := repos.Blocks.RestoreBlocks(ctx, a.Blocks); err != nil {
+			return err
+		}
+		for _, v := range a.Votes {
+			if err := repos.Votes.Restore(ctx, v.Vote()); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// groupVotesByBlock — раскладывает запечатанные голоса по блокам в порядке листьев
+func groupVotesByBlock(votes []*models.Vote) map[int][]*models.Vote {
+	byBlock := make(map[int][]*models.Vote)
+	for _, v := range votes {
+		if v.BlockID != nil {
+			byBlock[*v.BlockID] = append(byBlock[*v.BlockID], v)
+		}
+	}
+	for _, list := range byBlock {
+		sort.Slice(list, func(i, j int) bool { return list[i].LeafIndex < list[j].LeafIndex })
+	}
+	return byBlock
+}
+
+// verifyBlocks — проверяет цепочку и возвращает вердикт по первому найденному нарушению.
+// anonymous — голоса без user_id (из архива): обязательства v1 без него не пересчитать.
+func verifyBlocks(
+	election *models.Election,
+	choices []*models.Choice,
+	blocks []*models.Block,
+	votesByBlock map[int][]*models.Vote,
+	keys map[string]ed25519.PublicKey,
+	anonymous bool,
+) *models.ChainVerification {
+	definitionHash := election.DefinitionHash(choices)
+	result := &models.ChainVerification{
+		ElectionID: election.ID,
+		Status:     models.ChainValid,
+		Valid:      true,
+	}
+
+	seen := make(map[string]bool)
+	inChain := make(map[int]bool, len(blocks))
+	prevHash := ""
+	canonical, signed := false, false
+
+	for i, b := range blocks {
+		result.BlocksChecked = i + 1
+
+		legacy := b.HashVersion == models.HashVersionLegacy
+		votes := votesByBlock[b.Index]
+
+		var status, reason string
+		switch {
+		case b.Height != i:
+			status, reason = models.ChainBroken, "block height does not match its position in the chain"
+		case legacy && (!b.LegacyAllowed(canonical) || !election.CreatedAt.Before(models.LegacyHashCutoff)):
+			status, reason = models.ChainBroken, "legacy hash version on a block created after canonical hashing"
+		case !legacy && b.CalculateHash() == "":
+			status, reason = models.ChainBroken, "unsupported hash version"
+		case !legacy && b.CalculateHash() != b.Hash:
+			status, reason = models.ChainBroken, "stored hash does not match recomputed block hash"
+		case b.PrevHash != prevHash:
+			status, reason = models.ChainBroken, "previous_hash does not match hash of the previous block"
+		case b.Signature == "" && !b.UnsignedAllowed(election.CreatedAt, signed):
+			status, reason = models.ChainBroken, "block is not signed"
+		case b.Signature != "" && keys[b.KeyID] == nil:
+			status, reason = models.ChainBroken, "block is signed with an untrusted key"
+		case b.Signature != "" && !b.VerifySignature(keys[b.KeyID]):
+			status, reason = models.ChainBroken, "block signature is invalid"
+		case b.Kind == models.BlockKindGenesis && i != 0:
+			status, reason = models.ChainBroken, "genesis block is not the first block of the chain"
+		case b.Kind == models.BlockKindGenesis && b.VoteHash != definitionHash:
+			status, reason = models.ChainBroken, "election title, description, creator or choices differ from the genesis block"
+		case b.Kind == models.BlockKindGenesis:
+			result.HasGenesis = true
+		case b.Kind == models.BlockKindAnchor && (signed || b.Signature == "" || b.VoteCount != 0):
+			status, reason = models.ChainBroken, "anchor block does not follow unsigned blocks"
+		case b.Kind == models.BlockKindAnchor:
+		case len(votes) == 0:
+			status, reason = models.ChainMissingVote, "block references votes that do not exist in this election"
+		case len(votes) != b.VoteCount || models.MerkleRoot(b.HashVersion, voteHashes(votes)) != b.VoteHash:
+			status, reason = models.ChainMissingVote, "votes sealed in the block do not match its Merkle root"
+		case anySeen(seen, votes):
+			status, reason = models.ChainBroken, "vote hash is chained more than once"
+		case !commitmentsMatch(votes, anonymous):
+			status, reason = models.ChainBroken, "stored vote contents do not match the committed vote hash"
+		}
+
+		if status != "" {
+			index, blockID := i, b.Index
+			result.Status = status
+			result.Valid = false
+			result.BrokenIndex = &index
+			result.BlockID = &blockID
+			result.Reason = reason
+			return result
+		}
+
+		// Хеш v1 считался от времени, которое не попало в БД, пересчитать его нельзя;
+		// такие блоки проверяются только по связям, времени создания и голосам
+		if legacy {
+			result.LegacyBlocks++
+		}
+		canonical = canonical || !legacy
+		if b.Signature == "" {
+			result.UnsignedBlocks++
+		} else {
+			signed = true
+		}
+
+		for _, v := range votes {
+			seen[v.VoteHash] = true
+		}
+		inChain[b.Index] = true
+		prevHash = b.Hash
+	}
+
+	// Неподписанные блоки, записанные до подписей, должен закрепить подписанный
+	// блок после них (см. AnchorUnsignedChains): иначе их можно заменить целиком
+	if result.UnsignedBlocks > 0 && !signed {
+		index, blockID := len(blocks)-1, blocks[len(blocks)-1].Index
+		result.Status = models.ChainBroken
+		result.Valid = false
+		result.BrokenIndex = &index
+		result.BlockID = &blockID
+		result.Reason = "unsigned blocks are not anchored by a signed block"
+		return result
+	}
+
+	// Голос, ссылающийся на блок вне цепочки, не сверен ни с одним корнем Merkle
+	orphan := -1
+	for blockID := range votesByBlock {
+		if !inChain[blockID] && (orphan < 0 || blockID < orphan) {
+			orphan = blockID
+		}
+	}
+	if orphan >= 0 {
+		result.Status = models.ChainMissingBlock
+		result.Valid = false
+		result.BlockID = &orphan
+		result.Reason = "votes reference a block that is not in the chain"
+		return result
+	}
+
+	// Вне черновика цепочка обязана начинаться с генезис-блока: без него голоса
+	// не связаны с определением голосования
+	if !result.HasGenesis && election.Status != models.ElectionDraft {
+		result.Status = models.ChainNoGenesis
+		result.Valid = false
+		result.Reason = "chain has no genesis block committing to the election definition"
+		if len(blocks) > 0 {
+			index, blockID := 0, blocks[0].Index
+			result.BrokenIndex = &index
+			result.BlockID = &blockID
+		}
+	}
+	return result
+}
+
+func voteHashes(votes []*models.Vote) []string {
+	hashes := make([]string, len(votes))
+	for i, v := range votes {
+		hashes[i] = v.VoteHash
+	}
+	return hashes
+}
+
+// commitmentsMatch — пересчитывает хеши голосов из сохранённых бюллетеня и соли
+// и проверяет, что вариант голоса совпадает с зафиксированным в бюллетене
+func commitmentsMatch(votes []*models.Vote, anonymous bool) bool {
+	for _, v := range votes {
+		if anonymous && v.HashVersion == models.VoteHashLegacy {
+			continue
+		}
+		if v.CalculateHash() != v.VoteHash {
+			return false
+		}
+		if v.HashVersion == models.VoteHashBallot {
+			ballot, err := v.BallotContents()
+			if err != nil || ballot.FirstChoice() != v.ChoiceID {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func anySeen(seen map[string]bool, votes []*models.Vote) bool {
+	for _, v := range votes {
+		if seen[v.VoteHash] {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,65 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"voting-blockchain/internal/voting/models"
+	"voting-blockchain/internal/voting/repositories"
+)
+
+// sealPending — запечатывает все ожидающие голоса голосования в один блок
+// с корнем Merkle их хешей. Должна вызываться под блокировкой голосования.
+// Если ожидающих голосов нет, возвращает nil.
+func sealPending(ctx context.Context, repos *repositories.Repositories, signer *BlockSigner, electionID int) (*models.Block, error) {
+	pending, err := repos.Votes.GetPending(ctx, electionID)
+	if err != nil {
+		return nil, err
+	}
+	return sealVotes(ctx, repos, signer, electionID, pending)
+}
+
+// sealIfDue — запечатывает ожидающие голоса, если набрался размер пачки
+// или истекло окно голосования
+func sealIfDue(ctx context.Context, repos *repositories.Repositories, signer *BlockSigner, election *models.Election) (*models.Block, error) {
+	pending, err := repos.Votes.GetPending(ctx, election.ID)
+	if err != nil {
+		return nil, err
+	}
+	if !election.BatchDue(pending, time.Now()) {
+		return nil, nil
+	}
+	return sealVotes(ctx, repos, signer, election.ID, pending)
+}
+
+func sealVotes(ctx context.Context, repos *repositories.Repositories, signer *BlockSigner, electionID int, votes []*models.Vote) (*models.Block, error) {
+	if len(votes) == 0 {
+		return nil, nil
+	}
+
+	lastBlock, err := repos.Blocks.GetLastBlock(ctx, electionID)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return nil, err
+	}
+
+	leaves := make([]string, len(votes))
+	ids := make([]int, len(votes))
+	for i, v := range votes {
+		leaves[i] = v.VoteHash
+		ids[i] = v.ID
+	}
+
+	block := models.NewBlock(electionID, lastBlock, models.MerkleRoot(models.CurrentHashVersion, leaves), time.Now())
+	block.VoteCount = len(votes)
+	signer.Sign(block)
+
+	if err := repos.Blocks.AddBlock(ctx, block); err != nil {
+		return nil, err
+	}
+	if err := repos.Votes.AttachToBlock(ctx, block.Index, ids); err != nil {
+		return nil, err
+	}
+	return block, nil
+}
@@ -0,0 +1,194 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"voting-blockchain/internal/voting/models"
+	"voting-blockchain/internal/voting/repositories"
+)
+
+// ChoiceService — управление вариантами голосования. Варианты входят в
+// генезис-блок, поэтому меняются только пока голосование в черновике.
+type ChoiceService interface {
+	List(ctx context.Context, electionID int) ([]*models.Choice, error)
+	Add(ctx context.Context, c *models.Choice) error
+	Update(ctx context.Context, c *models.Choice) error
+	Reorder(ctx context.Context, electionID int, ids []int) ([]*models.Choice, error)
+	Delete(ctx context.Context, electionID, choiceID int) error
+}
+
+type choiceService struct {
+	electionRepo repositories.ElectionRepository
+	choiceRepo   repositories.ChoiceRepository
+	uow          repositories.UnitOfWork
+}
+
+func NewChoiceService(
+	electionRepo repositories.ElectionRepository,
+	choiceRepo repositories.ChoiceRepository,
+	uow repositories.UnitOfWork,
+) ChoiceService {
+	return &choiceService{
+		electionRepo: electionRepo,
+		choiceRepo:   choiceRepo,
+		uow:          uow,
+	}
+}
+
+// List — варианты голосования в порядке бюллетеня
+func (s *choiceService) List(ctx context.Context, electionID int) ([]*models.Choice, error) {
+	if _, err := s.electionRepo.GetByID(ctx, electionID); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrElectionNotFound
+		}
+		return nil, err
+	}
+	return s.choiceRepo.GetChoices(ctx, electionID)
+}
+
+// Add — добавляет вариант в конец бюллетеня; в голосовании с вопросами
+// c.ContestID должен указывать на один из них. В c возвращаются id и позиция.
+func (s *choiceService) Add(ctx context.Context, c *models.Choice) error {
+	return s.inDraft(ctx, c.ElectionID, func(repos *repositories.Repositories, election *models.Election) error {
+		if !hasContest(election, c.ContestID) {
+			return fmt.Errorf("%w: вопроса %d нет в голосовании", ErrInvalidChoiceData, c.ContestID)
+		}
+		if err := validateChoice(ctx, repos, c); err != nil {
+			return err
+		}
+		return repos.Choices.AddChoice(ctx, c)
+	})
+}
+
+// Update — меняет текст, описание и изображение варианта; позиция задаётся
+// только через Reorder, а вопрос варианта не меняется. В c возвращается
+// сохранённый вариант.
+func (s *choiceService) Update(ctx context.Context, c *models.Choice) error {
+	return s.inDraft(ctx, c.ElectionID, func(repos *repositories.Repositories, _ *models.Election) error {
+		existing, err := repos.Choices.GetChoice(ctx, c.ElectionID, c.ID)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrChoiceNotFound
+		}
+		if err != nil {
+			return err
+		}
+		c.ContestID = existing.ContestID
+		if err := validateChoice(ctx, repos, c); err != nil {
+			return err
+		}
+		if err := repos.Choices.UpdateChoice(ctx, c); err != nil {
+			return err
+		}
+		c.Position = existing.Position
+		return nil
+	})
+}
+
+// Reorder — задаёт порядок вариантов в бюллетене. ids должен перечислять
+// каждый вариант голосования ровно один раз.
+func (s *choiceService) Reorder(ctx context.Context, electionID int, ids []int) ([]*models.Choice, error) {
+	var res []*models.Choice
+	err := s.inDraft(ctx, electionID, func(repos *repositories.Repositories, _ *models.Election) error {
+		choices, err := repos.Choices.GetChoices(ctx, electionID)
+		if err != nil {
+			return err
+		}
+		if len(ids) != len(choices) {
+			return fmt.Errorf("%w: нужно перечислить все %d вариантов", ErrInvalidChoiceData, len(choices))
+		}
+		known := make(map[int]bool, len(choices))
+		for _, c := range choices {
+			known[c.ID] = true
+		}
+		for _, id := range ids {
+			if !known[id] {
+				return fmt.Errorf("%w: вариант %d отсутствует или повторяется", ErrInvalidChoiceData, id)
+			}
+			delete(known, id)
+		}
+
+		if err := repos.Choices.ReorderChoices(ctx, electionID, ids); err != nil {
+			return err
+		}
+		res, err = repos.Choices.GetChoices(ctx, electionID)
+		return err
+	})
+	return res, err
+}
+
+// Delete — удаляет вариант; позиции остальных не сдвигаются, порядок сохраняется
+func (s *choiceService) Delete(ctx context.Context, electionID, choiceID int) error {
+	return s.inDraft(ctx, electionID, func(repos *repositories.Repositories, _ *models.Election) error {
+		err := repos.Choices.DeleteChoice(ctx, electionID, choiceID)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrChoiceNotFound
+		}
+		return err
+	})
+}
+
+// inDraft — выполняет fn под блокировкой голосования, если оно ещё в черновике
+func (s *choiceService) inDraft(
+	ctx context.Context,
+	electionID int,
+	fn func(repos *repositories.Repositories, election *models.Election) error,
+) error {
+	return s.uow.DoInElection(ctx, electionID, func(repos *repositories.Repositories) error {
+		election, err := repos.Elections.GetByID(ctx, electionID)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrElectionNotFound
+		}
+		if err != nil {
+			return err
+		}
+		if election.Status != models.ElectionDraft {
+			return ErrChoicesLocked
+		}
+		return fn(repos, election)
+	})
+}
+
+// hasContest — подходит ли вопрос contestID голосованию: у голосования без
+// вопросов варианты без вопроса, у голосования с вопросами — один из них
+func hasContest(e *models.Election, contestID int) bool {
+	if len(e.Contests) == 0 {
+		return contestID == 0
+	}
+	for _, q := range e.Contests {
+		if q.ID == contestID {
+			return true
+		}
+	}
+	return false
+}
+
+// validateChoice — текст обязателен и уникален в вопросе (по нему
+// сопоставляются голоса в старом формате), изображение — ссылка http(s)
+func validateChoice(ctx context.Context, repos *repositories.Repositories, c *models.Choice) error {
+	c.Text = strings.TrimSpace(c.Text)
+	if c.Text == "" {
+		return fmt.Errorf("%w: текст варианта не может быть пустым", ErrInvalidChoiceData)
+	}
+	if c.ImageURL != "" {
+		u, err := url.Parse(c.ImageURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("%w: image_url должен быть ссылкой http(s)", ErrInvalidChoiceData)
+		}
+	}
+
+	choices, err := repos.Choices.GetChoices(ctx, c.ElectionID)
+	if err != nil {
+		return err
+	}
+	for _, other := range choices {
+		if other.ID != c.ID && other.ContestID == c.ContestID && other.Text == c.Text {
+			return fmt.Errorf("%w: вариант %q уже есть", ErrInvalidChoiceData, c.Text)
+		}
+	}
+	return nil
+}
@@ -1,56 +1,408 @@
-package services
-
-import (
-	"context"
-
-	"voting-blockchain/internal/voting/models"
-	"voting-blockchain/internal/voting/repositories"
-)
-
-type ElectionService interface {
-	Create(ctx context.Context, e *models.Election) error
-	GetByID(ctx context.Context, id int) (*models.Election, error)
-	List(ctx context.Context) ([]*models.Election, error)
-	Update(ctx context.Context, e *models.Election) error
-	Delete(ctx context.Context, id int) error
-	CreateChoices(ctx context.Context, electionID int, choices []string) error
-}
-
-type electionService struct {
-	electionRepo repositories.ElectionRepository
-	choiceRepo   repositories.ChoiceRepository
-}
-
-func NewElectionService(
-	electionRepo repositories.ElectionRepository,
-	choiceRepo repositories.ChoiceRepository,
-) ElectionService {
-	return &electionService{
-		electionRepo: electionRepo,
-		choiceRepo:   choiceRepo,
-	}
-}
-
-func (s *electionService) Create(ctx context.Context, e *models.Election) error {
-	return s.electionRepo.Create(ctx, e)
-}
-
-func (s *electionService) GetByID(ctx context.Context, id int) (*models.Election, error) {
-	return s.electionRepo.GetByID(ctx, id)
-}
-
-func (s *electionService) List(ctx context.Context) ([]*models.Election, error) {
-	return s.electionRepo.List(ctx)
-}
-
-func (s *electionService) Update(ctx context.Context, e *models.Election) error {
-	return s.electionRepo.Update(ctx, e)
-}
-
-func (s *electionService) Delete(ctx context.Context, id int) error {
-	return s.electionRepo.Delete(ctx, id)
-}
-
-func (s *electionService) CreateChoices(ctx context.Context, electionID int, choices []string) error {
-	return s.choiceRepo.CreateChoices(ctx, electionID, choices)
-}
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"voting-blockchain/internal/voting/models"
+	"voting-blockchain/internal/voting/repositories"
+)
+
+type ElectionService interface {
+	Create(ctx context.Context, e *models.Election, choices []string) error
+	GetByID(ctx context.Context, id int) (*models.Election, error)
+	List(ctx context.Context) ([]*models.Election, error)
+	Update(ctx context.Context, e *models.Election, set UpdateFields, actorID int) error
+	GetTransitions(ctx context.Context, id int) ([]*models.ElectionTransition, error)
+	EncryptionKey(ctx context.Context, id int) (*models.ElectionPublicKey, error)
+	Delete(ctx context.Context, id int) error
+}
+
+// UpdateFields — поля Update, переданные явно. Пустое окно голосования и
+// запрет вписанных вариантов в models.Election не отличить от «не передано»,
+// поэтому nil и false в этих полях применяются, только если они отмечены здесь.
+type UpdateFields struct {
+	OpensAt       bool
+	ClosesAt      bool
+	AllowWriteIns bool
+}
+
+type electionService struct {
+	electionRepo repositories.ElectionRepository
+	choiceRepo   repositories.ChoiceRepository
+	uow          repositories.UnitOfWork
+	signer       *BlockSigner
+}
+
+func NewElectionService(
+	electionRepo repositories.ElectionRepository,
+	choiceRepo repositories.ChoiceRepository,
+	uow repositories.UnitOfWork,
+	signer *BlockSigner,
+) ElectionService {
+	return &electionService{
+		electionRepo: electionRepo,
+		choiceRepo:   choiceRepo,
+		uow:          uow,
+		signer:       signer,
+	}
+}
+
+// Create — создаёт голосование с вариантами в черновике. Голосование с
+// вопросами (e.Contests) получает варианты из Contest.Choices, а choices
+// должен быть пуст. Если e.Status задан (scheduled или open), голосование
+// сразу переводится в него, и в той же транзакции пишется генезис-блок,
+// фиксирующий его определение.
+func (s *electionService) Create(ctx context.Context, e *models.Election, choices []string) error {
+	// По умолчанию каждый голос запечатывается в собственный блок
+	if e.BatchSize < 1 {
+		e.BatchSize = 1
+	}
+	if err := validateSchedule(e); err != nil {
+		return err
+	}
+	if err := validateVotingMethod(e); err != nil {
+		return err
+	}
+	if err := validateEligibility(e); err != nil {
+		return err
+	}
+	if err := validateAnonymity(e); err != nil {
+		return err
+	}
+	if err := validateEncryption(e); err != nil {
+		return err
+	}
+	if err := normalizeChoiceTexts(choices); err != nil {
+		return err
+	}
+	if len(e.Contests) > 0 && len(choices) > 0 {
+		return fmt.Errorf("%w: у голосования с вопросами варианты задаются в вопросах", ErrInvalidContest)
+	}
+	if len(e.Contests) > 0 && e.AllowWriteIns {
+		return fmt.Errorf("%w: вписанные варианты разрешаются в вопросах", ErrInvalidContest)
+	}
+	for _, q := range e.Contests {
+		if q.Title = strings.TrimSpace(q.Title); q.Title == "" {
+			return fmt.Errorf("%w: у вопроса нет названия", ErrInvalidContest)
+		}
+		if err := validateContestMethod(q); err != nil {
+			return err
+		}
+		if err := normalizeChoiceTexts(q.Choices); err != nil {
+			return err
+		}
+	}
+
+	// Ключ голосования с порогом создают доверенные лица, см. TrusteeService
+	var secret string
+	if e.Encrypted() && !e.TrusteeDecryption() {
+		var err error
+		if secret, err = newEncryptionKey(e); err != nil {
+			return err
+		}
+	}
+
+	target := e.Status
+	e.Status = models.ElectionDraft
+	e.IsActive = false
+
+	return s.uow.Do(ctx, func(repos *repositories.Repositories) error {
+		if err := repos.Elections.Create(ctx, e); err != nil {
+			return err
+		}
+		if secret != "" {
+			if err := repos.Secrets.Save(ctx, e.ID, secret); err != nil {
+				return err
+			}
+		}
+
+		if len(choices) > 0 {
+			if err := repos.Choices.CreateChoices(ctx, e.ID, choices); err != nil {
+				return err
+			}
+		}
+		for _, q := range e.Contests {
+			for _, text := range q.Choices {
+				if err := repos.Choices.AddChoice(ctx, &models.Choice{ElectionID: e.ID, ContestID: q.ID, Text: text}); err != nil {
+					return err
+				}
+			}
+		}
+
+		if target == "" || target == models.ElectionDraft {
+			return nil
+		}
+		return transitionElection(ctx, repos, s.signer, e, target, &e.CreatedBy)
+	})
+}
+
+func (s *electionService) GetByID(ctx context.Context, id int) (*models.Election, error) {
+	return s.electionRepo.GetByID(ctx, id)
+}
+
+func (s *electionService) List(ctx context.Context) ([]*models.Election, error) {
+	return s.electionRepo.List(ctx)
+}
+
+// Update — обновляет голосование и, если e.Status отличается от текущего,
+// выполняет переход состояния. Название, описание, способ голосования, режимы
+// допуска, анонимности и шифрования входят в генезис-блок и меняются только в
+// черновике; окно голосования — только до его открытия. Пустые поля e не
+// меняются, кроме отмеченных в set. В e возвращается сохранённое голосование.
+func (s *electionService) Update(ctx context.Context, e *models.Election, set UpdateFields, actorID int) error {
+	return s.uow.DoInElection(ctx, e.ID, func(repos *repositories.Repositories) error {
+		existing, err := repos.Elections.GetByID(ctx, e.ID)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrElectionNotFound
+		}
+		if err != nil {
+			return err
+		}
+
+		// Не переданные поля не меняются: PUT {"status": "closed"} — только переход
+		if e.Title == "" {
+			e.Title = existing.Title
+		}
+		if e.Description == "" {
+			e.Description = existing.Description
+		}
+		if e.OpensAt == nil && !set.OpensAt {
+			e.OpensAt = existing.OpensAt
+		}
+		if e.ClosesAt == nil && !set.ClosesAt {
+			e.ClosesAt = existing.ClosesAt
+		}
+		if e.VotingMethod == "" {
+			e.VotingMethod = existing.Method()
+			if e.MaxScore == 0 {
+				e.MaxScore = existing.MaxScore
+			}
+			if e.Seats == 0 {
+				e.Seats = existing.Seats
+			}
+		}
+		if !e.AllowWriteIns && !set.AllowWriteIns {
+			e.AllowWriteIns = existing.AllowWriteIns
+		}
+		if err := validateVotingMethod(e); err != nil {
+			return err
+		}
+		if e.Eligibility == "" {
+			e.Eligibility = existing.EligibilityMode()
+		}
+		if err := validateEligibility(e); err != nil {
+			return err
+		}
+		if e.Anonymity == "" {
+			e.Anonymity = existing.AnonymityMode()
+		}
+		if err := validateAnonymity(e); err != nil {
+			return err
+		}
+		if e.Encryption == "" {
+			e.Encryption = existing.EncryptionMode()
+		}
+		if e.TrusteeThreshold == 0 {
+			e.TrusteeThreshold = existing.TrusteeThreshold
+		}
+		if err := validateEncryption(e); err != nil {
+			return err
+		}
+		if existing.Status != models.ElectionDraft &&
+			(existing.Title != e.Title || existing.Description != e.Description ||
+				existing.Method() != e.VotingMethod || existing.MaxScore != e.MaxScore || existing.Seats != e.Seats ||
+				existing.AllowWriteIns != e.AllowWriteIns || existing.EligibilityMode() != e.Eligibility ||
+				existing.AnonymityMode() != e.Anonymity || existing.EncryptionMode() != e.Encryption ||
+				existing.TrusteeThreshold != e.TrusteeThreshold) {
+			return ErrDefinitionLocked
+		}
+		if existing.Status != models.ElectionDraft && existing.Status != models.ElectionScheduled &&
+			(!sameTime(existing.OpensAt, e.OpensAt) || !sameTime(existing.ClosesAt, e.ClosesAt)) {
+			return ErrScheduleLocked
+		}
+
+		existing.Title = e.Title
+		existing.Description = e.Description
+		existing.VotingMethod = e.VotingMethod
+		existing.MaxScore = e.MaxScore
+		existing.Seats = e.Seats
+		existing.AllowWriteIns = e.AllowWriteIns
+		existing.Eligibility = e.Eligibility
+		existing.Anonymity = e.Anonymity
+		if existing.EncryptionMode() != e.Encryption || existing.TrusteeThreshold != e.TrusteeThreshold {
+			existing.Encryption = e.Encryption
+			existing.TrusteeThreshold = e.TrusteeThreshold
+			if err := setupEncryptionKey(ctx, repos, existing); err != nil {
+				return err
+			}
+		}
+		existing.OpensAt = e.OpensAt
+		existing.ClosesAt = e.ClosesAt
+		if err := validateSchedule(existing); err != nil {
+			return err
+		}
+
+		if e.Status != "" && e.Status != existing.Status {
+			err = transitionElection(ctx, repos, s.signer, existing, e.Status, &actorID)
+		} else {
+			err = repos.Elections.Update(ctx, existing)
+		}
+		if err != nil {
+			return err
+		}
+		*e = *existing
+		return nil
+	})
+}
+
+// GetTransitions — история смены состояний голосования
+func (s *electionService) GetTransitions(ctx context.Context, id int) ([]*models.ElectionTransition, error) {
+	return s.electionRepo.ListTransitions(ctx, id)
+}
+
+// EncryptionKey — открытый ключ шифрования бюллетеней голосования с группой
+// и диапазоном значений, а в режиме threshold — с открытыми частями долей
+// доверенных лиц. Пока церемония не завершена, ключа нет.
+func (s *electionService) EncryptionKey(ctx context.Context, id int) (*models.ElectionPublicKey, error) {
+	var key *models.ElectionPublicKey
+	err := s.uow.Do(ctx, func(repos *repositories.Repositories) error {
+		e, err := getElection(ctx, repos, id)
+		if err != nil {
+			return err
+		}
+		if !e.Encrypted() {
+			return ErrNotEncrypted
+		}
+		if e.EncryptionKey == "" {
+			return ErrCeremonyPending
+		}
+		key = ElectionPublicKeyFor(e)
+		if !e.TrusteeDecryption() {
+			return nil
+		}
+		trustees, err := repos.Trustees.List(ctx, id)
+		if err != nil {
+			return err
+		}
+		key.Threshold = e.TrusteeThreshold
+		key.Trustees, err = verificationKeys(trustees)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+func (s *electionService) Delete(ctx context.Context, id int) error {
+	return s.electionRepo.Delete(ctx, id)
+}
+
+// validateSchedule — приводит окно голосования к UTC (opens_at и closes_at
+// хранятся без часового пояса) и проверяет, что оно не заканчивается раньше,
+// чем началось
+func validateSchedule(e *models.Election) error {
+	e.OpensAt, e.ClosesAt = utcTime(e.OpensAt), utcTime(e.ClosesAt)
+	if e.OpensAt != nil && e.ClosesAt != nil && !e.ClosesAt.After(*e.OpensAt) {
+		return fmt.Errorf("%w: окончание должно быть позже начала", ErrInvalidSchedule)
+	}
+	return nil
+}
+
+// validateVotingMethod — способ голосования известен (пустой — plurality),
+// наибольшая оценка задаётся только для score (по умолчанию DefaultMaxScore),
+// число мест — только для stv (по умолчанию одно), а вписанные варианты
+// разрешаются только в plurality
+func validateVotingMethod(e *models.Election) error {
+	q := e.DefaultContest()
+	if err := validateContestMethod(q); err != nil {
+		return err
+	}
+	e.VotingMethod, e.MaxScore, e.Seats = q.VotingMethod, q.MaxScore, q.Seats
+	return nil
+}
+
+// validateEligibility — режим допуска известен; пустой — all
+func validateEligibility(e *models.Election) error {
+	e.Eligibility = e.EligibilityMode()
+	if !models.IsEligibility(e.Eligibility) {
+		return ErrInvalidEligibility
+	}
+	return nil
+}
+
+// validateAnonymity — режим анонимности известен; пустой — none
+func validateAnonymity(e *models.Election) error {
+	e.Anonymity = e.AnonymityMode()
+	if !models.IsAnonymity(e.Anonymity) {
+		return ErrInvalidAnonymity
+	}
+	return nil
+}
+
+// validateContestMethod — то же для вопроса голосования
+func validateContestMethod(q *models.Contest) error {
+	if q.VotingMethod == "" {
+		q.VotingMethod = models.VotingPlurality
+	}
+	if !models.IsVotingMethod(q.VotingMethod) {
+		return ErrInvalidVotingMethod
+	}
+	if q.AllowWriteIns && q.VotingMethod != models.VotingPlurality {
+		return fmt.Errorf("%w: вписанные варианты возможны только в plurality", ErrInvalidVotingMethod)
+	}
+	if q.VotingMethod != models.VotingSTV {
+		q.Seats = 0
+	} else if q.Seats == 0 {
+		q.Seats = 1
+	} else if q.Seats < 0 {
+		return fmt.Errorf("%w: seats должно быть положительным", ErrInvalidVotingMethod)
+	}
+	if q.VotingMethod != models.VotingScore {
+		q.MaxScore = 0
+		return nil
+	}
+	if q.MaxScore == 0 {
+		q.MaxScore = models.DefaultMaxScore
+	}
+	if q.MaxScore < 1 || q.MaxScore > 100 {
+		return fmt.Errorf("%w: max_score должен быть от 1 до 100", ErrInvalidVotingMethod)
+	}
+	return nil
+}
+
+// normalizeChoiceTexts — тексты вариантов непустые и различные; пробелы по
+// краям отбрасываются
+func normalizeChoiceTexts(texts []string) error {
+	seen := make(map[string]bool, len(texts))
+	for i, text := range texts {
+		text = strings.TrimSpace(text)
+		if text == "" || seen[text] {
+			return fmt.Errorf("%w: варианты должны быть непустыми и различными", ErrInvalidChoiceData)
+		}
+		seen[text] = true
+		texts[i] = text
+	}
+	return nil
+}
+
+func sameTime(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Equal(*b)
+}
+
+// utcTime — t в UTC; nil остаётся nil
+func utcTime(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	u := t.UTC()
+	return &u
+}
@@ -0,0 +1,395 @@
+package services
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+
+	"voting-blockchain/internal/voting/models"
+)
+
+// Экспоненциальный ElGamal над 2048-битной MODP-группой 14 из RFC 3526:
+// p = 2q+1 — безопасное простое, g = 2 порождает подгруппу порядка q.
+// Значение m шифруется как (g^r, g^m·h^r), поэтому произведение шифртекстов
+// шифрует сумму значений. Расшифровывается только сумма: g^sum = B / A^x, а
+// sum находится алгоритмом «шаг младенца — шаг великана», она не больше числа
+// бюллетеней, умноженного на наибольшее значение.
+//
+// Что значение допустимо (0/1 или оценка), доказывается дизъюнктивным
+// доказательством Чаума–Педерсена, а верность расшифровки суммы —
+// доказательством равенства логарифмов. Вызовы получаются хешем
+// (эвристика Фиата–Шамира) и привязаны к голосованию.
+var (
+	elgamalP = mustBigHex("" +
+		"FFFFFFFFFFFFFFFFC90FDAA22168C234C4C6628B80DC1CD129024E088A67CC74" +
+		"020BBEA63B139B22514A08798E3404DDEF9519B3CD3A431B302B0A6DF25F1437" +
+		"4FE1356D6D51C245E485B576625E7EC6F44C42E9A637ED6B0BFF5CB6F406B7ED" +
+		"EE386BFB5A899FA5AE9F24117C4B1FE649286651ECE45B3DC2007CB8A163BF05" +
+		"98DA48361C55D39A69163FA8FD24CF5F83655D23DCA3AD961C62F356208552BB" +
+		"9ED529077096966D670C354E4ABC9804F1746C08CA18217C32905E462E36CE3B" +
+		"E39E772C180E86039B2783A2EC07A28FB5C55DF06F4C52C9DE2BCBF695581718" +
+		"3995497CEA956AE515D2261898FA051015728E5A8AACAA68FFFFFFFFFFFFFFFF")
+	elgamalQ = new(big.Int).Rsh(elgamalP, 1)
+	elgamalG = big.NewInt(2)
+)
+
+// elementBytes — длина элемента группы в кодировке для хеша
+const elementBytes = 256
+
+func mustBigHex(s string) *big.Int {
+	n, ok := new(big.Int).SetString(s, 16)
+	if !ok {
+		panic("некорректная константа группы ElGamal")
+	}
+	return n
+}
+
+func bigHex(n *big.Int) string {
+	return hex.EncodeToString(n.Bytes())
+}
+
+// parseScalar — число из hex в [0, q)
+func parseScalar(s string) (*big.Int, error) {
+	raw, err := hex.DecodeString(s)
+	if err != nil || len(raw) == 0 {
+		return nil, errors.New("некорректное число")
+	}
+	n := new(big.Int).SetBytes(raw)
+	if n.Cmp(elgamalQ) >= 0 {
+		return nil, errors.New("число вне группы")
+	}
+	return n, nil
+}
+
+// parseElement — элемент подгруппы порядка q из hex: 1 < n < p и n^q = 1.
+// Проверка принадлежности подгруппе закрывает атаки малыми подгруппами.
+func parseElement(s string) (*big.Int, error) {
+	raw, err := hex.DecodeString(s)
+	if err != nil || len(raw) == 0 {
+		return nil, errors.New("некорректный элемент группы")
+	}
+	n := new(big.Int).SetBytes(raw)
+	if n.Cmp(big.NewInt(1)) <= 0 || n.Cmp(elgamalP) >= 0 || new(big.Int).Exp(n, elgamalQ, elgamalP).Cmp(big.NewInt(1)) != 0 {
+		return nil, errors.New("элемент вне группы")
+	}
+	return n, nil
+}
+
+// randomScalar — случайное число в [1, q)
+func randomScalar() (*big.Int, error) {
+	for {
+		n, err := rand.Int(rand.Reader, elgamalQ)
+		if err != nil {
+			return nil, err
+		}
+		if n.Sign() > 0 {
+			return n, nil
+		}
+	}
+}
+
+func modExp(base, exp *big.Int) *big.Int {
+	return new(big.Int).Exp(base, exp, elgamalP)
+}
+
+func modMul(a, b *big.Int) *big.Int {
+	n := new(big.Int).Mul(a, b)
+	return n.Mod(n, elgamalP)
+}
+
+func modInv(a *big.Int) *big.Int {
+	return new(big.Int).ModInverse(a, elgamalP)
+}
+
+// gPow — g^m для небольшого целого m, в том числе отрицательного
+func gPow(m int) *big.Int {
+	e := big.NewInt(int64(m))
+	return modExp(elgamalG, e.Mod(e, elgamalQ))
+}
+
+// generateElGamalKey — закрытый ключ x и открытый h = g^x
+func generateElGamalKey() (x, h *big.Int, err error) {
+	if x, err = randomScalar(); err != nil {
+		return nil, nil, err
+	}
+	return x, modExp(elgamalG, x), nil
+}
+
+// fiatShamir — вызов доказательства: sha256 над доменом, голосованием и
+// элементами группы фиксированной длины, по модулю q
+func fiatShamir(electionID int, elements ...*big.Int) *big.Int {
+	h := sha256.New()
+	fmt.Fprintf(h, "vbc-elgamal\nelection:%d\n", electionID)
+	buf := make([]byte, elementBytes)
+	for _, e := range elements {
+		h.Write(e.FillBytes(buf))
+	}
+	c := new(big.Int).SetBytes(h.Sum(nil))
+	return c.Mod(c, elgamalQ)
+}
+
+// elgamalCiphertext — шифртекст (a, b) в числах
+type elgamalCiphertext struct {
+	a, b *big.Int
+}
+
+func (ct elgamalCiphertext) model() models.Ciphertext {
+	return models.Ciphertext{A: bigHex(ct.a), B: bigHex(ct.b)}
+}
+
+func parseCiphertext(c models.Ciphertext) (elgamalCiphertext, error) {
+	a, err := parseElement(c.A)
+	if err != nil {
+		return elgamalCiphertext{}, err
+	}
+	b, err := parseElement(c.B)
+	if err != nil {
+		return elgamalCiphertext{}, err
+	}
+	return elgamalCiphertext{a, b}, nil
+}
+
+// encryptValue — шифрует m на ключ h со случайностью r
+func encryptValue(h *big.Int, m int, r *big.Int) elgamalCiphertext {
+	return elgamalCiphertext{
+		a: modExp(elgamalG, r),
+		b: modMul(gPow(m), modExp(h, r)),
+	}
+}
+
+// multiplyCiphertexts — произведение шифртекстов, шифрующее сумму значений;
+// для пустого списка — шифртекст нуля (1, 1)
+func multiplyCiphertexts(cts []elgamalCiphertext) elgamalCiphertext {
+	sum := elgamalCiphertext{big.NewInt(1), big.NewInt(1)}
+	for _, ct := range cts {
+		sum = elgamalCiphertext{modMul(sum.a, ct.a), modMul(sum.b, ct.b)}
+	}
+	return sum
+}
+
+// proveValue — дизъюнктивное доказательство того, что ct = Enc(m, r) шифрует
+// одно из values (m среди них): по ветви на значение, ветви чужих значений
+// подделываются, а вызов настоящей дополняет их сумму до общего вызова
+func proveValue(electionID int, h *big.Int, ct elgamalCiphertext, m int, r *big.Int, values []int) ([]models.ProofPart, error) {
+	real := -1
+	for i, v := range values {
+		if v == m {
+			real = i
+		}
+	}
+	if real < 0 {
+		return nil, fmt.Errorf("%w: значение %d недопустимо", ErrInvalidBallot, m)
+	}
+
+	cs := make([]*big.Int, len(values))
+	zs := make([]*big.Int, len(values))
+	commitments := []*big.Int{h, ct.a, ct.b}
+	var w *big.Int
+	for i, v := range values {
+		var t1, t2 *big.Int
+		if i == real {
+			var err error
+			if w, err = randomScalar(); err != nil {
+				return nil, err
+			}
+			t1, t2 = modExp(elgamalG, w), modExp(h, w)
+		} else {
+			var err error
+			if cs[i], err = randomScalar(); err != nil {
+				return nil, err
+			}
+			if zs[i], err = randomScalar(); err != nil {
+				return nil, err
+			}
+			t1, t2 = valueCommitments(h, ct, v, cs[i], zs[i])
+		}
+		commitments = append(commitments, t1, t2)
+	}
+
+	c := fiatShamir(electionID, commitments...)
+	for i := range values {
+		if i != real {
+			c.Sub(c, cs[i])
+		}
+	}
+	cs[real] = c.Mod(c, elgamalQ)
+	z := new(big.Int).Mul(cs[real], r)
+	z.Add(z, w)
+	zs[real] = z.Mod(z, elgamalQ)
+
+	proof := make([]models.ProofPart, len(values))
+	for i := range values {
+		proof[i] = models.ProofPart{C: bigHex(cs[i]), Z: bigHex(zs[i])}
+	}
+	return proof, nil
+}
+
+// valueCommitments — обязательства ветви v, восстановленные из вызова и
+// ответа: g^z·a^-c и h^z·(b/g^v)^-c
+func valueCommitments(h *big.Int, ct elgamalCiphertext, v int, c, z *big.Int) (*big.Int, *big.Int) {
+	negC := new(big.Int).Sub(elgamalQ, c)
+	t1 := modMul(modExp(elgamalG, z), modExp(ct.a, negC))
+	bv := modMul(ct.b, modInv(gPow(v)))
+	t2 := modMul(modExp(h, z), modExp(bv, negC))
+	return t1, t2
+}
+
+// verifyValue — проверяет доказательство proveValue
+func verifyValue(electionID int, h *big.Int, ct elgamalCiphertext, values []int, proof []models.ProofPart) bool {
+	if len(proof) != len(values) {
+		return false
+	}
+	commitments := []*big.Int{h, ct.a, ct.b}
+	sum := new(big.Int)
+	for i, v := range values {
+		c, err := parseScalar(proof[i].C)
+		if err != nil {
+			return false
+		}
+		z, err := parseScalar(proof[i].Z)
+		if err != nil {
+			return false
+		}
+		t1, t2 := valueCommitments(h, ct, v, c, z)
+		commitments = append(commitments, t1, t2)
+		sum.Add(sum, c)
+	}
+	return sum.Mod(sum, elgamalQ).Cmp(fiatShamir(electionID, commitments...)) == 0
+}
+
+// proveDecryption — частичная расшифровка D = a^x и доказательство того, что
+// log_g h = log_a D (Чаум–Педерсен)
+func proveDecryption(electionID int, x, h, a *big.Int) (*big.Int, models.ProofPart, error) {
+	d := modExp(a, x)
+	w, err := randomScalar()
+	if err != nil {
+		return nil, models.ProofPart{}, err
+	}
+	c := fiatShamir(electionID, h, a, d, modExp(elgamalG, w), modExp(a, w))
+	z := new(big.Int).Mul(c, x)
+	z.Add(z, w)
+	return d, models.ProofPart{C: bigHex(c), Z: bigHex(z.Mod(z, elgamalQ))}, nil
+}
+
+// verifyDecryption — проверяет доказательство proveDecryption
+func verifyDecryption(electionID int, h, a, d *big.Int, proof models.ProofPart) bool {
+	c, err := parseScalar(proof.C)
+	if err != nil {
+		return false
+	}
+	z, err := parseScalar(proof.Z)
+	if err != nil {
+		return false
+	}
+	negC := new(big.Int).Sub(elgamalQ, c)
+	t1 := modMul(modExp(elgamalG, z), modExp(h, negC))
+	t2 := modMul(modExp(a, z), modExp(d, negC))
+	return c.Cmp(fiatShamir(electionID, h, a, d, t1, t2)) == 0
+}
+
+// discreteLog — m из g^m на отрезке [0, bound] алгоритмом «шаг младенца — шаг
+// великана»: O(√bound) умножений вместо перебора, который на больших
+// голосованиях занимал минуты под блокировкой подсчёта
+func discreteLog(gm *big.Int, bound int) (int, bool) {
+	if bound < 0 {
+		return 0, false
+	}
+	step := int(new(big.Int).Sqrt(big.NewInt(int64(bound))).Int64()) + 1
+
+	// Шаги младенца: g^j для j < step
+	baby := make(map[string]int, step)
+	acc := big.NewInt(1)
+	for j := 0; j < step; j++ {
+		if _, ok := baby[string(acc.Bytes())]; !ok {
+			baby[string(acc.Bytes())] = j
+		}
+		acc = modMul(acc, elgamalG)
+	}
+
+	// Шаги великана: gm·g^(-i·step), пока не попадём в таблицу
+	giant := modInv(acc)
+	gamma := new(big.Int).Set(gm)
+	for i := 0; i*step <= bound; i++ {
+		if j, ok := baby[string(gamma.Bytes())]; ok {
+			if m := i*step + j; m <= bound {
+				return m, true
+			}
+			return 0, false
+		}
+		gamma = modMul(gamma, giant)
+	}
+	return 0, false
+}
+
+// ElectionPublicKeyFor — открытый ключ шифрования бюллетеней голосования с группой
+func ElectionPublicKeyFor(e *models.Election) *models.ElectionPublicKey {
+	return &models.ElectionPublicKey{
+		ElectionID:   e.ID,
+		Encryption:   e.EncryptionMode(),
+		P:            bigHex(elgamalP),
+		Q:            bigHex(elgamalQ),
+		G:            bigHex(elgamalG),
+		H:            e.EncryptionKey,
+		VotingMethod: e.Method(),
+		MaxValue:     encryptedMaxValue(e),
+	}
+}
+
+// encryptedMaxValue — наибольшее значение варианта в зашифрованном бюллетене
+func encryptedMaxValue(e *models.Election) int {
+	if e.Method() == models.VotingScore {
+		return e.MaxScore
+	}
+	return 1
+}
+
+// valueRange — допустимые значения от 0 до max
+func valueRange(max int) []int {
+	values := make([]int, max+1)
+	for i := range values {
+		values[i] = i
+	}
+	return values
+}
+
+// EncryptBallot — шифрует бюллетень на стороне избирателя: values — значение
+// каждого варианта (1 — выбран или одобрен, оценка для score), choiceIDs —
+// все варианты голосования. Для plurality ровно одно значение равно 1.
+func EncryptBallot(key *models.ElectionPublicKey, choiceIDs []int, values map[int]int) (*models.EncryptedBallot, error) {
+	h, err := parseElement(key.H)
+	if err != nil {
+		return nil, err
+	}
+	ids := append([]int(nil), choiceIDs...)
+	sort.Ints(ids)
+
+	ballot := &models.EncryptedBallot{}
+	var cts []elgamalCiphertext
+	total, totalR := 0, new(big.Int)
+	for _, id := range ids {
+		r, err := randomScalar()
+		if err != nil {
+			return nil, err
+		}
+		ct := encryptValue(h, values[id], r)
+		proof, err := proveValue(key.ElectionID, h, ct, values[id], r, valueRange(key.MaxValue))
+		if err != nil {
+			return nil, err
+		}
+		ballot.Choices = append(ballot.Choices, models.EncryptedChoice{ChoiceID: id, Ciphertext: ct.model(), Proof: proof})
+		cts = append(cts, ct)
+		total += values[id]
+		totalR.Add(totalR, r)
+	}
+	if key.VotingMethod == models.VotingPlurality {
+		ballot.SumProof, err = proveValue(key.ElectionID, h, multiplyCiphertexts(cts), total, totalR.Mod(totalR, elgamalQ), []int{1})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return ballot, nil
+}
@@ -0,0 +1,424 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/jackc/pgx/v5"
+	"voting-blockchain/internal/voting/models"
+	"voting-blockchain/internal/voting/repositories"
+)
+
+// validateEncryption — режим шифрования известен (пустой — none), для
+// threshold задан порог доверенных лиц. Зашифровать можно только бюллетень
+// одного вопроса способом, итог которого — сумма значений вариантов:
+// plurality, approval или score, без вписанных вариантов.
+func validateEncryption(e *models.Election) error {
+	e.Encryption = e.EncryptionMode()
+	if !models.IsEncryption(e.Encryption) {
+		return ErrInvalidEncryption
+	}
+	if !e.TrusteeDecryption() {
+		e.TrusteeThreshold = 0
+	} else if e.TrusteeThreshold < 1 || e.TrusteeThreshold > MaxTrustees {
+		return fmt.Errorf("%w: trustee_threshold должен быть от 1 до %d", ErrInvalidEncryption, MaxTrustees)
+	}
+	if !e.Encrypted() {
+		e.EncryptionKey = ""
+		return nil
+	}
+	if len(e.Contests) > 0 || e.AllowWriteIns {
+		return fmt.Errorf("%w: шифруются только бюллетени без вопросов и вписанных вариантов", ErrInvalidEncryption)
+	}
+	switch e.Method() {
+	case models.VotingPlurality, models.VotingApproval, models.VotingScore:
+		return nil
+	}
+	return fmt.Errorf("%w: способ %s не подсчитывается по зашифрованным бюллетеням", ErrInvalidEncryption, e.Method())
+}
+
+// newEncryptionKey — новый ключ шифрования бюллетеней: открытый ключ
+// записывается в e.EncryptionKey, закрытый возвращается в hex для Secrets
+func newEncryptionKey(e *models.Election) (string, error) {
+	x, h, err := generateElGamalKey()
+	if err != nil {
+		return "", err
+	}
+	e.EncryptionKey = bigHex(h)
+	return bigHex(x), nil
+}
+
+// setupEncryptionKey — создаёт ключ шифрования бюллетеней голосования или
+// удаляет его, если голосование больше не шифруется. В режиме threshold ключ
+// создаётся церемонией заново: розданные доли отбрасываются.
+func setupEncryptionKey(ctx context.Context, repos *repositories.Repositories, e *models.Election) error {
+	if !e.Encrypted() || e.TrusteeDecryption() {
+		e.EncryptionKey = ""
+		if err := repos.Trustees.ResetDeals(ctx, e.ID); err != nil {
+			return err
+		}
+		return repos.Secrets.Delete(ctx, e.ID)
+	}
+	secret, err := newEncryptionKey(e)
+	if err != nil {
+		return err
+	}
+	return repos.Secrets.Save(ctx, e.ID, secret)
+}
+
+// normalizeEncryptedBallot — зашифрованный бюллетень: других полей нет, по
+// значению на каждый вариант голосования в порядке id, доказательства верны.
+// Возвращает пустой вариант: в голосе не сохраняется ничего открытого.
+func normalizeEncryptedBallot(e *models.Election, ballot *models.Ballot, choices []*models.Choice) (*models.Choice, error) {
+	if ballot == nil || ballot.IsEmpty() {
+		return nil, ErrChoiceRequired
+	}
+	if ballot.Encrypted == nil || otherFields(ballot, "encrypted") {
+		return nil, fmt.Errorf("%w: голосование принимает только зашифрованные бюллетени", ErrInvalidBallot)
+	}
+	if _, err := verifyEncryptedBallot(e, ballot.Encrypted, choices); err != nil {
+		return nil, err
+	}
+	return &models.Choice{ElectionID: e.ID}, nil
+}
+
+// rejectReplayedBallot — отклоняет бюллетень, шифртексты которого уже есть среди
+// поданных: скопировав чужой бюллетень вместе с доказательствами, избиратель
+// повторил бы чужой выбор, не зная его. Сравниваются A = g^r — у честно
+// зашифрованных бюллетеней они не повторяются.
+func rejectReplayedBallot(ctx context.Context, repos *repositories.Repositories, electionID int, b *models.EncryptedBallot) error {
+	fresh := make(map[string]bool, len(b.Choices))
+	for _, c := range b.Choices {
+		ct, err := parseCiphertext(c.Ciphertext)
+		if err != nil {
+			return fmt.Errorf("%w: вариант %d: %v", ErrInvalidBallot, c.ChoiceID, err)
+		}
+		fresh[bigHex(ct.a)] = true
+	}
+	if len(fresh) != len(b.Choices) {
+		return fmt.Errorf("%w: шифртексты вариантов повторяются", ErrInvalidBallot)
+	}
+
+	votes, err := repos.Votes.GetByElectionID(ctx, electionID)
+	if err != nil {
+		return err
+	}
+	for _, v := range votes {
+		cast, err := v.BallotContents()
+		if err != nil || cast.Encrypted == nil {
+			continue
+		}
+		for _, c := range cast.Encrypted.Choices {
+			ct, err := parseCiphertext(c.Ciphertext)
+			if err == nil && fresh[bigHex(ct.a)] {
+				return fmt.Errorf("%w: шифртекст повторяет уже поданный бюллетень", ErrInvalidBallot)
+			}
+		}
+	}
+	return nil
+}
+
+// verifyEncryptedBallot — проверяет зашифрованный бюллетень и возвращает его
+// шифртексты в порядке вариантов
+func verifyEncryptedBallot(e *models.Election, b *models.EncryptedBallot, choices []*models.Choice) ([]elgamalCiphertext, error) {
+	h, err := parseElement(e.EncryptionKey)
+	if err != nil {
+		return nil, fmt.Errorf("%w: у голосования нет ключа шифрования", ErrNotEncrypted)
+	}
+	if len(b.Choices) != len(choices) {
+		return nil, fmt.Errorf("%w: нужно по шифртексту на каждый вариант", ErrInvalidBallot)
+	}
+	ids := make(map[int]bool, len(choices))
+	for _, c := range choices {
+		ids[c.ID] = true
+	}
+
+	values := valueRange(encryptedMaxValue(e))
+	cts := make([]elgamalCiphertext, len(b.Choices))
+	for i, c := range b.Choices {
+		if !ids[c.ChoiceID] {
+			return nil, ErrInvalidChoice
+		}
+		if i > 0 && b.Choices[i-1].ChoiceID >= c.ChoiceID {
+			return nil, fmt.Errorf("%w: варианты должны идти по возрастанию id", ErrInvalidBallot)
+		}
+		ct, err := parseCiphertext(c.Ciphertext)
+		if err != nil {
+			return nil, fmt.Errorf("%w: вариант %d: %v", ErrInvalidBallot, c.ChoiceID, err)
+		}
+		if !verifyValue(e.ID, h, ct, values, c.Proof) {
+			return nil, fmt.Errorf("%w: неверное доказательство варианта %d", ErrInvalidBallot, c.ChoiceID)
+		}
+		cts[i] = ct
+	}
+
+	if e.Method() == models.VotingPlurality {
+		if !verifyValue(e.ID, h, multiplyCiphertexts(cts), []int{1}, b.SumProof) {
+			return nil, fmt.Errorf("%w: неверное доказательство единственного выбора", ErrInvalidBallot)
+		}
+	} else if len(b.SumProof) > 0 {
+		return nil, ErrInvalidBallot
+	}
+	return cts, nil
+}
+
+// TallyEncrypted — итоги голосования с зашифрованными бюллетенями без
+// расшифровки: шифртексты запечатанных голосов перемножаются по вариантам,
+// бюллетени с неверными доказательствами отклоняются. Число голосов за
+// варианты становится известно только после decryptTally.
+func TallyEncrypted(e *models.Election, choices []*models.Choice, votes []*models.Vote) *models.ElectionResults {
+	results, _ := newResults(e.ID, e.Method(), choices)
+	sums := make([][]elgamalCiphertext, len(choices))
+	for _, vote := range votes {
+		if vote.BlockID == nil {
+			continue
+		}
+		ballot, err := vote.BallotContents()
+		if err != nil || ballot.Encrypted == nil {
+			results.Rejected++
+			continue
+		}
+		cts, err := verifyEncryptedBallot(e, ballot.Encrypted, choices)
+		if err != nil {
+			results.Rejected++
+			continue
+		}
+		for i, ct := range cts {
+			sums[i] = append(sums[i], ct)
+		}
+		results.TotalVotes++
+	}
+
+	results.Encrypted = &models.EncryptedTally{Choices: make([]models.EncryptedChoiceTally, len(choices))}
+	for i, c := range choices {
+		results.Encrypted.Choices[i] = models.EncryptedChoiceTally{ChoiceID: c.ID, Sum: multiplyCiphertexts(sums[i]).model()}
+	}
+	return results
+}
+
+// decryptTally — расшифровывает суммы вариантов и заполняет итоги. Сумму
+// расшифровывает закрытый ключ голосования с доказательством (elgamal) или
+// частичные расшифровки доверенных лиц (threshold, см. trusteeDecryptor).
+func decryptTally(ctx context.Context, repos *repositories.Repositories, e *models.Election, results *models.ElectionResults) error {
+	var decrypt func(i int, t *models.EncryptedChoiceTally, sum elgamalCiphertext) (*big.Int, error)
+	var err error
+	if e.TrusteeDecryption() {
+		decrypt, err = trusteeDecryptor(ctx, repos, e, results)
+	} else {
+		decrypt, err = secretDecryptor(ctx, repos, e)
+	}
+	if err != nil {
+		return err
+	}
+	bound := results.TotalVotes * encryptedMaxValue(e)
+
+	for i := range results.Encrypted.Choices {
+		t := &results.Encrypted.Choices[i]
+		sum, err := parseSum(t.Sum)
+		if err != nil {
+			return err
+		}
+		d, err := decrypt(i, t, sum)
+		if err != nil {
+			return err
+		}
+		value, ok := discreteLog(modMul(sum.b, modInv(d)), bound)
+		if !ok {
+			return fmt.Errorf("сумма варианта %d вне диапазона", t.ChoiceID)
+		}
+		t.D = bigHex(d)
+
+		if e.Method() == models.VotingScore {
+			results.Choices[i].Votes = results.TotalVotes
+			results.Choices[i].Score = value
+		} else {
+			results.Choices[i].Votes = value
+		}
+	}
+	results.Encrypted.Decrypted = true
+
+	if e.Method() == models.VotingScore {
+		results.Winners = leaders(results.Choices, func(c models.ChoiceResult) int { return c.Score })
+	} else {
+		results.Winners = leaders(results.Choices, func(c models.ChoiceResult) int { return c.Votes })
+	}
+	return nil
+}
+
+// secretDecryptor — расшифровка закрытым ключом голосования: D = A^x и
+// доказательство равенства логарифмов
+func secretDecryptor(ctx context.Context, repos *repositories.Repositories, e *models.Election) (func(int, *models.EncryptedChoiceTally, elgamalCiphertext) (*big.Int, error), error) {
+	secret, err := repos.Secrets.Get(ctx, e.ID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, fmt.Errorf("%w: закрытый ключ голосования не найден", ErrNotEncrypted)
+	}
+	if err != nil {
+		return nil, err
+	}
+	x, err := parseScalar(secret)
+	if err != nil {
+		return nil, err
+	}
+	h := modExp(elgamalG, x)
+
+	return func(i int, t *models.EncryptedChoiceTally, sum elgamalCiphertext) (*big.Int, error) {
+		d, proof, err := proveDecryption(e.ID, x, h, sum.a)
+		if err != nil {
+			return nil, err
+		}
+		t.Proof = &proof
+		return d, nil
+	}, nil
+}
+
+// trusteeDecryptor — расшифровка частичными расшифровками доверенных лиц:
+// берутся первые по номеру TrusteeThreshold лиц, чьи расшифровки сходятся с
+// текущими суммами, и D собирается по Лагранжу. Использованные расшифровки
+// публикуются в итогах.
+func trusteeDecryptor(ctx context.Context, repos *repositories.Repositories, e *models.Election, results *models.ElectionResults) (func(int, *models.EncryptedChoiceTally, elgamalCiphertext) (*big.Int, error), error) {
+	trustees, err := repos.Trustees.List(ctx, e.ID)
+	if err != nil {
+		return nil, err
+	}
+	keys, err := verificationKeys(trustees)
+	if err != nil {
+		return nil, err
+	}
+	stored, err := repos.Trustees.ListDecryptions(ctx, e.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	// partials[вариант][доверенное лицо] — проверенные частичные расшифровки
+	partials := make([]map[int]*big.Int, len(results.Encrypted.Choices))
+	used := 0
+	for _, k := range keys {
+		if used == e.TrusteeThreshold {
+			break
+		}
+		ds, ok := trusteePartials(e.ID, k, results.Encrypted, stored[k.Index])
+		if !ok {
+			continue
+		}
+		for i, d := range ds {
+			if partials[i] == nil {
+				partials[i] = make(map[int]*big.Int)
+			}
+			partials[i][k.Index] = d
+			results.Encrypted.Choices[i].Partials = append(results.Encrypted.Choices[i].Partials, stored[k.Index][i])
+		}
+		used++
+	}
+	if used < e.TrusteeThreshold {
+		return nil, fmt.Errorf("%w: есть %d из %d", ErrDecryptionPending, used, e.TrusteeThreshold)
+	}
+
+	return func(i int, t *models.EncryptedChoiceTally, sum elgamalCiphertext) (*big.Int, error) {
+		return combinePartials(partials[i]), nil
+	}, nil
+}
+
+// trusteePartials — проверяет частичные расшифровки доверенного лица
+// по открытой части его доли и возвращает D по вариантам
+func trusteePartials(electionID int, key models.TrusteeKey, tally *models.EncryptedTally, partials []models.PartialDecryption) ([]*big.Int, bool) {
+	h, err := parseElement(key.H)
+	if err != nil || len(partials) != len(tally.Choices) {
+		return nil, false
+	}
+	res := make([]*big.Int, len(partials))
+	for i, t := range tally.Choices {
+		sum, err := parseSum(t.Sum)
+		if err != nil || partials[i].ChoiceID != t.ChoiceID {
+			return nil, false
+		}
+		d, ok := verifyPartial(electionID, h, sum, partials[i])
+		if !ok {
+			return nil, false
+		}
+		res[i] = d
+	}
+	return res, true
+}
+
+// VerifyEncryptedTally — проверяет расшифровку итогов голосования: каждое D
+// подтверждено доказательством (или, в режиме threshold, собрано из
+// проверенных частичных расшифровок порога доверенных лиц), а B/D = g^v, где
+// v — опубликованное значение варианта. Суммы сверяются с голосами цепочки
+// через TallyEncrypted.
+func VerifyEncryptedTally(key *models.ElectionPublicKey, results *models.ElectionResults) bool {
+	if results.Encrypted == nil || !results.Encrypted.Decrypted || len(results.Encrypted.Choices) != len(results.Choices) {
+		return false
+	}
+	h, err := parseElement(key.H)
+	if err != nil {
+		return false
+	}
+	for i, t := range results.Encrypted.Choices {
+		sum, err := parseSum(t.Sum)
+		if err != nil {
+			return false
+		}
+		var d *big.Int
+		if key.Threshold > 0 {
+			d = verifyCombined(key, sum, t)
+		} else {
+			d = verifySecretDecryption(key.ElectionID, h, sum, t)
+		}
+		if d == nil || bigHex(d) != t.D {
+			return false
+		}
+		value := results.Choices[i].Votes
+		if key.VotingMethod == models.VotingScore {
+			value = results.Choices[i].Score
+		}
+		if modMul(sum.b, modInv(d)).Cmp(gPow(value)) != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// verifySecretDecryption — D суммы, подтверждённое доказательством, или nil
+func verifySecretDecryption(electionID int, h *big.Int, sum elgamalCiphertext, t models.EncryptedChoiceTally) *big.Int {
+	if sum.a.Cmp(big.NewInt(1)) == 0 {
+		// Сумма без бюллетеней — единица группы, её расшифровка тоже единица
+		return big.NewInt(1)
+	}
+	d, err := parseElement(t.D)
+	if err != nil || t.Proof == nil || !verifyDecryption(electionID, h, sum.a, d, *t.Proof) {
+		return nil
+	}
+	return d
+}
+
+// verifyCombined — D, собранное из проверенных частичных расшифровок не
+// меньше чем порога различных доверенных лиц, или nil
+func verifyCombined(key *models.ElectionPublicKey, sum elgamalCiphertext, t models.EncryptedChoiceTally) *big.Int {
+	partials := make(map[int]*big.Int, len(t.Partials))
+	for _, p := range t.Partials {
+		h, err := parseElement(trusteeKey(key.Trustees, p.Trustee))
+		if err != nil || partials[p.Trustee] != nil {
+			return nil
+		}
+		d, ok := verifyPartial(key.ElectionID, h, sum, p)
+		if !ok {
+			return nil
+		}
+		partials[p.Trustee] = d
+	}
+	if len(partials) < key.Threshold {
+		return nil
+	}
+	return combinePartials(partials)
+}
+
+// parseSum — произведение шифртекстов; в отличие от шифртекста бюллетеня
+// может быть единицей группы (1, 1), если бюллетеней нет
+func parseSum(c models.Ciphertext) (elgamalCiphertext, error) {
+	if one := bigHex(big.NewInt(1)); c.A == one && c.B == one {
+		return multiplyCiphertexts(nil), nil
+	}
+	return parseCiphertext(c)
+}
@@ -0,0 +1,96 @@
+package services
+
+import "errors"
+
+var (
+	// ErrDefinitionLocked — определение голосования уже зафиксировано генезис-блоком
+	ErrDefinitionLocked = errors.New("название, описание и способ голосования зафиксированы в генезис-блоке и не могут быть изменены")
+	// ErrInvalidTransition — переход между этими состояниями голосования запрещён
+	ErrInvalidTransition = errors.New("недопустимая смена состояния голосования")
+	// ErrInvalidSchedule — окно голосования задано неверно
+	ErrInvalidSchedule = errors.New("некорректное расписание голосования")
+	// ErrScheduleLocked — окно голосования меняется только до его открытия
+	ErrScheduleLocked = errors.New("время голосования нельзя менять после его открытия")
+	// ErrElectionNotOpen — голосование сейчас не принимает голоса
+	ErrElectionNotOpen = errors.New("голосование не открыто")
+	// ErrAlreadyVoted — пользователь уже голосовал в этом голосовании
+	ErrAlreadyVoted = errors.New("пользователь уже голосовал в этом голосовании")
+	// ErrChoiceRequired — в бюллетене не указан вариант
+	ErrChoiceRequired = errors.New("не указан вариант голосования")
+	// ErrInvalidChoice — варианта нет среди вариантов голосования
+	ErrInvalidChoice = errors.New("такого варианта нет в этом голосовании")
+	// ErrChoiceNotFound — варианта с таким id в голосовании нет
+	ErrChoiceNotFound = errors.New("вариант не найден")
+	// ErrChoicesLocked — варианты зафиксированы генезис-блоком при выходе из черновика
+	ErrChoicesLocked = errors.New("варианты голосования можно менять только в черновике")
+	// ErrInvalidChoiceData — текст, изображение или порядок вариантов заданы неверно
+	ErrInvalidChoiceData = errors.New("некорректные данные варианта")
+	// ErrInvalidContest — вопрос голосования задан неверно
+	ErrInvalidContest = errors.New("некорректный вопрос голосования")
+	// ErrInvalidBallot — бюллетень не соответствует способу голосования
+	ErrInvalidBallot = errors.New("бюллетень не соответствует способу голосования")
+	// ErrInvalidVotingMethod — неизвестный способ голосования
+	ErrInvalidVotingMethod = errors.New("неизвестный способ голосования")
+	// ErrVoteNotFound — голоса с таким хешем в голосовании нет
+	ErrVoteNotFound = errors.New("голос не найден")
+	// ErrElectionNotFound — голосования с таким id нет
+	ErrElectionNotFound = errors.New("голосование не найдено")
+	// ErrInvalidArchive — файл не является архивом голосования поддерживаемой версии
+	ErrInvalidArchive = errors.New("некорректный архив голосования")
+	// ErrArchiveChainInvalid — цепочка архива не прошла проверку, импорт отклонён
+	ErrArchiveChainInvalid = errors.New("цепочка блоков архива не прошла проверку")
+	// ErrElectionExists — голосование с id из архива уже есть в базе
+	ErrElectionExists = errors.New("голосование с таким id уже существует")
+	// ErrWriteInsNotAllowed — голосование или вопрос не разрешает вписанные варианты
+	ErrWriteInsNotAllowed = errors.New("вписанные варианты в этом голосовании не разрешены")
+	// ErrWriteInNotFound — за такой вписанный вариант не подано ни одного запечатанного голоса
+	ErrWriteInNotFound = errors.New("вписанный вариант не найден")
+	// ErrWriteInsLocked — решения по вписанным вариантам принимаются от открытия до утверждения итогов
+	ErrWriteInsLocked = errors.New("вписанные варианты можно разбирать только до утверждения итогов")
+	// ErrWriteInsPending — итоги нельзя утвердить, пока не разобраны все вписанные варианты
+	ErrWriteInsPending = errors.New("не все вписанные варианты разобраны")
+	// ErrNotEligible — пользователя нет в списке избирателей голосования
+	ErrNotEligible = errors.New("пользователя нет в списке избирателей")
+	// ErrInvalidEligibility — неизвестный режим допуска избирателей
+	ErrInvalidEligibility = errors.New("неизвестный режим допуска избирателей")
+	// ErrInvalidRollEntry — запись списка избирателей задана неверно
+	ErrInvalidRollEntry = errors.New("некорректная запись списка избирателей")
+	// ErrRollLocked — список избирателей меняется только до закрытия голосования
+	ErrRollLocked = errors.New("список избирателей можно менять только до закрытия голосования")
+	// ErrInvalidBallotCode — кода голосования нет или он уже использован
+	ErrInvalidBallotCode = errors.New("код голосования недействителен или уже использован")
+	// ErrInvalidCodeCount — число выпускаемых кодов голосования вне допустимого
+	ErrInvalidCodeCount = errors.New("некорректное число кодов голосования")
+	// ErrBallotCodesLocked — коды голосования выпускаются только до открытия голосования
+	ErrBallotCodesLocked = errors.New("коды голосования можно выпускать только до открытия голосования")
+	// ErrInvalidAnonymity — неизвестный режим анонимности голосования
+	ErrInvalidAnonymity = errors.New("неизвестный режим анонимности")
+	// ErrNotAnonymous — жетоны выдаются только в анонимных голосованиях
+	ErrNotAnonymous = errors.New("голосование не анонимное")
+	// ErrAnonymousVoting — в анонимном голосовании голос подаётся только по жетону
+	ErrAnonymousVoting = errors.New("в анонимном голосовании голос подаётся по жетону")
+	// ErrTokenAlreadyIssued — пользователь уже получил жетон этого голосования
+	ErrTokenAlreadyIssued = errors.New("жетон уже выдан")
+	// ErrInvalidBlindToken — жетон или его подпись неверны
+	ErrInvalidBlindToken = errors.New("жетон недействителен")
+	// ErrBlindKeyPending — ключ подписи жетонов создаётся при выходе голосования из черновика
+	ErrBlindKeyPending = errors.New("ключ подписи жетонов ещё не создан")
+	// ErrInvalidEncryption — неизвестный режим шифрования или он не подходит голосованию
+	ErrInvalidEncryption = errors.New("некорректный режим шифрования бюллетеней")
+	// ErrNotEncrypted — у голосования нет ключа шифрования бюллетеней
+	ErrNotEncrypted = errors.New("бюллетени голосования не шифруются")
+	// ErrNotTrustee — пользователь не доверенное лицо голосования
+	ErrNotTrustee = errors.New("пользователь не доверенное лицо голосования")
+	// ErrTrusteeExists — пользователь уже доверенное лицо голосования
+	ErrTrusteeExists = errors.New("доверенное лицо уже зарегистрировано")
+	// ErrInvalidTrusteeData — ключ, обязательства, доли или расшифровка доверенного лица некорректны
+	ErrInvalidTrusteeData = errors.New("некорректные данные доверенного лица")
+	// ErrCeremonyStage — действие не соответствует этапу церемонии ключа
+	ErrCeremonyStage = errors.New("действие не соответствует этапу церемонии ключа")
+	// ErrCeremonyPending — голосование нельзя начать, пока церемония ключа не завершена
+	ErrCeremonyPending = errors.New("церемония ключа голосования не завершена")
+	// ErrElectionNotClosed — частичные расшифровки принимаются только у закрытого голосования
+	ErrElectionNotClosed = errors.New("голосование не закрыто")
+	// ErrDecryptionPending — для подсчёта не хватает частичных расшифровок доверенных лиц
+	ErrDecryptionPending = errors.New("недостаточно частичных расшифровок доверенных лиц")
+)
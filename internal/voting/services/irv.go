@@ -0,0 +1,136 @@
+package services
+
+import "voting-blockchain/internal/voting/models"
+
+// TallyInstantRunoff — подсчёт ранжированных бюллетеней мгновенным вторым
+// туром. В каждом туре бюллетень отдаётся первому ещё не выбывшему варианту;
+// побеждает вариант, набравший больше половины неисчерпанных бюллетеней,
+// иначе выбывает вариант с наименьшим числом голосов. Ничья за последнее
+// место решается по предыдущим турам, начиная с ближайшего, а затем
+// выбывает вариант, стоящий в бюллетене ниже.
+//
+// Учитываются только голоса, запечатанные в блоки. Бюллетени с неизвестными
+// или повторяющимися вариантами считаются отклонёнными.
+func TallyInstantRunoff(electionID int, choices []*models.Choice, votes []*models.Vote) *models.ElectionResults {
+	results, index := newResults(electionID, models.VotingInstantRunoff, choices)
+
+	var rankings [][]int
+	for _, vote := range votes {
+		if vote.BlockID == nil {
+			continue
+		}
+		ballot, err := vote.BallotContents()
+		if err != nil || !validRanking(ballot.Ranking, index) {
+			results.Rejected++
+			continue
+		}
+		rankings = append(rankings, ballot.Ranking)
+		results.Choices[index[ballot.Ranking[0]]].Votes++
+		results.TotalVotes++
+	}
+
+	continuing := make(map[int]bool, len(choices))
+	for _, c := range choices {
+		continuing[c.ID] = true
+	}
+
+	var history []map[int]int
+	for round := 1; len(continuing) > 0; round++ {
+		counts := make(map[int]int, len(continuing))
+		exhausted := 0
+		for _, ranking := range rankings {
+			if id, ok := firstContinuing(ranking, continuing); ok {
+				counts[id]++
+			} else {
+				exhausted++
+			}
+		}
+		history = append(history, counts)
+
+		r := models.RunoffRound{Round: round, Exhausted: exhausted}
+		for _, c := range choices {
+			if continuing[c.ID] {
+				r.Tallies = append(r.Tallies, models.ChoiceResult{ChoiceID: c.ID, Text: c.Text, Votes: counts[c.ID]})
+			}
+		}
+
+		active := len(rankings) - exhausted
+		if active == 0 {
+			// Голосов нет или все бюллетени исчерпаны: победителя нет
+			results.Rounds = append(results.Rounds, r)
+			break
+		}
+		if winner, ok := majority(r.Tallies, active); ok {
+			results.Rounds = append(results.Rounds, r)
+			results.Winners = []int{winner}
+			break
+		}
+
+		loser := runoffLoser(r.Tallies, history)
+		r.Eliminated = []int{loser}
+		delete(continuing, loser)
+		results.Rounds = append(results.Rounds, r)
+	}
+
+	return results
+}
+
+// validRanking — список вариантов непустой, а варианты в нём известны и не повторяются
+func validRanking(ranking []int, index map[int]int) bool {
+	if len(ranking) == 0 {
+		return false
+	}
+	seen := make(map[int]bool, len(ranking))
+	for _, id := range ranking {
+		if _, ok := index[id]; !ok || seen[id] {
+			return false
+		}
+		seen[id] = true
+	}
+	return true
+}
+
+func firstContinuing(ranking []int, continuing map[int]bool) (int, bool) {
+	for _, id := range ranking {
+		if continuing[id] {
+			return id, true
+		}
+	}
+	return 0, false
+}
+
+// majority — вариант, набравший больше половины из active голосов
+func majority(tallies []models.ChoiceResult, active int) (int, bool) {
+	for _, t := range tallies {
+		if t.Votes*2 > active {
+			return t.ChoiceID, true
+		}
+	}
+	return 0, false
+}
+
+// runoffLoser — выбывающий вариант: наименьшее число голосов в текущем туре,
+// при равенстве — в предыдущих, затем — последний в порядке бюллетеня
+func runoffLoser(tallies []models.ChoiceResult, history []map[int]int) int {
+	tied := make([]int, 0, len(tallies))
+	for _, t := range tallies {
+		tied = append(tied, t.ChoiceID)
+	}
+	for round := len(history) - 1; round >= 0 && len(tied) > 1; round-- {
+		counts := history[round]
+		lowest := counts[tied[0]]
+		for _, id := range tied[1:] {
+			if counts[id] < lowest {
+				lowest = counts[id]
+			}
+		}
+		next := tied[:0]
+		for _, id := range tied {
+			if counts[id] == lowest {
+				next = append(next, id)
+			}
+		}
+		tied = next
+	}
+	return tied[len(tied)-1]
+}
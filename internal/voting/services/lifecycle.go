@@ -0,0 +1,125 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"voting-blockchain/internal/voting/models"
+	"voting-blockchain/internal/voting/repositories"
+)
+
+// transitionElection — переводит голосование в состояние to и записывает переход.
+// Должна вызываться под блокировкой голосования. actorID == nil — переход
+// выполняет система. При выходе из черновика пишется генезис-блок, фиксирующий
+// определение голосования, при закрытии запечатываются ожидающие голоса,
+// при переходе в tallied подсчитываются и сохраняются итоги, а утвердить
+// итоги можно, только когда разобраны все вписанные варианты. Голосование с
+// пороговым шифрованием покидает черновик только с ключом, созданным церемонией,
+// а анонимное при выходе из черновика получает ключ подписи жетонов.
+func transitionElection(
+	ctx context.Context,
+	repos *repositories.Repositories,
+	signer *BlockSigner,
+	e *models.Election,
+	to string,
+	actorID *int,
+) error {
+	if !models.CanTransition(e.Status, to) {
+		return fmt.Errorf("%w: %s → %s", ErrInvalidTransition, e.Status, to)
+	}
+	if to == models.ElectionScheduled && e.OpensAt == nil {
+		return fmt.Errorf("%w: для планирования нужно время начала", ErrInvalidSchedule)
+	}
+
+	if e.Status == models.ElectionDraft && e.Encrypted() && e.EncryptionKey == "" {
+		return ErrCeremonyPending
+	}
+	if e.Status == models.ElectionDraft && e.BlindTokens() {
+		// Ключ подписи жетонов создаётся здесь, а не по первому запросу, чтобы
+		// его отпечаток вошёл в определение, зафиксированное генезис-блоком
+		priv, err := electionBlindKey(ctx, repos, e.ID, true)
+		if err != nil {
+			return err
+		}
+		e.BlindKeyFingerprint = blindPublicKey(e.ID, &priv.PublicKey).Fingerprint
+	}
+	if e.Status == models.ElectionDraft {
+		if err := writeGenesis(ctx, repos, signer, e); err != nil {
+			return err
+		}
+	}
+	if to == models.ElectionClosed {
+		if _, err := sealPending(ctx, repos, signer, e.ID); err != nil {
+			return err
+		}
+	}
+	if to == models.ElectionTallied {
+		if err := saveTally(ctx, repos, e); err != nil {
+			return err
+		}
+	}
+	if to == models.ElectionCertified {
+		if err := checkWriteInsReviewed(ctx, repos, e); err != nil {
+			return err
+		}
+	}
+
+	from := e.Status
+	e.Status = to
+	e.IsActive = to == models.ElectionOpen
+	if err := repos.Elections.Update(ctx, e); err != nil {
+		return err
+	}
+
+	return repos.Elections.RecordTransition(ctx, &models.ElectionTransition{
+		ElectionID: e.ID,
+		FromStatus: from,
+		ToStatus:   to,
+		ChangedBy:  actorID,
+	})
+}
+
+// writeGenesis — пишет генезис-блок, фиксирующий определение голосования
+func writeGenesis(ctx context.Context, repos *repositories.Repositories, signer *BlockSigner, e *models.Election) error {
+	choices, err := repos.Choices.GetChoices(ctx, e.ID)
+	if err != nil {
+		return err
+	}
+
+	genesis := models.NewGenesisBlock(e.ID, e.DefinitionHash(choices), time.Now())
+	signer.Sign(genesis)
+	return repos.Blocks.AddBlock(ctx, genesis)
+}
+
+// saveTally — подсчитывает итоги голосования с учётом решений по вписанным
+// вариантам и явку по списку избирателей и сохраняет их, заменяя подсчитанные
+// ранее. Суммы зашифрованных бюллетеней при этом расшифровываются.
+func saveTally(ctx context.Context, repos *repositories.Repositories, e *models.Election) error {
+	choices, err := repos.Choices.GetChoices(ctx, e.ID)
+	if err != nil {
+		return err
+	}
+	votes, err := repos.Votes.GetByElectionID(ctx, e.ID)
+	if err != nil {
+		return err
+	}
+	blocks, err := repos.Blocks.GetAllBlocks(ctx, e.ID)
+	if err != nil {
+		return err
+	}
+	votes = ChainedVotes(blocks, votes)
+	if e.WriteInReviews, err = repos.Elections.ListWriteInReviews(ctx, e.ID); err != nil {
+		return err
+	}
+	tally := &models.Tally{ElectionID: e.ID, Results: TallyElection(e, choices, votes)}
+	if e.Encrypted() {
+		if err := decryptTally(ctx, repos, e, tally.Results); err != nil {
+			return err
+		}
+	}
+	if tally.Results.Turnout, err = rollTurnout(ctx, repos.Rolls, repos.Codes, e, votes); err != nil {
+		return err
+	}
+	return repos.Elections.SaveTally(ctx, tally)
+}
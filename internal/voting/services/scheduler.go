@@ -0,0 +1,121 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"voting-blockchain/internal/voting/models"
+	"voting-blockchain/internal/voting/repositories"
+)
+
+// schedulerLockKey — ключ блокировки, под которой работает планировщик:
+// при нескольких репликах тик выполняет только одна из них
+const schedulerLockKey = 1
+
+// Scheduler — фоновый планировщик голосований: открывает запланированные
+// голосования в OpensAt, закрывает открытые в ClosesAt (запечатывая ожидающие
+// голоса и подсчитывая итоги, кроме голосований с пороговым шифрованием) и
+// запечатывает пачки, у которых истекло окно.
+type Scheduler struct {
+	electionRepo repositories.ElectionRepository
+	uow          repositories.UnitOfWork
+	locker       repositories.Locker
+	signer       *BlockSigner
+	interval     time.Duration
+	now          func() time.Time
+}
+
+// NewScheduler — конструктор планировщика
+func NewScheduler(
+	electionRepo repositories.ElectionRepository,
+	uow repositories.UnitOfWork,
+	locker repositories.Locker,
+	signer *BlockSigner,
+	interval time.Duration,
+) *Scheduler {
+	return &Scheduler{
+		electionRepo: electionRepo,
+		uow:          uow,
+		locker:       locker,
+		signer:       signer,
+		interval:     interval,
+		now:          time.Now,
+	}
+}
+
+// Run — выполняет Tick каждые interval, пока не отменён ctx
+func (s *Scheduler) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		if err := s.Tick(ctx); err != nil {
+			log.Printf("Планировщик голосований: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Tick — один проход планировщика. Если планировщик уже работает в другой
+// реплике, ничего не делает.
+func (s *Scheduler) Tick(ctx context.Context) error {
+	_, err := s.locker.RunExclusive(ctx, schedulerLockKey, func(ctx context.Context) error {
+		// opens_at и closes_at хранятся без часового пояса в UTC, поэтому и
+		// сравниваются с текущим временем в UTC
+		now := s.now().UTC()
+		due, err := s.electionRepo.ListDue(ctx, now)
+		if err != nil {
+			return err
+		}
+
+		// Ошибка одного голосования не должна останавливать остальные
+		var errs []error
+		for _, e := range due {
+			if err := s.advance(ctx, e.ID, now); err != nil {
+				errs = append(errs, err)
+			}
+		}
+		return errors.Join(errs...)
+	})
+	return err
+}
+
+// advance — продвигает одно голосование под его блокировкой. Состояние
+// перечитывается внутри транзакции, поэтому ручной переход, сделанный
+// между ListDue и блокировкой, не выполняется повторно.
+func (s *Scheduler) advance(ctx context.Context, electionID int, now time.Time) error {
+	return s.uow.DoInElection(ctx, electionID, func(repos *repositories.Repositories) error {
+		e, err := repos.Elections.GetByID(ctx, electionID)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case e.Status == models.ElectionScheduled && e.OpensAt != nil && !now.Before(*e.OpensAt):
+			return transitionElection(ctx, repos, s.signer, e, models.ElectionOpen, nil)
+		case e.Status == models.ElectionOpen && e.ClosesAt != nil && !now.Before(*e.ClosesAt):
+			if err := transitionElection(ctx, repos, s.signer, e, models.ElectionClosed, nil); err != nil {
+				return err
+			}
+			// Итоги с порогом подсчитываются, когда доверенные лица пришлют расшифровки
+			if e.TrusteeDecryption() {
+				return nil
+			}
+			return transitionElection(ctx, repos, s.signer, e, models.ElectionTallied, nil)
+		case e.Status == models.ElectionOpen:
+			_, err := sealIfDue(ctx, repos, s.signer, e)
+			return err
+		}
+		return nil
+	})
+}
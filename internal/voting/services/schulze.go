@@ -0,0 +1,113 @@
+package services
+
+import "voting-blockchain/internal/voting/models"
+
+// TallySchulze — подсчёт ранжированных бюллетеней методом Шульце. Вариант,
+// стоящий в бюллетене выше, предпочитается стоящему ниже и всем
+// неранжированным; неранжированные варианты между собой равны. Победители —
+// варианты, чей сильнейший путь к каждому другому не слабее обратного
+// (победитель Кондорсе, если он есть, всегда среди них).
+//
+// В итоги попадают матрица попарных предпочтений и матрица сильнейших путей
+// в порядке вариантов. Учитываются только голоса, запечатанные в блоки.
+func TallySchulze(electionID int, choices []*models.Choice, votes []*models.Vote) *models.ElectionResults {
+	results, index := newResults(electionID, models.VotingSchulze, choices)
+	n := len(choices)
+
+	pairwise := newMatrix(n)
+	for _, vote := range votes {
+		if vote.BlockID == nil {
+			continue
+		}
+		ballot, err := vote.BallotContents()
+		if err != nil || !validRanking(ballot.Ranking, index) {
+			results.Rejected++
+			continue
+		}
+		results.Choices[index[ballot.Ranking[0]]].Votes++
+		results.TotalVotes++
+
+		// Ранг варианта: позиция в бюллетене, неранжированные — после всех
+		rank := make([]int, n)
+		for i := range rank {
+			rank[i] = len(ballot.Ranking)
+		}
+		for pos, id := range ballot.Ranking {
+			rank[index[id]] = pos
+		}
+		for i := 0; i < n; i++ {
+			for j := 0; j < n; j++ {
+				if rank[i] < rank[j] {
+					pairwise[i][j]++
+				}
+			}
+		}
+	}
+
+	paths := strongestPaths(pairwise)
+
+	condorcet := &models.CondorcetResults{
+		ChoiceIDs:      make([]int, n),
+		Pairwise:       pairwise,
+		StrongestPaths: paths,
+	}
+	for i, c := range choices {
+		condorcet.ChoiceIDs[i] = c.ID
+	}
+	results.Condorcet = condorcet
+
+	if results.TotalVotes > 0 {
+		for i := 0; i < n; i++ {
+			wins := true
+			for j := 0; j < n; j++ {
+				if i != j && paths[j][i] > paths[i][j] {
+					wins = false
+					break
+				}
+			}
+			if wins {
+				results.Winners = append(results.Winners, choices[i].ID)
+			}
+		}
+	}
+
+	return results
+}
+
+// strongestPaths — сила сильнейшего пути между каждой парой вариантов
+// (вариант алгоритма Флойда — Уоршелла)
+func strongestPaths(pairwise [][]int) [][]int {
+	n := len(pairwise)
+	p := newMatrix(n)
+	for i := 0; i < n; i++ {
+		for j := 0; j < n; j++ {
+			if i != j && pairwise[i][j] > pairwise[j][i] {
+				p[i][j] = pairwise[i][j]
+			}
+		}
+	}
+	for k := 0; k < n; k++ {
+		for i := 0; i < n; i++ {
+			if i == k {
+				continue
+			}
+			for j := 0; j < n; j++ {
+				if j == i || j == k {
+					continue
+				}
+				if via := min(p[i][k], p[k][j]); via > p[i][j] {
+					p[i][j] = via
+				}
+			}
+		}
+	}
+	return p
+}
+
+func newMatrix(n int) [][]int {
+	m := make([][]int, n)
+	for i := range m {
+		m[i] = make([]int, n)
+	}
+	return m
+}
@@ -0,0 +1,63 @@
+package services
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"voting-blockchain/internal/voting/models"
+)
+
+// BlockSigner — подписывает блоки ключом узла. Приватный ключ живёт только
+// в памяти процесса и в конфигурации, в Postgres попадает лишь публичный.
+type BlockSigner struct {
+	key   ed25519.PrivateKey
+	keyID string
+}
+
+// NewBlockSigner — создаёт подписчика из seed Ed25519 (32 байта в hex)
+func NewBlockSigner(seedHex string) (*BlockSigner, error) {
+	seed, err := hex.DecodeString(seedHex)
+	if err != nil || len(seed) != ed25519.SeedSize {
+		return nil, errors.New("ключ подписи блоков должен быть 32-байтовым seed Ed25519 в hex")
+	}
+	return newBlockSigner(ed25519.NewKeyFromSeed(seed)), nil
+}
+
+// GenerateBlockSigner — создаёт подписчика со случайным ключом. Подходит для
+// тестов и локального запуска: после перезапуска ключ будет другим.
+func GenerateBlockSigner() (*BlockSigner, error) {
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	return newBlockSigner(key), nil
+}
+
+func newBlockSigner(key ed25519.PrivateKey) *BlockSigner {
+	pub := key.Public().(ed25519.PublicKey)
+	return &BlockSigner{key: key, keyID: models.KeyIDFor(pub)}
+}
+
+// KeyID — идентификатор текущего ключа
+func (s *BlockSigner) KeyID() string {
+	return s.keyID
+}
+
+// PublicKey — публичная часть ключа для публикации в signing_keys
+func (s *BlockSigner) PublicKey() *models.SigningKey {
+	return &models.SigningKey{
+		KeyID:     s.keyID,
+		Algorithm: models.SignatureAlgorithm,
+		PublicKey: hex.EncodeToString(s.key.Public().(ed25519.PublicKey)),
+		CreatedAt: time.Now().UTC(),
+	}
+}
+
+// Sign — подписывает уже посчитанный хеш блока
+func (s *BlockSigner) Sign(b *models.Block) {
+	b.KeyID = s.keyID
+	b.Signature = hex.EncodeToString(ed25519.Sign(s.key, b.SigningPayload()))
+}
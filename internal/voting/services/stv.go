@@ -0,0 +1,218 @@
+package services
+
+import (
+	"encoding/csv"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"voting-blockchain/internal/voting/models"
+)
+
+// stvScale — веса бюллетеней хранятся в стотысячных долях голоса; доли,
+// не поместившиеся при передаче излишка, отбрасываются
+const stvScale = 100000
+
+// stvBallot — бюллетень в подсчёте STV: текущий вариант и вес
+type stvBallot struct {
+	ranking []int
+	pos     int
+	weight  int
+}
+
+// TallySTV — подсчёт единым передаваемым голосом на seats мест. Квота Друпа
+// floor(V/(seats+1))+1; вариант, набравший квоту, избирается, а его излишек
+// передаётся следующим предпочтениям всех его бюллетеней с уменьшенным весом
+// (взвешенный инклюзивный метод Грегори, WIGM). Если никто не набрал квоту,
+// исключается вариант с наименьшим числом голосов (ничья решается как в
+// TallyInstantRunoff). Каждый тур записывается в протокол подсчёта.
+//
+// Учитываются только голоса, запечатанные в блоки. Бюллетени с неизвестными
+// или повторяющимися вариантами считаются отклонёнными.
+func TallySTV(electionID, seats int, choices []*models.Choice, votes []*models.Vote) *models.ElectionResults {
+	results, index := newResults(electionID, models.VotingSTV, choices)
+	results.Seats = seats
+
+	piles := make(map[int][]*stvBallot, len(choices))
+	for _, vote := range votes {
+		if vote.BlockID == nil {
+			continue
+		}
+		ballot, err := vote.BallotContents()
+		if err != nil || !validRanking(ballot.Ranking, index) {
+			results.Rejected++
+			continue
+		}
+		first := ballot.Ranking[0]
+		piles[first] = append(piles[first], &stvBallot{ranking: ballot.Ranking, weight: stvScale})
+		results.Choices[index[first]].Votes++
+		results.TotalVotes++
+	}
+	if results.TotalVotes == 0 || seats < 1 {
+		return results
+	}
+
+	quota := (results.TotalVotes/(seats+1) + 1) * stvScale
+	results.Quota = fromScaled(quota)
+
+	status := make(map[int]string, len(choices))
+	for _, c := range choices {
+		status[c.ID] = models.CountContinuing
+	}
+	continuing := func() []int {
+		var ids []int
+		for _, c := range choices {
+			if status[c.ID] == models.CountContinuing {
+				ids = append(ids, c.ID)
+			}
+		}
+		return ids
+	}
+
+	// fixed — голоса избранного варианта после передачи его излишка
+	fixed := make(map[int]int)
+	exhausted := 0
+	// move — передаёт бюллетень следующему продолжающему варианту
+	move := func(b *stvBallot) {
+		for b.pos++; b.pos < len(b.ranking); b.pos++ {
+			if id := b.ranking[b.pos]; status[id] == models.CountContinuing {
+				piles[id] = append(piles[id], b)
+				return
+			}
+		}
+		exhausted += b.weight
+	}
+
+	var elected, pending []int
+	var history []map[int]int
+	for round := 1; ; round++ {
+		totals := make(map[int]int, len(choices))
+		r := models.CountRound{Round: round, Exhausted: fromScaled(exhausted)}
+		for _, c := range choices {
+			if v, ok := fixed[c.ID]; ok {
+				totals[c.ID] = v
+			} else {
+				for _, b := range piles[c.ID] {
+					totals[c.ID] += b.weight
+				}
+			}
+			r.Tallies = append(r.Tallies, models.CountTally{
+				ChoiceID: c.ID,
+				Text:     c.Text,
+				Votes:    fromScaled(totals[c.ID]),
+				Status:   status[c.ID],
+			})
+		}
+		history = append(history, totals)
+
+		// Избираются все набравшие квоту, начиная с наибольшего числа голосов
+		var reached []int
+		for _, id := range continuing() {
+			if totals[id] >= quota {
+				reached = append(reached, id)
+			}
+		}
+		sort.SliceStable(reached, func(i, j int) bool { return totals[reached[i]] > totals[reached[j]] })
+		for _, id := range reached {
+			status[id] = models.CountElected
+			elected = append(elected, id)
+			pending = append(pending, id)
+			r.Elected = append(r.Elected, id)
+		}
+		sort.SliceStable(pending, func(i, j int) bool { return totals[pending[i]] > totals[pending[j]] })
+
+		remaining := continuing()
+		switch {
+		case len(elected) >= seats:
+		case len(elected)+len(remaining) <= seats:
+			// Продолжающих вариантов не больше свободных мест: избираются все
+			for _, id := range remaining {
+				status[id] = models.CountElected
+				elected = append(elected, id)
+				r.Elected = append(r.Elected, id)
+			}
+		case len(pending) > 0:
+			x := pending[0]
+			pending = pending[1:]
+			surplus := totals[x] - quota
+			for _, b := range piles[x] {
+				b.weight = b.weight * surplus / totals[x]
+				move(b)
+			}
+			piles[x] = nil
+			fixed[x] = quota
+			r.Transferred = x
+		default:
+			tallies := make([]models.ChoiceResult, 0, len(remaining))
+			for _, id := range remaining {
+				tallies = append(tallies, models.ChoiceResult{ChoiceID: id, Votes: totals[id]})
+			}
+			loser := runoffLoser(tallies, history)
+			status[loser] = models.CountExcluded
+			for _, b := range piles[loser] {
+				move(b)
+			}
+			piles[loser] = nil
+			r.Excluded = []int{loser}
+		}
+
+		results.CountSheet = append(results.CountSheet, r)
+		if len(elected) >= seats || len(continuing()) == 0 {
+			break
+		}
+	}
+
+	results.Winners = elected
+	return results
+}
+
+func fromScaled(v int) float64 {
+	return float64(v) / stvScale
+}
+
+// WriteCountSheetCSV — протокол подсчёта STV в CSV: строка на тур, столбец
+// на вариант, затем исчерпанные бюллетени и действие тура
+func WriteCountSheetCSV(w io.Writer, results *models.ElectionResults) error {
+	names := make(map[int]string, len(results.Choices))
+	header := []string{"round"}
+	for _, c := range results.Choices {
+		names[c.ChoiceID] = c.Text
+		header = append(header, c.Text)
+	}
+	header = append(header, "exhausted", "elected", "transferred", "excluded")
+
+	join := func(ids []int) string {
+		texts := make([]string, len(ids))
+		for i, id := range ids {
+			texts[i] = names[id]
+		}
+		return strings.Join(texts, "; ")
+	}
+
+	cw := csv.NewWriter(w)
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+	for _, r := range results.CountSheet {
+		row := []string{strconv.Itoa(r.Round)}
+		for _, t := range r.Tallies {
+			row = append(row, strconv.FormatFloat(t.Votes, 'f', 5, 64))
+		}
+		transferred := ""
+		if r.Transferred != 0 {
+			transferred = names[r.Transferred]
+		}
+		row = append(row,
+			strconv.FormatFloat(r.Exhausted, 'f', 5, 64),
+			join(r.Elected),
+			transferred,
+			join(r.Excluded),
+		)
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
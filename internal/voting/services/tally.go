@@ -0,0 +1,307 @@
+package services
+
+import (
+	"sort"
+
+	"voting-blockchain/internal/voting/models"
+)
+
+// TallyStrategy — подсчёт итогов одного вопроса одним способом голосования.
+// Учитываются только голоса, запечатанные в блоки; бюллетени, не прошедшие
+// проверку способа, попадают в Rejected.
+type TallyStrategy interface {
+	Tally(q *models.Contest, choices []*models.Choice, votes []*models.Vote) *models.ElectionResults
+}
+
+// TallyFunc — функция подсчёта как TallyStrategy
+type TallyFunc func(q *models.Contest, choices []*models.Choice, votes []*models.Vote) *models.ElectionResults
+
+func (f TallyFunc) Tally(q *models.Contest, choices []*models.Choice, votes []*models.Vote) *models.ElectionResults {
+	return f(q, choices, votes)
+}
+
+// tallyStrategies — способы подсчёта по способу голосования вопроса. Новый
+// способ голосования добавляется сюда и в models.IsVotingMethod.
+var tallyStrategies = map[string]TallyStrategy{
+	models.VotingPlurality: TallyFunc(func(q *models.Contest, choices []*models.Choice, votes []*models.Vote) *models.ElectionResults {
+		return TallyVotes(q.ElectionID, choices, votes)
+	}),
+	models.VotingInstantRunoff: TallyFunc(func(q *models.Contest, choices []*models.Choice, votes []*models.Vote) *models.ElectionResults {
+		return TallyInstantRunoff(q.ElectionID, choices, votes)
+	}),
+	models.VotingSchulze: TallyFunc(func(q *models.Contest, choices []*models.Choice, votes []*models.Vote) *models.ElectionResults {
+		return TallySchulze(q.ElectionID, choices, votes)
+	}),
+	models.VotingSTV: TallyFunc(func(q *models.Contest, choices []*models.Choice, votes []*models.Vote) *models.ElectionResults {
+		return TallySTV(q.ElectionID, q.Seats, choices, votes)
+	}),
+	models.VotingApproval: TallyFunc(func(q *models.Contest, choices []*models.Choice, votes []*models.Vote) *models.ElectionResults {
+		return TallyApproval(q.ElectionID, choices, votes)
+	}),
+	models.VotingScore: TallyFunc(func(q *models.Contest, choices []*models.Choice, votes []*models.Vote) *models.ElectionResults {
+		return TallyScore(q.ElectionID, q.MaxScore, choices, votes)
+	}),
+}
+
+// TallyStrategyFor — способ подсчёта для способа голосования
+func TallyStrategyFor(method string) (TallyStrategy, bool) {
+	s, ok := tallyStrategies[method]
+	return s, ok
+}
+
+// ChainedVotes — голоса без тех, что ссылаются на блок вне цепочки blocks:
+// такие голоса не сверяются ни с одним корнем Merkle и в итоги не идут.
+// Ожидающие голоса (без блока) остаются.
+func ChainedVotes(blocks []*models.Block, votes []*models.Vote) []*models.Vote {
+	inChain := make(map[int]bool, len(blocks))
+	for _, b := range blocks {
+		inChain[b.Index] = true
+	}
+	chained := make([]*models.Vote, 0, len(votes))
+	for _, v := range votes {
+		if v.BlockID == nil || inChain[*v.BlockID] {
+			chained = append(chained, v)
+		}
+	}
+	return chained
+}
+
+// TallyElection — итоги голосования способом, заданным в его определении.
+// У голосования с вопросами каждый вопрос подсчитывается своим способом по
+// ответам на него, а итоги вопросов возвращаются в Contests. Вписанные
+// варианты засчитываются вариантам по решениям e.WriteInReviews. Зашифрованные
+// бюллетени только суммируются, см. TallyEncrypted.
+func TallyElection(e *models.Election, choices []*models.Choice, votes []*models.Vote) *models.ElectionResults {
+	if e.Encrypted() {
+		return TallyEncrypted(e, choices, votes)
+	}
+	if len(e.Contests) == 0 {
+		results := tallyContest(e.DefaultContest(), choices, resolveWriteIns(votes, e.WriteInReviews, 0))
+		markReviewed(results, e.WriteInReviews, 0)
+		return results
+	}
+
+	results := &models.ElectionResults{ElectionID: e.ID}
+	for _, vote := range votes {
+		if vote.BlockID == nil {
+			continue
+		}
+		if ballot, err := vote.BallotContents(); err != nil || len(ballot.Contests) == 0 {
+			results.Rejected++
+		} else {
+			results.TotalVotes++
+		}
+	}
+	for _, q := range e.Contests {
+		r := tallyContest(q, models.ContestChoices(choices, q.ID), resolveWriteIns(contestVotes(votes, q.ID), e.WriteInReviews, q.ID))
+		markReviewed(r, e.WriteInReviews, q.ID)
+		r.ContestID = q.ID
+		r.Title = q.Title
+		results.Contests = append(results.Contests, r)
+	}
+	return results
+}
+
+// tallyContest — итоги одного вопроса. Если способ неизвестен (например, в
+// архиве более новой версии), все запечатанные голоса считаются отклонёнными.
+func tallyContest(q *models.Contest, choices []*models.Choice, votes []*models.Vote) *models.ElectionResults {
+	if strategy, ok := TallyStrategyFor(q.Method()); ok {
+		return strategy.Tally(q, choices, votes)
+	}
+	results, _ := newResults(q.ElectionID, q.Method(), choices)
+	for _, vote := range votes {
+		if vote.BlockID != nil {
+			results.Rejected++
+		}
+	}
+	return results
+}
+
+// contestVotes — запечатанные голоса, сведённые к ответам на вопрос
+// contestID: бюллетень такого голоса — ответ на этот вопрос. Голоса без
+// ответа на вопрос в его подсчёт не попадают.
+func contestVotes(votes []*models.Vote, contestID int) []*models.Vote {
+	var res []*models.Vote
+	for _, vote := range votes {
+		if vote.BlockID == nil {
+			continue
+		}
+		ballot, err := vote.BallotContents()
+		if err != nil {
+			continue
+		}
+		for i := range ballot.Contests {
+			if ballot.Contests[i].ContestID == contestID {
+				res = append(res, &models.Vote{
+					ElectionID:  vote.ElectionID,
+					HashVersion: models.VoteHashBallot,
+					Ballot:      ballot.Contests[i].Ballot.Canonical(),
+					BlockID:     vote.BlockID,
+				})
+				break
+			}
+		}
+	}
+	return res
+}
+
+// TallyVotes — подсчёт голосов по вариантам голосования, включая варианты
+// без голосов. Учитываются только голоса, уже запечатанные в блоки цепочки.
+// Вариант берётся из бюллетеня, зафиксированного хешем голоса; голоса,
+// поданные до проверки вариантов, сопоставляются по тексту, а не совпавшие
+// ни с одним вариантом считаются отклонёнными. Голоса за вписанные варианты
+// учитываются и перечисляются в WriteIns, но победителями не становятся.
+func TallyVotes(electionID int, choices []*models.Choice, votes []*models.Vote) *models.ElectionResults {
+	results, index := newResults(electionID, models.VotingPlurality, choices)
+	byText := make(map[string]int, len(choices))
+	for i, c := range choices {
+		byText[c.Text] = i
+	}
+	writeIns := make(map[string]int)
+
+	for _, vote := range votes {
+		if vote.BlockID == nil {
+			continue
+		}
+
+		ballot, err := vote.BallotContents()
+		if err != nil {
+			results.Rejected++
+			continue
+		}
+		if ballot.WriteIn != "" {
+			key := models.WriteInKey(ballot.WriteIn)
+			if _, ok := writeIns[key]; !ok {
+				writeIns[key] = len(results.WriteIns)
+				results.WriteIns = append(results.WriteIns, models.WriteInTally{Text: ballot.WriteIn})
+			}
+			results.WriteIns[writeIns[key]].Votes++
+			results.TotalVotes++
+			continue
+		}
+		i, ok := index[ballot.ChoiceID]
+		if !ok && ballot.ChoiceID == 0 {
+			i, ok = byText[ballot.Choice]
+		}
+		if !ok {
+			results.Rejected++
+			continue
+		}
+		results.Choices[i].Votes++
+		results.TotalVotes++
+	}
+
+	sort.SliceStable(results.WriteIns, func(i, j int) bool { return results.WriteIns[i].Votes > results.WriteIns[j].Votes })
+	results.Winners = leaders(results.Choices, func(c models.ChoiceResult) int { return c.Votes })
+	return results
+}
+
+// markReviewed — отмечает вписанные варианты итогов вопроса contestID, по
+// которым принято решение оставить их вписанными
+func markReviewed(results *models.ElectionResults, reviews []*models.WriteInReview, contestID int) {
+	for i := range results.WriteIns {
+		t := &results.WriteIns[i]
+		t.ContestID = contestID
+		for _, w := range reviews {
+			if w.ContestID == contestID && models.WriteInKey(w.Text) == models.WriteInKey(t.Text) {
+				t.Reviewed = true
+			}
+		}
+	}
+}
+
+// TallyApproval — подсчёт одобрений: каждый одобренный вариант получает
+// голос, побеждает вариант с наибольшим числом одобрений
+func TallyApproval(electionID int, choices []*models.Choice, votes []*models.Vote) *models.ElectionResults {
+	results, index := newResults(electionID, models.VotingApproval, choices)
+
+	for _, vote := range votes {
+		if vote.BlockID == nil {
+			continue
+		}
+		ballot, err := vote.BallotContents()
+		if err != nil || !validRanking(ballot.Approvals, index) {
+			results.Rejected++
+			continue
+		}
+		for _, id := range ballot.Approvals {
+			results.Choices[index[id]].Votes++
+		}
+		results.TotalVotes++
+	}
+
+	results.Winners = leaders(results.Choices, func(c models.ChoiceResult) int { return c.Votes })
+	return results
+}
+
+// TallyScore — подсчёт оценок от 0 до maxScore: побеждает вариант с
+// наибольшей суммой оценок. Неоценённый вариант получает 0.
+func TallyScore(electionID, maxScore int, choices []*models.Choice, votes []*models.Vote) *models.ElectionResults {
+	results, index := newResults(electionID, models.VotingScore, choices)
+
+	for _, vote := range votes {
+		if vote.BlockID == nil {
+			continue
+		}
+		ballot, err := vote.BallotContents()
+		if err != nil || !validScores(ballot.Scores, maxScore, index) {
+			results.Rejected++
+			continue
+		}
+		for _, s := range ballot.Scores {
+			results.Choices[index[s.ChoiceID]].Votes++
+			results.Choices[index[s.ChoiceID]].Score += s.Score
+		}
+		results.TotalVotes++
+	}
+
+	results.Winners = leaders(results.Choices, func(c models.ChoiceResult) int { return c.Score })
+	return results
+}
+
+// newResults — пустые итоги по всем вариантам и индекс варианта по его id
+func newResults(electionID int, method string, choices []*models.Choice) (*models.ElectionResults, map[int]int) {
+	results := &models.ElectionResults{
+		ElectionID:   electionID,
+		VotingMethod: method,
+		Choices:      make([]models.ChoiceResult, len(choices)),
+	}
+	index := make(map[int]int, len(choices))
+	for i, c := range choices {
+		results.Choices[i] = models.ChoiceResult{ChoiceID: c.ID, Text: c.Text}
+		index[c.ID] = i
+	}
+	return results, index
+}
+
+// leaders — варианты с наибольшим положительным значением key; при равенстве
+// побеждают все
+func leaders(choices []models.ChoiceResult, key func(models.ChoiceResult) int) []int {
+	best := 0
+	var res []int
+	for _, c := range choices {
+		switch v := key(c); {
+		case v > best:
+			best, res = v, []int{c.ChoiceID}
+		case v == best && v > 0:
+			res = append(res, c.ChoiceID)
+		}
+	}
+	return res
+}
+
+// validScores — оценки непустые, в пределах [0, maxScore], а варианты в них
+// известны и не повторяются
+func validScores(scores []models.ChoiceScore, maxScore int, index map[int]int) bool {
+	if len(scores) == 0 {
+		return false
+	}
+	seen := make(map[int]bool, len(scores))
+	for _, s := range scores {
+		if _, ok := index[s.ChoiceID]; !ok || seen[s.ChoiceID] || s.Score < 0 || s.Score > maxScore {
+			return false
+		}
+		seen[s.ChoiceID] = true
+	}
+	return true
+}
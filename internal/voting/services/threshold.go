@@ -0,0 +1,302 @@
+package services
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"voting-blockchain/internal/voting/models"
+)
+
+// Пороговое шифрование — распределённое создание ключа по Педерсену поверх
+// той же группы ElGamal. Каждое доверенное лицо i выбирает многочлен f_i
+// степени порог−1, публикует обязательства g^a_ij его коэффициентов и
+// передаёт лицу l долю f_i(l), зашифрованную на ключ l. Ключ голосования —
+// h = Π g^a_i0, доля лица l — x_l = Σ f_i(l), и её открытая часть
+// h_l = g^x_l вычисляется по обязательствам. Сумму бюллетеней расшифровывают
+// любые «порог» лиц: D = Π (A^x_l)^λ_l с коэффициентами Лагранжа λ_l.
+// Закрытого ключа голосования x = Σ f_i(0) не знает никто.
+
+// MaxTrustees — наибольшее число доверенных лиц голосования
+const MaxTrustees = 50
+
+// hashToScalar — равномерное число по модулю q из хеша домена и элементов
+// группы: sha256 в режиме счётчика на 64 бита длиннее q
+func hashToScalar(domain string, elements ...*big.Int) *big.Int {
+	buf := make([]byte, elementBytes)
+	var out []byte
+	for counter := uint32(0); len(out) < elementBytes+8; counter++ {
+		h := sha256.New()
+		h.Write([]byte(domain))
+		for _, e := range elements {
+			h.Write(e.FillBytes(buf))
+		}
+		binary.Write(h, binary.BigEndian, counter)
+		out = h.Sum(out)
+	}
+	n := new(big.Int).SetBytes(out)
+	return n.Mod(n, elgamalQ)
+}
+
+// shareMask — маска доли from → to, выведенная из общего секрета y^r
+func shareMask(electionID, from, to int, secret *big.Int) *big.Int {
+	return hashToScalar(fmt.Sprintf("vbc-trustee-share\nelection:%d\nfrom:%d\nto:%d\n", electionID, from, to), secret)
+}
+
+// sealShare — шифрует долю на ключ получателя y: R = g^r, C = доля + маска
+func sealShare(electionID, from, to int, y, share *big.Int) (models.EncryptedShare, error) {
+	r, err := randomScalar()
+	if err != nil {
+		return models.EncryptedShare{}, err
+	}
+	c := new(big.Int).Add(share, shareMask(electionID, from, to, modExp(y, r)))
+	return models.EncryptedShare{
+		From: from,
+		To:   to,
+		R:    bigHex(modExp(elgamalG, r)),
+		C:    bigHex(c.Mod(c, elgamalQ)),
+	}, nil
+}
+
+// openShare — расшифровывает долю закрытым ключом получателя
+func openShare(electionID int, key *big.Int, s models.EncryptedShare) (*big.Int, error) {
+	r, err := parseElement(s.R)
+	if err != nil {
+		return nil, err
+	}
+	c, err := parseScalar(s.C)
+	if err != nil {
+		return nil, err
+	}
+	share := new(big.Int).Sub(c, shareMask(electionID, s.From, s.To, modExp(r, key)))
+	return share.Mod(share, elgamalQ), nil
+}
+
+// evalPoly — значение многочлена с коэффициентами coeffs в точке x по модулю q
+func evalPoly(coeffs []*big.Int, x int) *big.Int {
+	res := new(big.Int)
+	for i := len(coeffs) - 1; i >= 0; i-- {
+		res.Mul(res, big.NewInt(int64(x)))
+		res.Add(res, coeffs[i])
+		res.Mod(res, elgamalQ)
+	}
+	return res
+}
+
+// commitmentAt — g^f(x) по обязательствам многочлена: Π C_j^(x^j)
+func commitmentAt(commitments []*big.Int, x int) *big.Int {
+	res := big.NewInt(1)
+	power := big.NewInt(1)
+	for _, c := range commitments {
+		res = modMul(res, modExp(c, power))
+		power = new(big.Int).Mul(power, big.NewInt(int64(x)))
+	}
+	return res
+}
+
+// parseCommitments — обязательства доверенного лица как элементы группы
+func parseCommitments(hexes []string) ([]*big.Int, error) {
+	res := make([]*big.Int, len(hexes))
+	for i, s := range hexes {
+		c, err := parseElement(s)
+		if err != nil {
+			return nil, err
+		}
+		res[i] = c
+	}
+	return res, nil
+}
+
+// verificationKeys — открытые части долей h_l = Π_i g^f_i(l) всех доверенных лиц
+func verificationKeys(trustees []*models.Trustee) ([]models.TrusteeKey, error) {
+	commitments := make([][]*big.Int, len(trustees))
+	for i, t := range trustees {
+		c, err := parseCommitments(t.Commitments)
+		if err != nil {
+			return nil, err
+		}
+		commitments[i] = c
+	}
+	keys := make([]models.TrusteeKey, len(trustees))
+	for l, t := range trustees {
+		h := big.NewInt(1)
+		for _, c := range commitments {
+			h = modMul(h, commitmentAt(c, t.Index))
+		}
+		keys[l] = models.TrusteeKey{Index: t.Index, H: bigHex(h)}
+	}
+	return keys, nil
+}
+
+// combinedKey — ключ голосования h = Π g^a_i0
+func combinedKey(trustees []*models.Trustee) (string, error) {
+	h := big.NewInt(1)
+	for _, t := range trustees {
+		if len(t.Commitments) == 0 {
+			return "", errors.New("нет обязательств доверенного лица")
+		}
+		c, err := parseElement(t.Commitments[0])
+		if err != nil {
+			return "", err
+		}
+		h = modMul(h, c)
+	}
+	return bigHex(h), nil
+}
+
+// lagrangeAt0 — коэффициент Лагранжа точки i среди indices в нуле по модулю q
+func lagrangeAt0(indices []int, i int) *big.Int {
+	num, den := big.NewInt(1), big.NewInt(1)
+	for _, m := range indices {
+		if m == i {
+			continue
+		}
+		num.Mul(num, big.NewInt(int64(m)))
+		den.Mul(den, big.NewInt(int64(m-i)))
+	}
+	den.Mod(den, elgamalQ)
+	num.Mul(num, den.ModInverse(den, elgamalQ))
+	return num.Mod(num, elgamalQ)
+}
+
+// combinePartials — D = Π D_i^λ_i по частичным расшифровкам доверенных лиц
+func combinePartials(partials map[int]*big.Int) *big.Int {
+	indices := make([]int, 0, len(partials))
+	for i := range partials {
+		indices = append(indices, i)
+	}
+	d := big.NewInt(1)
+	for _, i := range indices {
+		d = modMul(d, modExp(partials[i], lagrangeAt0(indices, i)))
+	}
+	return d
+}
+
+// verifyPartial — частичная расшифровка суммы sum доверенным лицом с
+// открытой частью доли h: D — элемент группы, доказательство верно. Сумма без
+// бюллетеней (единица группы) расшифровывается в единицу.
+func verifyPartial(electionID int, h *big.Int, sum elgamalCiphertext, p models.PartialDecryption) (*big.Int, bool) {
+	if sum.a.Cmp(big.NewInt(1)) == 0 {
+		return big.NewInt(1), p.D == bigHex(big.NewInt(1))
+	}
+	d, err := parseElement(p.D)
+	if err != nil || !verifyDecryption(electionID, h, sum.a, d, p.Proof) {
+		return nil, false
+	}
+	return d, true
+}
+
+// TrusteeClient — то, что доверенное лицо выполняет у себя: хранит ключ для
+// получения долей, свой многочлен и долю ключа голосования. Сервер получает
+// только открытые части. Нужен клиентам на Go и для проверки церемонии в
+// одном процессе (см. SimulateKeyCeremony).
+type TrusteeClient struct {
+	ElectionID int
+	Index      int
+	UserID     int
+	key        *big.Int
+	poly       []*big.Int
+	share      *big.Int
+}
+
+// NewTrusteeClient — клиент зарегистрированного доверенного лица с новым
+// ключом для получения долей
+func NewTrusteeClient(t *models.Trustee) (*TrusteeClient, error) {
+	key, err := randomScalar()
+	if err != nil {
+		return nil, err
+	}
+	return &TrusteeClient{ElectionID: t.ElectionID, Index: t.Index, UserID: t.UserID, key: key}, nil
+}
+
+// PublicKey — ключ для получения долей, публикуется на этапе keys
+func (c *TrusteeClient) PublicKey() string {
+	return bigHex(modExp(elgamalG, c.key))
+}
+
+// Deal — выбирает многочлен степени threshold−1 и готовит обязательства и
+// доли всем доверенным лицам, зашифрованные на их опубликованные ключи
+func (c *TrusteeClient) Deal(threshold int, trustees []*models.Trustee) (*models.TrusteeDeal, error) {
+	c.poly = make([]*big.Int, threshold)
+	deal := &models.TrusteeDeal{}
+	for j := range c.poly {
+		a, err := randomScalar()
+		if err != nil {
+			return nil, err
+		}
+		c.poly[j] = a
+		deal.Commitments = append(deal.Commitments, bigHex(modExp(elgamalG, a)))
+	}
+	for _, t := range trustees {
+		y, err := parseElement(t.PublicKey)
+		if err != nil {
+			return nil, fmt.Errorf("ключ доверенного лица %d: %w", t.Index, err)
+		}
+		s, err := sealShare(c.ElectionID, c.Index, t.Index, y, evalPoly(c.poly, t.Index))
+		if err != nil {
+			return nil, err
+		}
+		deal.Shares = append(deal.Shares, s)
+	}
+	return deal, nil
+}
+
+// AcceptShares — расшифровывает доли, адресованные этому лицу, и сверяет
+// каждую с обязательствами отправителя (Фельдман). Если все сошлись,
+// запоминает долю ключа голосования и возвращает true.
+func (c *TrusteeClient) AcceptShares(trustees []*models.Trustee, shares []models.EncryptedShare) bool {
+	byIndex := make(map[int]*models.Trustee, len(trustees))
+	for _, t := range trustees {
+		byIndex[t.Index] = t
+	}
+	if len(shares) != len(trustees) {
+		return false
+	}
+	total := new(big.Int)
+	seen := make(map[int]bool, len(shares))
+	for _, s := range shares {
+		dealer := byIndex[s.From]
+		if dealer == nil || s.To != c.Index || seen[s.From] {
+			return false
+		}
+		seen[s.From] = true
+		commitments, err := parseCommitments(dealer.Commitments)
+		if err != nil {
+			return false
+		}
+		share, err := openShare(c.ElectionID, c.key, s)
+		if err != nil || modExp(elgamalG, share).Cmp(commitmentAt(commitments, c.Index)) != 0 {
+			return false
+		}
+		total.Add(total, share)
+	}
+	c.share = total.Mod(total, elgamalQ)
+	return true
+}
+
+// Decrypt — частичные расшифровки сумм вариантов своей долей ключа
+func (c *TrusteeClient) Decrypt(tally *models.EncryptedTally) ([]models.PartialDecryption, error) {
+	if c.share == nil {
+		return nil, errors.New("доля ключа не получена")
+	}
+	h := modExp(elgamalG, c.share)
+	var res []models.PartialDecryption
+	for _, t := range tally.Choices {
+		sum, err := parseSum(t.Sum)
+		if err != nil {
+			return nil, err
+		}
+		p := models.PartialDecryption{ChoiceID: t.ChoiceID, D: bigHex(big.NewInt(1))}
+		if sum.a.Cmp(big.NewInt(1)) != 0 {
+			d, proof, err := proveDecryption(c.ElectionID, c.share, h, sum.a)
+			if err != nil {
+				return nil, err
+			}
+			p.D, p.Proof = bigHex(d), proof
+		}
+		res = append(res, p)
+	}
+	return res, nil
+}
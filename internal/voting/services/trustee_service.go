@@ -0,0 +1,443 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"voting-blockchain/internal/voting/models"
+	"voting-blockchain/internal/voting/repositories"
+)
+
+// TrusteeService — церемония ключа голосования с пороговым шифрованием и
+// частичные расшифровки итогов. Церемония проходит в черновике: админ
+// регистрирует доверенных лиц, каждое публикует ключ для получения долей
+// (keys), затем обязательства и зашифрованные доли (deal), затем проверяет
+// полученные доли (verify). Когда все подтвердили, ключ голосования
+// публикуется, и голосование можно открыть. После закрытия доверенные лица
+// присылают частичные расшифровки сумм, и при подсчёте их хватает любых
+// TrusteeThreshold.
+type TrusteeService interface {
+	Register(ctx context.Context, electionID, userID int) (*models.Trustee, error)
+	Ceremony(ctx context.Context, electionID int) (*models.Ceremony, error)
+	PublishKey(ctx context.Context, electionID, userID int, publicKey string) (*models.Trustee, error)
+	Deal(ctx context.Context, electionID, userID int, deal *models.TrusteeDeal) error
+	Shares(ctx context.Context, electionID, userID int) ([]models.EncryptedShare, error)
+	Verify(ctx context.Context, electionID, userID int, accepted bool) (*models.Ceremony, error)
+	SubmitDecryption(ctx context.Context, electionID, userID int, partials []models.PartialDecryption) error
+}
+
+type trusteeService struct {
+	uow repositories.UnitOfWork
+}
+
+func NewTrusteeService(uow repositories.UnitOfWork) TrusteeService {
+	return &trusteeService{uow: uow}
+}
+
+// Register — добавляет пользователя в доверенные лица; пока никто не
+// опубликовал обязательства
+func (s *trusteeService) Register(ctx context.Context, electionID, userID int) (*models.Trustee, error) {
+	var trustee *models.Trustee
+	err := s.uow.DoInElection(ctx, electionID, func(repos *repositories.Repositories) error {
+		_, trustees, err := ceremonyElection(ctx, repos, electionID)
+		if err != nil {
+			return err
+		}
+		if dealt(trustees) {
+			return fmt.Errorf("%w: доли уже раздаются", ErrCeremonyStage)
+		}
+		if len(trustees) >= MaxTrustees {
+			return fmt.Errorf("%w: не больше %d доверенных лиц", ErrInvalidTrusteeData, MaxTrustees)
+		}
+		for _, t := range trustees {
+			if t.UserID == userID {
+				return ErrTrusteeExists
+			}
+		}
+		unknown, err := repos.Rolls.UnknownUsers(ctx, []int{userID})
+		if err != nil {
+			return err
+		}
+		if len(unknown) > 0 {
+			return fmt.Errorf("%w: пользователь %d не найден", ErrInvalidTrusteeData, userID)
+		}
+
+		trustee = &models.Trustee{ElectionID: electionID, UserID: userID}
+		return repos.Trustees.Add(ctx, trustee)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return trustee, nil
+}
+
+// Ceremony — состояние церемонии: этап, доверенные лица с опубликованными
+// данными и, когда ключ готов, ключ голосования и открытые части долей
+func (s *trusteeService) Ceremony(ctx context.Context, electionID int) (*models.Ceremony, error) {
+	var ceremony *models.Ceremony
+	err := s.uow.DoInElection(ctx, electionID, func(repos *repositories.Repositories) error {
+		e, err := getElection(ctx, repos, electionID)
+		if err != nil {
+			return err
+		}
+		if !e.TrusteeDecryption() {
+			return ErrNotEncrypted
+		}
+		trustees, err := repos.Trustees.List(ctx, electionID)
+		if err != nil {
+			return err
+		}
+		ceremony, err = describeCeremony(e, trustees)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return ceremony, nil
+}
+
+// PublishKey — ключ доверенного лица для получения долей; до раздачи долей
+// его можно заменить
+func (s *trusteeService) PublishKey(ctx context.Context, electionID, userID int, publicKey string) (*models.Trustee, error) {
+	var trustee *models.Trustee
+	err := s.uow.DoInElection(ctx, electionID, func(repos *repositories.Repositories) error {
+		_, trustees, err := ceremonyElection(ctx, repos, electionID)
+		if err != nil {
+			return err
+		}
+		if dealt(trustees) {
+			return fmt.Errorf("%w: доли уже раздаются", ErrCeremonyStage)
+		}
+		if trustee, err = trusteeOf(trustees, userID); err != nil {
+			return err
+		}
+		if _, err := parseElement(publicKey); err != nil {
+			return fmt.Errorf("%w: ключ: %v", ErrInvalidTrusteeData, err)
+		}
+		trustee.PublicKey = publicKey
+		return repos.Trustees.Update(ctx, trustee)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return trustee, nil
+}
+
+// Deal — обязательства многочлена доверенного лица (по одному на
+// коэффициент, их TrusteeThreshold) и его доли всем доверенным лицам.
+// Принимается один раз, когда все опубликовали ключи.
+func (s *trusteeService) Deal(ctx context.Context, electionID, userID int, deal *models.TrusteeDeal) error {
+	return s.uow.DoInElection(ctx, electionID, func(repos *repositories.Repositories) error {
+		e, trustees, err := ceremonyElection(ctx, repos, electionID)
+		if err != nil {
+			return err
+		}
+		if ceremonyStage(e, trustees) != models.CeremonyDeal {
+			return ErrCeremonyStage
+		}
+		trustee, err := trusteeOf(trustees, userID)
+		if err != nil {
+			return err
+		}
+		if len(trustee.Commitments) > 0 {
+			return fmt.Errorf("%w: доли уже розданы", ErrCeremonyStage)
+		}
+
+		if len(deal.Commitments) != e.TrusteeThreshold {
+			return fmt.Errorf("%w: нужно %d обязательств", ErrInvalidTrusteeData, e.TrusteeThreshold)
+		}
+		if _, err := parseCommitments(deal.Commitments); err != nil {
+			return fmt.Errorf("%w: обязательства: %v", ErrInvalidTrusteeData, err)
+		}
+		if len(deal.Shares) != len(trustees) {
+			return fmt.Errorf("%w: нужно по доле каждому доверенному лицу", ErrInvalidTrusteeData)
+		}
+		to := make(map[int]bool, len(deal.Shares))
+		for i := range deal.Shares {
+			share := &deal.Shares[i]
+			share.From = trustee.Index
+			if _, err := trusteeAt(trustees, share.To); err != nil || to[share.To] {
+				return fmt.Errorf("%w: доля для %d", ErrInvalidTrusteeData, share.To)
+			}
+			to[share.To] = true
+			if _, err := parseElement(share.R); err != nil {
+				return fmt.Errorf("%w: доля для %d: %v", ErrInvalidTrusteeData, share.To, err)
+			}
+			if _, err := parseScalar(share.C); err != nil {
+				return fmt.Errorf("%w: доля для %d: %v", ErrInvalidTrusteeData, share.To, err)
+			}
+		}
+
+		trustee.Commitments = deal.Commitments
+		if err := repos.Trustees.Update(ctx, trustee); err != nil {
+			return err
+		}
+		return repos.Trustees.SaveShares(ctx, electionID, deal.Shares)
+	})
+}
+
+// Shares — доли, адресованные доверенному лицу; выдаются, когда доли раздали все
+func (s *trusteeService) Shares(ctx context.Context, electionID, userID int) ([]models.EncryptedShare, error) {
+	var shares []models.EncryptedShare
+	err := s.uow.DoInElection(ctx, electionID, func(repos *repositories.Repositories) error {
+		e, trustees, err := ceremonyElection(ctx, repos, electionID)
+		if err != nil {
+			return err
+		}
+		if ceremonyStage(e, trustees) != models.CeremonyVerify {
+			return ErrCeremonyStage
+		}
+		trustee, err := trusteeOf(trustees, userID)
+		if err != nil {
+			return err
+		}
+		shares, err = repos.Trustees.SharesFor(ctx, electionID, trustee.Index)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return shares, nil
+}
+
+// Verify — доверенное лицо сообщает, сошлись ли его доли с обязательствами.
+// Если нет, все доли и обязательства отбрасываются и этап deal проходит
+// заново. Когда подтвердили все, ключ голосования h = Π g^a_i0 записывается
+// в голосование.
+func (s *trusteeService) Verify(ctx context.Context, electionID, userID int, accepted bool) (*models.Ceremony, error) {
+	var ceremony *models.Ceremony
+	err := s.uow.DoInElection(ctx, electionID, func(repos *repositories.Repositories) error {
+		e, trustees, err := ceremonyElection(ctx, repos, electionID)
+		if err != nil {
+			return err
+		}
+		if ceremonyStage(e, trustees) != models.CeremonyVerify {
+			return ErrCeremonyStage
+		}
+		trustee, err := trusteeOf(trustees, userID)
+		if err != nil {
+			return err
+		}
+
+		if !accepted {
+			if err := repos.Trustees.ResetDeals(ctx, electionID); err != nil {
+				return err
+			}
+			for _, t := range trustees {
+				t.Commitments, t.Verified = nil, false
+			}
+		} else {
+			trustee.Verified = true
+			if err := repos.Trustees.Update(ctx, trustee); err != nil {
+				return err
+			}
+			if allVerified(trustees) {
+				if e.EncryptionKey, err = combinedKey(trustees); err != nil {
+					return err
+				}
+				if err := repos.Elections.Update(ctx, e); err != nil {
+					return err
+				}
+			}
+		}
+		ceremony, err = describeCeremony(e, trustees)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return ceremony, nil
+}
+
+// SubmitDecryption — частичные расшифровки сумм вариантов долей доверенного
+// лица: по одной на вариант, каждая с доказательством по открытой части доли.
+// Суммы пересчитываются по запечатанным голосам закрытого голосования.
+func (s *trusteeService) SubmitDecryption(ctx context.Context, electionID, userID int, partials []models.PartialDecryption) error {
+	return s.uow.DoInElection(ctx, electionID, func(repos *repositories.Repositories) error {
+		e, err := getElection(ctx, repos, electionID)
+		if err != nil {
+			return err
+		}
+		if !e.TrusteeDecryption() {
+			return ErrNotEncrypted
+		}
+		if e.Status != models.ElectionClosed {
+			return ErrElectionNotClosed
+		}
+		trustees, err := repos.Trustees.List(ctx, electionID)
+		if err != nil {
+			return err
+		}
+		trustee, err := trusteeOf(trustees, userID)
+		if err != nil {
+			return err
+		}
+		keys, err := verificationKeys(trustees)
+		if err != nil {
+			return err
+		}
+		h, err := parseElement(trusteeKey(keys, trustee.Index))
+		if err != nil {
+			return err
+		}
+
+		results, err := encryptedAggregate(ctx, repos, e)
+		if err != nil {
+			return err
+		}
+		if len(partials) != len(results.Encrypted.Choices) {
+			return fmt.Errorf("%w: нужно по расшифровке на каждый вариант", ErrInvalidTrusteeData)
+		}
+		for i, t := range results.Encrypted.Choices {
+			partials[i].Trustee = trustee.Index
+			sum, err := parseSum(t.Sum)
+			if err != nil {
+				return err
+			}
+			if partials[i].ChoiceID != t.ChoiceID {
+				return fmt.Errorf("%w: расшифровки должны идти в порядке вариантов", ErrInvalidTrusteeData)
+			}
+			if _, ok := verifyPartial(e.ID, h, sum, partials[i]); !ok {
+				return fmt.Errorf("%w: неверная расшифровка варианта %d", ErrInvalidTrusteeData, t.ChoiceID)
+			}
+		}
+		return repos.Trustees.SaveDecryption(ctx, electionID, trustee.Index, partials)
+	})
+}
+
+// getElection — голосование по id или ErrElectionNotFound
+func getElection(ctx context.Context, repos *repositories.Repositories, electionID int) (*models.Election, error) {
+	e, err := repos.Elections.GetByID(ctx, electionID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrElectionNotFound
+	}
+	return e, err
+}
+
+// ceremonyElection — голосование с пороговым шифрованием в черновике и его
+// доверенные лица; после выхода из черновика ключ не меняется
+func ceremonyElection(ctx context.Context, repos *repositories.Repositories, electionID int) (*models.Election, []*models.Trustee, error) {
+	e, err := getElection(ctx, repos, electionID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !e.TrusteeDecryption() {
+		return nil, nil, ErrNotEncrypted
+	}
+	if e.Status != models.ElectionDraft || e.EncryptionKey != "" {
+		return nil, nil, ErrDefinitionLocked
+	}
+	trustees, err := repos.Trustees.List(ctx, electionID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return e, trustees, nil
+}
+
+// ceremonyStage — этап церемонии по опубликованным данным
+func ceremonyStage(e *models.Election, trustees []*models.Trustee) string {
+	if e.EncryptionKey != "" {
+		return models.CeremonyDone
+	}
+	if len(trustees) < e.TrusteeThreshold {
+		return models.CeremonyKeys
+	}
+	for _, t := range trustees {
+		if t.PublicKey == "" {
+			return models.CeremonyKeys
+		}
+	}
+	for _, t := range trustees {
+		if len(t.Commitments) == 0 {
+			return models.CeremonyDeal
+		}
+	}
+	return models.CeremonyVerify
+}
+
+// describeCeremony — состояние церемонии для публикации
+func describeCeremony(e *models.Election, trustees []*models.Trustee) (*models.Ceremony, error) {
+	c := &models.Ceremony{
+		ElectionID: e.ID,
+		Threshold:  e.TrusteeThreshold,
+		Stage:      ceremonyStage(e, trustees),
+		Trustees:   trustees,
+		PublicKey:  e.EncryptionKey,
+	}
+	if c.Stage == models.CeremonyDone {
+		keys, err := verificationKeys(trustees)
+		if err != nil {
+			return nil, err
+		}
+		c.VerificationKeys = keys
+	}
+	return c, nil
+}
+
+// dealt — кто-то из доверенных лиц уже опубликовал обязательства
+func dealt(trustees []*models.Trustee) bool {
+	for _, t := range trustees {
+		if len(t.Commitments) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func allVerified(trustees []*models.Trustee) bool {
+	for _, t := range trustees {
+		if !t.Verified {
+			return false
+		}
+	}
+	return true
+}
+
+// trusteeOf — доверенное лицо-пользователь или ErrNotTrustee
+func trusteeOf(trustees []*models.Trustee, userID int) (*models.Trustee, error) {
+	for _, t := range trustees {
+		if t.UserID == userID {
+			return t, nil
+		}
+	}
+	return nil, ErrNotTrustee
+}
+
+// trusteeAt — доверенное лицо с номером index
+func trusteeAt(trustees []*models.Trustee, index int) (*models.Trustee, error) {
+	for _, t := range trustees {
+		if t.Index == index {
+			return t, nil
+		}
+	}
+	return nil, ErrNotTrustee
+}
+
+// trusteeKey — открытая часть доли доверенного лица index
+func trusteeKey(keys []models.TrusteeKey, index int) string {
+	for _, k := range keys {
+		if k.Index == index {
+			return k.H
+		}
+	}
+	return ""
+}
+
+// encryptedAggregate — суммы зашифрованных бюллетеней по запечатанным голосам
+func encryptedAggregate(ctx context.Context, repos *repositories.Repositories, e *models.Election) (*models.ElectionResults, error) {
+	choices, err := repos.Choices.GetChoices(ctx, e.ID)
+	if err != nil {
+		return nil, err
+	}
+	votes, err := repos.Votes.GetByElectionID(ctx, e.ID)
+	if err != nil {
+		return nil, err
+	}
+	blocks, err := repos.Blocks.GetAllBlocks(ctx, e.ID)
+	if err != nil {
+		return nil, err
+	}
+	return TallyEncrypted(e, choices, ChainedVotes(blocks, votes)), nil
+}
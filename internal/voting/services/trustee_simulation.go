@@ -0,0 +1,78 @@
+package services
+
+import (
+	"context"
+	"fmt"
+
+	"voting-blockchain/internal/voting/models"
+)
+
+// SimulateKeyCeremony — проводит церемонию ключа в одном процессе: регистрирует
+// пользователей userIDs доверенными лицами и выполняет за каждого этапы keys,
+// deal и verify через svc, как это делали бы их клиенты. Возвращает клиентов
+// с долями ключа для SimulateDecryption. Нужна для тестов и демонстраций:
+// в настоящей церемонии доли хранятся у разных людей.
+func SimulateKeyCeremony(ctx context.Context, svc TrusteeService, electionID int, userIDs []int) ([]*TrusteeClient, error) {
+	var clients []*TrusteeClient
+	for _, userID := range userIDs {
+		t, err := svc.Register(ctx, electionID, userID)
+		if err != nil {
+			return nil, err
+		}
+		c, err := NewTrusteeClient(t)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := svc.PublishKey(ctx, electionID, userID, c.PublicKey()); err != nil {
+			return nil, err
+		}
+		clients = append(clients, c)
+	}
+
+	ceremony, err := svc.Ceremony(ctx, electionID)
+	if err != nil {
+		return nil, err
+	}
+	for _, c := range clients {
+		deal, err := c.Deal(ceremony.Threshold, ceremony.Trustees)
+		if err != nil {
+			return nil, err
+		}
+		if err := svc.Deal(ctx, electionID, c.UserID, deal); err != nil {
+			return nil, err
+		}
+	}
+
+	if ceremony, err = svc.Ceremony(ctx, electionID); err != nil {
+		return nil, err
+	}
+	for _, c := range clients {
+		shares, err := svc.Shares(ctx, electionID, c.UserID)
+		if err != nil {
+			return nil, err
+		}
+		accepted := c.AcceptShares(ceremony.Trustees, shares)
+		if _, err := svc.Verify(ctx, electionID, c.UserID, accepted); err != nil {
+			return nil, err
+		}
+		if !accepted {
+			return nil, fmt.Errorf("%w: доверенное лицо %d отклонило доли", ErrInvalidTrusteeData, c.Index)
+		}
+	}
+	return clients, nil
+}
+
+// SimulateDecryption — каждый из clients расшифровывает суммы tally своей
+// долей и отправляет частичные расшифровки через svc
+func SimulateDecryption(ctx context.Context, svc TrusteeService, tally *models.EncryptedTally, clients []*TrusteeClient) error {
+	for _, c := range clients {
+		partials, err := c.Decrypt(tally)
+		if err != nil {
+			return err
+		}
+		if err := svc.SubmitDecryption(ctx, c.ElectionID, c.UserID, partials); err != nil {
+			return err
+		}
+	}
+	return nil
+}
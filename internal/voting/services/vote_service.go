@@ -1,121 +1,333 @@
-package services
-
-import (
-	"context"
-	"crypto/sha256"
-	"encoding/hex"
-	"errors"
-	"fmt"
-	"time"
-
-	"github.com/jackc/pgx/v5"
-	"voting-blockchain/internal/voting/models"
-	"voting-blockchain/internal/voting/repositories"
-)
-
-type VoteService interface {
-	CastVote(ctx context.Context, userID, electionID int, choice string) error
-	GetBlockchain(ctx context.Context, electionID int) ([]*models.Block, error)
-	GetResults(ctx context.Context, electionID int) (map[string]int, error)
-	GetChoices(ctx context.Context, electionID int) ([]*models.Choice, error)
-}
-
-type voteService struct {
-	voteRepo  repositories.VoteRepository
-	blockRepo repositories.BlockchainRepository
-}
-
-func NewVoteService(
-	voteRepo repositories.VoteRepository,
-	blockRepo repositories.BlockchainRepository,
-) VoteService {
-	return &voteService{
-		voteRepo:  voteRepo,
-		blockRepo: blockRepo,
-	}
-}
-
-func (s *voteService) CastVote(ctx context.Context, userID, electionID int, choice string) error {
-	exists, err := s.voteRepo.HasVoted(ctx, userID, electionID)
-	if err != nil {
-		return err
-	}
-	if exists {
-		return errors.New("пользователь уже голосовал в этом голосовании")
-	}
-
-	hash := generateVoteHash(userID, electionID, choice)
-
-	vote := &models.Vote{
-		UserID:     userID,
-		ElectionID: electionID,
-		Choice:     choice,
-		VoteHash:   hash,
-	}
-
-	if err := s.voteRepo.Create(ctx, vote); err != nil {
-		return err
-	}
-
-	lastBlock, err := s.blockRepo.GetLastBlock(ctx, electionID)
-	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
-		return err
-	}
-
-	newBlock := &models.Block{
-		Timestamp:  time.Now(),
-		VoteHash:   vote.VoteHash,
-		ElectionID: electionID,
-	}
-
-	if lastBlock != nil {
-		newBlock.PrevHash = lastBlock.Hash
-	}
-
-	newBlock.Hash = generateBlockHash(newBlock)
-
-	return s.blockRepo.AddBlock(ctx, newBlock)
-}
-
-func (s *voteService) GetBlockchain(ctx context.Context, electionID int) ([]*models.Block, error) {
-	return s.blockRepo.GetAllBlocks(ctx, electionID)
-}
-
-// Подсчет количества голосов по каждому варианту
-func (s *voteService) GetResults(ctx context.Context, electionID int) (map[string]int, error) {
-	blocks, err := s.blockRepo.GetAllBlocks(ctx, electionID)
-	if err != nil {
-		return nil, err
-	}
-
-	results := make(map[string]int)
-
-	for _, block := range blocks {
-		vote, err := s.voteRepo.GetByHash(ctx, block.VoteHash)
-		if err != nil {
-			return nil, err
-		}
-		results[vote.Choice]++
-	}
-
-	return results, nil
-}
-
-// Возврат списка уникальных вариантов (Choices)
-func (s *voteService) GetChoices(ctx context.Context, electionID int) ([]*models.Choice, error) {
-	return s.voteRepo.GetResults(ctx, electionID)
-}
-
-func generateVoteHash(userID, electionID int, choice string) string {
-	raw := fmt.Sprintf("%d|%d|%s", userID, electionID, choice)
-	hash := sha256.Sum256([]byte(raw))
-	return hex.EncodeToString(hash[:])
-}
-
-func generateBlockHash(b *models.Block) string {
-	data := []byte(
-		b.Timestamp.String() + b.VoteHash + b.PrevHash + fmt.Sprintf("%d", b.ElectionID),
-	)
-	hash := sha256.Sum256(data)
-	return hex.EncodeToString(hash[:])
-}
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"voting-blockchain/internal/voting/models"
+	"voting-blockchain/internal/voting/repositories"
+)
+
+type VoteService interface {
+	CastVote(ctx context.Context, userID, electionID int, ballot *models.Ballot) (*models.VoteReceipt, error)
+	CastVoteWithCode(ctx context.Context, electionID int, code string, ballot *models.Ballot) (*models.VoteReceipt, error)
+	CastAnonymousVote(ctx context.Context, electionID int, token, signature []byte, ballot *models.Ballot) (*models.VoteReceipt, error)
+	GetInclusionProof(ctx context.Context, electionID int, voteHash string) (*models.VoteReceipt, error)
+	GetBlockchain(ctx context.Context, electionID int) ([]*models.Block, error)
+	GetResults(ctx context.Context, electionID int) (*models.ElectionResults, error)
+	GetChoices(ctx context.Context, electionID int) ([]*models.Choice, error)
+}
+
+type voteService struct {
+	voteRepo     repositories.VoteRepository
+	blockRepo    repositories.BlockchainRepository
+	electionRepo repositories.ElectionRepository
+	choiceRepo   repositories.ChoiceRepository
+	rollRepo     repositories.VoterRollRepository
+	codeRepo     repositories.BallotCodeRepository
+	uow          repositories.UnitOfWork
+	signer       *BlockSigner
+}
+
+func NewVoteService(
+	voteRepo repositories.VoteRepository,
+	blockRepo repositories.BlockchainRepository,
+	electionRepo repositories.ElectionRepository,
+	choiceRepo repositories.ChoiceRepository,
+	rollRepo repositories.VoterRollRepository,
+	codeRepo repositories.BallotCodeRepository,
+	uow repositories.UnitOfWork,
+	signer *BlockSigner,
+) VoteService {
+	return &voteService{
+		voteRepo:     voteRepo,
+		blockRepo:    blockRepo,
+		electionRepo: electionRepo,
+		choiceRepo:   choiceRepo,
+		rollRepo:     rollRepo,
+		codeRepo:     codeRepo,
+		uow:          uow,
+		signer:       signer,
+	}
+}
+
+// CastVote — проверяет допуск избирателя и бюллетень по вариантам голосования,
+// сохраняет голос и, если пачка набралась, запечатывает её в блок. Всё
+// выполняется одной транзакцией под блокировкой голосования, чтобы
+// параллельные голоса не разветвили цепочку. Возвращает квитанцию избирателя.
+func (s *voteService) CastVote(ctx context.Context, userID, electionID int, ballot *models.Ballot) (*models.VoteReceipt, error) {
+	return s.castVote(ctx, electionID, userID, ballot, func(repos *repositories.Repositories, election *models.Election) error {
+		if election.BlindTokens() {
+			return ErrAnonymousVoting
+		}
+		if election.RollRestricted() {
+			eligible, err := repos.Rolls.IsEligible(ctx, electionID, userID)
+			if err != nil {
+				return err
+			}
+			if !eligible {
+				return ErrNotEligible
+			}
+		}
+
+		exists, err := repos.Votes.HasVoted(ctx, userID, electionID)
+		if err != nil {
+			return err
+		}
+		if exists {
+			return ErrAlreadyVoted
+		}
+		return nil
+	})
+}
+
+// CastVoteWithCode — то же, что CastVote, но избиратель допускается по
+// одноразовому коду вместо учётной записи. Код гасится в той же транзакции,
+// а голос сохраняется без пользователя.
+func (s *voteService) CastVoteWithCode(ctx context.Context, electionID int, code string, ballot *models.Ballot) (*models.VoteReceipt, error) {
+	return s.castVote(ctx, electionID, 0, ballot, func(repos *repositories.Repositories, election *models.Election) error {
+		ok, err := repos.Codes.Consume(ctx, electionID, models.BallotCodeHash(code))
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return ErrInvalidBallotCode
+		}
+		return nil
+	})
+}
+
+// CastAnonymousVote — голос анонимного голосования по жетону со слепой
+// подписью (см. BlindTokenService). Вход не нужен: жетон проверяется
+// подписью ключа голосования и гасится хешем, голос сохраняется без пользователя.
+func (s *voteService) CastAnonymousVote(ctx context.Context, electionID int, token, signature []byte, ballot *models.Ballot) (*models.VoteReceipt, error) {
+	return s.castVote(ctx, electionID, 0, ballot, func(repos *repositories.Repositories, election *models.Election) error {
+		if !election.BlindTokens() {
+			return ErrNotAnonymous
+		}
+		priv, err := electionBlindKey(ctx, repos, electionID, false)
+		if err != nil {
+			return err
+		}
+		if !VerifyBlindToken(&priv.PublicKey, electionID, token, signature) {
+			return ErrInvalidBlindToken
+		}
+		fresh, err := repos.Blind.SpendToken(ctx, electionID, models.BlindTokenHash(token))
+		if err != nil {
+			return err
+		}
+		if !fresh {
+			return ErrAlreadyVoted
+		}
+		return nil
+	})
+}
+
+// castVote — общая часть CastVote, CastVoteWithCode и CastAnonymousVote; admit проверяет допуск
+// избирателя к открытому голосованию
+func (s *voteService) castVote(
+	ctx context.Context,
+	electionID, userID int,
+	ballot *models.Ballot,
+	admit func(repos *repositories.Repositories, election *models.Election) error,
+) (*models.VoteReceipt, error) {
+	var receipt *models.VoteReceipt
+	err := s.uow.DoInElection(ctx, electionID, func(repos *repositories.Repositories) error {
+		election, err := repos.Elections.GetByID(ctx, electionID)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrElectionNotFound
+		}
+		if err != nil {
+			return err
+		}
+		if !election.AcceptsVotes(time.Now()) {
+			return ErrElectionNotOpen
+		}
+		if err := admit(repos, election); err != nil {
+			return err
+		}
+
+		choices, err := repos.Choices.GetChoices(ctx, electionID)
+		if err != nil {
+			return err
+		}
+		choice, err := normalizeBallot(election, ballot, choices)
+		if err != nil {
+			return err
+		}
+		if election.Encrypted() {
+			if err := rejectReplayedBallot(ctx, repos, electionID, ballot.Encrypted); err != nil {
+				return err
+			}
+		}
+
+		salt, err := generateSalt()
+		if err != nil {
+			return err
+		}
+
+		vote := &models.Vote{
+			UserID:      userID,
+			ElectionID:  electionID,
+			Choice:      choice.Text,
+			ChoiceID:    choice.ID,
+			Ballot:      ballot.Canonical(),
+			Salt:        salt,
+			HashVersion: models.CurrentVoteHashVersion,
+		}
+		vote.VoteHash = vote.CalculateHash()
+
+		if err := repos.Votes.Create(ctx, vote); err != nil {
+			return err
+		}
+
+		block, err := sealIfDue(ctx, repos, s.signer, election)
+		if err != nil {
+			return err
+		}
+
+		// Соль отдаётся только самому избирателю: с ней он может пересчитать
+		// хеш своего голоса из выбора
+		receipt = &models.VoteReceipt{
+			ElectionID: electionID,
+			VoteHash:   vote.VoteHash,
+			Salt:       vote.Salt,
+			Status:     models.ReceiptPending,
+		}
+		if block != nil {
+			sealed, err := repos.Votes.GetByBlockID(ctx, block.Index)
+			if err != nil {
+				return err
+			}
+			fillSealedReceipt(receipt, block, sealed)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return receipt, nil
+}
+
+// GetInclusionProof — квитанция с доказательством включения голоса: путь Merkle
+// до корня его блока и цепочка блоков от него до текущей вершины
+func (s *voteService) GetInclusionProof(ctx context.Context, electionID int, voteHash string) (*models.VoteReceipt, error) {
+	vote, err := s.voteRepo.GetByHash(ctx, voteHash)
+	if errors.Is(err, pgx.ErrNoRows) || (err == nil && vote.ElectionID != electionID) {
+		return nil, ErrVoteNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	receipt := &models.VoteReceipt{ElectionID: electionID, VoteHash: vote.VoteHash, Status: models.ReceiptPending}
+	if vote.BlockID == nil {
+		return receipt, nil
+	}
+
+	blocks, err := s.blockRepo.GetAllBlocks(ctx, electionID)
+	if err != nil {
+		return nil, err
+	}
+	sealed, err := s.voteRepo.GetByBlockID(ctx, *vote.BlockID)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, b := range blocks {
+		if b.Index == *vote.BlockID {
+			fillSealedReceipt(receipt, b, sealed)
+			receipt.ChainPath = blocks[i:]
+			receipt.HeadHash = blocks[len(blocks)-1].Hash
+			return receipt, nil
+		}
+	}
+	return nil, ErrVoteNotFound
+}
+
+// fillSealedReceipt — дополняет квитанцию данными блока и путём Merkle
+func fillSealedReceipt(receipt *models.VoteReceipt, block *models.Block, sealed []*models.Vote) {
+	leaves := make([]string, len(sealed))
+	for i, v := range sealed {
+		leaves[i] = v.VoteHash
+		if v.VoteHash == receipt.VoteHash {
+			receipt.LeafIndex = i
+		}
+	}
+
+	height := block.Height
+	receipt.Status = models.ReceiptSealed
+	receipt.BlockIndex = &height
+	receipt.BlockHash = block.Hash
+	receipt.HashVersion = block.HashVersion
+	receipt.MerkleRoot = block.VoteHash
+	receipt.LeafCount = len(leaves)
+	receipt.MerkleProof = models.MerkleProof(block.HashVersion, leaves, receipt.LeafIndex)
+}
+
+func (s *voteService) GetBlockchain(ctx context.Context, electionID int) ([]*models.Block, error) {
+	return s.blockRepo.GetAllBlocks(ctx, electionID)
+}
+
+// Подсчет голосов способом голосования, см. TallyElection.
+// Для подсчитанного голосования возвращаются сохранённые итоги.
+func (s *voteService) GetResults(ctx context.Context, electionID int) (*models.ElectionResults, error) {
+	tally, err := s.electionRepo.GetTally(ctx, electionID)
+	if err == nil {
+		tally.Results.Final = true
+		return tally.Results, nil
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return nil, err
+	}
+
+	election, err := s.electionRepo.GetByID(ctx, electionID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrElectionNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	choices, err := s.choiceRepo.GetChoices(ctx, electionID)
+	if err != nil {
+		return nil, err
+	}
+
+	votes, err := s.voteRepo.GetByElectionID(ctx, electionID)
+	if err != nil {
+		return nil, err
+	}
+	blocks, err := s.blockRepo.GetAllBlocks(ctx, electionID)
+	if err != nil {
+		return nil, err
+	}
+	votes = ChainedVotes(blocks, votes)
+
+	if election.WriteInReviews, err = s.electionRepo.ListWriteInReviews(ctx, electionID); err != nil {
+		return nil, err
+	}
+	results := TallyElection(election, choices, votes)
+	if results.Turnout, err = rollTurnout(ctx, s.rollRepo, s.codeRepo, election, votes); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// Возврат списка уникальных вариантов (Choices)
+func (s *voteService) GetChoices(ctx context.Context, electionID int) ([]*models.Choice, error) {
+	return s.voteRepo.GetResults(ctx, electionID)
+}
+
+// generateSalt — 32 случайных байта соли голоса в hex
+func generateSalt() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,233 @@
+package services
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"voting-blockchain/internal/voting/models"
+	"voting-blockchain/internal/voting/repositories"
+)
+
+// VoterRollService — списки избирателей. Список хранится вне цепочки: в
+// генезис-блок попадает только режим допуска, а не сами избиратели.
+type VoterRollService interface {
+	List(ctx context.Context, electionID int) ([]*models.RollEntry, error)
+	Upload(ctx context.Context, electionID int, entries []models.RollEntry, replace bool) (*models.RollSummary, error)
+}
+
+type voterRollService struct {
+	electionRepo repositories.ElectionRepository
+	rollRepo     repositories.VoterRollRepository
+	uow          repositories.UnitOfWork
+}
+
+func NewVoterRollService(
+	electionRepo repositories.ElectionRepository,
+	rollRepo repositories.VoterRollRepository,
+	uow repositories.UnitOfWork,
+) VoterRollService {
+	return &voterRollService{
+		electionRepo: electionRepo,
+		rollRepo:     rollRepo,
+		uow:          uow,
+	}
+}
+
+// List — записи списка избирателей в порядке добавления
+func (s *voterRollService) List(ctx context.Context, electionID int) ([]*models.RollEntry, error) {
+	if _, err := s.electionRepo.GetByID(ctx, electionID); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrElectionNotFound
+		}
+		return nil, err
+	}
+	return s.rollRepo.List(ctx, electionID)
+}
+
+// Upload — добавляет записи в список избирателей или, если replace, заменяет
+// им весь список. Email приводятся к нижнему регистру, повторы пропускаются;
+// список меняется только до закрытия голосования. Загрузка принимается
+// целиком или не принимается совсем.
+func (s *voterRollService) Upload(ctx context.Context, electionID int, entries []models.RollEntry, replace bool) (*models.RollSummary, error) {
+	entries, err := normalizeRoll(entries)
+	if err != nil {
+		return nil, err
+	}
+
+	summary := &models.RollSummary{}
+	err = s.uow.DoInElection(ctx, electionID, func(repos *repositories.Repositories) error {
+		election, err := repos.Elections.GetByID(ctx, electionID)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrElectionNotFound
+		}
+		if err != nil {
+			return err
+		}
+		switch election.Status {
+		case models.ElectionDraft, models.ElectionScheduled, models.ElectionOpen:
+		default:
+			return ErrRollLocked
+		}
+
+		var ids []int
+		for _, e := range entries {
+			if e.UserID != 0 {
+				ids = append(ids, e.UserID)
+			}
+		}
+		if len(ids) > 0 {
+			unknown, err := repos.Rolls.UnknownUsers(ctx, ids)
+			if err != nil {
+				return err
+			}
+			if len(unknown) > 0 {
+				return fmt.Errorf("%w: нет пользователей с id %v", ErrInvalidRollEntry, unknown)
+			}
+		}
+
+		if replace {
+			if err := repos.Rolls.Clear(ctx, electionID); err != nil {
+				return err
+			}
+		}
+		if summary.Added, err = repos.Rolls.Add(ctx, electionID, entries); err != nil {
+			return err
+		}
+		summary.Size, err = repos.Rolls.Size(ctx, electionID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return summary, nil
+}
+
+// normalizeRoll — в каждой записи ровно одно из user_id и email, email
+// похож на адрес и приводится к нижнему регистру; повторы отбрасываются
+func normalizeRoll(entries []models.RollEntry) ([]models.RollEntry, error) {
+	res := make([]models.RollEntry, 0, len(entries))
+	seenIDs := make(map[int]bool)
+	seenEmails := make(map[string]bool)
+	for i, e := range entries {
+		e.Email = strings.ToLower(strings.TrimSpace(e.Email))
+		switch {
+		case (e.UserID == 0) == (e.Email == ""):
+			return nil, fmt.Errorf("%w: запись %d: нужен ровно один из user_id и email", ErrInvalidRollEntry, i+1)
+		case e.UserID < 0:
+			return nil, fmt.Errorf("%w: запись %d: некорректный user_id", ErrInvalidRollEntry, i+1)
+		case e.Email != "" && !validEmail(e.Email):
+			return nil, fmt.Errorf("%w: запись %d: некорректный email %q", ErrInvalidRollEntry, i+1, e.Email)
+		}
+		if seenIDs[e.UserID] || seenEmails[e.Email] {
+			continue
+		}
+		if e.UserID != 0 {
+			seenIDs[e.UserID] = true
+		} else {
+			seenEmails[e.Email] = true
+		}
+		res = append(res, models.RollEntry{UserID: e.UserID, Email: e.Email})
+	}
+	return res, nil
+}
+
+// validEmail — одна @, непустые имя и домен, без пробелов
+func validEmail(email string) bool {
+	name, domain, ok := strings.Cut(email, "@")
+	return ok && name != "" && domain != "" && !strings.Contains(domain, "@") &&
+		!strings.ContainsFunc(email, func(r rune) bool { return r <= ' ' })
+}
+
+// ParseRollCSV — читает список избирателей из CSV. Если первая строка —
+// заголовок с колонками user_id и/или email, значения берутся из них (при
+// обоих заполненных — user_id); иначе каждая строка — одно значение: число
+// считается id пользователя, остальное — email. Пустые строки пропускаются.
+func ParseRollCSV(r io.Reader) ([]models.RollEntry, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+	records, err := cr.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidRollEntry, err)
+	}
+
+	userCol, emailCol, first := -1, -1, 0
+	if len(records) > 0 {
+		for i, name := range records[0] {
+			switch strings.ToLower(strings.TrimSpace(name)) {
+			case "user_id":
+				userCol = i
+			case "email":
+				emailCol = i
+			}
+		}
+		if userCol >= 0 || emailCol >= 0 {
+			first = 1
+		}
+	}
+
+	var entries []models.RollEntry
+	for line := first; line < len(records); line++ {
+		rec := records[line]
+		field := func(i int) string {
+			if i < 0 || i >= len(rec) {
+				return ""
+			}
+			return strings.TrimSpace(rec[i])
+		}
+
+		userID, email := field(userCol), field(emailCol)
+		if first == 0 {
+			userID, email = "", field(0)
+			if _, err := strconv.Atoi(email); err == nil {
+				userID, email = email, ""
+			}
+		}
+		switch {
+		case userID != "":
+			id, err := strconv.Atoi(userID)
+			if err != nil {
+				return nil, fmt.Errorf("%w: строка %d: некорректный user_id %q", ErrInvalidRollEntry, line+1, userID)
+			}
+			entries = append(entries, models.RollEntry{UserID: id})
+		case email != "":
+			entries = append(entries, models.RollEntry{Email: email})
+		}
+	}
+	return entries, nil
+}
+
+// rollTurnout — явка голосования по списку избирателей: поданные голоса от
+// числа избирателей в списке и выпущенных кодов голосования. Для голосования,
+// открытого всем, — nil.
+func rollTurnout(
+	ctx context.Context,
+	rolls repositories.VoterRollRepository,
+	codes repositories.BallotCodeRepository,
+	e *models.Election,
+	votes []*models.Vote,
+) (*models.Turnout, error) {
+	if !e.RollRestricted() {
+		return nil, nil
+	}
+	size, err := rolls.Size(ctx, e.ID)
+	if err != nil {
+		return nil, err
+	}
+	stats, err := codes.Stats(ctx, e.ID)
+	if err != nil {
+		return nil, err
+	}
+	t := &models.Turnout{RollSize: size, Codes: stats.Issued, Voted: len(votes)}
+	if electorate := size + stats.Issued; electorate > 0 {
+		t.Percent = math.Round(float64(t.Voted)*10000/float64(electorate)) / 100
+	}
+	return t, nil
+}
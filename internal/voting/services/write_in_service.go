@@ -0,0 +1,239 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/jackc/pgx/v5"
+	"voting-blockchain/internal/voting/models"
+	"voting-blockchain/internal/voting/repositories"
+)
+
+// WriteInService — разбор вписанных вариантов. Голоса за вписанный вариант
+// зафиксированы в цепочке как есть; администратор решает, какому варианту
+// они засчитываются, и итоги нельзя утвердить, пока решения не приняты.
+type WriteInService interface {
+	List(ctx context.Context, electionID int) ([]*models.WriteInTally, error)
+	Review(ctx context.Context, w *models.WriteInReview) error
+}
+
+type writeInService struct {
+	electionRepo repositories.ElectionRepository
+	voteRepo     repositories.VoteRepository
+	uow          repositories.UnitOfWork
+}
+
+func NewWriteInService(
+	electionRepo repositories.ElectionRepository,
+	voteRepo repositories.VoteRepository,
+	uow repositories.UnitOfWork,
+) WriteInService {
+	return &writeInService{
+		electionRepo: electionRepo,
+		voteRepo:     voteRepo,
+		uow:          uow,
+	}
+}
+
+// List — вписанные варианты запечатанных голосов по вопросам с числом голосов
+// и принятыми решениями
+func (s *writeInService) List(ctx context.Context, electionID int) ([]*models.WriteInTally, error) {
+	if _, err := s.electionRepo.GetByID(ctx, electionID); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrElectionNotFound
+		}
+		return nil, err
+	}
+	votes, err := s.voteRepo.GetByElectionID(ctx, electionID)
+	if err != nil {
+		return nil, err
+	}
+	reviews, err := s.electionRepo.ListWriteInReviews(ctx, electionID)
+	if err != nil {
+		return nil, err
+	}
+	return tallyWriteIns(votes, reviews), nil
+}
+
+// Review — засчитывает голоса за вписанный вариант варианту w.ChoiceID того же
+// вопроса или, если он 0, оставляет их за вписанным вариантом. Решение можно
+// менять до утверждения итогов; у подсчитанного голосования итоги
+// пересчитываются сразу.
+func (s *writeInService) Review(ctx context.Context, w *models.WriteInReview) error {
+	return s.uow.DoInElection(ctx, w.ElectionID, func(repos *repositories.Repositories) error {
+		election, err := repos.Elections.GetByID(ctx, w.ElectionID)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrElectionNotFound
+		}
+		if err != nil {
+			return err
+		}
+		switch election.Status {
+		case models.ElectionOpen, models.ElectionClosed, models.ElectionTallied:
+		default:
+			return ErrWriteInsLocked
+		}
+
+		text, ok := models.NormalizeWriteIn(w.Text)
+		if !ok {
+			return ErrWriteInNotFound
+		}
+		w.Text = text
+		if !hasContest(election, w.ContestID) {
+			return fmt.Errorf("%w: вопроса %d нет в голосовании", ErrInvalidContest, w.ContestID)
+		}
+		if w.ChoiceID != 0 {
+			choices, err := repos.Choices.GetChoices(ctx, w.ElectionID)
+			if err != nil {
+				return err
+			}
+			if !hasChoice(models.ContestChoices(choices, w.ContestID), w.ChoiceID) {
+				return ErrInvalidChoice
+			}
+		}
+
+		votes, err := repos.Votes.GetByElectionID(ctx, w.ElectionID)
+		if err != nil {
+			return err
+		}
+		found := false
+		for _, t := range tallyWriteIns(votes, nil) {
+			found = found || (t.ContestID == w.ContestID && models.WriteInKey(t.Text) == models.WriteInKey(text))
+		}
+		if !found {
+			return ErrWriteInNotFound
+		}
+
+		if err := repos.Elections.SaveWriteInReview(ctx, w); err != nil {
+			return err
+		}
+		if election.Status == models.ElectionTallied {
+			return saveTally(ctx, repos, election)
+		}
+		return nil
+	})
+}
+
+// checkWriteInsReviewed — по каждому вписанному варианту голосования принято решение
+func checkWriteInsReviewed(ctx context.Context, repos *repositories.Repositories, e *models.Election) error {
+	votes, err := repos.Votes.GetByElectionID(ctx, e.ID)
+	if err != nil {
+		return err
+	}
+	reviews, err := repos.Elections.ListWriteInReviews(ctx, e.ID)
+	if err != nil {
+		return err
+	}
+	pending := 0
+	for _, t := range tallyWriteIns(votes, reviews) {
+		if !t.Reviewed {
+			pending++
+		}
+	}
+	if pending > 0 {
+		return fmt.Errorf("%w: осталось %d", ErrWriteInsPending, pending)
+	}
+	return nil
+}
+
+// tallyWriteIns — вписанные варианты запечатанных голосов, сгруппированные по
+// вопросу и тексту без учёта регистра, с решениями из reviews. Текст группы —
+// первый из поданных; группы упорядочены по вопросу и убыванию числа голосов.
+func tallyWriteIns(votes []*models.Vote, reviews []*models.WriteInReview) []*models.WriteInTally {
+	type groupKey struct {
+		contestID int
+		text      string
+	}
+	groups := make(map[groupKey]*models.WriteInTally)
+	var res []*models.WriteInTally
+	for _, vote := range votes {
+		if vote.BlockID == nil {
+			continue
+		}
+		ballot, err := vote.BallotContents()
+		if err != nil {
+			continue
+		}
+		for _, answer := range ballotAnswers(ballot) {
+			if answer.WriteIn == "" {
+				continue
+			}
+			key := groupKey{answer.ContestID, models.WriteInKey(answer.WriteIn)}
+			t := groups[key]
+			if t == nil {
+				t = &models.WriteInTally{ContestID: answer.ContestID, Text: answer.WriteIn}
+				groups[key] = t
+				res = append(res, t)
+			}
+			t.Votes++
+		}
+	}
+	for _, w := range reviews {
+		if t := groups[groupKey{w.ContestID, models.WriteInKey(w.Text)}]; t != nil {
+			t.Reviewed = true
+			t.ChoiceID = w.ChoiceID
+		}
+	}
+
+	sort.SliceStable(res, func(i, j int) bool {
+		if res[i].ContestID != res[j].ContestID {
+			return res[i].ContestID < res[j].ContestID
+		}
+		return res[i].Votes > res[j].Votes
+	})
+	return res
+}
+
+// resolveWriteIns — голоса вопроса contestID, в которых вписанные варианты,
+// засчитанные решениями reviews другим вариантам, заменены этими вариантами
+func resolveWriteIns(votes []*models.Vote, reviews []*models.WriteInReview, contestID int) []*models.Vote {
+	merged := make(map[string]int)
+	for _, w := range reviews {
+		if w.ContestID == contestID && w.ChoiceID != 0 {
+			merged[models.WriteInKey(w.Text)] = w.ChoiceID
+		}
+	}
+	if len(merged) == 0 {
+		return votes
+	}
+
+	res := make([]*models.Vote, len(votes))
+	for i, vote := range votes {
+		res[i] = vote
+		ballot, err := vote.BallotContents()
+		if err != nil || ballot.WriteIn == "" {
+			continue
+		}
+		if choiceID, ok := merged[models.WriteInKey(ballot.WriteIn)]; ok {
+			resolved := models.Ballot{ChoiceID: choiceID}
+			res[i] = &models.Vote{
+				ElectionID:  vote.ElectionID,
+				HashVersion: models.VoteHashBallot,
+				Ballot:      resolved.Canonical(),
+				BlockID:     vote.BlockID,
+			}
+		}
+	}
+	return res
+}
+
+// ballotAnswers — ответы бюллетеня по вопросам; бюллетень голосования без
+// вопросов — единственный ответ с ContestID 0
+func ballotAnswers(ballot *models.Ballot) []models.ContestBallot {
+	if len(ballot.Contests) == 0 {
+		return []models.ContestBallot{{Ballot: *ballot}}
+	}
+	return ballot.Contests
+}
+
+// hasChoice — есть ли вариант choiceID среди choices
+func hasChoice(choices []*models.Choice, choiceID int) bool {
+	for _, c := range choices {
+		if c.ID == choiceID {
+			return true
+		}
+	}
+	return false
+}
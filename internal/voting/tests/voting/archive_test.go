@@ -0,0 +1,215 @@
+package voting_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"voting-blockchain/internal/voting/models"
+	"voting-blockchain/internal/voting/services"
+)
+
+// archiveOf — выгружает голосование из mockStore
+func archiveOf(t *testing.T, f *mockStore, id int) *models.ElectionArchive {
+	t.Helper()
+	a, err := f.blockchainService().ExportElection(context.Background(), id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return a
+}
+
+// roundTrip — записывает архив и читает его обратно, как это делает cmd/verify
+func roundTrip(t *testing.T, a *models.ElectionArchive) *models.ElectionArchive {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := services.WriteArchive(&buf, a); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(buf.String(), "UserID") || strings.Contains(buf.String(), "user_id") {
+		t.Fatal("archive must not contain voter identities")
+	}
+	read, err := services.ReadArchive(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return read
+}
+
+func TestVerifyArchive_Valid(t *testing.T) {
+	f, id := buildChain(t, 4)
+	a := roundTrip(t, archiveOf(t, f, id))
+
+	res := services.VerifyArchive(a, nil)
+	if !res.Valid || res.BlocksChecked != 5 {
+		t.Fatalf("expected valid archive, got %+v", res)
+	}
+
+	votes := make([]*models.Vote, len(a.Votes))
+	for i, v := range a.Votes {
+		votes[i] = v.Vote()
+	}
+	if tally := services.TallyVotes(a.Election.ID, a.Choices, votes); votesFor(tally, "A") != 4 {
+		t.Errorf("expected 4 votes for A, got %+v", tally)
+	}
+}
+
+func TestVerifyArchive_TamperedChoice(t *testing.T) {
+	f, id := buildChain(t, 3)
+	a := roundTrip(t, archiveOf(t, f, id))
+	a.Votes[2].ChoiceID = a.Choices[1].ID
+
+	res := services.VerifyArchive(a, nil)
+	if res.Valid || res.BrokenIndex == nil || *res.BrokenIndex != 3 {
+		t.Fatalf("expected broken block 3, got %+v", res)
+	}
+}
+
+func TestVerifyArchive_UntrustedKey(t *testing.T) {
+	f, id := buildChain(t, 2)
+	a := roundTrip(t, archiveOf(t, f, id))
+
+	other, err := services.GenerateBlockSigner()
+	if err != nil {
+		t.Fatal(err)
+	}
+	res := services.VerifyArchive(a, []*models.SigningKey{other.PublicKey()})
+	if res.Valid || res.BrokenIndex == nil || *res.BrokenIndex != 0 {
+		t.Fatalf("expected unknown key at genesis, got %+v", res)
+	}
+}
+
+func TestVerifyArchive_StrippedSignaturesWithTrustedKey(t *testing.T) {
+	f, id := buildChain(t, 2)
+	a := roundTrip(t, archiveOf(t, f, id))
+	for _, b := range a.Blocks {
+		b.Signature, b.KeyID = "", ""
+	}
+	a.Keys = nil
+
+	res := services.VerifyArchive(a, []*models.SigningKey{f.signer.PublicKey()})
+	if res.Valid || res.BrokenIndex == nil || *res.BrokenIndex != 0 {
+		t.Fatalf("expected unsigned genesis to fail, got %+v", res)
+	}
+	if res.KeySource != models.KeySourcePinned {
+		t.Errorf("expected pinned key source, got %q", res.KeySource)
+	}
+}
+
+func TestVerifyArchive_ReportsEmbeddedKeys(t *testing.T) {
+	f, id := buildChain(t, 1)
+	a := roundTrip(t, archiveOf(t, f, id))
+
+	if res := services.VerifyArchive(a, nil); res.KeySource != models.KeySourceArchive {
+		t.Fatalf("expected archive key source without trusted keys, got %+v", res)
+	}
+}
+
+func TestVerifyArchive_OrphanVoteRecord(t *testing.T) {
+	f, id := buildChain(t, 2)
+	orphanVote(f)
+	a := archiveOf(t, f, id)
+	a = roundTrip(t, a)
+
+	res := services.VerifyArchive(a, []*models.SigningKey{f.signer.PublicKey()})
+	if res.Valid || res.Status != models.ChainMissingBlock {
+		t.Fatalf("expected vote record outside the chain to be flagged, got %+v", res)
+	}
+
+	// Подсчёт, как в cmd/verify: только голоса из блоков цепочки
+	votes := make([]*models.Vote, len(a.Votes))
+	for i, v := range a.Votes {
+		votes[i] = v.Vote()
+	}
+	tally := services.TallyElection(a.Election, a.Choices, services.ChainedVotes(a.Blocks, votes))
+	if tally.TotalVotes != 2 || votesFor(tally, "A") != 2 {
+		t.Fatalf("expected the orphan vote to be left out of the tally, got %+v", tally)
+	}
+}
+
+func TestReadArchive_RejectsMismatchedManifest(t *testing.T) {
+	f, id := buildChain(t, 2)
+	a := archiveOf(t, f, id)
+	a.Manifest.Votes++
+
+	var buf bytes.Buffer
+	if err := services.WriteArchive(&buf, a); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := services.ReadArchive(&buf); !errors.Is(err, services.ErrInvalidArchive) {
+		t.Fatalf("expected ErrInvalidArchive, got %v", err)
+	}
+}
+
+func TestImportElection_RestoresVerifiableChain(t *testing.T) {
+	f, id := buildChain(t, 3)
+	a := roundTrip(t, archiveOf(t, f, id))
+
+	// Пустая база другого узла со своим ключом подписи; ключ исходного узла
+	// задан ему в конфигурации
+	dst := newMockStore()
+	dst.trusted = append(dst.trusted, f.signer.PublicKey())
+	res, err := dst.blockchainService().ImportElection(context.Background(), a)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.Valid {
+		t.Fatalf("expected valid import, got %+v", res)
+	}
+
+	check, err := dst.blockchainService().VerifyChain(context.Background(), id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !check.Valid || check.BlocksChecked != 4 {
+		t.Fatalf("expected restored chain to verify, got %+v", check)
+	}
+
+	if _, err := dst.blockchainService().ImportElection(context.Background(), a); !errors.Is(err, services.ErrElectionExists) {
+		t.Fatalf("expected ErrElectionExists on second import, got %v", err)
+	}
+}
+
+func TestImportElection_RejectsTamperedArchive(t *testing.T) {
+	f, id := buildChain(t, 3)
+	a := roundTrip(t, archiveOf(t, f, id))
+	a.Blocks[2].Timestamp = a.Blocks[2].Timestamp.Add(time.Second)
+
+	dst := newMockStore()
+	dst.trusted = append(dst.trusted, f.signer.PublicKey())
+	res, err := dst.blockchainService().ImportElection(context.Background(), a)
+	if !errors.Is(err, services.ErrArchiveChainInvalid) {
+		t.Fatalf("expected ErrArchiveChainInvalid, got %v", err)
+	}
+	if res == nil || res.BrokenIndex == nil || *res.BrokenIndex != 2 {
+		t.Fatalf("expected verdict with broken index 2, got %+v", res)
+	}
+	if len(dst.blocks.blocks) != 0 {
+		t.Error("rejected archive must not be written")
+	}
+}
+
+func TestImportElection_RejectsUntrustedKey(t *testing.T) {
+	f, id := buildChain(t, 2)
+	a := roundTrip(t, archiveOf(t, f, id))
+
+	// Архив подписан ключом, которого нет в конфигурации узла: ключи из самого
+	// архива не делают его доверенным
+	dst := newMockStore()
+	res, err := dst.blockchainService().ImportElection(context.Background(), a)
+	if !errors.Is(err, services.ErrArchiveChainInvalid) {
+		t.Fatalf("expected ErrArchiveChainInvalid, got %v", err)
+	}
+	if res == nil || res.BrokenIndex == nil || *res.BrokenIndex != 0 {
+		t.Fatalf("expected untrusted key at genesis, got %+v", res)
+	}
+	keys, _ := dst.keys.List(context.Background())
+	for _, k := range keys {
+		if k.KeyID == f.signer.PublicKey().KeyID {
+			t.Fatal("archive keys must not be stored")
+		}
+	}
+}
@@ -0,0 +1,193 @@
+package voting_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"regexp"
+	"strings"
+	"testing"
+
+	"voting-blockchain/internal/voting/models"
+	"voting-blockchain/internal/voting/services"
+)
+
+var ballotCodeFormat = regexp.MustCompile(`^[A-Z2-7]{4}(-[A-Z2-7]{4}){7}$`)
+
+// issueCodesAndOpen — выпускает count кодов голосования e, пока оно в
+// черновике, и открывает его
+func issueCodesAndOpen(t *testing.T, f *mockStore, e *models.Election, count int) []string {
+	t.Helper()
+	ctx := context.Background()
+	codes, err := f.ballotCodeService().Generate(ctx, e.ID, count)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := setStatus(ctx, f, e, models.ElectionOpen); err != nil {
+		t.Fatal(err)
+	}
+	return codes
+}
+
+// newDraftElection — голосование plurality с вариантами A, B и C в черновике
+func newDraftElection(t *testing.T, f *mockStore) *models.Election {
+	t.Helper()
+	e := &models.Election{Title: "Codes", CreatedBy: 1}
+	if err := f.electionService().Create(context.Background(), e, []string{"A", "B", "C"}); err != nil {
+		t.Fatal(err)
+	}
+	return e
+}
+
+func TestBallotCodes_SingleUseAndUnlinked(t *testing.T) {
+	f := newMockStore()
+	ctx := context.Background()
+	e := newDraftElection(t, f)
+
+	codes := issueCodesAndOpen(t, f, e, 5)
+	seen := make(map[string]bool)
+	for _, code := range codes {
+		if !ballotCodeFormat.MatchString(code) || seen[code] {
+			t.Fatalf("unexpected code %q in %v", code, codes)
+		}
+		seen[code] = true
+	}
+
+	// Код принимается в любом регистре и без разделителей
+	typed := strings.ToLower(strings.ReplaceAll(codes[0], "-", " "))
+	receipt, err := f.voteService().CastVoteWithCode(ctx, e.ID, typed, byText("A"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if receipt.VoteHash == "" || receipt.Salt == "" {
+		t.Fatalf("expected a full receipt, got %+v", receipt)
+	}
+	if _, err := f.voteService().CastVoteWithCode(ctx, e.ID, codes[0], byText("B")); !errors.Is(err, services.ErrInvalidBallotCode) {
+		t.Fatalf("expected ErrInvalidBallotCode for a used code, got %v", err)
+	}
+	if _, err := f.voteService().CastVoteWithCode(ctx, e.ID, "AAAA-BBBB", byText("B")); !errors.Is(err, services.ErrInvalidBallotCode) {
+		t.Fatalf("expected ErrInvalidBallotCode for an unknown code, got %v", err)
+	}
+
+	// Голос по коду сохраняется без пользователя
+	for _, v := range f.votes.votes {
+		if v.UserID != 0 {
+			t.Fatalf("code vote must not carry a user, got %+v", v)
+		}
+	}
+	// Голос по коду не мешает пользователю проголосовать самому
+	if _, err := f.voteService().CastVote(ctx, 1, e.ID, byText("B")); err != nil {
+		t.Fatal(err)
+	}
+
+	stats, err := f.ballotCodeService().Stats(ctx, e.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.Issued != 5 || stats.Used != 1 {
+		t.Fatalf("unexpected stats %+v", stats)
+	}
+	results, err := f.voteService().GetResults(ctx, e.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if votesFor(results, "A") != 1 || votesFor(results, "B") != 1 {
+		t.Fatalf("unexpected results %+v", results)
+	}
+}
+
+func TestBallotCodes_RejectedBallotKeepsCode(t *testing.T) {
+	f := newMockStore()
+	ctx := context.Background()
+	e := newDraftElection(t, f)
+	other := newMethodElection(t, f, models.VotingPlurality, 0)
+
+	codes := issueCodesAndOpen(t, f, e, 1)
+	if _, err := f.voteService().CastVoteWithCode(ctx, e.ID, codes[0], byText("Z")); !errors.Is(err, services.ErrInvalidChoice) {
+		t.Fatalf("expected ErrInvalidChoice, got %v", err)
+	}
+	if _, err := f.voteService().CastVoteWithCode(ctx, other.ID, codes[0], byText("A")); !errors.Is(err, services.ErrInvalidBallotCode) {
+		t.Fatalf("a code must only work in its own election, got %v", err)
+	}
+	if _, err := f.voteService().CastVoteWithCode(ctx, e.ID, codes[0], byText("A")); err != nil {
+		t.Fatalf("code must stay usable after a rejected ballot: %v", err)
+	}
+}
+
+func TestBallotCodes_Generation(t *testing.T) {
+	f := newMockStore()
+	ctx := context.Background()
+	e := newDraftElection(t, f)
+
+	for _, count := range []int{0, -1, services.MaxBallotCodes + 1} {
+		if _, err := f.ballotCodeService().Generate(ctx, e.ID, count); !errors.Is(err, services.ErrInvalidCodeCount) {
+			t.Errorf("count %d: expected ErrInvalidCodeCount, got %v", count, err)
+		}
+	}
+	if _, err := f.ballotCodeService().Generate(ctx, 999, 1); !errors.Is(err, services.ErrElectionNotFound) {
+		t.Fatalf("expected ErrElectionNotFound, got %v", err)
+	}
+
+	codes, err := f.ballotCodeService().Generate(ctx, e.ID, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := services.WriteBallotCodesCSV(&buf, e.ID, codes); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 3 || lines[0] != "n,election_id,code" || !strings.HasSuffix(lines[2], ","+codes[1]) {
+		t.Fatalf("unexpected csv %q", buf.String())
+	}
+
+	// После открытия коды не выпускаются: иначе можно добавить голоса без
+	// учётной записи прямо во время голосования
+	if err := setStatus(ctx, f, e, models.ElectionOpen); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.ballotCodeService().Generate(ctx, e.ID, 1); !errors.Is(err, services.ErrBallotCodesLocked) {
+		t.Fatalf("expected ErrBallotCodesLocked once open, got %v", err)
+	}
+	if err := setStatus(ctx, f, e, models.ElectionClosed); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.ballotCodeService().Generate(ctx, e.ID, 1); !errors.Is(err, services.ErrBallotCodesLocked) {
+		t.Fatalf("expected ErrBallotCodesLocked, got %v", err)
+	}
+	if _, err := f.voteService().CastVoteWithCode(ctx, e.ID, codes[0], byText("A")); !errors.Is(err, services.ErrElectionNotOpen) {
+		t.Fatalf("expected ErrElectionNotOpen, got %v", err)
+	}
+}
+
+func TestBallotCodes_CountTowardsRollTurnout(t *testing.T) {
+	f := newMockStore()
+	ctx := context.Background()
+	for id, email := range map[int]string{1: "ann@example.com", 2: "bob@example.com"} {
+		f.rolls.users[id] = email
+	}
+	e := &models.Election{Title: "Roll", CreatedBy: 1, Eligibility: models.EligibilityRoll}
+	if err := f.electionService().Create(ctx, e, []string{"A", "B"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := f.voterRollService().Upload(ctx, e.ID, []models.RollEntry{{UserID: 1}, {UserID: 2}}, false); err != nil {
+		t.Fatal(err)
+	}
+	codes := issueCodesAndOpen(t, f, e, 2)
+	// Код допускает к голосованию и вне списка избирателей
+	if _, err := f.voteService().CastVoteWithCode(ctx, e.ID, codes[1], byText("B")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.voteService().CastVote(ctx, 1, e.ID, byText("A")); err != nil {
+		t.Fatal(err)
+	}
+
+	results, err := f.voteService().GetResults(ctx, e.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if results.Turnout == nil || results.Turnout.RollSize != 2 || results.Turnout.Codes != 2 || results.Turnout.Voted != 2 || results.Turnout.Percent != 50 {
+		t.Fatalf("unexpected turnout %+v", results.Turnout)
+	}
+}
@@ -0,0 +1,259 @@
+package voting_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"errors"
+	"testing"
+
+	"voting-blockchain/internal/voting/models"
+	"voting-blockchain/internal/voting/services"
+)
+
+// newAnonymousElection — открытое анонимное голосование
+func newAnonymousElection(t *testing.T, f *mockStore, eligibility string) *models.Election {
+	t.Helper()
+	e := &models.Election{
+		Title:       "Anonymous",
+		CreatedBy:   1,
+		Status:      models.ElectionOpen,
+		Eligibility: eligibility,
+		Anonymity:   models.AnonymityBlind,
+	}
+	if err := f.electionService().Create(context.Background(), e, []string{"A", "B"}); err != nil {
+		t.Fatal(err)
+	}
+	return e
+}
+
+// obtainToken — то, что делает клиент избирателя: выбирает жетон, маскирует
+// его, получает слепую подпись и снимает с неё маску
+func obtainToken(ctx context.Context, f *mockStore, userID, electionID int) (token, sig []byte, err error) {
+	key, err := f.blindTokenService().PublicKey(ctx, electionID)
+	if err != nil {
+		return nil, nil, err
+	}
+	pub, err := services.ParseBlindPublicKey(key)
+	if err != nil {
+		return nil, nil, err
+	}
+	token = make([]byte, models.BlindTokenMinBytes)
+	if _, err := rand.Read(token); err != nil {
+		return nil, nil, err
+	}
+	blinded, r, err := services.BlindToken(pub, electionID, token)
+	if err != nil {
+		return nil, nil, err
+	}
+	if bytes.Contains(blinded, token) {
+		return nil, nil, errors.New("blinded message leaks the token")
+	}
+	blindSig, err := f.blindTokenService().Issue(ctx, userID, electionID, blinded)
+	if err != nil {
+		return nil, nil, err
+	}
+	return token, services.UnblindSignature(pub, blindSig, r), nil
+}
+
+func TestBlindTokens_AnonymousVoting(t *testing.T) {
+	f := newMockStore()
+	ctx := context.Background()
+	for id, email := range map[int]string{1: "ann@example.com", 2: "bob@example.com", 3: "carl@example.com"} {
+		f.rolls.users[id] = email
+	}
+	e := newAnonymousElection(t, f, models.EligibilityRoll)
+	if _, err := f.voterRollService().Upload(ctx, e.ID, []models.RollEntry{{UserID: 1}, {UserID: 2}}, false); err != nil {
+		t.Fatal(err)
+	}
+
+	token1, sig1, err := obtainToken(ctx, f, 1, e.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	token2, sig2, err := obtainToken(ctx, f, 2, e.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := obtainToken(ctx, f, 1, e.ID); !errors.Is(err, services.ErrTokenAlreadyIssued) {
+		t.Fatalf("expected ErrTokenAlreadyIssued, got %v", err)
+	}
+	if _, _, err := obtainToken(ctx, f, 3, e.ID); !errors.Is(err, services.ErrNotEligible) {
+		t.Fatalf("expected ErrNotEligible for a user off the roll, got %v", err)
+	}
+
+	// В анонимном голосовании голос от имени пользователя не принимается
+	if _, err := f.voteService().CastVote(ctx, 1, e.ID, byText("A")); !errors.Is(err, services.ErrAnonymousVoting) {
+		t.Fatalf("expected ErrAnonymousVoting, got %v", err)
+	}
+
+	receipt, err := f.voteService().CastAnonymousVote(ctx, e.ID, token1, sig1, byText("A"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if receipt.VoteHash == "" {
+		t.Fatalf("expected a receipt, got %+v", receipt)
+	}
+	if _, err := f.voteService().CastAnonymousVote(ctx, e.ID, token1, sig1, byText("B")); !errors.Is(err, services.ErrAlreadyVoted) {
+		t.Fatalf("expected ErrAlreadyVoted for a spent token, got %v", err)
+	}
+	// Подпись одного жетона не подходит к другому
+	if _, err := f.voteService().CastAnonymousVote(ctx, e.ID, token2, sig1, byText("B")); !errors.Is(err, services.ErrInvalidBlindToken) {
+		t.Fatalf("expected ErrInvalidBlindToken, got %v", err)
+	}
+	// Отклонённый бюллетень не гасит жетон
+	if _, err := f.voteService().CastAnonymousVote(ctx, e.ID, token2, sig2, byText("Z")); !errors.Is(err, services.ErrInvalidChoice) {
+		t.Fatalf("expected ErrInvalidChoice, got %v", err)
+	}
+	if _, err := f.voteService().CastAnonymousVote(ctx, e.ID, token2, sig2, byText("B")); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, v := range f.votes.votes {
+		if v.UserID != 0 {
+			t.Fatalf("anonymous vote must not carry a user, got %+v", v)
+		}
+	}
+	results, err := f.voteService().GetResults(ctx, e.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if votesFor(results, "A") != 1 || votesFor(results, "B") != 1 {
+		t.Fatalf("unexpected results %+v", results)
+	}
+	if results.Turnout == nil || results.Turnout.Voted != 2 || results.Turnout.Percent != 100 {
+		t.Fatalf("unexpected turnout %+v", results.Turnout)
+	}
+}
+
+func TestBlindTokens_ScopedToElection(t *testing.T) {
+	f := newMockStore()
+	ctx := context.Background()
+	e := newAnonymousElection(t, f, "")
+	other := newAnonymousElection(t, f, "")
+	plain := newMethodElection(t, f, models.VotingPlurality, 0)
+
+	token, sig, err := obtainToken(ctx, f, 1, e.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.voteService().CastAnonymousVote(ctx, other.ID, token, sig, byText("A")); !errors.Is(err, services.ErrInvalidBlindToken) {
+		t.Fatalf("a token must only work in its own election, got %v", err)
+	}
+	if _, err := f.voteService().CastAnonymousVote(ctx, plain.ID, token, sig, byText("A")); !errors.Is(err, services.ErrNotAnonymous) {
+		t.Fatalf("expected ErrNotAnonymous, got %v", err)
+	}
+	if _, err := f.blindTokenService().PublicKey(ctx, plain.ID); !errors.Is(err, services.ErrNotAnonymous) {
+		t.Fatalf("expected ErrNotAnonymous, got %v", err)
+	}
+
+	// Ключ голосования создаётся один раз
+	first, err := f.blindTokenService().PublicKey(ctx, e.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := f.blindTokenService().PublicKey(ctx, e.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first.N != second.N || first.E != second.E {
+		t.Fatal("blind key must be stable")
+	}
+}
+
+func TestBlindTokens_RejectsBadMaskWithoutSpendingIssuance(t *testing.T) {
+	f := newMockStore()
+	ctx := context.Background()
+	e := newAnonymousElection(t, f, "")
+
+	key, err := f.blindTokenService().PublicKey(ctx, e.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	size := len(key.N) / 2
+	for name, blinded := range map[string][]byte{
+		"zero":      make([]byte, size),
+		"too short": {1, 2, 3},
+		"too long":  bytes.Repeat([]byte{0xff}, size+1),
+	} {
+		if _, err := f.blindTokenService().Issue(ctx, 1, e.ID, blinded); !errors.Is(err, services.ErrInvalidBlindToken) {
+			t.Errorf("%s: expected ErrInvalidBlindToken, got %v", name, err)
+		}
+	}
+	if _, _, err := obtainToken(ctx, f, 1, e.ID); err != nil {
+		t.Fatalf("a rejected mask must not use up the user's token: %v", err)
+	}
+
+	if err := setStatus(ctx, f, e, models.ElectionClosed); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := obtainToken(ctx, f, 2, e.ID); !errors.Is(err, services.ErrElectionNotOpen) {
+		t.Fatalf("expected ErrElectionNotOpen after closing, got %v", err)
+	}
+}
+
+func TestBlindTokens_AnonymityIsPartOfDefinition(t *testing.T) {
+	f := newMockStore()
+	ctx := context.Background()
+
+	if err := f.electionService().Create(ctx, &models.Election{Title: "X", Anonymity: "mixnet"}, []string{"A"}); !errors.Is(err, services.ErrInvalidAnonymity) {
+		t.Fatalf("expected ErrInvalidAnonymity, got %v", err)
+	}
+
+	e := newMethodElection(t, f, models.VotingPlurality, 0)
+	if e.Anonymity != models.AnonymityNone {
+		t.Fatalf("expected none by default, got %q", e.Anonymity)
+	}
+	plainHash := e.DefinitionHash(nil)
+	e.Anonymity = models.AnonymityBlind
+	if e.DefinitionHash(nil) == plainHash {
+		t.Fatal("anonymity must be covered by the definition hash")
+	}
+	err := f.electionService().Update(ctx, &models.Election{ID: e.ID, Title: e.Title, Anonymity: models.AnonymityBlind}, services.UpdateFields{}, 1)
+	if !errors.Is(err, services.ErrDefinitionLocked) {
+		t.Fatalf("expected ErrDefinitionLocked, got %v", err)
+	}
+}
+
+// Ключ подписи жетонов создаётся при выходе из черновика, а не по запросу
+// без входа, и его отпечаток фиксируется генезис-блоком
+func TestBlindTokens_KeyCommittedAtGenesis(t *testing.T) {
+	f := newMockStore()
+	ctx := context.Background()
+
+	e := &models.Election{Title: "Anonymous", CreatedBy: 1, Anonymity: models.AnonymityBlind}
+	if err := f.electionService().Create(ctx, e, []string{"A", "B"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.blindTokenService().PublicKey(ctx, e.ID); !errors.Is(err, services.ErrBlindKeyPending) {
+		t.Fatalf("expected ErrBlindKeyPending in draft, got %v", err)
+	}
+	if len(f.blind.keys) != 0 {
+		t.Fatal("reading the public key must not create it")
+	}
+
+	open := &models.Election{ID: e.ID, Status: models.ElectionOpen}
+	if err := f.electionService().Update(ctx, open, services.UpdateFields{}, 1); err != nil {
+		t.Fatal(err)
+	}
+	key, err := f.blindTokenService().PublicKey(ctx, e.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if key.Fingerprint == "" || key.Fingerprint != open.BlindKeyFingerprint {
+		t.Fatalf("expected published key %q to match the definition, got %q", open.BlindKeyFingerprint, key.Fingerprint)
+	}
+	if key.Fingerprint != models.BlindKeyFingerprint(key.N, key.E) {
+		t.Fatal("fingerprint must be computable from the published key")
+	}
+
+	// Подмена ключа после генезис-блока меняет определение голосования
+	f.elections.elections[e.ID].BlindKeyFingerprint = models.BlindKeyFingerprint("00", 65537)
+	res, err := f.blockchainService().VerifyChain(ctx, e.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Valid {
+		t.Fatal("expected a substituted blind key to break the genesis block")
+	}
+}
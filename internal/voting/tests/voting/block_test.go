@@ -0,0 +1,98 @@
+package voting_test
+
+import (
+	"testing"
+	"time"
+
+	"voting-blockchain/internal/voting/models"
+)
+
+func TestBlockHash_RecomputableAfterStorage(t *testing.T) {
+	loc := time.FixedZone("UTC+5", 5*3600)
+	b := models.NewBlock(7, nil, "vote", time.Date(2025, 7, 1, 17, 0, 0, 123456789, loc))
+
+	// Так блок возвращается из Postgres: микросекунды, UTC
+	stored := &models.Block{
+		Height:      b.Height,
+		Timestamp:   time.Date(2025, 7, 1, 12, 0, 0, 123456000, time.UTC),
+		VoteHash:    b.VoteHash,
+		PrevHash:    b.PrevHash,
+		HashVersion: b.HashVersion,
+		ElectionID:  b.ElectionID,
+	}
+
+	if stored.CalculateHash() != b.Hash {
+		t.Fatal("hash must be recomputable from the stored row")
+	}
+}
+
+func TestBlockHash_CommitsToHeight(t *testing.T) {
+	ts := time.Date(2025, 7, 1, 12, 0, 0, 0, time.UTC)
+	genesis := models.NewBlock(1, nil, "a", ts)
+	next := models.NewBlock(1, genesis, "b", ts)
+
+	if next.Height != 1 || next.PrevHash != genesis.Hash {
+		t.Fatalf("unexpected link: %+v", next)
+	}
+
+	moved := *next
+	moved.Height = 5
+	if moved.CalculateHash() == next.Hash {
+		t.Error("hash must change with block height")
+	}
+}
+
+func TestMerkleRoot(t *testing.T) {
+	v := models.CurrentHashVersion
+	if models.MerkleRoot(models.HashVersionCanonical, []string{"abc"}) != "abc" {
+		t.Error("in version 2 the root of a single leaf is the leaf itself")
+	}
+	if models.MerkleRoot(v, []string{"abc"}) == "abc" {
+		t.Error("leaves must be hashed with their own prefix")
+	}
+
+	leaves := []string{"a", "b", "c"}
+	root := models.MerkleRoot(v, leaves)
+	if root == "" || root == models.MerkleRoot(v, []string{"b", "a", "c"}) {
+		t.Error("root must depend on leaf order")
+	}
+	if root == models.MerkleRoot(v, []string{"a", "b", "c", "c"}) {
+		t.Error("odd leaf must not be duplicated")
+	}
+}
+
+func TestMerkleProof_AllLeaves(t *testing.T) {
+	for _, v := range []int{models.HashVersionCanonical, models.CurrentHashVersion} {
+		for n := 1; n <= 9; n++ {
+			leaves := make([]string, n)
+			for i := range leaves {
+				leaves[i] = string(rune('a' + i))
+			}
+			root := models.MerkleRoot(v, leaves)
+			for i, leaf := range leaves {
+				if !models.VerifyMerkleProof(v, leaf, i, n, models.MerkleProof(v, leaves, i), root) {
+					t.Fatalf("v%d: proof for leaf %d of %d does not verify", v, i, n)
+				}
+			}
+		}
+	}
+}
+
+func TestMerkleProof_InternalNodeIsNotALeaf(t *testing.T) {
+	leaves := []string{"a", "b", "c", "d"}
+	for _, v := range []int{models.HashVersionCanonical, models.CurrentHashVersion} {
+		root := models.MerkleRoot(v, leaves)
+		full := models.MerkleProof(v, leaves, 0)
+
+		// Узел над a и b с путём на уровень короче
+		node := models.MerkleRoot(v, leaves[:2])
+		if v == models.CurrentHashVersion {
+			if models.VerifyMerkleProof(v, node, 0, 2, full[1:], root) {
+				t.Errorf("v%d: a prefixed leaf must not match an internal node", v)
+			}
+		}
+		if models.VerifyMerkleProof(v, node, 0, len(leaves), full[1:], root) {
+			t.Errorf("v%d: a proof shorter than the tree depth must not verify", v)
+		}
+	}
+}
@@ -0,0 +1,444 @@
+package voting_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"voting-blockchain/internal/voting/models"
+	"voting-blockchain/internal/voting/services"
+)
+
+// buildChain — создаёт голосование с генезис-блоком и n блоками голосов
+func buildChain(t *testing.T, n int) (*mockStore, int) {
+	t.Helper()
+	f := newMockStore()
+
+	e := &models.Election{Title: "Board", CreatedBy: 1, Status: models.ElectionOpen}
+	if err := f.electionService().Create(context.Background(), e, []string{"A", "B"}); err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < n; i++ {
+		if _, err := f.voteService().CastVote(context.Background(), i+1, e.ID, byText("A")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return f, e.ID
+}
+
+func TestVerifyChain_Valid(t *testing.T) {
+	f, id := buildChain(t, 5)
+
+	res, err := f.blockchainService().VerifyChain(context.Background(), id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.Valid || res.Status != models.ChainValid || !res.HasGenesis {
+		t.Fatalf("expected valid chain with genesis, got %+v", res)
+	}
+	if res.BlocksChecked != 6 {
+		t.Errorf("expected 6 blocks checked, got %d", res.BlocksChecked)
+	}
+}
+
+func TestVerifyChain_TamperedVoteHash(t *testing.T) {
+	f, id := buildChain(t, 5)
+	f.blocks.blocks[3].VoteHash = "forged"
+
+	res, err := f.blockchainService().VerifyChain(context.Background(), id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Valid || res.Status != models.ChainBroken {
+		t.Fatalf("expected broken chain, got %+v", res)
+	}
+	if res.BrokenIndex == nil || *res.BrokenIndex != 3 {
+		t.Errorf("expected broken index 3, got %v", res.BrokenIndex)
+	}
+}
+
+func TestVerifyChain_BrokenLink(t *testing.T) {
+	f, id := buildChain(t, 5)
+	b := f.blocks.blocks[4]
+	b.PrevHash = "0000"
+	b.Hash = b.CalculateHash()
+
+	res, err := f.blockchainService().VerifyChain(context.Background(), id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Status != models.ChainBroken || res.BrokenIndex == nil || *res.BrokenIndex != 4 {
+		t.Fatalf("expected broken link at 4, got %+v", res)
+	}
+}
+
+func TestVerifyChain_MissingVote(t *testing.T) {
+	f, id := buildChain(t, 5)
+	f.votes.votes = f.votes.votes[1:]
+
+	res, err := f.blockchainService().VerifyChain(context.Background(), id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Status != models.ChainMissingVote || res.BrokenIndex == nil || *res.BrokenIndex != 1 {
+		t.Fatalf("expected missing vote at 1, got %+v", res)
+	}
+}
+
+// orphanVote — голос, дописанный в базу со ссылкой на блок вне цепочки
+func orphanVote(f *mockStore) {
+	v := *f.votes.votes[0]
+	blockID := 999
+	v.ID, v.UserID, v.Salt, v.BlockID = 100, 100, "orphan", &blockID
+	v.VoteHash = v.CalculateHash()
+	f.votes.votes = append(f.votes.votes, &v)
+}
+
+func TestVerifyChain_VoteOutsideChain(t *testing.T) {
+	f, id := buildChain(t, 2)
+	orphanVote(f)
+
+	res, err := f.blockchainService().VerifyChain(context.Background(), id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Valid || res.Status != models.ChainMissingBlock || res.BlockID == nil || *res.BlockID != 999 {
+		t.Fatalf("expected vote outside the chain to be flagged, got %+v", res)
+	}
+
+	results, err := f.voteService().GetResults(context.Background(), id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if results.TotalVotes != 2 || votesFor(results, "A") != 2 {
+		t.Fatalf("expected only chained votes to be counted, got %+v", results)
+	}
+}
+
+func TestVerifyChain_ChoicesChangedAfterGenesis(t *testing.T) {
+	f, id := buildChain(t, 2)
+	f.choices.choices[1].Text = "C"
+
+	res, err := f.blockchainService().VerifyChain(context.Background(), id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Status != models.ChainBroken || res.BrokenIndex == nil || *res.BrokenIndex != 0 {
+		t.Fatalf("expected genesis mismatch at 0, got %+v", res)
+	}
+}
+
+// relink — пересчитывает хеши и подписи блоков после правки, как узел при записи.
+// Блоки v1 не подписываются, а их хеш берётся от несохранённого времени: из полей
+// блока его не получить, как и у настоящих блоков до канонического формата.
+func relink(f *mockStore, blocks []*models.Block) {
+	prev := ""
+	for i, b := range blocks {
+		b.PrevHash = prev
+		if b.HashVersion == models.HashVersionLegacy {
+			b.Hash = fmt.Sprintf("%064x", i+1)
+			b.Signature, b.KeyID = "", ""
+		} else {
+			b.Hash = b.CalculateHash()
+			f.signer.Sign(b)
+		}
+		prev = b.Hash
+	}
+}
+
+// legacyChain — цепочка голосования, начатого до генезис-блоков: первые legacy
+// из n блоков голосов записаны в формате v1
+func legacyChain(t *testing.T, n, legacy int) (*mockStore, int) {
+	t.Helper()
+	f, id := buildChain(t, n)
+	f.elections.elections[id].CreatedAt = models.LegacyHashCutoff.Add(-24 * time.Hour)
+	f.blocks.blocks = f.blocks.blocks[1:]
+	for i, b := range f.blocks.blocks {
+		b.Height = i
+		if i < legacy {
+			b.HashVersion = models.HashVersionLegacy
+			b.Timestamp = models.LegacyHashCutoff.Add(time.Duration(i-legacy) * time.Minute)
+			// В блоке v1 листья дерева не хешировались
+			b.VoteHash = models.MerkleRoot(models.HashVersionLegacy, []string{f.votes.votes[i].VoteHash})
+		}
+	}
+	relink(f, f.blocks.blocks)
+	return f, id
+}
+
+func TestVerifyChain_LegacyBlocksCheckedByLinks(t *testing.T) {
+	f, id := legacyChain(t, 3, 2)
+
+	res, err := f.blockchainService().VerifyChain(context.Background(), id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Status != models.ChainNoGenesis || res.BlocksChecked != 3 || res.LegacyBlocks != 2 {
+		t.Fatalf("expected legacy blocks to pass up to the missing genesis, got %+v", res)
+	}
+
+	// Связь PrevHash проверяется и у блоков v1
+	f.blocks.blocks[1].PrevHash = "0000"
+	res, err = f.blockchainService().VerifyChain(context.Background(), id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Status != models.ChainBroken || res.BrokenIndex == nil || *res.BrokenIndex != 1 {
+		t.Fatalf("expected broken legacy link at 1, got %+v", res)
+	}
+}
+
+func TestVerifyChain_ForgedLegacyChain(t *testing.T) {
+	// Злоумышленник с доступом к БД заменяет всю цепочку, включая генезис,
+	// неподписанными блоками v1 со старым временем
+	forge := func(f *mockStore) {
+		for _, b := range f.blocks.blocks {
+			b.HashVersion = models.HashVersionLegacy
+			b.Timestamp = models.LegacyHashCutoff.Add(-time.Hour)
+		}
+		relink(f, f.blocks.blocks)
+	}
+
+	f, id := buildChain(t, 2)
+	forge(f)
+	res, err := f.blockchainService().VerifyChain(context.Background(), id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Status != models.ChainBroken || res.BrokenIndex == nil || *res.BrokenIndex != 0 {
+		t.Fatalf("expected legacy blocks in a new election to be rejected, got %+v", res)
+	}
+
+	// Даже в голосовании, созданном до канонического формата, генезис v1 не бывает
+	f, id = buildChain(t, 2)
+	f.elections.elections[id].CreatedAt = models.LegacyHashCutoff.Add(-24 * time.Hour)
+	forge(f)
+	res, err = f.blockchainService().VerifyChain(context.Background(), id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Status != models.ChainBroken || res.BrokenIndex == nil || *res.BrokenIndex != 0 {
+		t.Fatalf("expected a legacy genesis block to be rejected, got %+v", res)
+	}
+}
+
+func TestVerifyChain_UnsignedChainNeedsAnchor(t *testing.T) {
+	// Голосование, записанное после генезис-блоков, но до подписей
+	f, id := buildChain(t, 2)
+	ctx := context.Background()
+	f.elections.elections[id].CreatedAt = models.SignatureCutoff.Add(-24 * time.Hour)
+	prev := ""
+	for i, b := range f.blocks.blocks {
+		b.Timestamp = models.SignatureCutoff.Add(time.Duration(i-10) * time.Minute)
+		b.PrevHash = prev
+		b.Hash = b.CalculateHash()
+		b.Signature, b.KeyID = "", ""
+		prev = b.Hash
+	}
+
+	res, err := f.blockchainService().VerifyChain(ctx, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Status != models.ChainBroken || res.BrokenIndex == nil || *res.BrokenIndex != 2 {
+		t.Fatalf("expected an unanchored unsigned head, got %+v", res)
+	}
+
+	anchored, err := f.blockchainService().AnchorUnsignedChains(ctx)
+	if err != nil || anchored != 1 {
+		t.Fatalf("expected one anchored chain, got %d, %v", anchored, err)
+	}
+	res, err = f.blockchainService().VerifyChain(ctx, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.Valid || res.UnsignedBlocks != 3 || res.BlocksChecked != 4 {
+		t.Fatalf("expected anchored chain to verify, got %+v", res)
+	}
+	if anchored, err := f.blockchainService().AnchorUnsignedChains(ctx); err != nil || anchored != 0 {
+		t.Fatalf("expected signed head to need no anchor, got %d, %v", anchored, err)
+	}
+
+	// После якоря блоки, записанные до подписей, уже не подменить
+	f.blocks.blocks[1].Timestamp = f.blocks.blocks[1].Timestamp.Add(time.Second)
+	f.blocks.blocks[1].Hash = f.blocks.blocks[1].CalculateHash()
+	f.blocks.blocks[2].PrevHash = f.blocks.blocks[1].Hash
+	f.blocks.blocks[2].Hash = f.blocks.blocks[2].CalculateHash()
+	res, err = f.blockchainService().VerifyChain(ctx, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Status != models.ChainBroken || res.BrokenIndex == nil || *res.BrokenIndex != 3 {
+		t.Fatalf("expected the anchor to catch rewritten unsigned blocks, got %+v", res)
+	}
+}
+
+func TestVerifyChain_LegacyVersionBackdated(t *testing.T) {
+	// Блок, созданный после перехода на канонический формат
+	f, id := buildChain(t, 3)
+	f.blocks.blocks[2].VoteHash = "forged"
+	f.blocks.blocks[2].HashVersion = models.HashVersionLegacy
+
+	res, err := f.blockchainService().VerifyChain(context.Background(), id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Status != models.ChainBroken || res.BrokenIndex == nil || *res.BrokenIndex != 2 {
+		t.Fatalf("expected backdated legacy block at 2, got %+v", res)
+	}
+
+	// Блок v1 со старым временем после блока v2
+	f, id = buildChain(t, 3)
+	f.blocks.blocks[2].HashVersion = models.HashVersionLegacy
+	f.blocks.blocks[2].Timestamp = models.LegacyHashCutoff.Add(-time.Hour)
+	relink(f, f.blocks.blocks)
+
+	res, err = f.blockchainService().VerifyChain(context.Background(), id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Status != models.ChainBroken || res.BrokenIndex == nil || *res.BrokenIndex != 2 {
+		t.Fatalf("expected legacy block after a canonical one at 2, got %+v", res)
+	}
+}
+
+func TestVerifyChain_MissingGenesis(t *testing.T) {
+	// Цепочка, записанная до генезис-блоков
+	f, id := buildChain(t, 2)
+	f.blocks.blocks = f.blocks.blocks[1:]
+	for i, b := range f.blocks.blocks {
+		b.Height = i
+	}
+	relink(f, f.blocks.blocks)
+
+	res, err := f.blockchainService().VerifyChain(context.Background(), id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Valid || res.Status != models.ChainNoGenesis || res.HasGenesis {
+		t.Fatalf("expected a chain without genesis to be flagged, got %+v", res)
+	}
+}
+
+func TestElectionUpdate_DefinitionLocked(t *testing.T) {
+	f, id := buildChain(t, 0)
+
+	err := f.electionService().Update(context.Background(), &models.Election{ID: id, Title: "Other", CreatedBy: 1}, services.UpdateFields{}, 1)
+	if err != services.ErrDefinitionLocked {
+		t.Fatalf("expected ErrDefinitionLocked, got %v", err)
+	}
+}
+
+func TestVerifyChain_RegeneratedChainFailsSignature(t *testing.T) {
+	f, id := buildChain(t, 3)
+
+	// Злоумышленник с доступом к БД подменяет голос и пересчитывает все хеши дальше
+	f.votes.votes[1].ChoiceID = 2
+	f.votes.votes[1].Ballot = (&models.Ballot{ChoiceID: 2}).Canonical()
+	f.votes.votes[1].VoteHash = f.votes.votes[1].CalculateHash()
+	prev := f.blocks.blocks[1]
+	for _, b := range f.blocks.blocks[2:] {
+		b.VoteHash = f.votes.votes[b.Height-1].VoteHash
+		b.PrevHash = prev.Hash
+		b.Hash = b.CalculateHash()
+		prev = b
+	}
+
+	res, err := f.blockchainService().VerifyChain(context.Background(), id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Status != models.ChainBroken || res.BrokenIndex == nil || *res.BrokenIndex != 2 {
+		t.Fatalf("expected invalid signature at 2, got %+v", res)
+	}
+}
+
+func TestVerifyChain_UnsignedAfterSigned(t *testing.T) {
+	f, id := buildChain(t, 3)
+	f.blocks.blocks[3].Signature = ""
+
+	res, err := f.blockchainService().VerifyChain(context.Background(), id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Status != models.ChainBroken || res.BrokenIndex == nil || *res.BrokenIndex != 3 {
+		t.Fatalf("expected unsigned block at 3, got %+v", res)
+	}
+}
+
+func TestVerifyChain_StrippedSignatures(t *testing.T) {
+	f, id := buildChain(t, 3)
+	for _, b := range f.blocks.blocks {
+		b.Signature, b.KeyID = "", ""
+	}
+
+	res, err := f.blockchainService().VerifyChain(context.Background(), id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Status != models.ChainBroken || res.BrokenIndex == nil || *res.BrokenIndex != 0 {
+		t.Fatalf("expected unsigned genesis to break the chain, got %+v", res)
+	}
+}
+
+func TestVerifyChain_KeyInsertedIntoDatabase(t *testing.T) {
+	f, id := buildChain(t, 3)
+
+	// Злоумышленник добавляет свой ключ в signing_keys и переподписывает цепочку
+	forger, err := services.GenerateBlockSigner()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := f.keys.Activate(context.Background(), forger.PublicKey()); err != nil {
+		t.Fatal(err)
+	}
+	for _, b := range f.blocks.blocks {
+		forger.Sign(b)
+	}
+
+	res, err := f.blockchainService().VerifyChain(context.Background(), id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Status != models.ChainBroken || res.BrokenIndex == nil || *res.BrokenIndex != 0 {
+		t.Fatalf("expected a key outside the configuration to be untrusted, got %+v", res)
+	}
+}
+
+func TestVerifyChain_SurvivesKeyRotation(t *testing.T) {
+	f, id := buildChain(t, 2)
+	f.rotateKey()
+	if _, err := f.voteService().CastVote(context.Background(), 99, id, byText("B")); err != nil {
+		t.Fatal(err)
+	}
+
+	res, err := f.blockchainService().VerifyChain(context.Background(), id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.Valid || res.UnsignedBlocks != 0 {
+		t.Fatalf("expected valid chain signed by two keys, got %+v", res)
+	}
+
+	// Без прежнего ключа в конфигурации его подписи не принимаются
+	trusted := f.trusted
+	f.trusted = nil
+	res, err = f.blockchainService().VerifyChain(context.Background(), id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Valid {
+		t.Fatalf("expected the retired key to need configuration, got %+v", res)
+	}
+	f.trusted = trusted
+
+	keys, err := f.blockchainService().GetSigningKeys(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(keys) != 2 || keys[0].RetiredAt == nil || keys[1].RetiredAt != nil {
+		t.Fatalf("expected one retired and one current key, got %+v", keys)
+	}
+}
@@ -0,0 +1,113 @@
+package voting_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"voting-blockchain/internal/voting/models"
+	"voting-blockchain/internal/voting/services"
+)
+
+func TestChoices_EditableInDraft(t *testing.T) {
+	f := newMockStore()
+	ctx := context.Background()
+	e := &models.Election{Title: "Board", CreatedBy: 1}
+	if err := f.electionService().Create(ctx, e, []string{"A", "B"}); err != nil {
+		t.Fatal(err)
+	}
+	svc := f.choiceService()
+
+	c := &models.Choice{ElectionID: e.ID, Text: " C ", Description: "Third", ImageURL: "https://example.org/c.png"}
+	if err := svc.Add(ctx, c); err != nil {
+		t.Fatal(err)
+	}
+	if c.ID == 0 || c.Position != 3 || c.Text != "C" {
+		t.Fatalf("expected C appended at position 3, got %+v", c)
+	}
+
+	if err := svc.Update(ctx, &models.Choice{ID: 1, ElectionID: e.ID, Text: "Alpha"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := svc.Reorder(ctx, e.ID, []int{c.ID, 1, 2}); err != nil {
+		t.Fatal(err)
+	}
+	if err := svc.Delete(ctx, e.ID, 2); err != nil {
+		t.Fatal(err)
+	}
+
+	choices, err := svc.List(ctx, e.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(choices) != 2 || choices[0].Text != "C" || choices[1].Text != "Alpha" {
+		t.Fatalf("unexpected choices %+v", choices)
+	}
+
+	// Генезис фиксирует варианты в порядке бюллетеня, вместе с описанием и изображением
+	open := &models.Election{ID: e.ID, Title: e.Title, Status: models.ElectionOpen}
+	if err := f.electionService().Update(ctx, open, services.UpdateFields{}, 1); err != nil {
+		t.Fatal(err)
+	}
+	if f.blocks.blocks[0].VoteHash != e.DefinitionHash(choices) {
+		t.Fatal("genesis must commit to the edited choices")
+	}
+}
+
+func TestChoices_LockedOnceElectionLeavesDraft(t *testing.T) {
+	f, id := buildChain(t, 0)
+	ctx := context.Background()
+	svc := f.choiceService()
+
+	if err := svc.Add(ctx, &models.Choice{ElectionID: id, Text: "C"}); !errors.Is(err, services.ErrChoicesLocked) {
+		t.Fatalf("expected ErrChoicesLocked on add, got %v", err)
+	}
+	if err := svc.Update(ctx, &models.Choice{ID: 1, ElectionID: id, Text: "Z"}); !errors.Is(err, services.ErrChoicesLocked) {
+		t.Fatalf("expected ErrChoicesLocked on update, got %v", err)
+	}
+	if _, err := svc.Reorder(ctx, id, []int{2, 1}); !errors.Is(err, services.ErrChoicesLocked) {
+		t.Fatalf("expected ErrChoicesLocked on reorder, got %v", err)
+	}
+	if err := svc.Delete(ctx, id, 1); !errors.Is(err, services.ErrChoicesLocked) {
+		t.Fatalf("expected ErrChoicesLocked on delete, got %v", err)
+	}
+
+	res, err := f.blockchainService().VerifyChain(ctx, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.Valid {
+		t.Fatalf("expected untouched chain to stay valid, got %+v", res)
+	}
+}
+
+func TestChoices_RejectInvalidData(t *testing.T) {
+	f := newMockStore()
+	ctx := context.Background()
+	e := &models.Election{Title: "Board", CreatedBy: 1}
+	if err := f.electionService().Create(ctx, e, []string{"A", "B"}); err != nil {
+		t.Fatal(err)
+	}
+	svc := f.choiceService()
+
+	for _, c := range []*models.Choice{
+		{ElectionID: e.ID, Text: "  "},
+		{ElectionID: e.ID, Text: "A"},
+		{ElectionID: e.ID, Text: "C", ImageURL: "javascript:alert(1)"},
+	} {
+		if err := svc.Add(ctx, c); !errors.Is(err, services.ErrInvalidChoiceData) {
+			t.Errorf("expected ErrInvalidChoiceData for %+v, got %v", c, err)
+		}
+	}
+	for _, ids := range [][]int{{1}, {1, 1}, {1, 3}} {
+		if _, err := svc.Reorder(ctx, e.ID, ids); !errors.Is(err, services.ErrInvalidChoiceData) {
+			t.Errorf("expected ErrInvalidChoiceData for order %v, got %v", ids, err)
+		}
+	}
+	if err := svc.Delete(ctx, e.ID, 42); !errors.Is(err, services.ErrChoiceNotFound) {
+		t.Errorf("expected ErrChoiceNotFound, got %v", err)
+	}
+	if err := f.electionService().Create(ctx, &models.Election{Title: "Dup"}, []string{"A", "A"}); !errors.Is(err, services.ErrInvalidChoiceData) {
+		t.Errorf("expected duplicate choices to be rejected on create, got %v", err)
+	}
+}
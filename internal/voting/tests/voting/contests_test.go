@@ -0,0 +1,187 @@
+package voting_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"voting-blockchain/internal/voting/models"
+	"voting-blockchain/internal/voting/services"
+)
+
+// newContestElection — открытое голосование из двух вопросов: председатель
+// (plurality) и статьи бюджета (approval)
+func newContestElection(t *testing.T, f *mockStore) *models.Election {
+	t.Helper()
+	e := &models.Election{
+		Title:     "Общее собрание",
+		CreatedBy: 1,
+		Status:    models.ElectionOpen,
+		Contests: []*models.Contest{
+			{Title: "Председатель", Choices: []string{"Анна", "Борис"}},
+			{Title: "Бюджет", VotingMethod: models.VotingApproval, Choices: []string{"Парк", "Школа", "Дорога"}},
+		},
+	}
+	if err := f.electionService().Create(context.Background(), e, nil); err != nil {
+		t.Fatal(err)
+	}
+	return e
+}
+
+// contestChoiceID — id варианта text в вопросе contestID
+func contestChoiceID(t *testing.T, f *mockStore, contestID int, text string) int {
+	t.Helper()
+	for _, c := range f.choices.choices {
+		if c.ContestID == contestID && c.Text == text {
+			return c.ID
+		}
+	}
+	t.Fatalf("no choice %q in contest %d", text, contestID)
+	return 0
+}
+
+func TestContests_TalliedPerQuestion(t *testing.T) {
+	f := newMockStore()
+	ctx := context.Background()
+	e := newContestElection(t, f)
+	chair, budget := e.Contests[0].ID, e.Contests[1].ID
+
+	ballots := []struct {
+		chair     string
+		approvals []string
+	}{
+		{"Анна", []string{"Парк", "Школа"}},
+		{"Борис", []string{"Школа"}},
+		{"Анна", []string{"Дорога", "Школа"}},
+	}
+	for i, b := range ballots {
+		ballot := &models.Ballot{Contests: []models.ContestBallot{
+			{ContestID: budget},
+			{ContestID: chair, Ballot: models.Ballot{ChoiceID: contestChoiceID(t, f, chair, b.chair)}},
+		}}
+		for _, text := range b.approvals {
+			ballot.Contests[0].Approvals = append(ballot.Contests[0].Approvals, contestChoiceID(t, f, budget, text))
+		}
+		if _, err := f.voteService().CastVote(ctx, i+1, e.ID, ballot); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Ответы хранятся в порядке вопросов
+	if ballot, _ := f.votes.votes[0].BallotContents(); ballot.Contests[0].ContestID != chair {
+		t.Fatalf("expected contests sorted by id, got %+v", ballot.Contests)
+	}
+
+	results, err := f.voteService().GetResults(ctx, e.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if results.TotalVotes != 3 || len(results.Contests) != 2 {
+		t.Fatalf("unexpected results %+v", results)
+	}
+	chairResults, budgetResults := results.Contests[0], results.Contests[1]
+	if chairResults.ContestID != chair || chairResults.VotingMethod != models.VotingPlurality {
+		t.Fatalf("unexpected chair results %+v", chairResults)
+	}
+	if votesFor(chairResults, "Анна") != 2 || votesFor(chairResults, "Борис") != 1 || len(chairResults.Choices) != 2 {
+		t.Fatalf("unexpected chair tally %+v", chairResults.Choices)
+	}
+	if budgetResults.Title != "Бюджет" || budgetResults.VotingMethod != models.VotingApproval {
+		t.Fatalf("unexpected budget results %+v", budgetResults)
+	}
+	if votesFor(budgetResults, "Школа") != 3 || votesFor(budgetResults, "Парк") != 1 || votesFor(budgetResults, "Дорога") != 1 {
+		t.Fatalf("unexpected budget tally %+v", budgetResults.Choices)
+	}
+
+	res, err := f.blockchainService().VerifyChain(ctx, e.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.Valid {
+		t.Fatalf("expected valid chain, got %+v", res)
+	}
+}
+
+func TestContests_RejectsMalformedBallots(t *testing.T) {
+	f := newMockStore()
+	ctx := context.Background()
+	e := newContestElection(t, f)
+	chair, budget := e.Contests[0].ID, e.Contests[1].ID
+	anna := contestChoiceID(t, f, chair, "Анна")
+	park := contestChoiceID(t, f, budget, "Парк")
+
+	cases := map[string]*models.Ballot{
+		"flat answer":       {ChoiceID: anna},
+		"unknown contest":   {Contests: []models.ContestBallot{{ContestID: 999, Ballot: models.Ballot{ChoiceID: anna}}}},
+		"duplicate contest": {Contests: []models.ContestBallot{{ContestID: chair, Ballot: models.Ballot{ChoiceID: anna}}, {ContestID: chair}}},
+		"foreign choice":    {Contests: []models.ContestBallot{{ContestID: chair, Ballot: models.Ballot{ChoiceID: park}}}},
+		"mixed fields":      {ChoiceID: anna, Contests: []models.ContestBallot{{ContestID: chair, Ballot: models.Ballot{ChoiceID: anna}}}},
+	}
+	for name, ballot := range cases {
+		if _, err := f.voteService().CastVote(ctx, 1, e.ID, ballot); err == nil {
+			t.Errorf("%s: expected ballot to be rejected", name)
+		}
+	}
+	if len(f.votes.votes) != 0 {
+		t.Fatalf("expected no votes stored, got %d", len(f.votes.votes))
+	}
+}
+
+func TestContests_Validation(t *testing.T) {
+	f := newMockStore()
+	ctx := context.Background()
+
+	cases := map[string]*models.Election{
+		"no title":       {Title: "X", Contests: []*models.Contest{{Choices: []string{"A"}}}},
+		"bad method":     {Title: "X", Contests: []*models.Contest{{Title: "Q", VotingMethod: "borda", Choices: []string{"A"}}}},
+		"duplicate text": {Title: "X", Contests: []*models.Contest{{Title: "Q", Choices: []string{"A", "A"}}}},
+	}
+	for name, e := range cases {
+		if err := f.electionService().Create(ctx, e, nil); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+	mixed := &models.Election{Title: "X", Contests: []*models.Contest{{Title: "Q", Choices: []string{"A"}}}}
+	if err := f.electionService().Create(ctx, mixed, []string{"B"}); !errors.Is(err, services.ErrInvalidContest) {
+		t.Fatalf("expected ErrInvalidContest for choices next to contests, got %v", err)
+	}
+
+	// Варианты добавляются только в существующий вопрос
+	e := &models.Election{Title: "Черновик", Contests: []*models.Contest{{Title: "Q", Choices: []string{"A"}}}}
+	if err := f.electionService().Create(ctx, e, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.choiceService().Add(ctx, &models.Choice{ElectionID: e.ID, Text: "B"}); !errors.Is(err, services.ErrInvalidChoiceData) {
+		t.Fatalf("expected ErrInvalidChoiceData without contest, got %v", err)
+	}
+	c := &models.Choice{ElectionID: e.ID, ContestID: e.Contests[0].ID, Text: "B"}
+	if err := f.choiceService().Add(ctx, c); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestContests_DefinitionHashCoversContests(t *testing.T) {
+	f := newMockStore()
+	e := newContestElection(t, f)
+	choices, err := f.choices.GetChoices(context.Background(), e.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stored, err := f.elections.GetByID(context.Background(), e.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hash := stored.DefinitionHash(choices)
+	stored.Contests[1].VotingMethod = models.VotingPlurality
+	if stored.DefinitionHash(choices) == hash {
+		t.Fatal("definition hash must change when a contest's method changes")
+	}
+
+	a := roundTrip(t, archiveOf(t, f, e.ID))
+	if len(a.Election.Contests) != 2 {
+		t.Fatalf("expected contests in archive, got %+v", a.Election.Contests)
+	}
+	if res := services.VerifyArchive(a, nil); !res.Valid {
+		t.Fatalf("expected valid archive, got %+v", res)
+	}
+}
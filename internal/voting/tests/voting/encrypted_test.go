@@ -0,0 +1,256 @@
+package voting_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"voting-blockchain/internal/voting/models"
+	"voting-blockchain/internal/voting/services"
+)
+
+// newEncryptedElection — открытое голосование с зашифрованными бюллетенями и
+// вариантами A, B и C; возвращает его и id вариантов по тексту
+func newEncryptedElection(t *testing.T, f *mockStore, method string, maxScore int) (*models.Election, map[string]int) {
+	t.Helper()
+	e := &models.Election{
+		Title:        "Encrypted",
+		CreatedBy:    1,
+		Status:       models.ElectionOpen,
+		VotingMethod: method,
+		MaxScore:     maxScore,
+		Encryption:   models.EncryptionElGamal,
+	}
+	if err := f.electionService().Create(context.Background(), e, []string{"A", "B", "C"}); err != nil {
+		t.Fatal(err)
+	}
+	choices, err := f.choices.GetChoices(context.Background(), e.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ids := make(map[string]int, len(choices))
+	for _, c := range choices {
+		ids[c.Text] = c.ID
+	}
+	return e, ids
+}
+
+// encryptBallot — то, что делает клиент избирателя: шифрует значения
+// вариантов на опубликованный ключ голосования
+func encryptBallot(t *testing.T, f *mockStore, electionID int, ids map[string]int, values map[string]int) *models.Ballot {
+	t.Helper()
+	key, err := f.electionService().EncryptionKey(context.Background(), electionID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var choiceIDs []int
+	byID := make(map[int]int, len(values))
+	for text, id := range ids {
+		choiceIDs = append(choiceIDs, id)
+		byID[id] = values[text]
+	}
+	encrypted, err := services.EncryptBallot(key, choiceIDs, byID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &models.Ballot{Encrypted: encrypted}
+}
+
+func TestEncryptedBallots_OnlyAggregateIsDecrypted(t *testing.T) {
+	f := newMockStore()
+	ctx := context.Background()
+	e, ids := newEncryptedElection(t, f, models.VotingPlurality, 0)
+
+	for i, choice := range []string{"A", "A", "B"} {
+		ballot := encryptBallot(t, f, e.ID, ids, map[string]int{choice: 1})
+		if _, err := f.voteService().CastVote(ctx, i+1, e.ID, ballot); err != nil {
+			t.Fatalf("vote %d: %v", i+1, err)
+		}
+	}
+	for _, v := range f.votes.votes {
+		ballot, err := v.BallotContents()
+		if err != nil || v.Choice != "" || v.ChoiceID != 0 || ballot.ChoiceID != 0 || ballot.Encrypted == nil {
+			t.Fatalf("vote must not carry a plaintext choice, got %+v", v)
+		}
+	}
+
+	// До подсчёта видны только зашифрованные суммы
+	results, err := f.voteService().GetResults(ctx, e.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if results.TotalVotes != 3 || results.Encrypted == nil || results.Encrypted.Decrypted || votesFor(results, "A") != 0 {
+		t.Fatalf("expected an undecrypted aggregate, got %+v", results)
+	}
+
+	if err := setStatus(ctx, f, e, models.ElectionClosed); err != nil {
+		t.Fatal(err)
+	}
+	if err := setStatus(ctx, f, e, models.ElectionTallied); err != nil {
+		t.Fatal(err)
+	}
+	results, err = f.voteService().GetResults(ctx, e.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !results.Final || votesFor(results, "A") != 2 || votesFor(results, "B") != 1 || votesFor(results, "C") != 0 {
+		t.Fatalf("unexpected results %+v", results.Choices)
+	}
+	if len(results.Winners) != 1 || results.Winners[0] != ids["A"] {
+		t.Fatalf("expected A to win, got %v", results.Winners)
+	}
+
+	// Расшифровку может проверить каждый по открытому ключу
+	key, err := f.electionService().EncryptionKey(ctx, e.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !services.VerifyEncryptedTally(key, results) {
+		t.Fatal("decryption proofs must verify")
+	}
+	results.Choices[1].Votes++
+	if services.VerifyEncryptedTally(key, results) {
+		t.Fatal("a changed count must not verify")
+	}
+}
+
+func TestEncryptedBallots_RejectsInvalidBallots(t *testing.T) {
+	f := newMockStore()
+	ctx := context.Background()
+	e, ids := newEncryptedElection(t, f, models.VotingPlurality, 0)
+	other, _ := newEncryptedElection(t, f, models.VotingPlurality, 0)
+
+	forA := encryptBallot(t, f, e.ID, ids, map[string]int{"A": 1})
+	forB := encryptBallot(t, f, e.ID, ids, map[string]int{"B": 1})
+
+	// Голос за два варианта: каждый шифртекст допустим, но сумма — нет
+	double := *forA.Encrypted
+	double.Choices = append([]models.EncryptedChoice(nil), forA.Encrypted.Choices...)
+	double.Choices[1] = forB.Encrypted.Choices[1]
+
+	missing := *forA.Encrypted
+	missing.Choices = missing.Choices[:2]
+
+	cases := map[string]*models.Ballot{
+		"two choices":    {Encrypted: &double},
+		"missing choice": {Encrypted: &missing},
+		"plaintext":      byText("A"),
+		"mixed":          {Encrypted: forA.Encrypted, ChoiceID: ids["A"]},
+		"other key":      encryptBallot(t, f, other.ID, ids, map[string]int{"A": 1}),
+	}
+	for name, ballot := range cases {
+		if _, err := f.voteService().CastVote(ctx, 1, e.ID, ballot); !errors.Is(err, services.ErrInvalidBallot) {
+			t.Errorf("%s: expected ErrInvalidBallot, got %v", name, err)
+		}
+	}
+	if _, err := f.voteService().CastVote(ctx, 1, e.ID, forA); err != nil {
+		t.Fatalf("a rejected ballot must not use up the vote: %v", err)
+	}
+}
+
+func TestEncryptedBallots_RejectsCopiedBallot(t *testing.T) {
+	f := newMockStore()
+	ctx := context.Background()
+	e, ids := newEncryptedElection(t, f, models.VotingPlurality, 0)
+
+	ballot := encryptBallot(t, f, e.ID, ids, map[string]int{"A": 1})
+	if _, err := f.voteService().CastVote(ctx, 1, e.ID, ballot); err != nil {
+		t.Fatal(err)
+	}
+
+	// Чужой бюллетень с доказательствами проходит проверку, но повторять его нельзя
+	copied := &models.Ballot{Encrypted: ballot.Encrypted}
+	if _, err := f.voteService().CastVote(ctx, 2, e.ID, copied); !errors.Is(err, services.ErrInvalidBallot) {
+		t.Fatalf("expected ErrInvalidBallot for a copied ballot, got %v", err)
+	}
+	if _, err := f.voteService().CastVote(ctx, 2, e.ID, encryptBallot(t, f, e.ID, ids, map[string]int{"A": 1})); err != nil {
+		t.Fatalf("a freshly encrypted ballot for the same choice must be accepted: %v", err)
+	}
+}
+
+func TestEncryptedBallots_Score(t *testing.T) {
+	f := newMockStore()
+	ctx := context.Background()
+	e, ids := newEncryptedElection(t, f, models.VotingScore, 5)
+
+	key, err := f.electionService().EncryptionKey(ctx, e.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if key.MaxValue != 5 || key.VotingMethod != models.VotingScore {
+		t.Fatalf("unexpected key %+v", key)
+	}
+	for i, scores := range []map[string]int{{"A": 5, "B": 3}, {"A": 1, "B": 4}} {
+		if _, err := f.voteService().CastVote(ctx, i+1, e.ID, encryptBallot(t, f, e.ID, ids, scores)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := setStatus(ctx, f, e, models.ElectionClosed); err != nil {
+		t.Fatal(err)
+	}
+	if err := setStatus(ctx, f, e, models.ElectionTallied); err != nil {
+		t.Fatal(err)
+	}
+	results, err := f.voteService().GetResults(ctx, e.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	scores := map[string]int{}
+	for _, c := range results.Choices {
+		scores[c.Text] = c.Score
+	}
+	if scores["A"] != 6 || scores["B"] != 7 || scores["C"] != 0 {
+		t.Fatalf("unexpected scores %v", scores)
+	}
+	if len(results.Winners) != 1 || results.Winners[0] != ids["B"] || !services.VerifyEncryptedTally(key, results) {
+		t.Fatalf("unexpected results %+v", results)
+	}
+}
+
+func TestEncryptedBallots_Configuration(t *testing.T) {
+	f := newMockStore()
+	ctx := context.Background()
+
+	invalid := map[string]*models.Election{
+		"unknown":   {Title: "X", Encryption: "paillier"},
+		"irv":       {Title: "X", Encryption: models.EncryptionElGamal, VotingMethod: models.VotingInstantRunoff},
+		"write-ins": {Title: "X", Encryption: models.EncryptionElGamal, AllowWriteIns: true},
+	}
+	for name, e := range invalid {
+		if err := f.electionService().Create(ctx, e, []string{"A"}); !errors.Is(err, services.ErrInvalidEncryption) {
+			t.Errorf("%s: expected ErrInvalidEncryption, got %v", name, err)
+		}
+	}
+
+	plain := newMethodElection(t, f, models.VotingPlurality, 0)
+	if _, err := f.electionService().EncryptionKey(ctx, plain.ID); !errors.Is(err, services.ErrNotEncrypted) {
+		t.Fatalf("expected ErrNotEncrypted, got %v", err)
+	}
+	err := f.electionService().Update(ctx, &models.Election{ID: plain.ID, Title: plain.Title, Encryption: models.EncryptionElGamal}, services.UpdateFields{}, 1)
+	if !errors.Is(err, services.ErrDefinitionLocked) {
+		t.Fatalf("expected ErrDefinitionLocked, got %v", err)
+	}
+
+	// Ключ создаётся вместе с голосованием и входит в его определение
+	e, _ := newEncryptedElection(t, f, models.VotingApproval, 0)
+	if e.EncryptionKey == "" || f.secrets.secrets[e.ID] == "" {
+		t.Fatal("an encrypted election must get a key pair")
+	}
+	hash := e.DefinitionHash(nil)
+	e.EncryptionKey = plain.EncryptionKey
+	if e.DefinitionHash(nil) == hash {
+		t.Fatal("the encryption key must be covered by the definition hash")
+	}
+
+	// В черновике шифрование можно отключить, и закрытый ключ удаляется
+	draft := &models.Election{Title: "Draft", CreatedBy: 1, Encryption: models.EncryptionElGamal}
+	if err := f.electionService().Create(ctx, draft, []string{"A", "B"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.electionService().Update(ctx, &models.Election{ID: draft.ID, Title: draft.Title, Encryption: models.EncryptionNone}, services.UpdateFields{}, 1); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := f.secrets.secrets[draft.ID]; ok {
+		t.Fatal("the private key must be removed with encryption")
+	}
+}
@@ -0,0 +1,119 @@
+package voting_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"voting-blockchain/internal/voting/models"
+	"voting-blockchain/internal/voting/services"
+)
+
+func newRankedElection(t *testing.T, f *mockStore) *models.Election {
+	t.Helper()
+	e := &models.Election{Title: "Leader", CreatedBy: 1, Status: models.ElectionOpen, VotingMethod: models.VotingInstantRunoff}
+	if err := f.electionService().Create(context.Background(), e, []string{"A", "B", "C"}); err != nil {
+		t.Fatal(err)
+	}
+	return e
+}
+
+func TestInstantRunoff_EliminatesUntilMajority(t *testing.T) {
+	f := newMockStore()
+	ctx := context.Background()
+	e := newRankedElection(t, f)
+
+	ballots := [][]int{
+		{1, 2, 3}, {1, 3}, {1}, {1, 2},
+		{2, 1}, {2, 3}, {2},
+		{3, 2}, {3, 2}, {3},
+	}
+	for i, ranking := range ballots {
+		if _, err := f.voteService().CastVote(ctx, i+1, e.ID, &models.Ballot{Ranking: ranking}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	results, err := f.voteService().GetResults(ctx, e.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if results.VotingMethod != models.VotingInstantRunoff || results.TotalVotes != 10 {
+		t.Fatalf("unexpected results %+v", results)
+	}
+	if votesFor(results, "A") != 4 || votesFor(results, "B") != 3 || votesFor(results, "C") != 3 {
+		t.Fatalf("expected first preferences 4/3/3, got %+v", results.Choices)
+	}
+	if len(results.Rounds) != 2 {
+		t.Fatalf("expected 2 rounds, got %+v", results.Rounds)
+	}
+	// B и C равны в первом туре: выбывает C, стоящий в бюллетене ниже
+	if got := results.Rounds[0].Eliminated; len(got) != 1 || got[0] != 3 {
+		t.Fatalf("expected C eliminated in round 1, got %v", got)
+	}
+	last := results.Rounds[1]
+	if last.Exhausted != 1 || len(last.Tallies) != 2 || last.Tallies[1].Votes != 5 {
+		t.Fatalf("unexpected final round %+v", last)
+	}
+	if len(results.Winners) != 1 || results.Winners[0] != 2 {
+		t.Fatalf("expected B to win after transfers, got %v", results.Winners)
+	}
+
+	res, err := f.blockchainService().VerifyChain(ctx, e.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.Valid {
+		t.Fatalf("expected valid chain, got %+v", res)
+	}
+}
+
+func TestInstantRunoff_ValidatesRankedBallots(t *testing.T) {
+	f := newMockStore()
+	ctx := context.Background()
+	e := newRankedElection(t, f)
+	svc := f.voteService()
+
+	cases := []struct {
+		ballot *models.Ballot
+		want   error
+	}{
+		{&models.Ballot{}, services.ErrChoiceRequired},
+		{&models.Ballot{ChoiceID: 1}, services.ErrChoiceRequired},
+		{&models.Ballot{Ranking: []int{1, 9}}, services.ErrInvalidChoice},
+		{&models.Ballot{Ranking: []int{1, 2, 1}}, services.ErrInvalidBallot},
+		{&models.Ballot{ChoiceID: 1, Ranking: []int{1}}, services.ErrInvalidBallot},
+	}
+	for _, c := range cases {
+		if _, err := svc.CastVote(ctx, 1, e.ID, c.ballot); !errors.Is(err, c.want) {
+			t.Errorf("ballot %+v: expected %v, got %v", c.ballot, c.want, err)
+		}
+	}
+
+	// В голосовании plurality ранжирование не принимается
+	g, plurality := buildChain(t, 0)
+	if _, err := g.voteService().CastVote(ctx, 1, plurality, &models.Ballot{ChoiceID: 1, Ranking: []int{1}}); !errors.Is(err, services.ErrInvalidBallot) {
+		t.Errorf("expected ErrInvalidBallot for ranking in plurality election, got %v", err)
+	}
+}
+
+func TestInstantRunoff_MethodLockedByGenesis(t *testing.T) {
+	f := newMockStore()
+	ctx := context.Background()
+	e := newRankedElection(t, f)
+
+	plain := *e
+	plain.VotingMethod = models.VotingPlurality
+	choices, _ := f.choices.GetChoices(ctx, e.ID)
+	if e.DefinitionHash(choices) == plain.DefinitionHash(choices) {
+		t.Fatal("definition hash must commit to the voting method")
+	}
+
+	err := f.electionService().Update(ctx, &models.Election{ID: e.ID, Title: e.Title, VotingMethod: models.VotingPlurality}, services.UpdateFields{}, 1)
+	if !errors.Is(err, services.ErrDefinitionLocked) {
+		t.Fatalf("expected ErrDefinitionLocked, got %v", err)
+	}
+	if err := f.electionService().Create(ctx, &models.Election{Title: "X", VotingMethod: "borda"}, nil); !errors.Is(err, services.ErrInvalidVotingMethod) {
+		t.Fatalf("expected ErrInvalidVotingMethod, got %v", err)
+	}
+}
@@ -0,0 +1,218 @@
+package voting_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"voting-blockchain/internal/voting/models"
+	"voting-blockchain/internal/voting/services"
+)
+
+func TestLifecycle_DraftToOpenWritesGenesis(t *testing.T) {
+	f := newMockStore()
+	ctx := context.Background()
+
+	e := &models.Election{Title: "Draft", CreatedBy: 1}
+	if err := f.electionService().Create(ctx, e, []string{"A", "B"}); err != nil {
+		t.Fatal(err)
+	}
+	if e.Status != models.ElectionDraft || len(f.blocks.blocks) != 0 {
+		t.Fatalf("expected draft without genesis, got %s with %d blocks", e.Status, len(f.blocks.blocks))
+	}
+	if _, err := f.voteService().CastVote(ctx, 1, e.ID, byText("A")); !errors.Is(err, services.ErrElectionNotOpen) {
+		t.Fatalf("expected ErrElectionNotOpen for draft, got %v", err)
+	}
+
+	// В черновике определение ещё можно менять
+	edit := &models.Election{ID: e.ID, Title: "Renamed"}
+	if err := f.electionService().Update(ctx, edit, services.UpdateFields{}, 1); err != nil {
+		t.Fatal(err)
+	}
+
+	open := &models.Election{ID: e.ID, Title: "Renamed", Status: models.ElectionOpen}
+	if err := f.electionService().Update(ctx, open, services.UpdateFields{}, 1); err != nil {
+		t.Fatal(err)
+	}
+	if !open.IsActive || len(f.blocks.blocks) != 1 || f.blocks.blocks[0].Kind != models.BlockKindGenesis {
+		t.Fatalf("expected open election with genesis, got %+v", open)
+	}
+	if _, err := f.voteService().CastVote(ctx, 1, e.ID, byText("A")); err != nil {
+		t.Fatal(err)
+	}
+
+	res, err := f.blockchainService().VerifyChain(ctx, e.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.Valid || !res.HasGenesis {
+		t.Fatalf("expected valid chain with renamed definition, got %+v", res)
+	}
+}
+
+func TestLifecycle_BareStatusTransition(t *testing.T) {
+	f := newMockStore()
+	ctx := context.Background()
+
+	opensAt := time.Now().Add(-time.Hour)
+	closesAt := time.Now().Add(time.Hour)
+	e := &models.Election{Title: "Board", Description: "Annual", CreatedBy: 1, Status: models.ElectionOpen,
+		OpensAt: &opensAt, ClosesAt: &closesAt}
+	if err := f.electionService().Create(ctx, e, []string{"A", "B"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Как PUT {"status": "closed"}: остальные поля не переданы и не меняются
+	if err := f.electionService().Update(ctx, &models.Election{ID: e.ID, Status: models.ElectionClosed}, services.UpdateFields{}, 1); err != nil {
+		t.Fatalf("expected bare status transition, got %v", err)
+	}
+	got, err := f.electionService().GetByID(ctx, e.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Status != models.ElectionClosed || got.Title != "Board" || got.Description != "Annual" ||
+		got.OpensAt == nil || !got.OpensAt.Equal(opensAt) || got.ClosesAt == nil || !got.ClosesAt.Equal(closesAt) {
+		t.Fatalf("expected closed election with definition kept, got %+v", got)
+	}
+}
+
+// Как PUT {"opens_at": null, "closes_at": null}: явный null снимает окно,
+// а не оставляет его прежним
+func TestLifecycle_ClearVotingWindow(t *testing.T) {
+	f := newMockStore()
+	ctx := context.Background()
+
+	opensAt := time.Now().Add(time.Hour)
+	closesAt := opensAt.Add(time.Hour)
+	e := &models.Election{Title: "Board", CreatedBy: 1, OpensAt: &opensAt, ClosesAt: &closesAt}
+	if err := f.electionService().Create(ctx, e, []string{"A", "B"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := f.electionService().Update(ctx, &models.Election{ID: e.ID}, services.UpdateFields{ClosesAt: true}, 1); err != nil {
+		t.Fatal(err)
+	}
+	got, _ := f.electionService().GetByID(ctx, e.ID)
+	if got.OpensAt == nil || !got.OpensAt.Equal(opensAt) || got.ClosesAt != nil {
+		t.Fatalf("expected only closes_at cleared, got %v – %v", got.OpensAt, got.ClosesAt)
+	}
+
+	if err := f.electionService().Update(ctx, &models.Election{ID: e.ID}, services.UpdateFields{OpensAt: true}, 1); err != nil {
+		t.Fatal(err)
+	}
+	got, _ = f.electionService().GetByID(ctx, e.ID)
+	if got.OpensAt != nil || got.ClosesAt != nil {
+		t.Fatalf("expected the window cleared, got %v – %v", got.OpensAt, got.ClosesAt)
+	}
+}
+
+// Как PUT {"voting_method": "plurality"} без allow_write_ins: разрешение
+// вписанных вариантов не сбрасывается, а явный false его снимает
+func TestLifecycle_AllowWriteInsKeptUnlessSent(t *testing.T) {
+	f := newMockStore()
+	ctx := context.Background()
+
+	e := &models.Election{Title: "Board", CreatedBy: 1, AllowWriteIns: true}
+	if err := f.electionService().Create(ctx, e, []string{"A", "B"}); err != nil {
+		t.Fatal(err)
+	}
+
+	edit := &models.Election{ID: e.ID, VotingMethod: models.VotingPlurality}
+	if err := f.electionService().Update(ctx, edit, services.UpdateFields{}, 1); err != nil {
+		t.Fatal(err)
+	}
+	if !edit.AllowWriteIns {
+		t.Fatal("expected allow_write_ins kept when it is not sent")
+	}
+
+	edit = &models.Election{ID: e.ID, VotingMethod: models.VotingPlurality}
+	if err := f.electionService().Update(ctx, edit, services.UpdateFields{AllowWriteIns: true}, 1); err != nil {
+		t.Fatal(err)
+	}
+	if edit.AllowWriteIns {
+		t.Fatal("expected explicit false to clear allow_write_ins")
+	}
+}
+
+func TestLifecycle_IllegalTransitionRejected(t *testing.T) {
+	f, id := buildChain(t, 0)
+	ctx := context.Background()
+
+	err := f.electionService().Update(ctx, &models.Election{ID: id, Title: "Board", Status: models.ElectionCertified}, services.UpdateFields{}, 1)
+	if !errors.Is(err, services.ErrInvalidTransition) {
+		t.Fatalf("expected ErrInvalidTransition open → certified, got %v", err)
+	}
+	err = f.electionService().Update(ctx, &models.Election{ID: id, Title: "Board", Status: models.ElectionDraft}, services.UpdateFields{}, 1)
+	if !errors.Is(err, services.ErrInvalidTransition) {
+		t.Fatalf("expected ErrInvalidTransition open → draft, got %v", err)
+	}
+}
+
+func TestLifecycle_CloseSealsPendingAndRejectsVotes(t *testing.T) {
+	f := newMockStore()
+	ctx := context.Background()
+
+	e := &models.Election{Title: "Close", CreatedBy: 1, Status: models.ElectionOpen, BatchSize: 10}
+	if err := f.electionService().Create(ctx, e, []string{"A"}); err != nil {
+		t.Fatal(err)
+	}
+	for i := 1; i <= 3; i++ {
+		if _, err := f.voteService().CastVote(ctx, i, e.ID, byText("A")); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	closeReq := &models.Election{ID: e.ID, Title: "Close", Status: models.ElectionClosed}
+	if err := f.electionService().Update(ctx, closeReq, services.UpdateFields{}, 7); err != nil {
+		t.Fatal(err)
+	}
+	if pending, _ := f.votes.GetPending(ctx, e.ID); len(pending) != 0 {
+		t.Fatalf("expected pending votes to be sealed on close, got %d", len(pending))
+	}
+	if _, err := f.voteService().CastVote(ctx, 4, e.ID, byText("A")); !errors.Is(err, services.ErrElectionNotOpen) {
+		t.Fatalf("expected ErrElectionNotOpen after close, got %v", err)
+	}
+
+	history, err := f.electionService().GetTransitions(ctx, e.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(history) != 2 || history[1].FromStatus != models.ElectionOpen ||
+		history[1].ToStatus != models.ElectionClosed || *history[1].ChangedBy != 7 {
+		t.Fatalf("expected draft → open → closed history, got %+v", history)
+	}
+}
+
+func TestLifecycle_VotesOnlyInsideWindow(t *testing.T) {
+	f := newMockStore()
+	ctx := context.Background()
+
+	starts := time.Now().Add(time.Hour)
+	e := &models.Election{Title: "Later", CreatedBy: 1, Status: models.ElectionOpen, OpensAt: &starts}
+	if err := f.electionService().Create(ctx, e, []string{"A"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.voteService().CastVote(ctx, 1, e.ID, byText("A")); !errors.Is(err, services.ErrElectionNotOpen) {
+		t.Fatalf("expected ErrElectionNotOpen before opens_at, got %v", err)
+	}
+
+	ended := time.Now().Add(-time.Minute)
+	past := &models.Election{Title: "Ended", CreatedBy: 1, Status: models.ElectionOpen, ClosesAt: &ended}
+	if err := f.electionService().Create(ctx, past, []string{"A"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.voteService().CastVote(ctx, 1, past.ID, byText("A")); !errors.Is(err, services.ErrElectionNotOpen) {
+		t.Fatalf("expected ErrElectionNotOpen after closes_at, got %v", err)
+	}
+}
+
+func TestLifecycle_ScheduleRequiresStart(t *testing.T) {
+	f := newMockStore()
+
+	e := &models.Election{Title: "Scheduled", CreatedBy: 1, Status: models.ElectionScheduled}
+	err := f.electionService().Create(context.Background(), e, []string{"A"})
+	if !errors.Is(err, services.ErrInvalidSchedule) {
+		t.Fatalf("expected ErrInvalidSchedule, got %v", err)
+	}
+}
@@ -0,0 +1,980 @@
+package voting_test
+
+import (
+	"context"
+	"maps"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	"voting-blockchain/internal/voting/models"
+	"voting-blockchain/internal/voting/repositories"
+	"voting-blockchain/internal/voting/services"
+)
+
+type mockBlockRepo struct {
+	mu      sync.Mutex
+	blocks  []*models.Block
+	failAdd error
+}
+
+func (m *mockBlockRepo) AddBlock(ctx context.Context, b *models.Block) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.failAdd != nil {
+		return m.failAdd
+	}
+	b.Index = len(m.blocks) + 1
+	m.blocks = append(m.blocks, b)
+	return nil
+}
+
+func (m *mockBlockRepo) GetLastBlock(ctx context.Context, electionID int) (*models.Block, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i := len(m.blocks) - 1; i >= 0; i-- {
+		if m.blocks[i].ElectionID == electionID {
+			return m.blocks[i], nil
+		}
+	}
+	return nil, pgx.ErrNoRows
+}
+
+func (m *mockBlockRepo) GetAllBlocks(ctx context.Context, electionID int) ([]*models.Block, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var res []*models.Block
+	for _, b := range m.blocks {
+		if b.ElectionID == electionID {
+			res = append(res, b)
+		}
+	}
+	return res, nil
+}
+
+func (m *mockBlockRepo) RestoreBlocks(ctx context.Context, blocks []*models.Block) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, b := range blocks {
+		cp := *b
+		m.blocks = append(m.blocks, &cp)
+	}
+	return nil
+}
+
+type mockVoteRepo struct {
+	mu    sync.Mutex
+	votes []*models.Vote
+}
+
+func (m *mockVoteRepo) Create(ctx context.Context, v *models.Vote) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	v.ID = len(m.votes) + 1
+	v.CreatedAt = time.Now()
+	m.votes = append(m.votes, v)
+	return nil
+}
+
+func (m *mockVoteRepo) HasVoted(ctx context.Context, userID, electionID int) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, v := range m.votes {
+		if v.UserID == userID && v.ElectionID == electionID {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (m *mockVoteRepo) GetByElectionID(ctx context.Context, electionID int) ([]*models.Vote, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var res []*models.Vote
+	for _, v := range m.votes {
+		if v.ElectionID == electionID {
+			res = append(res, v)
+		}
+	}
+	return res, nil
+}
+
+func (m *mockVoteRepo) GetResults(ctx context.Context, electionID int) ([]*models.Choice, error) {
+	return nil, nil
+}
+
+func (m *mockVoteRepo) GetByHash(ctx context.Context, hash string) (*models.Vote, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, v := range m.votes {
+		if v.VoteHash == hash {
+			return v, nil
+		}
+	}
+	return nil, pgx.ErrNoRows
+}
+
+func (m *mockVoteRepo) GetPending(ctx context.Context, electionID int) ([]*models.Vote, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var res []*models.Vote
+	for _, v := range m.votes {
+		if v.ElectionID == electionID && v.BlockID == nil {
+			res = append(res, v)
+		}
+	}
+	return res, nil
+}
+
+func (m *mockVoteRepo) GetByBlockID(ctx context.Context, blockID int) ([]*models.Vote, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var res []*models.Vote
+	for _, v := range m.votes {
+		if v.BlockID != nil && *v.BlockID == blockID {
+			res = append(res, v)
+		}
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].LeafIndex < res[j].LeafIndex })
+	return res, nil
+}
+
+func (m *mockVoteRepo) AttachToBlock(ctx context.Context, blockID int, voteIDs []int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i, id := range voteIDs {
+		for _, v := range m.votes {
+			if v.ID == id {
+				b := blockID
+				v.BlockID = &b
+				v.LeafIndex = i
+			}
+		}
+	}
+	return nil
+}
+
+func (m *mockVoteRepo) Restore(ctx context.Context, v *models.Vote) error {
+	return m.Create(ctx, v)
+}
+
+type mockElectionRepo struct {
+	mu          sync.Mutex
+	elections   map[int]*models.Election
+	transitions []*models.ElectionTransition
+	tallies     map[int]*models.Tally
+	contests    int
+	reviews     []*models.WriteInReview
+}
+
+func newMockElectionRepo() *mockElectionRepo {
+	return &mockElectionRepo{elections: map[int]*models.Election{}, tallies: map[int]*models.Tally{}}
+}
+
+func (m *mockElectionRepo) Create(ctx context.Context, e *models.Election) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	e.ID = len(m.elections) + 1
+	e.CreatedAt = time.Now()
+	for i, c := range e.Contests {
+		m.contests++
+		c.ID = m.contests
+		c.ElectionID = e.ID
+		c.Position = i + 1
+	}
+	m.elections[e.ID] = copyElection(e)
+	return nil
+}
+
+// copyElection — копия голосования вместе с вопросами, как после чтения из базы
+func copyElection(e *models.Election) *models.Election {
+	cp := *e
+	cp.Contests = nil
+	cp.WriteInReviews = nil
+	for _, c := range e.Contests {
+		q := *c
+		q.Choices = nil
+		cp.Contests = append(cp.Contests, &q)
+	}
+	return &cp
+}
+
+func (m *mockElectionRepo) GetByID(ctx context.Context, id int) (*models.Election, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	e, ok := m.elections[id]
+	if !ok {
+		return nil, pgx.ErrNoRows
+	}
+	return copyElection(e), nil
+}
+
+func (m *mockElectionRepo) List(ctx context.Context) ([]*models.Election, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var res []*models.Election
+	for _, e := range m.elections {
+		res = append(res, e)
+	}
+	return res, nil
+}
+
+func (m *mockElectionRepo) ListDue(ctx context.Context, now time.Time) ([]*models.Election, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var res []*models.Election
+	for _, e := range m.elections {
+		opening := e.Status == models.ElectionScheduled && e.OpensAt != nil && !now.Before(*e.OpensAt)
+		open := e.Status == models.ElectionOpen &&
+			((e.ClosesAt != nil && !now.Before(*e.ClosesAt)) || e.BatchWindowSeconds > 0)
+		if opening || open {
+			cp := *e
+			res = append(res, &cp)
+		}
+	}
+	return res, nil
+}
+
+func (m *mockElectionRepo) Update(ctx context.Context, e *models.Election) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	cp := *e
+	m.elections[e.ID] = &cp
+	return nil
+}
+
+func (m *mockElectionRepo) Restore(ctx context.Context, e *models.Election) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, c := range e.Contests {
+		m.contests = max(m.contests, c.ID)
+	}
+	for _, w := range e.WriteInReviews {
+		cp := *w
+		cp.ElectionID = e.ID
+		m.reviews = append(m.reviews, &cp)
+	}
+	m.elections[e.ID] = copyElection(e)
+	return nil
+}
+
+func (m *mockElectionRepo) RecordTransition(ctx context.Context, t *models.ElectionTransition) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	t.ID = len(m.transitions) + 1
+	t.ChangedAt = time.Now()
+	m.transitions = append(m.transitions, t)
+	return nil
+}
+
+func (m *mockElectionRepo) ListTransitions(ctx context.Context, electionID int) ([]*models.ElectionTransition, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var res []*models.ElectionTransition
+	for _, t := range m.transitions {
+		if t.ElectionID == electionID {
+			res = append(res, t)
+		}
+	}
+	return res, nil
+}
+
+func (m *mockElectionRepo) SaveTally(ctx context.Context, t *models.Tally) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	t.ComputedAt = time.Now()
+	m.tallies[t.ElectionID] = t
+	return nil
+}
+
+func (m *mockElectionRepo) GetTally(ctx context.Context, electionID int) (*models.Tally, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	t, ok := m.tallies[electionID]
+	if !ok {
+		return nil, pgx.ErrNoRows
+	}
+	return t, nil
+}
+
+func (m *mockElectionRepo) SaveWriteInReview(ctx context.Context, w *models.WriteInReview) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	w.ReviewedAt = time.Now()
+	cp := *w
+	for i, r := range m.reviews {
+		if r.ElectionID == w.ElectionID && r.ContestID == w.ContestID && models.WriteInKey(r.Text) == models.WriteInKey(w.Text) {
+			m.reviews[i] = &cp
+			return nil
+		}
+	}
+	m.reviews = append(m.reviews, &cp)
+	return nil
+}
+
+func (m *mockElectionRepo) ListWriteInReviews(ctx context.Context, electionID int) ([]*models.WriteInReview, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var res []*models.WriteInReview
+	for _, r := range m.reviews {
+		if r.ElectionID == electionID {
+			cp := *r
+			res = append(res, &cp)
+		}
+	}
+	return res, nil
+}
+
+func (m *mockElectionRepo) Delete(ctx context.Context, id int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.elections, id)
+	return nil
+}
+
+type mockChoiceRepo struct {
+	mu      sync.Mutex
+	choices []*models.Choice
+}
+
+func (m *mockChoiceRepo) CreateChoices(ctx context.Context, electionID int, choices []string) error {
+	for _, text := range choices {
+		if err := m.AddChoice(ctx, &models.Choice{ElectionID: electionID, Text: text}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *mockChoiceRepo) GetChoices(ctx context.Context, electionID int) ([]*models.Choice, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var res []*models.Choice
+	for _, c := range m.choices {
+		if c.ElectionID == electionID {
+			cp := *c
+			res = append(res, &cp)
+		}
+	}
+	sort.SliceStable(res, func(i, j int) bool { return res[i].Position < res[j].Position })
+	return res, nil
+}
+
+func (m *mockChoiceRepo) GetChoice(ctx context.Context, electionID, choiceID int) (*models.Choice, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, c := range m.choices {
+		if c.ElectionID == electionID && c.ID == choiceID {
+			cp := *c
+			return &cp, nil
+		}
+	}
+	return nil, pgx.ErrNoRows
+}
+
+func (m *mockChoiceRepo) AddChoice(ctx context.Context, c *models.Choice) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	c.ID, c.Position = 1, 1
+	for _, other := range m.choices {
+		if other.ID >= c.ID {
+			c.ID = other.ID + 1
+		}
+		if other.ElectionID == c.ElectionID && other.Position >= c.Position {
+			c.Position = other.Position + 1
+		}
+	}
+	cp := *c
+	m.choices = append(m.choices, &cp)
+	return nil
+}
+
+func (m *mockChoiceRepo) UpdateChoice(ctx context.Context, c *models.Choice) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, other := range m.choices {
+		if other.ElectionID == c.ElectionID && other.ID == c.ID {
+			other.Text, other.Description, other.ImageURL = c.Text, c.Description, c.ImageURL
+			return nil
+		}
+	}
+	return pgx.ErrNoRows
+}
+
+func (m *mockChoiceRepo) ReorderChoices(ctx context.Context, electionID int, ids []int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i, id := range ids {
+		for _, c := range m.choices {
+			if c.ElectionID == electionID && c.ID == id {
+				c.Position = i + 1
+			}
+		}
+	}
+	return nil
+}
+
+func (m *mockChoiceRepo) DeleteChoice(ctx context.Context, electionID, choiceID int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i, c := range m.choices {
+		if c.ElectionID == electionID && c.ID == choiceID {
+			m.choices = append(m.choices[:i], m.choices[i+1:]...)
+			return nil
+		}
+	}
+	return pgx.ErrNoRows
+}
+
+func (m *mockChoiceRepo) RestoreChoices(ctx context.Context, choices []*models.Choice) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i, c := range choices {
+		cp := *c
+		if cp.Position == 0 {
+			cp.Position = i + 1
+		}
+		m.choices = append(m.choices, &cp)
+	}
+	return nil
+}
+
+type mockKeyRepo struct {
+	mu   sync.Mutex
+	keys []*models.SigningKey
+}
+
+func (m *mockKeyRepo) Activate(ctx context.Context, key *models.SigningKey) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := time.Now()
+	for _, k := range m.keys {
+		if k.RetiredAt == nil {
+			k.RetiredAt = &now
+		}
+	}
+	m.keys = append(m.keys, key)
+	return nil
+}
+
+func (m *mockKeyRepo) List(ctx context.Context) ([]*models.SigningKey, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]*models.SigningKey(nil), m.keys...), nil
+}
+
+// mockLocker — блокировка планировщика на TryLock, как advisory-блокировка
+// у нескольких реплик
+type mockLocker struct {
+	mu sync.Mutex
+}
+
+func (l *mockLocker) RunExclusive(ctx context.Context, key int, fn func(ctx context.Context) error) (bool, error) {
+	if !l.mu.TryLock() {
+		return false, nil
+	}
+	defer l.mu.Unlock()
+	return true, fn(ctx)
+}
+
+// mockRollRepo — списки избирателей; users — зарегистрированные пользователи (id → email)
+type mockRollRepo struct {
+	mu      sync.Mutex
+	entries []*models.RollEntry
+	users   map[int]string
+}
+
+func (m *mockRollRepo) Add(ctx context.Context, electionID int, entries []models.RollEntry) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	added := 0
+	for _, e := range entries {
+		if m.find(electionID, e) {
+			continue
+		}
+		e.ID = len(m.entries) + 1
+		e.ElectionID = electionID
+		e.AddedAt = time.Now()
+		m.entries = append(m.entries, &e)
+		added++
+	}
+	return added, nil
+}
+
+func (m *mockRollRepo) find(electionID int, e models.RollEntry) bool {
+	for _, r := range m.entries {
+		if r.ElectionID == electionID && ((e.UserID != 0 && r.UserID == e.UserID) || (e.Email != "" && strings.EqualFold(r.Email, e.Email))) {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *mockRollRepo) Clear(ctx context.Context, electionID int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var kept []*models.RollEntry
+	for _, r := range m.entries {
+		if r.ElectionID != electionID {
+			kept = append(kept, r)
+		}
+	}
+	m.entries = kept
+	return nil
+}
+
+func (m *mockRollRepo) List(ctx context.Context, electionID int) ([]*models.RollEntry, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var res []*models.RollEntry
+	for _, r := range m.entries {
+		if r.ElectionID == electionID {
+			cp := *r
+			res = append(res, &cp)
+		}
+	}
+	return res, nil
+}
+
+func (m *mockRollRepo) Size(ctx context.Context, electionID int) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	voters := make(map[string]bool)
+	for _, r := range m.entries {
+		if r.ElectionID != electionID {
+			continue
+		}
+		key := strings.ToLower(r.Email)
+		if r.UserID != 0 {
+			key = strconv.Itoa(r.UserID)
+		}
+		for id, email := range m.users {
+			if r.UserID == 0 && strings.EqualFold(email, r.Email) {
+				key = strconv.Itoa(id)
+			}
+		}
+		voters[key] = true
+	}
+	return len(voters), nil
+}
+
+func (m *mockRollRepo) IsEligible(ctx context.Context, electionID, userID int) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	email, registered := m.users[userID]
+	for _, r := range m.entries {
+		if r.ElectionID == electionID && (r.UserID == userID || (registered && r.UserID == 0 && strings.EqualFold(r.Email, email))) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (m *mockRollRepo) UnknownUsers(ctx context.Context, ids []int) ([]int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var res []int
+	for _, id := range ids {
+		if _, ok := m.users[id]; !ok {
+			res = append(res, id)
+		}
+	}
+	return res, nil
+}
+
+// mockCodeRepo — коды голосования: хеш кода → использован ли он
+type mockCodeRepo struct {
+	mu    sync.Mutex
+	codes map[int]map[string]bool
+}
+
+func (m *mockCodeRepo) Add(ctx context.Context, electionID int, hashes []string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.codes[electionID] == nil {
+		m.codes[electionID] = make(map[string]bool)
+	}
+	for _, h := range hashes {
+		m.codes[electionID][h] = false
+	}
+	return nil
+}
+
+func (m *mockCodeRepo) Consume(ctx context.Context, electionID int, hash string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	used, ok := m.codes[electionID][hash]
+	if !ok || used {
+		return false, nil
+	}
+	m.codes[electionID][hash] = true
+	return true, nil
+}
+
+func (m *mockCodeRepo) Stats(ctx context.Context, electionID int) (*models.BallotCodeStats, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	stats := &models.BallotCodeStats{Issued: len(m.codes[electionID])}
+	for _, used := range m.codes[electionID] {
+		if used {
+			stats.Used++
+		}
+	}
+	return stats, nil
+}
+
+// snapshot — копия состояния кодов для отката транзакции
+func (m *mockCodeRepo) snapshot() map[int]map[string]bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	cp := make(map[int]map[string]bool, len(m.codes))
+	for id, codes := range m.codes {
+		cp[id] = maps.Clone(codes)
+	}
+	return cp
+}
+
+// mockBlindRepo — ключи, выдачи и погашенные жетоны анонимных голосований
+type mockBlindRepo struct {
+	mu     sync.Mutex
+	keys   map[int]*models.BlindKey
+	issued map[[2]int]bool
+	spent  map[string]bool
+}
+
+func newMockBlindRepo() *mockBlindRepo {
+	return &mockBlindRepo{keys: map[int]*models.BlindKey{}, issued: map[[2]int]bool{}, spent: map[string]bool{}}
+}
+
+func (m *mockBlindRepo) GetKey(ctx context.Context, electionID int) (*models.BlindKey, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	k, ok := m.keys[electionID]
+	if !ok {
+		return nil, pgx.ErrNoRows
+	}
+	return k, nil
+}
+
+func (m *mockBlindRepo) SaveKey(ctx context.Context, key *models.BlindKey) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	key.CreatedAt = time.Now()
+	m.keys[key.ElectionID] = key
+	return nil
+}
+
+func (m *mockBlindRepo) RecordIssuance(ctx context.Context, electionID, userID int) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	key := [2]int{electionID, userID}
+	if m.issued[key] {
+		return false, nil
+	}
+	m.issued[key] = true
+	return true, nil
+}
+
+func (m *mockBlindRepo) SpendToken(ctx context.Context, electionID int, tokenHash string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	key := strconv.Itoa(electionID) + "/" + tokenHash
+	if m.spent[key] {
+		return false, nil
+	}
+	m.spent[key] = true
+	return true, nil
+}
+
+// snapshot — копия состояния для отката транзакции
+func (m *mockBlindRepo) snapshot() *mockBlindRepo {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return &mockBlindRepo{keys: maps.Clone(m.keys), issued: maps.Clone(m.issued), spent: maps.Clone(m.spent)}
+}
+
+// restore — возвращает состояние из snapshot
+func (m *mockBlindRepo) restore(from *mockBlindRepo) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.keys, m.issued, m.spent = from.keys, from.issued, from.spent
+}
+
+// mockSecretRepo — закрытые ключи шифрования бюллетеней
+type mockSecretRepo struct {
+	mu      sync.Mutex
+	secrets map[int]string
+}
+
+func (m *mockSecretRepo) Save(ctx context.Context, electionID int, privateKey string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.secrets[electionID] = privateKey
+	return nil
+}
+
+func (m *mockSecretRepo) Get(ctx context.Context, electionID int) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	key, ok := m.secrets[electionID]
+	if !ok {
+		return "", pgx.ErrNoRows
+	}
+	return key, nil
+}
+
+func (m *mockSecretRepo) Delete(ctx context.Context, electionID int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.secrets, electionID)
+	return nil
+}
+
+// mockTrusteeRepo — доверенные лица, их доли и частичные расшифровки
+type mockTrusteeRepo struct {
+	mu       sync.Mutex
+	trustees map[int][]models.Trustee
+	shares   map[int][]models.EncryptedShare
+	partials map[int]map[int][]models.PartialDecryption
+}
+
+func newMockTrusteeRepo() *mockTrusteeRepo {
+	return &mockTrusteeRepo{
+		trustees: map[int][]models.Trustee{},
+		shares:   map[int][]models.EncryptedShare{},
+		partials: map[int]map[int][]models.PartialDecryption{},
+	}
+}
+
+func (m *mockTrusteeRepo) Add(ctx context.Context, t *models.Trustee) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	t.Index = len(m.trustees[t.ElectionID]) + 1
+	t.CreatedAt = time.Now()
+	m.trustees[t.ElectionID] = append(m.trustees[t.ElectionID], *t)
+	return nil
+}
+
+func (m *mockTrusteeRepo) List(ctx context.Context, electionID int) ([]*models.Trustee, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var res []*models.Trustee
+	for _, t := range m.trustees[electionID] {
+		cp := t
+		res = append(res, &cp)
+	}
+	return res, nil
+}
+
+func (m *mockTrusteeRepo) Update(ctx context.Context, t *models.Trustee) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.trustees[t.ElectionID][t.Index-1] = *t
+	return nil
+}
+
+func (m *mockTrusteeRepo) SaveShares(ctx context.Context, electionID int, shares []models.EncryptedShare) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.shares[electionID] = append(m.shares[electionID], shares...)
+	return nil
+}
+
+func (m *mockTrusteeRepo) SharesFor(ctx context.Context, electionID, index int) ([]models.EncryptedShare, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var res []models.EncryptedShare
+	for _, s := range m.shares[electionID] {
+		if s.To == index {
+			res = append(res, s)
+		}
+	}
+	return res, nil
+}
+
+func (m *mockTrusteeRepo) ResetDeals(ctx context.Context, electionID int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.shares, electionID)
+	for i := range m.trustees[electionID] {
+		m.trustees[electionID][i].Commitments = nil
+		m.trustees[electionID][i].Verified = false
+	}
+	return nil
+}
+
+func (m *mockTrusteeRepo) SaveDecryption(ctx context.Context, electionID, index int, partials []models.PartialDecryption) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.partials[electionID] == nil {
+		m.partials[electionID] = map[int][]models.PartialDecryption{}
+	}
+	m.partials[electionID][index] = partials
+	return nil
+}
+
+func (m *mockTrusteeRepo) ListDecryptions(ctx context.Context, electionID int) (map[int][]models.PartialDecryption, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return maps.Clone(m.partials[electionID]), nil
+}
+
+// mockStore — in-memory хранилище с UnitOfWork. Транзакции выполняются по одной,
+// как под advisory-блокировкой, а при ошибке добавленные голоса, блоки, коды и жетоны откатываются.
+type mockStore struct {
+	mu        sync.Mutex
+	blocks    *mockBlockRepo
+	votes     *mockVoteRepo
+	elections *mockElectionRepo
+	choices   *mockChoiceRepo
+	keys      *mockKeyRepo
+	rolls     *mockRollRepo
+	codes     *mockCodeRepo
+	blind     *mockBlindRepo
+	secrets   *mockSecretRepo
+	trustees  *mockTrusteeRepo
+	trusted   []*models.SigningKey // прежние ключи узла, как в BLOCK_TRUSTED_KEYS
+	locker    *mockLocker
+	signer    *services.BlockSigner
+}
+
+func newMockStore() *mockStore {
+	s := &mockStore{
+		blocks:    &mockBlockRepo{},
+		votes:     &mockVoteRepo{},
+		elections: newMockElectionRepo(),
+		choices:   &mockChoiceRepo{},
+		keys:      &mockKeyRepo{},
+		rolls:     &mockRollRepo{users: map[int]string{}},
+		codes:     &mockCodeRepo{codes: map[int]map[string]bool{}},
+		blind:     newMockBlindRepo(),
+		secrets:   &mockSecretRepo{secrets: map[int]string{}},
+		trustees:  newMockTrusteeRepo(),
+		locker:    &mockLocker{},
+	}
+	s.rotateKey()
+	return s
+}
+
+// rotateKey — выпускает новый ключ подписи, как при перезапуске узла с другим
+// ключом; прежний ключ добавляется в доверенные
+func (s *mockStore) rotateKey() {
+	signer, err := services.GenerateBlockSigner()
+	if err != nil {
+		panic(err)
+	}
+	if s.signer != nil {
+		s.trusted = append(s.trusted, s.signer.PublicKey())
+	}
+	s.signer = signer
+	_ = s.keys.Activate(context.Background(), signer.PublicKey())
+}
+
+func (s *mockStore) Do(ctx context.Context, fn func(repos *repositories.Repositories) error) error {
+	return s.DoInElection(ctx, 0, fn)
+}
+
+func (s *mockStore) DoInElection(ctx context.Context, electionID int, fn func(repos *repositories.Repositories) error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	blocks, votes, codes, blind := len(s.blocks.blocks), len(s.votes.votes), s.codes.snapshot(), s.blind.snapshot()
+	err := fn(&repositories.Repositories{
+		Votes:     s.votes,
+		Blocks:    s.blocks,
+		Elections: s.elections,
+		Choices:   s.choices,
+		Keys:      s.keys,
+		Rolls:     s.rolls,
+		Codes:     s.codes,
+		Blind:     s.blind,
+		Secrets:   s.secrets,
+		Trustees:  s.trustees,
+	})
+	if err != nil {
+		s.blocks.blocks = s.blocks.blocks[:blocks]
+		s.votes.votes = s.votes.votes[:votes]
+		s.codes.codes = codes
+		s.blind.restore(blind)
+	}
+	return err
+}
+
+func (s *mockStore) electionService() services.ElectionService {
+	return services.NewElectionService(s.elections, s.choices, s, s.signer)
+}
+
+func (s *mockStore) choiceService() services.ChoiceService {
+	return services.NewChoiceService(s.elections, s.choices, s)
+}
+
+func (s *mockStore) writeInService() services.WriteInService {
+	return services.NewWriteInService(s.elections, s.votes, s)
+}
+
+func (s *mockStore) voteService() services.VoteService {
+	return services.NewVoteService(s.votes, s.blocks, s.elections, s.choices, s.rolls, s.codes, s, s.signer)
+}
+
+func (s *mockStore) voterRollService() services.VoterRollService {
+	return services.NewVoterRollService(s.elections, s.rolls, s)
+}
+
+func (s *mockStore) ballotCodeService() services.BallotCodeService {
+	return services.NewBallotCodeService(s.elections, s.codes, s)
+}
+
+func (s *mockStore) blindTokenService() services.BlindTokenService {
+	return services.NewBlindTokenService(s)
+}
+
+func (s *mockStore) trusteeService() services.TrusteeService {
+	return services.NewTrusteeService(s)
+}
+
+func (s *mockStore) blockchainService() services.BlockchainService {
+	return services.NewBlockchainService(s.blocks, s.votes, s.elections, s.choices, s.keys, s, s.signer, s.trusted)
+}
+
+func (s *mockStore) scheduler() *services.Scheduler {
+	return services.NewScheduler(s.elections, s, s.locker, s.signer, time.Second)
+}
+
+// byText — бюллетень с вариантом, заданным текстом
+func byText(choice string) *models.Ballot {
+	return &models.Ballot{Choice: choice}
+}
+
+// votesFor — число голосов за вариант с текстом text
+func votesFor(results *models.ElectionResults, text string) int {
+	for _, c := range results.Choices {
+		if c.Text == text {
+			return c.Votes
+		}
+	}
+	return -1
+}
+
+// rankedVotes — запечатанные голоса с ранжированными бюллетенями
+func rankedVotes(rankings ...[]int) []*models.Vote {
+	block := 1
+	votes := make([]*models.Vote, 0, len(rankings))
+	for _, ranking := range rankings {
+		ballot := &models.Ballot{Ranking: ranking}
+		votes = append(votes, &models.Vote{HashVersion: models.VoteHashBallot, Ballot: ballot.Canonical(), BlockID: &block})
+	}
+	return votes
+}
+
+// repeat — n копий ранжирования
+func repeat(n int, ranking ...int) [][]int {
+	res := make([][]int, n)
+	for i := range res {
+		res[i] = ranking
+	}
+	return res
+}
@@ -0,0 +1,185 @@
+package voting_test
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"voting-blockchain/internal/voting/models"
+	"voting-blockchain/internal/voting/repositories"
+	"voting-blockchain/internal/voting/services"
+)
+
+// pgPool — пул к базе из DATABASE_URL с применёнными миграциями; без неё тест
+// пропускается
+func pgPool(t *testing.T) *pgxpool.Pool {
+	t.Helper()
+	url := os.Getenv("DATABASE_URL")
+	if url == "" {
+		t.Skip("DATABASE_URL не задан")
+	}
+	pool, err := pgxpool.New(context.Background(), url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(pool.Close)
+	if err := pool.Ping(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	return pool
+}
+
+// pgUsers — создаёт n пользователей и удаляет их после теста
+func pgUsers(t *testing.T, pool *pgxpool.Pool, n int) []int {
+	t.Helper()
+	ctx := context.Background()
+	prefix := fmt.Sprintf("pg-test-%d-", time.Now().UnixNano())
+	t.Cleanup(func() {
+		_, _ = pool.Exec(ctx, `DELETE FROM users WHERE email LIKE $1`, prefix+"%")
+	})
+
+	ids := make([]int, n)
+	for i := range ids {
+		err := pool.QueryRow(ctx, `INSERT INTO users (email, password_hash) VALUES ($1, '') RETURNING id`,
+			fmt.Sprintf("%s%d@example.com", prefix, i)).Scan(&ids[i])
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	return ids
+}
+
+// Транзакции PgUnitOfWork идут параллельно на разных соединениях, и только
+// pg_advisory_xact_lock не даёт двум голосам прочитать одну вершину цепочки
+func TestPgUnitOfWork_ConcurrentVotersKeepLinearChain(t *testing.T) {
+	pool := pgPool(t)
+	ctx := context.Background()
+
+	signer, err := services.GenerateBlockSigner()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := repositories.NewSigningKeyPostgres(pool).Activate(ctx, signer.PublicKey()); err != nil {
+		t.Fatal(err)
+	}
+
+	const voters = 40
+	users := pgUsers(t, pool, voters)
+
+	electionRepo := repositories.NewElectionPostgres(pool)
+	choiceRepo := repositories.NewChoicePostgres(pool)
+	blockRepo := repositories.NewBlockchainPostgres(pool)
+	voteRepo := repositories.NewVotePostgres(pool)
+	uow := repositories.NewPgUnitOfWork(pool)
+
+	e := &models.Election{Title: "Concurrent", CreatedBy: users[0], Status: models.ElectionOpen}
+	if err := services.NewElectionService(electionRepo, choiceRepo, uow, signer).Create(ctx, e, []string{"A", "B"}); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_, _ = pool.Exec(ctx, `DELETE FROM votes WHERE election_id = $1`, e.ID)
+		_, _ = pool.Exec(ctx, `DELETE FROM blockchain WHERE election_id = $1`, e.ID)
+		_, _ = pool.Exec(ctx, `DELETE FROM elections WHERE id = $1`, e.ID)
+	})
+
+	votes := services.NewVoteService(voteRepo, blockRepo, electionRepo, choiceRepo,
+		repositories.NewVoterRollPostgres(pool), repositories.NewBallotCodePostgres(pool), uow, signer)
+
+	var wg sync.WaitGroup
+	errs := make(chan error, voters)
+	for _, userID := range users {
+		wg.Add(1)
+		go func(userID int) {
+			defer wg.Done()
+			_, err := votes.CastVote(ctx, userID, e.ID, byText("A"))
+			errs <- err
+		}(userID)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	blocks, err := blockRepo.GetAllBlocks(ctx, e.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(blocks) != voters+1 {
+		t.Fatalf("expected %d blocks, got %d", voters+1, len(blocks))
+	}
+	for i, b := range blocks {
+		if b.Height != i || (i > 0 && b.PrevHash != blocks[i-1].Hash) {
+			t.Fatalf("chain forks at height %d", i)
+		}
+	}
+
+	chain := services.NewBlockchainService(blockRepo, voteRepo, electionRepo, choiceRepo,
+		repositories.NewSigningKeyPostgres(pool), uow, signer, nil)
+	res, err := chain.VerifyChain(ctx, e.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.Valid {
+		t.Fatalf("expected valid chain, got %+v", res)
+	}
+}
+
+// Время с другим смещением записывается в столбец без часового пояса в UTC,
+// и планировщик сравнивает его с текущим временем в UTC
+func TestPgElections_ListDueAcrossTimeZones(t *testing.T) {
+	pool := pgPool(t)
+	ctx := context.Background()
+	users := pgUsers(t, pool, 1)
+
+	electionRepo := repositories.NewElectionPostgres(pool)
+	service := services.NewElectionService(electionRepo, repositories.NewChoicePostgres(pool),
+		repositories.NewPgUnitOfWork(pool), nil)
+
+	zone := time.FixedZone("+05:00", 5*60*60)
+	opens := time.Now().Add(time.Minute).In(zone)
+	e := &models.Election{Title: "Zoned", CreatedBy: users[0], OpensAt: &opens}
+	if err := service.Create(ctx, e, []string{"A"}); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_, _ = pool.Exec(ctx, `DELETE FROM elections WHERE id = $1`, e.ID)
+	})
+	_, err := pool.Exec(ctx, `UPDATE elections SET status = 'scheduled' WHERE id = $1`, e.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := electionRepo.GetByID(ctx, e.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !got.OpensAt.Equal(opens) {
+		t.Fatalf("expected opens_at %v, got %v", opens, got.OpensAt)
+	}
+
+	isDue := func(now time.Time) bool {
+		due, err := electionRepo.ListDue(ctx, now.UTC())
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, d := range due {
+			if d.ID == e.ID {
+				return true
+			}
+		}
+		return false
+	}
+	if isDue(time.Now()) {
+		t.Fatal("election is due before opens_at")
+	}
+	if !isDue(opens.Add(time.Second)) {
+		t.Fatal("election is not due after opens_at")
+	}
+}
@@ -0,0 +1,171 @@
+package voting_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"voting-blockchain/internal/voting/models"
+	"voting-blockchain/internal/voting/services"
+)
+
+func TestScheduler_OpensScheduledElection(t *testing.T) {
+	f := newMockStore()
+	ctx := context.Background()
+
+	opens := time.Now().Add(-time.Second)
+	e := &models.Election{Title: "Auto", CreatedBy: 1, Status: models.ElectionScheduled, OpensAt: &opens}
+	if err := f.electionService().Create(ctx, e, []string{"A"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := f.scheduler().Tick(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	got, _ := f.elections.GetByID(ctx, e.ID)
+	if got.Status != models.ElectionOpen {
+		t.Fatalf("expected open election, got %s", got.Status)
+	}
+	if _, err := f.voteService().CastVote(ctx, 1, e.ID, byText("A")); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// opens_at и closes_at хранятся без часового пояса, поэтому время с другим
+// смещением приводится к UTC до записи
+func TestScheduler_ScheduleStoredInUTC(t *testing.T) {
+	f := newMockStore()
+	ctx := context.Background()
+
+	zone := time.FixedZone("+05:00", 5*60*60)
+	opens := time.Now().Add(time.Hour).In(zone)
+	closes := opens.Add(time.Hour)
+	e := &models.Election{Title: "Zoned", CreatedBy: 1, Status: models.ElectionScheduled, OpensAt: &opens, ClosesAt: &closes}
+	if err := f.electionService().Create(ctx, e, []string{"A"}); err != nil {
+		t.Fatal(err)
+	}
+	got, _ := f.elections.GetByID(ctx, e.ID)
+	if got.OpensAt.Location() != time.UTC || got.ClosesAt.Location() != time.UTC || !got.OpensAt.Equal(opens) {
+		t.Fatalf("expected the window in UTC, got %v – %v", got.OpensAt, got.ClosesAt)
+	}
+
+	later := closes.Add(time.Hour)
+	if err := f.electionService().Update(ctx, &models.Election{ID: e.ID, ClosesAt: &later}, services.UpdateFields{}, 1); err != nil {
+		t.Fatal(err)
+	}
+	got, _ = f.elections.GetByID(ctx, e.ID)
+	if got.ClosesAt.Location() != time.UTC || !got.ClosesAt.Equal(later) {
+		t.Fatalf("expected the updated close time in UTC, got %v", got.ClosesAt)
+	}
+}
+
+func TestScheduler_ClosesSealsAndTallies(t *testing.T) {
+	f := newMockStore()
+	ctx := context.Background()
+
+	closes := time.Now().Add(time.Hour)
+	e := &models.Election{Title: "Auto", CreatedBy: 1, Status: models.ElectionOpen, ClosesAt: &closes, BatchSize: 10}
+	if err := f.electionService().Create(ctx, e, []string{"A", "B"}); err != nil {
+		t.Fatal(err)
+	}
+	for i, choice := range []string{"A", "B", "A"} {
+		if _, err := f.voteService().CastVote(ctx, i+1, e.ID, byText(choice)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Время закрытия наступило
+	past := time.Now().Add(-time.Second)
+	f.elections.elections[e.ID].ClosesAt = &past
+
+	if err := f.scheduler().Tick(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	got, _ := f.elections.GetByID(ctx, e.ID)
+	if got.Status != models.ElectionTallied {
+		t.Fatalf("expected tallied election, got %s", got.Status)
+	}
+	if pending, _ := f.votes.GetPending(ctx, e.ID); len(pending) != 0 {
+		t.Fatalf("expected pending votes sealed on close, got %d", len(pending))
+	}
+
+	results, err := f.voteService().GetResults(ctx, e.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if votesFor(results, "A") != 2 || votesFor(results, "B") != 1 || !results.Final {
+		t.Fatalf("unexpected tally %+v", results)
+	}
+
+	history, _ := f.electionService().GetTransitions(ctx, e.ID)
+	last := history[len(history)-1]
+	if len(history) != 3 || last.ToStatus != models.ElectionTallied || last.ChangedBy != nil {
+		t.Fatalf("expected system transitions up to tallied, got %+v", history)
+	}
+
+	res, err := f.blockchainService().VerifyChain(ctx, e.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.Valid {
+		t.Fatalf("expected valid chain after close, got %+v", res)
+	}
+}
+
+func TestScheduler_ReplicasApplyTransitionOnce(t *testing.T) {
+	f := newMockStore()
+	ctx := context.Background()
+
+	opens := time.Now().Add(-time.Second)
+	e := &models.Election{Title: "Auto", CreatedBy: 1, Status: models.ElectionScheduled, OpensAt: &opens}
+	if err := f.electionService().Create(ctx, e, []string{"A"}); err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := f.scheduler().Tick(ctx); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	history, _ := f.electionService().GetTransitions(ctx, e.ID)
+	opened := 0
+	for _, tr := range history {
+		if tr.ToStatus == models.ElectionOpen {
+			opened++
+		}
+	}
+	if opened != 1 {
+		t.Fatalf("expected a single open transition, got %d", opened)
+	}
+}
+
+func TestScheduler_SealsExpiredBatchWindow(t *testing.T) {
+	f := newMockStore()
+	ctx := context.Background()
+
+	e := &models.Election{Title: "Window", CreatedBy: 1, Status: models.ElectionOpen, BatchSize: 10, BatchWindowSeconds: 60}
+	if err := f.electionService().Create(ctx, e, []string{"A"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.voteService().CastVote(ctx, 1, e.ID, byText("A")); err != nil {
+		t.Fatal(err)
+	}
+	f.votes.votes[0].CreatedAt = time.Now().Add(-2 * time.Minute)
+
+	if err := f.scheduler().Tick(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if pending, _ := f.votes.GetPending(ctx, e.ID); len(pending) != 0 {
+		t.Fatalf("expected expired batch to be sealed, got %d pending", len(pending))
+	}
+}
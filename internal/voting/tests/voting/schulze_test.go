@@ -0,0 +1,76 @@
+package voting_test
+
+import (
+	"context"
+	"testing"
+
+	"voting-blockchain/internal/voting/models"
+	"voting-blockchain/internal/voting/services"
+)
+
+func TestSchulze_PairwiseAndStrongestPaths(t *testing.T) {
+	f := newMockStore()
+	e := &models.Election{Title: "Board", CreatedBy: 1, Status: models.ElectionOpen, VotingMethod: models.VotingSchulze}
+	if err := f.electionService().Create(context.Background(), e, []string{"A", "B", "C", "D", "E"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Классический пример метода Шульце: 45 избирателей, побеждает E
+	const a, b, c, d, x = 1, 2, 3, 4, 5
+	userID := 0
+	for _, g := range []struct {
+		count   int
+		ranking []int
+	}{
+		{5, []int{a, c, b, x, d}}, {5, []int{a, d, x, c, b}}, {8, []int{b, x, d, a, c}}, {3, []int{c, a, b, x, d}},
+		{7, []int{c, a, x, b, d}}, {2, []int{c, b, a, d, x}}, {7, []int{d, c, x, b, a}}, {8, []int{x, b, a, d, c}},
+	} {
+		for i := 0; i < g.count; i++ {
+			userID++
+			if _, err := f.voteService().CastVote(context.Background(), userID, e.ID, &models.Ballot{Ranking: g.ranking}); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	results, err := f.voteService().GetResults(context.Background(), e.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if results.TotalVotes != 45 || results.Condorcet == nil {
+		t.Fatalf("unexpected results %+v", results)
+	}
+	if p := results.Condorcet.Pairwise; p[0][1] != 20 || p[1][0] != 25 || p[1][3] != 33 || p[4][3] != 31 {
+		t.Fatalf("unexpected pairwise matrix %v", p)
+	}
+	want := [][]int{
+		{0, 28, 28, 30, 24},
+		{25, 0, 28, 33, 24},
+		{25, 29, 0, 29, 24},
+		{25, 28, 28, 0, 24},
+		{25, 28, 28, 31, 0},
+	}
+	for i := range want {
+		for j := range want[i] {
+			if results.Condorcet.StrongestPaths[i][j] != want[i][j] {
+				t.Fatalf("unexpected strongest paths %v", results.Condorcet.StrongestPaths)
+			}
+		}
+	}
+	if len(results.Winners) != 1 || results.Winners[0] != x {
+		t.Fatalf("expected E to win, got %v", results.Winners)
+	}
+}
+
+func TestSchulze_UnrankedChoicesRankLast(t *testing.T) {
+	choices := []*models.Choice{{ID: 1, Text: "A"}, {ID: 2, Text: "B"}, {ID: 3, Text: "C"}}
+	results := services.TallySchulze(1, choices, rankedVotes([]int{2}, []int{2, 1}, []int{1, 3}))
+	p := results.Condorcet.Pairwise
+	// B выше A в двух бюллетенях, A выше B в одном; C ниже всех, кроме третьего
+	if p[1][0] != 2 || p[0][1] != 1 || p[0][2] != 2 || p[2][0] != 0 || p[1][2] != 2 || p[2][1] != 1 {
+		t.Fatalf("unexpected pairwise matrix %v", p)
+	}
+	if len(results.Winners) != 1 || results.Winners[0] != 2 {
+		t.Fatalf("expected Condorcet winner B, got %v", results.Winners)
+	}
+}
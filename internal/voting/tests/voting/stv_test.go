@@ -0,0 +1,112 @@
+package voting_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"errors"
+	"testing"
+
+	"voting-blockchain/internal/voting/models"
+	"voting-blockchain/internal/voting/services"
+)
+
+var stvChoices = []*models.Choice{{ID: 1, Text: "A"}, {ID: 2, Text: "B"}, {ID: 3, Text: "C"}, {ID: 4, Text: "D"}}
+
+func TestSTV_TransfersSurplusWithReducedWeight(t *testing.T) {
+	var rankings [][]int
+	rankings = append(rankings, repeat(10, 1, 2)...)
+	rankings = append(rankings, repeat(4, 2)...)
+	rankings = append(rankings, repeat(3, 3, 4)...)
+	rankings = append(rankings, repeat(3, 4, 3)...)
+
+	results := services.TallySTV(1, 2, stvChoices, rankedVotes(rankings...))
+	if results.Quota != 7 || results.TotalVotes != 20 {
+		t.Fatalf("expected Droop quota 7 of 20 votes, got %+v", results)
+	}
+	if len(results.CountSheet) != 2 {
+		t.Fatalf("expected 2 rounds, got %+v", results.CountSheet)
+	}
+	first := results.CountSheet[0]
+	if len(first.Elected) != 1 || first.Elected[0] != 1 || first.Transferred != 1 {
+		t.Fatalf("expected A elected and its surplus transferred, got %+v", first)
+	}
+	// Излишек 3 из 10 голосов: каждый бюллетень A передаёт B 0.3 голоса
+	second := results.CountSheet[1]
+	if second.Tallies[0].Votes != 7 || second.Tallies[1].Votes != 7 || second.Tallies[0].Status != models.CountElected {
+		t.Fatalf("unexpected second round %+v", second.Tallies)
+	}
+	if len(results.Winners) != 2 || results.Winners[0] != 1 || results.Winners[1] != 2 {
+		t.Fatalf("expected A and B elected, got %v", results.Winners)
+	}
+}
+
+func TestSTV_ExcludesLowestUntilSeatsFilled(t *testing.T) {
+	var rankings [][]int
+	rankings = append(rankings, repeat(5, 1, 2)...)
+	rankings = append(rankings, repeat(4, 3)...)
+	rankings = append(rankings, repeat(3, 4, 3)...)
+	rankings = append(rankings, []int{2})
+
+	results := services.TallySTV(1, 2, stvChoices, rankedVotes(rankings...))
+	sheet := results.CountSheet
+	if len(sheet) != 4 {
+		t.Fatalf("expected 4 rounds, got %+v", sheet)
+	}
+	if len(sheet[1].Excluded) != 1 || sheet[1].Excluded[0] != 2 {
+		t.Fatalf("expected B excluded in round 2, got %+v", sheet[1])
+	}
+	if len(sheet[2].Excluded) != 1 || sheet[2].Excluded[0] != 4 || sheet[2].Exhausted != 1 {
+		t.Fatalf("expected D excluded with one exhausted ballot in round 3, got %+v", sheet[2])
+	}
+	if sheet[3].Tallies[2].Votes != 7 {
+		t.Fatalf("expected C to receive D's ballots, got %+v", sheet[3].Tallies)
+	}
+	if len(results.Winners) != 2 || results.Winners[0] != 1 || results.Winners[1] != 3 {
+		t.Fatalf("expected A and C elected, got %v", results.Winners)
+	}
+}
+
+func TestSTV_CountSheetCSV(t *testing.T) {
+	f := newMockStore()
+	ctx := context.Background()
+	e := &models.Election{Title: "Committee", CreatedBy: 1, Status: models.ElectionOpen, VotingMethod: models.VotingSTV, Seats: 2}
+	if err := f.electionService().Create(ctx, e, []string{"A", "B", "C", "D"}); err != nil {
+		t.Fatal(err)
+	}
+	for i, ranking := range [][]int{{1, 2}, {1, 2}, {1, 3}, {2}, {3, 4}} {
+		if _, err := f.voteService().CastVote(ctx, i+1, e.ID, &models.Ballot{Ranking: ranking}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	results, err := f.voteService().GetResults(ctx, e.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := services.WriteCountSheetCSV(&buf, results); err != nil {
+		t.Fatal(err)
+	}
+	rows, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	header := []string{"round", "A", "B", "C", "D", "exhausted", "elected", "transferred", "excluded"}
+	if len(rows) != len(results.CountSheet)+1 || len(rows[0]) != len(header) {
+		t.Fatalf("unexpected count sheet %v", rows)
+	}
+	for i, h := range header {
+		if rows[0][i] != h {
+			t.Fatalf("unexpected header %v", rows[0])
+		}
+	}
+	if rows[1][1] != "3.00000" || rows[1][6] != "A" || rows[1][7] != "A" {
+		t.Fatalf("unexpected first round %v", rows[1])
+	}
+
+	err = f.electionService().Create(ctx, &models.Election{Title: "X", VotingMethod: models.VotingSTV, Seats: -1}, nil)
+	if !errors.Is(err, services.ErrInvalidVotingMethod) {
+		t.Fatalf("expected ErrInvalidVotingMethod for negative seats, got %v", err)
+	}
+}
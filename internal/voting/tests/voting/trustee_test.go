@@ -0,0 +1,265 @@
+package voting_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"voting-blockchain/internal/voting/models"
+	"voting-blockchain/internal/voting/services"
+)
+
+// newThresholdElection — черновик с пороговым шифрованием, вариантами A, B и
+// C и зарегистрированными пользователями 11, 12 и 13 для доверенных лиц
+func newThresholdElection(t *testing.T, f *mockStore, threshold int) (*models.Election, map[string]int) {
+	t.Helper()
+	for _, id := range []int{11, 12, 13} {
+		f.rolls.users[id] = ""
+	}
+	e := &models.Election{
+		Title:            "Threshold",
+		CreatedBy:        1,
+		Encryption:       models.EncryptionThreshold,
+		TrusteeThreshold: threshold,
+	}
+	if err := f.electionService().Create(context.Background(), e, []string{"A", "B", "C"}); err != nil {
+		t.Fatal(err)
+	}
+	choices, err := f.choices.GetChoices(context.Background(), e.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ids := make(map[string]int, len(choices))
+	for _, c := range choices {
+		ids[c.Text] = c.ID
+	}
+	return e, ids
+}
+
+func TestTrusteeCeremony_AnyThresholdDecrypts(t *testing.T) {
+	f := newMockStore()
+	ctx := context.Background()
+	e, ids := newThresholdElection(t, f, 2)
+
+	if err := setStatus(ctx, f, e, models.ElectionOpen); !errors.Is(err, services.ErrCeremonyPending) {
+		t.Fatalf("expected ErrCeremonyPending before the ceremony, got %v", err)
+	}
+	if _, err := f.electionService().EncryptionKey(ctx, e.ID); !errors.Is(err, services.ErrCeremonyPending) {
+		t.Fatalf("expected ErrCeremonyPending, got %v", err)
+	}
+
+	clients, err := services.SimulateKeyCeremony(ctx, f.trusteeService(), e.ID, []int{11, 12, 13})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ceremony, err := f.trusteeService().Ceremony(ctx, e.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ceremony.Stage != models.CeremonyDone || ceremony.PublicKey == "" || len(ceremony.VerificationKeys) != 3 {
+		t.Fatalf("unexpected ceremony %+v", ceremony)
+	}
+	if _, ok := f.secrets.secrets[e.ID]; ok {
+		t.Fatal("the server must not hold a private key for a threshold election")
+	}
+
+	if err := setStatus(ctx, f, e, models.ElectionOpen); err != nil {
+		t.Fatal(err)
+	}
+	for i, choice := range []string{"A", "A", "B"} {
+		ballot := encryptBallot(t, f, e.ID, ids, map[string]int{choice: 1})
+		if _, err := f.voteService().CastVote(ctx, i+1, e.ID, ballot); err != nil {
+			t.Fatalf("vote %d: %v", i+1, err)
+		}
+	}
+	if err := setStatus(ctx, f, e, models.ElectionClosed); err != nil {
+		t.Fatal(err)
+	}
+	results, err := f.voteService().GetResults(ctx, e.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Одной частичной расшифровки меньше порога
+	if err := services.SimulateDecryption(ctx, f.trusteeService(), results.Encrypted, clients[:1]); err != nil {
+		t.Fatal(err)
+	}
+	if err := setStatus(ctx, f, e, models.ElectionTallied); !errors.Is(err, services.ErrDecryptionPending) {
+		t.Fatalf("expected ErrDecryptionPending, got %v", err)
+	}
+
+	// Порог набирают лица 1 и 3, без второго
+	if err := services.SimulateDecryption(ctx, f.trusteeService(), results.Encrypted, clients[2:]); err != nil {
+		t.Fatal(err)
+	}
+	if err := setStatus(ctx, f, e, models.ElectionTallied); err != nil {
+		t.Fatal(err)
+	}
+	results, err = f.voteService().GetResults(ctx, e.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !results.Final || votesFor(results, "A") != 2 || votesFor(results, "B") != 1 || votesFor(results, "C") != 0 {
+		t.Fatalf("unexpected results %+v", results.Choices)
+	}
+
+	key, err := f.electionService().EncryptionKey(ctx, e.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if key.Threshold != 2 || len(key.Trustees) != 3 {
+		t.Fatalf("unexpected key %+v", key)
+	}
+	if !services.VerifyEncryptedTally(key, results) {
+		t.Fatal("partial decryptions must verify")
+	}
+	results.Choices[0].Votes++
+	if services.VerifyEncryptedTally(key, results) {
+		t.Fatal("a changed count must not verify")
+	}
+}
+
+func TestTrusteeCeremony_RejectsInvalidInput(t *testing.T) {
+	f := newMockStore()
+	ctx := context.Background()
+	svc := f.trusteeService()
+
+	if err := f.electionService().Create(ctx, &models.Election{Title: "X", Encryption: models.EncryptionThreshold}, []string{"A"}); !errors.Is(err, services.ErrInvalidEncryption) {
+		t.Fatalf("expected ErrInvalidEncryption without a threshold, got %v", err)
+	}
+
+	e, ids := newThresholdElection(t, f, 2)
+	if _, err := svc.Register(ctx, e.ID, 99); !errors.Is(err, services.ErrInvalidTrusteeData) {
+		t.Fatalf("expected ErrInvalidTrusteeData for an unknown user, got %v", err)
+	}
+	if _, err := svc.Register(ctx, e.ID, 11); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := svc.Register(ctx, e.ID, 11); !errors.Is(err, services.ErrTrusteeExists) {
+		t.Fatalf("expected ErrTrusteeExists, got %v", err)
+	}
+	if _, err := svc.PublishKey(ctx, e.ID, 12, "02"); !errors.Is(err, services.ErrNotTrustee) {
+		t.Fatalf("expected ErrNotTrustee, got %v", err)
+	}
+	if err := svc.Deal(ctx, e.ID, 11, &models.TrusteeDeal{}); !errors.Is(err, services.ErrCeremonyStage) {
+		t.Fatalf("expected ErrCeremonyStage before keys are published, got %v", err)
+	}
+
+	e, ids = newThresholdElection(t, f, 2)
+	clients, err := services.SimulateKeyCeremony(ctx, svc, e.ID, []int{11, 12})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := svc.Register(ctx, e.ID, 13); !errors.Is(err, services.ErrDefinitionLocked) {
+		t.Fatalf("expected ErrDefinitionLocked after the ceremony, got %v", err)
+	}
+
+	// Частичная расшифровка принимается только после закрытия и только верная
+	if err := setStatus(ctx, f, e, models.ElectionOpen); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.voteService().CastVote(ctx, 1, e.ID, encryptBallot(t, f, e.ID, ids, map[string]int{"A": 1})); err != nil {
+		t.Fatal(err)
+	}
+	results, err := f.voteService().GetResults(ctx, e.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	partials, err := clients[0].Decrypt(results.Encrypted)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := svc.SubmitDecryption(ctx, e.ID, clients[0].UserID, partials); !errors.Is(err, services.ErrElectionNotClosed) {
+		t.Fatalf("expected ErrElectionNotClosed, got %v", err)
+	}
+	if err := setStatus(ctx, f, e, models.ElectionClosed); err != nil {
+		t.Fatal(err)
+	}
+	forged := append([]models.PartialDecryption(nil), partials...)
+	forged[0].D = partials[1].D
+	if err := svc.SubmitDecryption(ctx, e.ID, clients[0].UserID, forged); !errors.Is(err, services.ErrInvalidTrusteeData) {
+		t.Fatalf("expected ErrInvalidTrusteeData for a forged partial, got %v", err)
+	}
+	if err := svc.SubmitDecryption(ctx, e.ID, clients[1].UserID, partials); !errors.Is(err, services.ErrInvalidTrusteeData) {
+		t.Fatalf("expected ErrInvalidTrusteeData for another trustee's partial, got %v", err)
+	}
+	if err := svc.SubmitDecryption(ctx, e.ID, clients[0].UserID, partials); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestTrusteeCeremony_RejectedSharesRestartDeal(t *testing.T) {
+	f := newMockStore()
+	ctx := context.Background()
+	svc := f.trusteeService()
+	e, _ := newThresholdElection(t, f, 2)
+
+	var clients []*services.TrusteeClient
+	for _, userID := range []int{11, 12} {
+		trustee, err := svc.Register(ctx, e.ID, userID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		c, err := services.NewTrusteeClient(trustee)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := svc.PublishKey(ctx, e.ID, userID, c.PublicKey()); err != nil {
+			t.Fatal(err)
+		}
+		clients = append(clients, c)
+	}
+	ceremony, err := svc.Ceremony(ctx, e.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range clients {
+		deal, err := c.Deal(ceremony.Threshold, ceremony.Trustees)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := svc.Deal(ctx, e.ID, c.UserID, deal); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Жалоба на доли отбрасывает все обязательства
+	ceremony, err = svc.Verify(ctx, e.ID, 12, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ceremony.Stage != models.CeremonyDeal || len(f.trustees.shares[e.ID]) != 0 {
+		t.Fatalf("expected the deal stage to restart, got %+v", ceremony)
+	}
+	for _, trustee := range ceremony.Trustees {
+		if len(trustee.Commitments) > 0 || trustee.Verified {
+			t.Fatalf("commitments must be cleared, got %+v", trustee)
+		}
+	}
+
+	// Ключи для получения долей сохраняются, церемония завершается заново
+	for _, c := range clients {
+		deal, err := c.Deal(ceremony.Threshold, ceremony.Trustees)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := svc.Deal(ctx, e.ID, c.UserID, deal); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if ceremony, err = svc.Ceremony(ctx, e.ID); err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range clients {
+		shares, err := svc.Shares(ctx, e.ID, c.UserID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ceremony, err = svc.Verify(ctx, e.ID, c.UserID, c.AcceptShares(ceremony.Trustees, shares)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if ceremony.Stage != models.CeremonyDone || ceremony.PublicKey == "" {
+		t.Fatalf("unexpected ceremony %+v", ceremony)
+	}
+}
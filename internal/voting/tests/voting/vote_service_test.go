@@ -0,0 +1,299 @@
+package voting_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"voting-blockchain/internal/voting/models"
+	"voting-blockchain/internal/voting/services"
+)
+
+// mockStore выполняет DoInElection под одним мьютексом, поэтому тест проверяет
+// только логику сервиса при поочерёдных транзакциях; блокировку Postgres
+// проверяет TestPgUnitOfWork_ConcurrentVotersKeepLinearChain
+func TestCastVote_ConcurrentVotersKeepLinearChain(t *testing.T) {
+	store, electionID := buildChain(t, 0)
+	svc := store.voteService()
+
+	const voters = 300
+	var wg sync.WaitGroup
+	errs := make(chan error, voters)
+	for i := 1; i <= voters; i++ {
+		wg.Add(1)
+		go func(userID int) {
+			defer wg.Done()
+			_, err := svc.CastVote(context.Background(), userID, electionID, byText("A"))
+			errs <- err
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	blocks, _ := store.blocks.GetAllBlocks(context.Background(), electionID)
+	if len(blocks) != voters+1 {
+		t.Fatalf("expected %d blocks, got %d", voters+1, len(blocks))
+	}
+	for i, b := range blocks {
+		if b.Height != i {
+			t.Fatalf("block %d has height %d", i, b.Height)
+		}
+		if i > 0 && b.PrevHash != blocks[i-1].Hash {
+			t.Fatalf("chain forks at height %d", i)
+		}
+	}
+
+	res, err := store.blockchainService().VerifyChain(context.Background(), electionID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.Valid {
+		t.Fatalf("expected valid chain, got %+v", res)
+	}
+}
+
+func TestCastVote_ConcurrentDuplicatesAcceptOnce(t *testing.T) {
+	store, electionID := buildChain(t, 0)
+	svc := store.voteService()
+
+	const attempts = 50
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	accepted := 0
+	for i := 0; i < attempts; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := svc.CastVote(context.Background(), 42, electionID, byText("A")); err == nil {
+				mu.Lock()
+				accepted++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if accepted != 1 {
+		t.Fatalf("expected exactly one accepted vote, got %d", accepted)
+	}
+	if len(store.votes.votes) != 1 || len(store.blocks.blocks) != 2 {
+		t.Fatalf("expected 1 vote and 2 blocks, got %d and %d", len(store.votes.votes), len(store.blocks.blocks))
+	}
+}
+
+func TestCastVote_FailedBlockRollsBackVote(t *testing.T) {
+	store, electionID := buildChain(t, 0)
+	store.blocks.failAdd = errors.New("insert failed")
+
+	if _, err := store.voteService().CastVote(context.Background(), 1, electionID, byText("A")); err == nil {
+		t.Fatal("expected error when block cannot be written")
+	}
+	if len(store.votes.votes) != 0 {
+		t.Fatalf("vote must be rolled back together with the block, got %d votes", len(store.votes.votes))
+	}
+}
+
+func TestCastVote_BatchesVotesIntoMerkleBlocks(t *testing.T) {
+	store := newMockStore()
+	e := &models.Election{Title: "Batched", CreatedBy: 1, Status: models.ElectionOpen, BatchSize: 3}
+	if err := store.electionService().Create(context.Background(), e, []string{"A", "B"}); err != nil {
+		t.Fatal(err)
+	}
+
+	for userID := 1; userID <= 7; userID++ {
+		if _, err := store.voteService().CastVote(context.Background(), userID, e.ID, byText("A")); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if len(store.blocks.blocks) != 3 {
+		t.Fatalf("expected genesis and 2 sealed blocks, got %d blocks", len(store.blocks.blocks))
+	}
+	pending, _ := store.votes.GetPending(context.Background(), e.ID)
+	if len(pending) != 1 {
+		t.Fatalf("expected 1 pending vote, got %d", len(pending))
+	}
+
+	block, err := store.blockchainService().SealPending(context.Background(), e.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if block == nil || block.VoteCount != 1 || block.Height != 3 {
+		t.Fatalf("unexpected sealed block: %+v", block)
+	}
+
+	res, err := store.blockchainService().VerifyChain(context.Background(), e.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.Valid {
+		t.Fatalf("expected valid chain, got %+v", res)
+	}
+
+	results, err := store.voteService().GetResults(context.Background(), e.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if votesFor(results, "A") != 7 {
+		t.Errorf("expected 7 votes for A, got %d", votesFor(results, "A"))
+	}
+}
+
+func TestVerifyChain_DetectsVoteRemovedFromBatch(t *testing.T) {
+	store := newMockStore()
+	e := &models.Election{Title: "Batched", CreatedBy: 1, Status: models.ElectionOpen, BatchSize: 4}
+	if err := store.electionService().Create(context.Background(), e, []string{"A"}); err != nil {
+		t.Fatal(err)
+	}
+	for userID := 1; userID <= 4; userID++ {
+		if _, err := store.voteService().CastVote(context.Background(), userID, e.ID, byText("A")); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	store.votes.votes[2].BlockID = nil
+
+	res, err := store.blockchainService().VerifyChain(context.Background(), e.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Status != models.ChainMissingVote || res.BrokenIndex == nil || *res.BrokenIndex != 1 {
+		t.Fatalf("expected missing vote at 1, got %+v", res)
+	}
+}
+
+func TestReceipts_ProveInclusionUpToChainHead(t *testing.T) {
+	store := newMockStore()
+	e := &models.Election{Title: "Receipts", CreatedBy: 1, Status: models.ElectionOpen, BatchSize: 3}
+	if err := store.electionService().Create(context.Background(), e, []string{"A", "B"}); err != nil {
+		t.Fatal(err)
+	}
+	svc := store.voteService()
+
+	var receipts []*models.VoteReceipt
+	for userID := 1; userID <= 5; userID++ {
+		receipt, err := svc.CastVote(context.Background(), userID, e.ID, byText("B"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		receipts = append(receipts, receipt)
+	}
+
+	if receipts[0].Status != models.ReceiptPending {
+		t.Errorf("first vote of a batch must be pending, got %s", receipts[0].Status)
+	}
+	if receipts[2].Status != models.ReceiptSealed || !receipts[2].Verify() {
+		t.Fatalf("vote that completed the batch must get a verifiable receipt: %+v", receipts[2])
+	}
+
+	if _, err := store.blockchainService().SealPending(context.Background(), e.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, r := range receipts {
+		proof, err := svc.GetInclusionProof(context.Background(), e.ID, r.VoteHash)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !proof.Verify() {
+			t.Fatalf("proof for %s does not verify: %+v", r.VoteHash, proof)
+		}
+		if proof.HeadHash != store.blocks.blocks[len(store.blocks.blocks)-1].Hash {
+			t.Error("proof must end at the current chain head")
+		}
+	}
+
+	forged, _ := svc.GetInclusionProof(context.Background(), e.ID, receipts[0].VoteHash)
+	forged.MerkleProof[0].Hash = "00"
+	if forged.Verify() {
+		t.Error("tampered proof must not verify")
+	}
+
+	if _, err := svc.GetInclusionProof(context.Background(), e.ID, "unknown"); err != services.ErrVoteNotFound {
+		t.Errorf("expected ErrVoteNotFound, got %v", err)
+	}
+}
+
+func TestCastVote_SaltedCommitments(t *testing.T) {
+	store, electionID := buildChain(t, 0)
+	svc := store.voteService()
+
+	r1, err := svc.CastVote(context.Background(), 1, electionID, byText("A"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	r2, err := svc.CastVote(context.Background(), 2, electionID, byText("A"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if r1.VoteHash == r2.VoteHash {
+		t.Fatal("equal choices must not produce equal vote hashes")
+	}
+	legacy := &models.Vote{UserID: 1, ElectionID: electionID, Choice: "A", HashVersion: models.VoteHashLegacy}
+	if r1.VoteHash == legacy.CalculateHash() {
+		t.Fatal("vote hash must not be guessable from user, election and choice")
+	}
+
+	ballot := &models.Ballot{ChoiceID: 1}
+	own := &models.Vote{ElectionID: electionID, Ballot: ballot.Canonical(), Salt: r1.Salt, HashVersion: models.VoteHashBallot}
+	if own.CalculateHash() != r1.VoteHash {
+		t.Error("voter must be able to recompute the commitment from the receipt salt")
+	}
+
+	store.votes.votes[0].ChoiceID = 2
+	res, err := store.blockchainService().VerifyChain(context.Background(), electionID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Status != models.ChainBroken || res.BrokenIndex == nil || *res.BrokenIndex != 1 {
+		t.Fatalf("expected changed choice to break block 1, got %+v", res)
+	}
+}
+
+func TestCastVote_ValidatesChoiceAgainstElection(t *testing.T) {
+	store := newMockStore()
+	e := &models.Election{Title: "Choices", CreatedBy: 1, Status: models.ElectionOpen, BatchSize: 1}
+	if err := store.electionService().Create(context.Background(), e, []string{"A", "B", "C"}); err != nil {
+		t.Fatal(err)
+	}
+	svc := store.voteService()
+
+	if _, err := svc.CastVote(context.Background(), 1, e.ID, &models.Ballot{}); !errors.Is(err, services.ErrChoiceRequired) {
+		t.Fatalf("expected ErrChoiceRequired, got %v", err)
+	}
+	if _, err := svc.CastVote(context.Background(), 1, e.ID, &models.Ballot{ChoiceID: 99}); !errors.Is(err, services.ErrInvalidChoice) {
+		t.Fatalf("expected ErrInvalidChoice for unknown id, got %v", err)
+	}
+	if _, err := svc.CastVote(context.Background(), 1, e.ID, byText("D")); !errors.Is(err, services.ErrInvalidChoice) {
+		t.Fatalf("expected ErrInvalidChoice for unknown text, got %v", err)
+	}
+	if len(store.votes.votes) != 0 {
+		t.Fatalf("rejected ballots must not be stored, got %d votes", len(store.votes.votes))
+	}
+
+	if _, err := svc.CastVote(context.Background(), 1, e.ID, &models.Ballot{ChoiceID: 2}); err != nil {
+		t.Fatal(err)
+	}
+	if v := store.votes.votes[0]; v.ChoiceID != 2 || v.Choice != "B" {
+		t.Fatalf("expected vote for choice 2 (B), got %+v", v)
+	}
+
+	results, err := svc.GetResults(context.Background(), e.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results.Choices) != 3 || votesFor(results, "A") != 0 || votesFor(results, "B") != 1 || votesFor(results, "C") != 0 {
+		t.Fatalf("expected every choice in results, got %+v", results)
+	}
+	if results.TotalVotes != 1 || results.Final {
+		t.Fatalf("unexpected totals %+v", results)
+	}
+}
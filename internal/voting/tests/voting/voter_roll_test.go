@@ -0,0 +1,160 @@
+package voting_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"voting-blockchain/internal/voting/models"
+	"voting-blockchain/internal/voting/services"
+)
+
+// newRollElection — открытое голосование по списку избирателей; в системе
+// зарегистрированы пользователи 1–4
+func newRollElection(t *testing.T, f *mockStore) *models.Election {
+	t.Helper()
+	for id, email := range map[int]string{1: "ann@example.com", 2: "Bob@Example.com", 3: "carl@example.com", 4: "dina@example.com"} {
+		f.rolls.users[id] = email
+	}
+	e := &models.Election{Title: "Roll", CreatedBy: 1, Status: models.ElectionOpen, Eligibility: models.EligibilityRoll}
+	if err := f.electionService().Create(context.Background(), e, []string{"A", "B"}); err != nil {
+		t.Fatal(err)
+	}
+	return e
+}
+
+func TestVoterRoll_RestrictsVotingAndReportsTurnout(t *testing.T) {
+	f := newMockStore()
+	ctx := context.Background()
+	e := newRollElection(t, f)
+
+	entries := []models.RollEntry{{UserID: 1}, {Email: " BOB@example.com "}, {Email: "ann@example.com"}, {Email: "eve@example.com"}, {UserID: 1}}
+	summary, err := f.voterRollService().Upload(ctx, e.ID, entries, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Повтор user_id 1 отброшен; ann@example.com — тот же избиратель, что и id 1
+	if summary.Added != 4 || summary.Size != 3 {
+		t.Fatalf("unexpected summary %+v", summary)
+	}
+
+	for _, userID := range []int{1, 2} {
+		if _, err := f.voteService().CastVote(ctx, userID, e.ID, byText("A")); err != nil {
+			t.Fatalf("user %d: %v", userID, err)
+		}
+	}
+	if _, err := f.voteService().CastVote(ctx, 3, e.ID, byText("A")); !errors.Is(err, services.ErrNotEligible) {
+		t.Fatalf("expected ErrNotEligible for a user outside the roll, got %v", err)
+	}
+
+	results, err := f.voteService().GetResults(ctx, e.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if results.Turnout == nil || results.Turnout.RollSize != 3 || results.Turnout.Voted != 2 || results.Turnout.Percent != 66.67 {
+		t.Fatalf("unexpected turnout %+v", results.Turnout)
+	}
+
+	// Замена списка: пользователь 3 допускается, голоса уже поданных остаются
+	if _, err := f.voterRollService().Upload(ctx, e.ID, []models.RollEntry{{UserID: 3}}, true); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.voteService().CastVote(ctx, 3, e.ID, byText("B")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.voteService().CastVote(ctx, 4, e.ID, byText("B")); !errors.Is(err, services.ErrNotEligible) {
+		t.Fatalf("expected ErrNotEligible after replace, got %v", err)
+	}
+
+	if err := setStatus(ctx, f, e, models.ElectionClosed); err != nil {
+		t.Fatal(err)
+	}
+	if err := setStatus(ctx, f, e, models.ElectionTallied); err != nil {
+		t.Fatal(err)
+	}
+	results, err = f.voteService().GetResults(ctx, e.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !results.Final || results.Turnout == nil || results.Turnout.RollSize != 1 || results.Turnout.Voted != 3 {
+		t.Fatalf("expected stored turnout, got %+v", results.Turnout)
+	}
+	if _, err := f.voterRollService().Upload(ctx, e.ID, []models.RollEntry{{UserID: 4}}, false); !errors.Is(err, services.ErrRollLocked) {
+		t.Fatalf("expected ErrRollLocked after closing, got %v", err)
+	}
+}
+
+func TestVoterRoll_OpenElectionIgnoresRoll(t *testing.T) {
+	f := newMockStore()
+	ctx := context.Background()
+	e := newMethodElection(t, f, models.VotingPlurality, 0)
+	if e.Eligibility != models.EligibilityAll {
+		t.Fatalf("expected open-to-all by default, got %q", e.Eligibility)
+	}
+
+	if _, err := f.voteService().CastVote(ctx, 7, e.ID, byText("A")); err != nil {
+		t.Fatal(err)
+	}
+	results, err := f.voteService().GetResults(ctx, e.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if results.Turnout != nil {
+		t.Fatalf("expected no turnout for an open-to-all election, got %+v", results.Turnout)
+	}
+
+	if err := f.electionService().Create(ctx, &models.Election{Title: "X", Eligibility: "invited"}, []string{"A"}); !errors.Is(err, services.ErrInvalidEligibility) {
+		t.Fatalf("expected ErrInvalidEligibility, got %v", err)
+	}
+	// Режим допуска входит в генезис-блок и после открытия не меняется
+	err = f.electionService().Update(ctx, &models.Election{ID: e.ID, Title: e.Title, Eligibility: models.EligibilityRoll}, services.UpdateFields{}, 1)
+	if !errors.Is(err, services.ErrDefinitionLocked) {
+		t.Fatalf("expected ErrDefinitionLocked, got %v", err)
+	}
+}
+
+func TestVoterRoll_RejectsInvalidEntries(t *testing.T) {
+	f := newMockStore()
+	ctx := context.Background()
+	e := newRollElection(t, f)
+
+	cases := map[string][]models.RollEntry{
+		"empty":        {{}},
+		"both":         {{UserID: 1, Email: "ann@example.com"}},
+		"bad email":    {{Email: "not an email"}},
+		"no domain":    {{Email: "ann@"}},
+		"unknown user": {{UserID: 1}, {UserID: 99}},
+	}
+	for name, entries := range cases {
+		if _, err := f.voterRollService().Upload(ctx, e.ID, entries, false); !errors.Is(err, services.ErrInvalidRollEntry) {
+			t.Errorf("%s: expected ErrInvalidRollEntry, got %v", name, err)
+		}
+	}
+	if entries, _ := f.voterRollService().List(ctx, e.ID); len(entries) != 0 {
+		t.Fatalf("rejected uploads must not change the roll, got %+v", entries)
+	}
+}
+
+func TestParseRollCSV(t *testing.T) {
+	withHeader := "name,email,user_id\nAnn,ann@example.com,\nBob,bob@example.com,2\n\n"
+	entries, err := services.ParseRollCSV(strings.NewReader(withHeader))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 || entries[0].Email != "ann@example.com" || entries[1].UserID != 2 || entries[1].Email != "" {
+		t.Fatalf("unexpected entries %+v", entries)
+	}
+
+	entries, err = services.ParseRollCSV(strings.NewReader("42\ncarl@example.com\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 || entries[0].UserID != 42 || entries[1].Email != "carl@example.com" {
+		t.Fatalf("unexpected entries %+v", entries)
+	}
+
+	if _, err := services.ParseRollCSV(strings.NewReader("user_id\nforty-two\n")); !errors.Is(err, services.ErrInvalidRollEntry) {
+		t.Fatalf("expected ErrInvalidRollEntry for a non-numeric user_id, got %v", err)
+	}
+}
@@ -0,0 +1,136 @@
+package voting_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"voting-blockchain/internal/voting/models"
+	"voting-blockchain/internal/voting/services"
+)
+
+func newMethodElection(t *testing.T, f *mockStore, method string, maxScore int) *models.Election {
+	t.Helper()
+	e := &models.Election{Title: "Methods", CreatedBy: 1, Status: models.ElectionOpen, VotingMethod: method, MaxScore: maxScore}
+	if err := f.electionService().Create(context.Background(), e, []string{"A", "B", "C"}); err != nil {
+		t.Fatal(err)
+	}
+	return e
+}
+
+func TestApproval_CountsEveryApprovedChoice(t *testing.T) {
+	f := newMockStore()
+	ctx := context.Background()
+	e := newMethodElection(t, f, models.VotingApproval, 0)
+
+	for i, approvals := range [][]int{{3, 1}, {1}, {2, 3}, {3}} {
+		if _, err := f.voteService().CastVote(ctx, i+1, e.ID, &models.Ballot{Approvals: approvals}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Одобрения хранятся в каноническом порядке по id
+	if ballot, _ := f.votes.votes[0].BallotContents(); ballot.Approvals[0] != 1 || ballot.Approvals[1] != 3 {
+		t.Fatalf("expected approvals sorted by id, got %v", ballot.Approvals)
+	}
+
+	results, err := f.voteService().GetResults(ctx, e.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if results.VotingMethod != models.VotingApproval || results.TotalVotes != 4 {
+		t.Fatalf("unexpected results %+v", results)
+	}
+	if votesFor(results, "A") != 2 || votesFor(results, "B") != 1 || votesFor(results, "C") != 3 {
+		t.Fatalf("unexpected approvals %+v", results.Choices)
+	}
+	if len(results.Winners) != 1 || results.Winners[0] != 3 {
+		t.Fatalf("expected C to win, got %v", results.Winners)
+	}
+
+	res, err := f.blockchainService().VerifyChain(ctx, e.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.Valid {
+		t.Fatalf("expected valid chain, got %+v", res)
+	}
+}
+
+func TestScore_SumsScoresAndReportsTies(t *testing.T) {
+	f := newMockStore()
+	ctx := context.Background()
+	e := newMethodElection(t, f, models.VotingScore, 0)
+	if e.MaxScore != models.DefaultMaxScore {
+		t.Fatalf("expected default max score, got %d", e.MaxScore)
+	}
+
+	ballots := [][]models.ChoiceScore{
+		{{ChoiceID: 1, Score: 5}, {ChoiceID: 2, Score: 3}},
+		{{ChoiceID: 2, Score: 4}, {ChoiceID: 1, Score: 2}, {ChoiceID: 3, Score: 0}},
+		{{ChoiceID: 3, Score: 1}},
+	}
+	for i, scores := range ballots {
+		if _, err := f.voteService().CastVote(ctx, i+1, e.ID, &models.Ballot{Scores: scores}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	results, err := f.voteService().GetResults(ctx, e.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[int]int{1: 7, 2: 7, 3: 1}
+	for _, c := range results.Choices {
+		if c.Score != want[c.ChoiceID] {
+			t.Errorf("choice %d: expected score %d, got %d", c.ChoiceID, want[c.ChoiceID], c.Score)
+		}
+	}
+	if len(results.Winners) != 2 || results.Winners[0] != 1 || results.Winners[1] != 2 {
+		t.Fatalf("expected A and B tied, got %v", results.Winners)
+	}
+}
+
+func TestVotingMethods_ValidateBallots(t *testing.T) {
+	ctx := context.Background()
+	cases := []struct {
+		method string
+		ballot *models.Ballot
+		want   error
+	}{
+		{models.VotingApproval, &models.Ballot{}, services.ErrChoiceRequired},
+		{models.VotingApproval, &models.Ballot{Approvals: []int{1, 9}}, services.ErrInvalidChoice},
+		{models.VotingApproval, &models.Ballot{Approvals: []int{2, 2}}, services.ErrInvalidBallot},
+		{models.VotingApproval, &models.Ballot{ChoiceID: 1}, services.ErrChoiceRequired},
+		{models.VotingApproval, &models.Ballot{Approvals: []int{1}, Ranking: []int{1}}, services.ErrInvalidBallot},
+		{models.VotingScore, &models.Ballot{Scores: []models.ChoiceScore{{ChoiceID: 1, Score: 6}}}, services.ErrInvalidBallot},
+		{models.VotingScore, &models.Ballot{Scores: []models.ChoiceScore{{ChoiceID: 1, Score: -1}}}, services.ErrInvalidBallot},
+		{models.VotingScore, &models.Ballot{Scores: []models.ChoiceScore{{ChoiceID: 1}, {ChoiceID: 1}}}, services.ErrInvalidBallot},
+		{models.VotingScore, &models.Ballot{Scores: []models.ChoiceScore{{ChoiceID: 7, Score: 1}}}, services.ErrInvalidChoice},
+		{models.VotingPlurality, &models.Ballot{ChoiceID: 1, Approvals: []int{1}}, services.ErrInvalidBallot},
+	}
+	for _, c := range cases {
+		f := newMockStore()
+		e := newMethodElection(t, f, c.method, 0)
+		if _, err := f.voteService().CastVote(ctx, 1, e.ID, c.ballot); !errors.Is(err, c.want) {
+			t.Errorf("%s ballot %+v: expected %v, got %v", c.method, c.ballot, c.want, err)
+		}
+	}
+}
+
+func TestVotingMethods_StrategyPerMethod(t *testing.T) {
+	for _, method := range []string{models.VotingPlurality, models.VotingInstantRunoff, models.VotingApproval, models.VotingScore} {
+		strategy, ok := services.TallyStrategyFor(method)
+		if !ok {
+			t.Fatalf("no tally strategy for %s", method)
+		}
+		q := &models.Contest{ElectionID: 1, VotingMethod: method, MaxScore: 5}
+		if got := strategy.Tally(q, nil, nil).VotingMethod; got != method {
+			t.Errorf("strategy for %s reported %s", method, got)
+		}
+	}
+
+	f := newMockStore()
+	if err := f.electionService().Create(context.Background(), &models.Election{Title: "X", VotingMethod: models.VotingScore, MaxScore: 1000}, nil); !errors.Is(err, services.ErrInvalidVotingMethod) {
+		t.Fatalf("expected ErrInvalidVotingMethod for max_score out of range, got %v", err)
+	}
+}
@@ -0,0 +1,188 @@
+package voting_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"voting-blockchain/internal/voting/models"
+	"voting-blockchain/internal/voting/services"
+)
+
+// newWriteInElection — открытое голосование plurality с вариантами A и B и
+// разрешёнными вписанными вариантами
+func newWriteInElection(t *testing.T, f *mockStore) *models.Election {
+	t.Helper()
+	e := &models.Election{Title: "Write-ins", CreatedBy: 1, Status: models.ElectionOpen, AllowWriteIns: true}
+	if err := f.electionService().Create(context.Background(), e, []string{"A", "B"}); err != nil {
+		t.Fatal(err)
+	}
+	return e
+}
+
+// setStatus — переводит голосование в состояние status от имени администратора
+func setStatus(ctx context.Context, f *mockStore, e *models.Election, status string) error {
+	return f.electionService().Update(ctx, &models.Election{ID: e.ID, Title: e.Title, Status: status}, services.UpdateFields{}, 1)
+}
+
+func TestWriteIns_NormalisedAndCounted(t *testing.T) {
+	f := newMockStore()
+	ctx := context.Background()
+	e := newWriteInElection(t, f)
+
+	ballots := []*models.Ballot{
+		{WriteIn: "  john \t smith "},
+		{WriteIn: "John Smith"},
+		{WriteIn: "a"}, // совпадает с вариантом A без учёта регистра
+		{WriteIn: "Jane"},
+		{ChoiceID: 2},
+	}
+	for i, b := range ballots {
+		if _, err := f.voteService().CastVote(ctx, i+1, e.ID, b); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if ballot, _ := f.votes.votes[0].BallotContents(); ballot.WriteIn != "john smith" {
+		t.Fatalf("expected normalised write-in, got %q", ballot.WriteIn)
+	}
+	if ballot, _ := f.votes.votes[2].BallotContents(); ballot.ChoiceID != 1 || ballot.WriteIn != "" {
+		t.Fatalf("expected write-in matching a choice to be stored as the choice, got %+v", ballot)
+	}
+
+	results, err := f.voteService().GetResults(ctx, e.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if results.TotalVotes != 5 || votesFor(results, "A") != 1 || votesFor(results, "B") != 1 {
+		t.Fatalf("unexpected results %+v", results)
+	}
+	if len(results.WriteIns) != 2 || results.WriteIns[0].Text != "john smith" || results.WriteIns[0].Votes != 2 {
+		t.Fatalf("unexpected write-ins %+v", results.WriteIns)
+	}
+
+	res, err := f.blockchainService().VerifyChain(ctx, e.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.Valid {
+		t.Fatalf("expected valid chain, got %+v", res)
+	}
+}
+
+func TestWriteIns_ReviewBeforeCertification(t *testing.T) {
+	f := newMockStore()
+	ctx := context.Background()
+	e := newWriteInElection(t, f)
+	for i, b := range []*models.Ballot{{WriteIn: "John Smith"}, {WriteIn: "john smith"}, {WriteIn: "Jane"}, {ChoiceID: 1}} {
+		if _, err := f.voteService().CastVote(ctx, i+1, e.ID, b); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Голоса за John Smith засчитываются варианту B ещё до закрытия
+	if err := f.writeInService().Review(ctx, &models.WriteInReview{ElectionID: e.ID, Text: "JOHN  SMITH", ChoiceID: 2}); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.writeInService().Review(ctx, &models.WriteInReview{ElectionID: e.ID, Text: "Nobody"}); !errors.Is(err, services.ErrWriteInNotFound) {
+		t.Fatalf("expected ErrWriteInNotFound, got %v", err)
+	}
+	if err := f.writeInService().Review(ctx, &models.WriteInReview{ElectionID: e.ID, Text: "Jane", ChoiceID: 99}); !errors.Is(err, services.ErrInvalidChoice) {
+		t.Fatalf("expected ErrInvalidChoice, got %v", err)
+	}
+
+	if err := setStatus(ctx, f, e, models.ElectionClosed); err != nil {
+		t.Fatal(err)
+	}
+	if err := setStatus(ctx, f, e, models.ElectionTallied); err != nil {
+		t.Fatal(err)
+	}
+	if err := setStatus(ctx, f, e, models.ElectionCertified); !errors.Is(err, services.ErrWriteInsPending) {
+		t.Fatalf("expected ErrWriteInsPending while Jane is not reviewed, got %v", err)
+	}
+
+	list, err := f.writeInService().List(ctx, e.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 2 || !list[0].Reviewed || list[0].ChoiceID != 2 || list[0].Votes != 2 || list[1].Reviewed {
+		t.Fatalf("unexpected write-in list %+v", list)
+	}
+
+	// Решение после подсчёта пересчитывает сохранённые итоги
+	if err := f.writeInService().Review(ctx, &models.WriteInReview{ElectionID: e.ID, Text: "jane"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := setStatus(ctx, f, e, models.ElectionCertified); err != nil {
+		t.Fatal(err)
+	}
+	results, err := f.voteService().GetResults(ctx, e.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !results.Final || votesFor(results, "B") != 2 || votesFor(results, "A") != 1 || results.TotalVotes != 4 {
+		t.Fatalf("unexpected certified results %+v", results)
+	}
+	if len(results.WriteIns) != 1 || !results.WriteIns[0].Reviewed || len(results.Winners) != 1 || results.Winners[0] != 2 {
+		t.Fatalf("expected B to win with Jane kept as a write-in, got %+v", results)
+	}
+	if err := f.writeInService().Review(ctx, &models.WriteInReview{ElectionID: e.ID, Text: "Jane", ChoiceID: 1}); !errors.Is(err, services.ErrWriteInsLocked) {
+		t.Fatalf("expected ErrWriteInsLocked after certification, got %v", err)
+	}
+
+	// Решения уходят в архив, и по нему итоги пересчитываются так же
+	a := roundTrip(t, archiveOf(t, f, e.ID))
+	if res := services.VerifyArchive(a, nil); !res.Valid {
+		t.Fatalf("expected valid archive, got %+v", res)
+	}
+	votes := make([]*models.Vote, len(a.Votes))
+	for i, v := range a.Votes {
+		votes[i] = v.Vote()
+	}
+	if tally := services.TallyElection(a.Election, a.Choices, votes); votesFor(tally, "B") != 2 {
+		t.Fatalf("expected archived reviews to be applied, got %+v", tally)
+	}
+}
+
+func TestWriteIns_Policy(t *testing.T) {
+	f := newMockStore()
+	ctx := context.Background()
+
+	plain := newMethodElection(t, f, models.VotingPlurality, 0)
+	if _, err := f.voteService().CastVote(ctx, 1, plain.ID, &models.Ballot{WriteIn: "Jane"}); !errors.Is(err, services.ErrWriteInsNotAllowed) {
+		t.Fatalf("expected ErrWriteInsNotAllowed, got %v", err)
+	}
+
+	ranked := &models.Election{Title: "X", VotingMethod: models.VotingInstantRunoff, AllowWriteIns: true}
+	if err := f.electionService().Create(ctx, ranked, []string{"A"}); !errors.Is(err, services.ErrInvalidVotingMethod) {
+		t.Fatalf("expected ErrInvalidVotingMethod for write-ins outside plurality, got %v", err)
+	}
+
+	e := newWriteInElection(t, f)
+	for name, b := range map[string]*models.Ballot{
+		"blank":     {WriteIn: " \t "},
+		"too long":  {WriteIn: strings.Repeat("x", models.MaxWriteInLength+1)},
+		"control":   {WriteIn: "Jane\x00"},
+		"with id":   {WriteIn: "Jane", ChoiceID: 1},
+		"with text": {WriteIn: "Jane", Choice: "A"},
+	} {
+		if _, err := f.voteService().CastVote(ctx, 1, e.ID, b); err == nil {
+			t.Errorf("%s: expected write-in to be rejected", name)
+		}
+	}
+
+	// Разрешение вписанных вариантов входит в определение голосования
+	choices, err := f.choices.GetChoices(ctx, e.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stored, err := f.elections.GetByID(ctx, e.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hash := stored.DefinitionHash(choices)
+	stored.AllowWriteIns = false
+	if stored.DefinitionHash(choices) == hash {
+		t.Fatal("definition hash must cover allow_write_ins")
+	}
+}
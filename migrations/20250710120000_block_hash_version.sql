@@ -0,0 +1,29 @@
+-- +goose Up
+-- Канонический формат хеширования блоков: высота блока в цепочке и версия формата хеша
+
+-- Колонка используется репозиторием голосов, но отсутствовала в исходной схеме
+ALTER TABLE votes ADD COLUMN IF NOT EXISTS vote_hash TEXT;
+
+ALTER TABLE blockchain ADD COLUMN height INT;
+ALTER TABLE blockchain ADD COLUMN hash_version INT NOT NULL DEFAULT 1;
+
+-- Существующие блоки получают высоту по порядку вставки и остаются версии 1
+UPDATE blockchain b
+SET height = ordered.rn - 1
+FROM (
+    SELECT id, row_number() OVER (PARTITION BY election_id ORDER BY id) AS rn
+    FROM blockchain
+) ordered
+WHERE b.id = ordered.id;
+
+ALTER TABLE blockchain ALTER COLUMN height SET NOT NULL;
+ALTER TABLE blockchain ALTER COLUMN hash_version DROP DEFAULT;
+
+CREATE UNIQUE INDEX IF NOT EXISTS blockchain_election_height_idx ON blockchain (election_id, height);
+
+-- +goose Down
+-- Удаляет высоту и версию хеша
+
+DROP INDEX IF EXISTS blockchain_election_height_idx;
+ALTER TABLE blockchain DROP COLUMN IF EXISTS hash_version;
+ALTER TABLE blockchain DROP COLUMN IF EXISTS height;
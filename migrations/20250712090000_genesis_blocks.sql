@@ -0,0 +1,9 @@
+-- +goose Up
+-- Тип блока: генезис-блок голосования или блок с голосом
+
+ALTER TABLE blockchain ADD COLUMN kind TEXT NOT NULL DEFAULT 'vote';
+
+-- +goose Down
+-- Удаляет тип блока
+
+ALTER TABLE blockchain DROP COLUMN IF EXISTS kind;
@@ -0,0 +1,31 @@
+-- +goose Up
+-- Пачки голосов в блоках: корень Merkle вместо одного голоса на блок
+
+ALTER TABLE elections ADD COLUMN batch_size INT NOT NULL DEFAULT 1;
+ALTER TABLE elections ADD COLUMN batch_window_seconds INT NOT NULL DEFAULT 0;
+
+ALTER TABLE blockchain ADD COLUMN vote_count INT NOT NULL DEFAULT 1;
+UPDATE blockchain SET vote_count = 0 WHERE kind = 'genesis';
+
+ALTER TABLE votes ADD COLUMN block_id INT REFERENCES blockchain(id);
+ALTER TABLE votes ADD COLUMN leaf_index INT;
+
+-- До пачек каждый блок содержал ровно один голос
+UPDATE votes v
+SET block_id = b.id, leaf_index = 0
+FROM blockchain b
+WHERE b.election_id = v.election_id AND b.vote_hash = v.vote_hash AND b.kind = 'vote';
+
+CREATE INDEX IF NOT EXISTS votes_pending_idx ON votes (election_id) WHERE block_id IS NULL;
+CREATE INDEX IF NOT EXISTS votes_block_idx ON votes (block_id, leaf_index);
+
+-- +goose Down
+-- Возвращает схему «один голос — один блок»
+
+DROP INDEX IF EXISTS votes_block_idx;
+DROP INDEX IF EXISTS votes_pending_idx;
+ALTER TABLE votes DROP COLUMN IF EXISTS leaf_index;
+ALTER TABLE votes DROP COLUMN IF EXISTS block_id;
+ALTER TABLE blockchain DROP COLUMN IF EXISTS vote_count;
+ALTER TABLE elections DROP COLUMN IF EXISTS batch_window_seconds;
+ALTER TABLE elections DROP COLUMN IF EXISTS batch_size;
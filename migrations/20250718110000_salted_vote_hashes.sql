@@ -0,0 +1,16 @@
+-- +goose Up
+-- Хеши голосов с секретной солью вместо sha256("userID|electionID|choice")
+
+ALTER TABLE votes ADD COLUMN salt TEXT;
+ALTER TABLE votes ADD COLUMN hash_version INT NOT NULL DEFAULT 1;
+ALTER TABLE votes ALTER COLUMN hash_version DROP DEFAULT;
+
+-- Уже записанные голоса остаются версии 1: их хеши вошли в блоки, и пересчёт
+-- сломал бы цепочку. Они по-прежнему проверяются, но их выбор подбирается
+-- перебором; соль получают только голоса, поданные после миграции.
+
+-- +goose Down
+-- Удаляет соль и версию хеша голоса
+
+ALTER TABLE votes DROP COLUMN IF EXISTS hash_version;
+ALTER TABLE votes DROP COLUMN IF EXISTS salt;
@@ -0,0 +1,22 @@
+-- +goose Up
+-- Подписи блоков ключом узла Ed25519 и реестр публичных ключей
+
+CREATE TABLE IF NOT EXISTS signing_keys (
+    key_id TEXT PRIMARY KEY,
+    algorithm TEXT NOT NULL,
+    public_key TEXT NOT NULL UNIQUE,
+    created_at TIMESTAMP NOT NULL DEFAULT now(),
+    retired_at TIMESTAMP
+);
+
+-- Блоки, записанные до миграции, остаются без подписи; их закрепляет подписанный
+-- блок-якорь, который узел дописывает при запуске с ANCHOR_UNSIGNED_CHAINS=true
+ALTER TABLE blockchain ADD COLUMN signature TEXT NOT NULL DEFAULT '';
+ALTER TABLE blockchain ADD COLUMN key_id TEXT REFERENCES signing_keys(key_id);
+
+-- +goose Down
+-- Удаляет подписи блоков и реестр ключей
+
+ALTER TABLE blockchain DROP COLUMN IF EXISTS key_id;
+ALTER TABLE blockchain DROP COLUMN IF EXISTS signature;
+DROP TABLE IF EXISTS signing_keys;
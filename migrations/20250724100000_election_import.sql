@@ -0,0 +1,11 @@
+-- +goose Up
+-- Импорт голосований из архива в пустую базу
+
+-- created_by входит в хеш определения голосования и восстанавливается как есть,
+-- хотя пользователя-создателя в новой базе может не быть
+ALTER TABLE elections DROP CONSTRAINT IF EXISTS elections_created_by_fkey;
+
+-- +goose Down
+-- Возвращает ссылку на создателя; голосования с несуществующим создателем её нарушат
+
+ALTER TABLE elections ADD CONSTRAINT elections_created_by_fkey FOREIGN KEY (created_by) REFERENCES users(id);
@@ -0,0 +1,33 @@
+-- +goose Up
+-- Состояния голосования вместо флага is_active, окно голосования и история переходов
+
+ALTER TABLE elections ADD COLUMN status TEXT NOT NULL DEFAULT 'draft';
+ALTER TABLE elections ADD COLUMN starts_at TIMESTAMP;
+ALTER TABLE elections ADD COLUMN ends_at TIMESTAMP;
+
+-- Существующие голосования уже вышли из черновика. Созданные до генезис-блоков
+-- его не получают: VerifyChain помечает такие цепочки статусом no_genesis
+UPDATE elections SET status = CASE WHEN is_active THEN 'open' ELSE 'closed' END;
+
+ALTER TABLE elections ADD CONSTRAINT elections_status_check
+    CHECK (status IN ('draft', 'scheduled', 'open', 'closed', 'tallied', 'certified', 'archived'));
+
+CREATE TABLE IF NOT EXISTS election_transitions (
+    id SERIAL PRIMARY KEY,
+    election_id INTEGER NOT NULL REFERENCES elections(id) ON DELETE CASCADE,
+    from_status TEXT NOT NULL,
+    to_status TEXT NOT NULL,
+    changed_by INTEGER, -- NULL — переход выполнила система
+    changed_at TIMESTAMP NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS election_transitions_election_idx ON election_transitions (election_id, id);
+
+-- +goose Down
+-- Возвращает голосованиям только флаг is_active
+
+DROP TABLE IF EXISTS election_transitions;
+ALTER TABLE elections DROP CONSTRAINT IF EXISTS elections_status_check;
+ALTER TABLE elections DROP COLUMN IF EXISTS ends_at;
+ALTER TABLE elections DROP COLUMN IF EXISTS starts_at;
+ALTER TABLE elections DROP COLUMN IF EXISTS status;
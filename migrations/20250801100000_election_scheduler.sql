@@ -0,0 +1,24 @@
+-- +goose Up
+-- Автоматическое открытие и закрытие голосований планировщиком и сохранённые итоги
+
+ALTER TABLE elections RENAME COLUMN starts_at TO opens_at;
+ALTER TABLE elections RENAME COLUMN ends_at TO closes_at;
+
+-- Планировщик ищет голосования, которые пора открыть или закрыть
+CREATE INDEX IF NOT EXISTS elections_schedule_idx ON elections (status, opens_at, closes_at)
+    WHERE status IN ('scheduled', 'open');
+
+-- Итоги, подсчитанные при переходе в tallied
+CREATE TABLE IF NOT EXISTS election_tallies (
+    election_id INTEGER PRIMARY KEY REFERENCES elections(id) ON DELETE CASCADE,
+    results JSONB NOT NULL,
+    computed_at TIMESTAMP NOT NULL DEFAULT now()
+);
+
+-- +goose Down
+-- Удаляет итоги и возвращает прежние названия колонок окна голосования
+
+DROP TABLE IF EXISTS election_tallies;
+DROP INDEX IF EXISTS elections_schedule_idx;
+ALTER TABLE elections RENAME COLUMN closes_at TO ends_at;
+ALTER TABLE elections RENAME COLUMN opens_at TO starts_at;
@@ -0,0 +1,22 @@
+-- +goose Up
+-- Голоса ссылаются на вариант голосования по id, а хеш голоса фиксирует бюллетень
+
+ALTER TABLE votes ADD COLUMN choice_id INT REFERENCES choices(id);
+ALTER TABLE votes ADD COLUMN ballot TEXT;
+
+-- Прежние голоса сопоставляются с вариантами по тексту. Их хеши посчитаны от
+-- текста (версии 1 и 2) и не меняются; ballot остаётся пустым, а голоса, не
+-- совпавшие ни с одним вариантом, попадают в итогах в rejected.
+UPDATE votes v
+SET choice_id = c.id
+FROM choices c
+WHERE c.election_id = v.election_id AND c.text = v.choice;
+
+CREATE INDEX IF NOT EXISTS votes_choice_idx ON votes (election_id, choice_id);
+
+-- +goose Down
+-- Удаляет ссылку на вариант и бюллетень
+
+DROP INDEX IF EXISTS votes_choice_idx;
+ALTER TABLE votes DROP COLUMN IF EXISTS ballot;
+ALTER TABLE votes DROP COLUMN IF EXISTS choice_id;
@@ -0,0 +1,26 @@
+-- +goose Up
+-- Порядок, описание и изображение вариантов голосования
+
+ALTER TABLE choices ADD COLUMN position INT NOT NULL DEFAULT 0;
+ALTER TABLE choices ADD COLUMN description TEXT NOT NULL DEFAULT '';
+ALTER TABLE choices ADD COLUMN image_url TEXT NOT NULL DEFAULT '';
+
+-- Существующие варианты сохраняют прежний порядок (по id), поэтому хеши
+-- определений в генезис-блоках не меняются
+UPDATE choices c
+SET position = o.position
+FROM (
+    SELECT id, ROW_NUMBER() OVER (PARTITION BY election_id ORDER BY id) AS position
+    FROM choices
+) o
+WHERE o.id = c.id;
+
+CREATE INDEX IF NOT EXISTS choices_position_idx ON choices (election_id, position);
+
+-- +goose Down
+-- Удаляет порядок, описание и изображение вариантов
+
+DROP INDEX IF EXISTS choices_position_idx;
+ALTER TABLE choices DROP COLUMN IF EXISTS image_url;
+ALTER TABLE choices DROP COLUMN IF EXISTS description;
+ALTER TABLE choices DROP COLUMN IF EXISTS position;
@@ -0,0 +1,9 @@
+-- +goose Up
+-- Способ голосования: plurality (один вариант) или irv (ранжированный бюллетень)
+
+ALTER TABLE elections ADD COLUMN voting_method TEXT NOT NULL DEFAULT 'plurality';
+
+-- +goose Down
+-- Удаляет способ голосования
+
+ALTER TABLE elections DROP COLUMN IF EXISTS voting_method;
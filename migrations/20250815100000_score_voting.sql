@@ -0,0 +1,9 @@
+-- +goose Up
+-- Наибольшая оценка в голосованиях score (для остальных способов 0)
+
+ALTER TABLE elections ADD COLUMN max_score INT NOT NULL DEFAULT 0;
+
+-- +goose Down
+-- Удаляет наибольшую оценку
+
+ALTER TABLE elections DROP COLUMN IF EXISTS max_score;
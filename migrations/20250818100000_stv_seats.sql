@@ -0,0 +1,9 @@
+-- +goose Up
+-- Число мест в голосованиях stv (для остальных способов 0)
+
+ALTER TABLE elections ADD COLUMN seats INT NOT NULL DEFAULT 0;
+
+-- +goose Down
+-- Удаляет число мест
+
+ALTER TABLE elections DROP COLUMN IF EXISTS seats;
@@ -0,0 +1,23 @@
+-- +goose Up
+-- Вопросы голосования: у каждого свой способ голосования и свои варианты
+
+CREATE TABLE IF NOT EXISTS contests (
+    id SERIAL PRIMARY KEY,
+    election_id INTEGER NOT NULL REFERENCES elections(id) ON DELETE CASCADE,
+    title TEXT NOT NULL,
+    position INT NOT NULL,
+    voting_method TEXT NOT NULL DEFAULT 'plurality',
+    max_score INT NOT NULL DEFAULT 0,
+    seats INT NOT NULL DEFAULT 0
+);
+
+CREATE INDEX IF NOT EXISTS contests_election_idx ON contests (election_id, position);
+
+-- Варианты голосований без вопросов остаются с contest_id = NULL
+ALTER TABLE choices ADD COLUMN contest_id INT REFERENCES contests(id) ON DELETE CASCADE;
+
+-- +goose Down
+-- Удаляет вопросы голосования
+
+ALTER TABLE choices DROP COLUMN IF EXISTS contest_id;
+DROP TABLE IF EXISTS contests;
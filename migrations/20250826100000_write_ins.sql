@@ -0,0 +1,26 @@
+-- +goose Up
+-- Вписанные варианты: разрешаются голосованием или вопросом, решения
+-- администратора по ним хранятся отдельно от голосов
+
+ALTER TABLE elections ADD COLUMN allow_write_ins BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE contests ADD COLUMN allow_write_ins BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- contest_id = 0 — голосование без вопросов; choice_id = NULL — голоса
+-- остаются за вписанным вариантом
+CREATE TABLE IF NOT EXISTS write_in_reviews (
+    election_id INTEGER NOT NULL REFERENCES elections(id) ON DELETE CASCADE,
+    contest_id INT NOT NULL DEFAULT 0,
+    text TEXT NOT NULL,
+    choice_id INT,
+    reviewed_by INT,
+    reviewed_at TIMESTAMP NOT NULL DEFAULT now()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS write_in_reviews_text_idx ON write_in_reviews (election_id, contest_id, lower(text));
+
+-- +goose Down
+-- Удаляет вписанные варианты
+
+DROP TABLE IF EXISTS write_in_reviews;
+ALTER TABLE contests DROP COLUMN IF EXISTS allow_write_ins;
+ALTER TABLE elections DROP COLUMN IF EXISTS allow_write_ins;
@@ -0,0 +1,24 @@
+-- +goose Up
+-- Списки избирателей: голосование открыто всем (all) или только списку (roll)
+
+ALTER TABLE elections ADD COLUMN eligibility TEXT NOT NULL DEFAULT 'all';
+
+-- Избиратель задаётся id пользователя или email; по email совпадают и
+-- пользователи, зарегистрированные после загрузки списка
+CREATE TABLE IF NOT EXISTS voter_rolls (
+    id SERIAL PRIMARY KEY,
+    election_id INTEGER NOT NULL REFERENCES elections(id) ON DELETE CASCADE,
+    user_id INTEGER REFERENCES users(id) ON DELETE CASCADE,
+    email TEXT,
+    added_at TIMESTAMP NOT NULL DEFAULT now(),
+    CHECK ((user_id IS NULL) <> (email IS NULL))
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS voter_rolls_user_idx ON voter_rolls (election_id, user_id) WHERE user_id IS NOT NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS voter_rolls_email_idx ON voter_rolls (election_id, lower(email)) WHERE email IS NOT NULL;
+
+-- +goose Down
+-- Удаляет списки избирателей
+
+DROP TABLE IF EXISTS voter_rolls;
+ALTER TABLE elections DROP COLUMN IF EXISTS eligibility;
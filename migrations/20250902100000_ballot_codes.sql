@@ -0,0 +1,18 @@
+-- +goose Up
+-- Одноразовые коды для голосования без учётной записи. Хранится только хеш
+-- кода; ни пользователь, ни поданный по коду голос с ним не связаны
+
+CREATE TABLE IF NOT EXISTS ballot_codes (
+    id SERIAL PRIMARY KEY,
+    election_id INTEGER NOT NULL REFERENCES elections(id) ON DELETE CASCADE,
+    code_hash TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT now(),
+    -- час использования: точное время совпало бы со временем голоса
+    used_at TIMESTAMP,
+    UNIQUE (election_id, code_hash)
+);
+
+-- +goose Down
+-- Удаляет коды голосования
+
+DROP TABLE IF EXISTS ballot_codes;
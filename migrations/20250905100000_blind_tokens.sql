@@ -0,0 +1,36 @@
+-- +goose Up
+-- Анонимные голосования: пользователь получает слепую подпись RSA на жетон,
+-- а голос подаётся по жетону без входа в систему
+
+ALTER TABLE elections ADD COLUMN anonymity TEXT NOT NULL DEFAULT 'none';
+
+-- Ключ подписи жетонов голосования (PKCS#1 DER)
+CREATE TABLE IF NOT EXISTS blind_keys (
+    election_id INTEGER PRIMARY KEY REFERENCES elections(id) ON DELETE CASCADE,
+    private_key BYTEA NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT now()
+);
+
+-- Кто получил жетон: не больше одного на пользователя. Время — с точностью
+-- до часа, чтобы его нельзя было сопоставить со временем голоса
+CREATE TABLE IF NOT EXISTS blind_issuances (
+    election_id INTEGER NOT NULL REFERENCES elections(id) ON DELETE CASCADE,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    issued_at TIMESTAMP NOT NULL DEFAULT date_trunc('hour', now()),
+    PRIMARY KEY (election_id, user_id)
+);
+
+-- Использованные жетоны (sha256); с голосами и пользователями не связаны
+CREATE TABLE IF NOT EXISTS spent_tokens (
+    election_id INTEGER NOT NULL REFERENCES elections(id) ON DELETE CASCADE,
+    token_hash TEXT NOT NULL,
+    PRIMARY KEY (election_id, token_hash)
+);
+
+-- +goose Down
+-- Удаляет анонимные голосования
+
+DROP TABLE IF EXISTS spent_tokens;
+DROP TABLE IF EXISTS blind_issuances;
+DROP TABLE IF EXISTS blind_keys;
+ALTER TABLE elections DROP COLUMN IF EXISTS anonymity;
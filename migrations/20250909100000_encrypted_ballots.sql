@@ -0,0 +1,20 @@
+-- +goose Up
+-- Зашифрованные бюллетени: голоса шифруются экспоненциальным ElGamal на
+-- открытый ключ голосования, расшифровывается только сумма по вариантам
+
+ALTER TABLE elections ADD COLUMN encryption TEXT NOT NULL DEFAULT 'none';
+ALTER TABLE elections ADD COLUMN encryption_key TEXT NOT NULL DEFAULT '';
+
+-- Закрытый ключ x голосования (hex); в архив не выгружается
+CREATE TABLE IF NOT EXISTS election_secrets (
+    election_id INTEGER PRIMARY KEY REFERENCES elections(id) ON DELETE CASCADE,
+    private_key TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT now()
+);
+
+-- +goose Down
+-- Удаляет зашифрованные бюллетени
+
+DROP TABLE IF EXISTS election_secrets;
+ALTER TABLE elections DROP COLUMN IF EXISTS encryption_key;
+ALTER TABLE elections DROP COLUMN IF EXISTS encryption;
@@ -0,0 +1,45 @@
+-- +goose Up
+-- Пороговое шифрование: ключ голосования создают доверенные лица, и сумму
+-- бюллетеней расшифровывают любые trustee_threshold из них
+
+ALTER TABLE elections ADD COLUMN trustee_threshold INTEGER NOT NULL DEFAULT 0;
+
+-- Доверенные лица: ключ для получения долей и обязательства многочлена (JSON)
+CREATE TABLE IF NOT EXISTS trustees (
+    election_id INTEGER NOT NULL REFERENCES elections(id) ON DELETE CASCADE,
+    idx INTEGER NOT NULL,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    public_key TEXT NOT NULL DEFAULT '',
+    commitments JSONB,
+    verified BOOLEAN NOT NULL DEFAULT false,
+    created_at TIMESTAMP NOT NULL DEFAULT now(),
+    PRIMARY KEY (election_id, idx),
+    UNIQUE (election_id, user_id)
+);
+
+-- Доли, зашифрованные на ключ получателя
+CREATE TABLE IF NOT EXISTS trustee_shares (
+    election_id INTEGER NOT NULL REFERENCES elections(id) ON DELETE CASCADE,
+    from_idx INTEGER NOT NULL,
+    to_idx INTEGER NOT NULL,
+    r TEXT NOT NULL,
+    c TEXT NOT NULL,
+    PRIMARY KEY (election_id, from_idx, to_idx)
+);
+
+-- Частичные расшифровки сумм по вариантам (JSON)
+CREATE TABLE IF NOT EXISTS partial_decryptions (
+    election_id INTEGER NOT NULL REFERENCES elections(id) ON DELETE CASCADE,
+    idx INTEGER NOT NULL,
+    partials JSONB NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT now(),
+    PRIMARY KEY (election_id, idx)
+);
+
+-- +goose Down
+-- Удаляет пороговое шифрование
+
+DROP TABLE IF EXISTS partial_decryptions;
+DROP TABLE IF EXISTS trustee_shares;
+DROP TABLE IF EXISTS trustees;
+ALTER TABLE elections DROP COLUMN IF EXISTS trustee_threshold;
@@ -0,0 +1,10 @@
+-- +goose Up
+-- Отпечаток ключа подписи жетонов: ключ создаётся при выходе голосования из
+-- черновика, а отпечаток входит в определение и фиксируется генезис-блоком
+
+ALTER TABLE elections ADD COLUMN blind_key_fingerprint TEXT NOT NULL DEFAULT '';
+
+-- +goose Down
+-- Удаляет отпечаток ключа подписи жетонов
+
+ALTER TABLE elections DROP COLUMN IF EXISTS blind_key_fingerprint;